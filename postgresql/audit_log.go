@@ -0,0 +1,212 @@
+package postgresql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// auditRecord is a line of the audit log (see the audit_log provider attribute).
+type auditRecord struct {
+	Time       string `json:"time"`
+	Resource   string `json:"resource,omitempty"`
+	Operation  string `json:"operation,omitempty"`
+	Object     string `json:"object,omitempty"`
+	Database   string `json:"database"`
+	Statement  string `json:"statement"`
+	DurationMS int64  `json:"duration_ms"`
+	Rows       int64  `json:"rows"`
+	Outcome    string `json:"outcome"`
+	Error      string `json:"error,omitempty"`
+	SQLState   string `json:"sqlstate,omitempty"`
+}
+
+// auditTarget is the operation of a resource running the statements, set in the
+// context of the client by auditStatements.
+type auditTarget struct {
+	resource  string
+	operation string
+	object    string
+}
+
+type auditTargetKey struct{}
+
+// auditReadOnlyCommands are the first keywords of the statements which do not change
+// the server, they are not written in the audit log.
+var auditReadOnlyCommands = map[string]bool{
+	"BEGIN":     true,
+	"COMMIT":    true,
+	"END":       true,
+	"RELEASE":   true,
+	"RESET":     true,
+	"ROLLBACK":  true,
+	"SAVEPOINT": true,
+	"SELECT":    true,
+	"SET":       true,
+	"SHOW":      true,
+	"START":     true,
+	"TABLE":     true,
+	"VALUES":    true,
+}
+
+// auditFiles are the open audit logs, shared by the connection pools writing in the same file.
+var (
+	auditFiles     = map[string]*auditFile{}
+	auditFilesLock sync.Mutex
+)
+
+type auditFile struct {
+	sync.Mutex
+	path string
+	file *os.File
+}
+
+// openAuditFile returns the audit log of the path, opened in append mode and
+// created readable by its owner only if it does not exist.
+func openAuditFile(path string) (*auditFile, error) {
+	auditFilesLock.Lock()
+	defer auditFilesLock.Unlock()
+
+	if f, ok := auditFiles[path]; ok {
+		return f, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("could not open audit log %s: {{err}}", path), err)
+	}
+	f := &auditFile{path: path, file: file}
+	auditFiles[path] = f
+	return f, nil
+}
+
+func (f *auditFile) write(record auditRecord) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("[WARN] could not encode audit log record: %v", err)
+		return
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	if _, err := f.file.Write(append(line, '\n')); err != nil {
+		log.Printf("[WARN] could not write audit log %s: %v", f.path, err)
+	}
+}
+
+// auditLogger is a query tracer writing the statements changing the server in the
+// audit log, with the resource operation running them, their duration and outcome.
+// The statements are redacted (see redactStatement) and their arguments are not written.
+// It forwards the traces to the tracer it wraps.
+type auditLogger struct {
+	tracer pgx.QueryTracer
+	file   *auditFile
+}
+
+type auditStartKey struct{}
+
+func (l *auditLogger) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx = l.tracer.TraceQueryStart(ctx, conn, data)
+	return context.WithValue(ctx, auditStartKey{}, time.Now())
+}
+
+func (l *auditLogger) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	l.tracer.TraceQueryEnd(ctx, conn, data)
+
+	statement, _ := ctx.Value(traceStatementKey{}).(string)
+	if !isAuditedStatement(statement) {
+		return
+	}
+
+	record := auditRecord{
+		Statement: redactStatement(statement),
+		Outcome:   "success",
+		Rows:      data.CommandTag.RowsAffected(),
+	}
+	if start, ok := ctx.Value(auditStartKey{}).(time.Time); ok {
+		record.Time = start.UTC().Format(time.RFC3339Nano)
+		record.DurationMS = time.Since(start).Milliseconds()
+	}
+	if target, ok := ctx.Value(auditTargetKey{}).(auditTarget); ok {
+		record.Resource = target.resource
+		record.Operation = target.operation
+		record.Object = target.object
+	}
+	if conn != nil {
+		record.Database = conn.Config().Database
+	}
+	if data.Err != nil {
+		record.Outcome = "error"
+		record.Error = data.Err.Error()
+		var pgErr *pgconn.PgError
+		if errors.As(data.Err, &pgErr) {
+			record.SQLState = pgErr.Code
+		}
+	}
+
+	l.file.write(record)
+}
+
+// isAuditedStatement returns whether the statement may change the server,
+// i.e. its first keyword is not a read-only or a transaction control command.
+func isAuditedStatement(statement string) bool {
+	fields := strings.Fields(strings.TrimLeft(statement, "( \t\r\n"))
+	if len(fields) == 0 {
+		return false
+	}
+	command := strings.ToUpper(strings.TrimRight(fields[0], ";"))
+	return !auditReadOnlyCommands[command]
+}
+
+// withAuditTarget returns a copy of the client whose statements are written
+// in the audit log with the resource operation running them.
+func (c *Client) withAuditTarget(target auditTarget) *Client {
+	client := *c
+	client.ctx = context.WithValue(c.ctx, auditTargetKey{}, target)
+	return &client
+}
+
+// auditStatements wraps the functions of a resource so the statements they execute
+// are written in the audit log (see auditLogger) with the operation and its object.
+func auditStatements(name string, r *schema.Resource) {
+	_, hasName := r.Schema["name"]
+
+	wrap := func(operation string, fn func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+		if fn == nil {
+			return nil
+		}
+		return func(d *schema.ResourceData, meta interface{}) error {
+			client, ok := meta.(*Client)
+			if !ok || client.config.AuditLog == "" || client.ctx == nil {
+				return fn(d, meta)
+			}
+
+			// The ID of the created objects is not known yet.
+			object := d.Id()
+			if object == "" && hasName {
+				object, _ = d.Get("name").(string)
+			}
+			return fn(d, client.withAuditTarget(auditTarget{
+				resource:  name,
+				operation: operation,
+				object:    object,
+			}))
+		}
+	}
+
+	r.Create = wrap("create", r.Create)
+	r.Read = wrap("read", r.Read)
+	r.Update = wrap("update", r.Update)
+	r.Delete = wrap("delete", r.Delete)
+}
@@ -0,0 +1,49 @@
+package postgresql
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIsAuditedStatement(t *testing.T) {
+	cases := map[string]bool{
+		"":                             false,
+		"SELECT rolname FROM pg_roles": false,
+		"  select 1":                   false,
+		"(SELECT 1) UNION (SELECT 2)":  false,
+		"begin isolation level repeatable read read only": false,
+		"commit":                             false,
+		"ROLLBACK;":                          false,
+		"SET search_path TO public":          false,
+		"SHOW server_version":                false,
+		"CREATE ROLE \"foo\"":                true,
+		"\n\tGRANT USAGE ON SCHEMA s TO r":   true,
+		"ALTER ROLE \"foo\" PASSWORD 'bar'":  true,
+		"DROP SCHEMA s CASCADE":              true,
+		"WITH t AS (DELETE FROM x) SELECT 1": true,
+	}
+
+	for statement, expected := range cases {
+		if actual := isAuditedStatement(statement); actual != expected {
+			t.Errorf("isAuditedStatement(%q): expected %t, got %t", statement, expected, actual)
+		}
+	}
+}
+
+func TestConnConfigAuditLog(t *testing.T) {
+	dsn := "postgres://postgres@localhost:5432/postgres"
+
+	c := &Config{AuditLog: filepath.Join(t.TempDir(), "missing", "audit.log")}
+	if _, err := c.connConfig(dsn); err == nil {
+		t.Error("expected an error for an audit log in a missing directory")
+	}
+
+	c = &Config{AuditLog: filepath.Join(t.TempDir(), "audit.log")}
+	connConfig, err := c.connConfig(dsn)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := connConfig.Tracer.(*auditLogger); !ok {
+		t.Errorf("expected the audit logger as tracer, got %T", connConfig.Tracer)
+	}
+}
@@ -2,16 +2,22 @@ package postgresql
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"net"
+	"os"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 	"unicode"
 
 	"github.com/blang/semver"
 	"github.com/hashicorp/errwrap"
-	_ "github.com/lib/pq" //PostgreSQL db
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 )
 
 type featureName uint
@@ -27,16 +33,114 @@ const (
 	featureReplication
 	featureExtension
 	featurePrivileges
+	featureParameterPrivileges
+	featureACLDefault
+	featureMaterializedView
+	featureGrantedBy
+	featureGrantedByAnyRole
+	featureDefaultPrivilegesTypes
+	featureDefaultPrivilegesSchemas
+	featureExtensionCreateCascade
+	featureExtensionMembers
+	featureSuperuserRole
+	featureDBSetTablespace
+	featureDDLExport
+	featureDeclarativePartitioning
+	featureMaintainPrivilege
+	featurePublication
+	featurePublicationTruncate
+	featureSubscription
+	featureReplicationSlot
+	featureFailoverSlots
+	featureEventTrigger
+	featureSequence
+)
+
+// featureNames are the names of the features in the feature_overrides provider attribute.
+var featureNames = map[string]featureName{
+	"create_role_with":            featureCreateRoleWith,
+	"db_allow_connections":        featureDBAllowConnections,
+	"db_is_template":              featureDBIsTemplate,
+	"fallback_application_name":   featureFallbackApplicationName,
+	"rls":                         featureRLS,
+	"reassign_owned_current_user": featureReassignOwnedCurrentUser,
+	"schema_create_if_not_exist":  featureSchemaCreateIfNotExist,
+	"replication":                 featureReplication,
+	"extension":                   featureExtension,
+	"privileges":                  featurePrivileges,
+	"parameter_privileges":        featureParameterPrivileges,
+	"acl_default":                 featureACLDefault,
+	"materialized_view":           featureMaterializedView,
+	"granted_by":                  featureGrantedBy,
+	"granted_by_any_role":         featureGrantedByAnyRole,
+	"default_privileges_types":    featureDefaultPrivilegesTypes,
+	"default_privileges_schemas":  featureDefaultPrivilegesSchemas,
+	"extension_create_cascade":    featureExtensionCreateCascade,
+	"extension_members":           featureExtensionMembers,
+	"superuser_role":              featureSuperuserRole,
+	"db_set_tablespace":           featureDBSetTablespace,
+	"ddl_export":                  featureDDLExport,
+	"declarative_partitioning":    featureDeclarativePartitioning,
+	"maintain_privilege":          featureMaintainPrivilege,
+	"publication":                 featurePublication,
+	"publication_truncate":        featurePublicationTruncate,
+	"subscription":                featureSubscription,
+	"replication_slot":            featureReplicationSlot,
+	"failover_slots":              featureFailoverSlots,
+	"event_trigger":               featureEventTrigger,
+	"sequence":                    featureSequence,
+}
+
+// serverFlavor is the PostgreSQL-compatible server (or managed service)
+// the provider is connected to.
+type serverFlavor string
+
+const (
+	flavorPostgreSQL serverFlavor = "postgresql"
+	flavorAlloyDB    serverFlavor = "alloydb"
+	flavorRedshift   serverFlavor = "redshift"
+	flavorYugabyteDB serverFlavor = "yugabytedb"
+	flavorSupabase   serverFlavor = "supabase"
+	flavorGreenplum  serverFlavor = "greenplum"
+	flavorEDB        serverFlavor = "edb"
+)
+
+// dbPoolIdleTimeout is the time after which idle connections are closed
+// and unused connection pools are removed from the registry.
+const dbPoolIdleTimeout = 30 * time.Second
+
+const (
+	// dbPoolPingInterval is the time after which a connection pool is pinged
+	// before being used again, to detect the connections silently dropped
+	// by the network (e.g.: NAT or load balancer idle timeout).
+	dbPoolPingInterval = 10 * time.Second
+	// dbPoolPingTimeout is the maximum wait for the ping, the pool is
+	// reopened if the server does not answer in time.
+	dbPoolPingTimeout = 10 * time.Second
+	// tcpKeepaliveCount is the number of unanswered TCP keepalive probes
+	// after which a connection is considered dead.
+	tcpKeepaliveCount = 3
 )
 
 type dbRegistryEntry struct {
-	db      *sql.DB
-	version semver.Version
+	database string
+	// db is nil if the connection pool has been closed,
+	// it will be reopened on the next use.
+	db *sql.DB
+	// connConfig is the configuration of the connections of the pool,
+	// validated when the entry is created so reopening the pool cannot fail.
+	connConfig *pgx.ConnConfig
+	version    semver.Version
+	flavor     serverFlavor
+	lastUsed   time.Time
 }
 
 var (
+	// dbRegistry caches the connection pools per DSN (so per database).
+	// The entries are kept when their pool is closed so the version
+	// of the server is only fingerprinted once.
 	dbRegistryLock sync.Mutex
-	dbRegistry     map[string]dbRegistryEntry = make(map[string]dbRegistryEntry, 1)
+	dbRegistry     map[string]*dbRegistryEntry = make(map[string]*dbRegistryEntry, 1)
 
 	// Mapping of feature flags to versions
 	featureSupported = map[featureName]semver.Range{
@@ -70,6 +174,114 @@ var (
 		// We do not support postgresql_grant and postgresql_default_privileges
 		// for Postgresql < 9.
 		featurePrivileges: semver.MustParseRange(">=9.0.0"),
+
+		// GRANT SET / ALTER SYSTEM ON PARAMETER
+		featureParameterPrivileges: semver.MustParseRange(">=15.0.0"),
+
+		// acldefault() function, needed by postgresql_revoke
+		featureACLDefault: semver.MustParseRange(">=9.2.0"),
+
+		// CREATE MATERIALIZED VIEW
+		featureMaterializedView: semver.MustParseRange(">=9.3.0"),
+
+		// GRANT / REVOKE ... GRANTED BY
+		featureGrantedBy: semver.MustParseRange(">=14.0.0"),
+
+		// GRANT / REVOKE ... GRANTED BY another role than the current user
+		featureGrantedByAnyRole: semver.MustParseRange(">=16.0.0"),
+
+		// ALTER DEFAULT PRIVILEGES ... ON TYPES
+		featureDefaultPrivilegesTypes: semver.MustParseRange(">=9.2.0"),
+
+		// ALTER DEFAULT PRIVILEGES ... ON SCHEMAS
+		featureDefaultPrivilegesSchemas: semver.MustParseRange(">=10.0.0"),
+
+		// CREATE EXTENSION ... CASCADE
+		featureExtensionCreateCascade: semver.MustParseRange(">=9.6.0"),
+
+		// to_regclass(), to_regprocedure() and to_regtype(), needed to read the extension member objects
+		featureExtensionMembers: semver.MustParseRange(">=9.4.0"),
+
+		// CREATE ROLE ... SUPERUSER
+		featureSuperuserRole: semver.MustParseRange(">=8.1.0"),
+
+		// ALTER DATABASE ... SET TABLESPACE
+		featureDBSetTablespace: semver.MustParseRange(">=8.4.0"),
+
+		// format() and the pg_get_*def() functions, needed by the postgresql_ddl data source
+		featureDDLExport: semver.MustParseRange(">=9.3.0"),
+
+		// CREATE TABLE ... PARTITION BY / PARTITION OF
+		featureDeclarativePartitioning: semver.MustParseRange(">=10.0.0"),
+
+		// GRANT MAINTAIN ON TABLE (VACUUM, ANALYZE, REINDEX, ...)
+		featureMaintainPrivilege: semver.MustParseRange(">=17.0.0"),
+
+		// CREATE PUBLICATION (logical replication)
+		featurePublication: semver.MustParseRange(">=10.0.0"),
+
+		// CREATE PUBLICATION ... WITH (publish = 'truncate')
+		featurePublicationTruncate: semver.MustParseRange(">=11.0.0"),
+
+		// CREATE SUBSCRIPTION (logical replication)
+		featureSubscription: semver.MustParseRange(">=10.0.0"),
+
+		// pg_create_physical_replication_slot / pg_create_logical_replication_slot
+		featureReplicationSlot: semver.MustParseRange(">=9.4.0"),
+
+		// Logical replication slots synchronized to the standby servers
+		// (failover option of the slots and subscriptions, synced column of pg_replication_slots)
+		featureFailoverSlots: semver.MustParseRange(">=17.0.0"),
+
+		// CREATE EVENT TRIGGER
+		featureEventTrigger: semver.MustParseRange(">=9.3.0"),
+
+		// pg_sequences (the parameters of the sequences are no longer in the sequence relation)
+		featureSequence: semver.MustParseRange(">=10.0.0"),
+	}
+
+	// Features which are not available on some flavors, whatever their version.
+	flavorUnsupportedFeatures = map[serverFlavor][]featureName{
+		// Only Google can create superusers and roles with replication
+		// (the administrator is only a member of alloydbsuperuser).
+		flavorAlloyDB: {featureSuperuserRole, featureReplication},
+		// The tablespaces of YugabyteDB only define the placement of the new tables.
+		flavorYugabyteDB: {featureDBSetTablespace},
+		// The postgres user of Supabase projects is not a superuser.
+		flavorSupabase: {featureSuperuserRole},
+	}
+
+	// Resources which cannot be used on some flavors.
+	flavorUnsupportedResources = map[serverFlavor][]string{
+		// Redshift is forked from PostgreSQL 8.0 and its catalog is too different
+		// (roles and grants have their own implementation, see *_redshift.go).
+		flavorRedshift: {
+			"postgresql_app_user",
+			"postgresql_database",
+			"postgresql_default_privileges",
+			"postgresql_event_trigger",
+			"postgresql_extension",
+			"postgresql_foreign_data_wrapper",
+			"postgresql_foreign_server",
+			"postgresql_function",
+			"postgresql_policy",
+			"postgresql_publication",
+			"postgresql_readonly_grants",
+			"postgresql_replication_slot",
+			"postgresql_revoke",
+			"postgresql_row_level_security",
+			"postgresql_sequence",
+			"postgresql_subscription",
+			"postgresql_transaction",
+			"postgresql_user_mapping",
+			"postgresql_citus_distributed_table",
+			"postgresql_citus_reference_table",
+			"postgresql_partman_parent",
+			"postgresql_postgis_spatial_ref_sys",
+			"postgresql_postgres_fdw",
+			"postgresql_timescaledb_continuous_aggregate",
+			"postgresql_timescaledb_policy",
+		},
 	}
 )
 
@@ -81,12 +293,28 @@ type Config struct {
 	Password          string
 	DatabaseUsername  string
 	Superuser         bool
+	DegradeGracefully bool
 	SSLMode           string
 	ApplicationName   string
 	Timeout           int
 	ConnectTimeoutSec int
 	MaxConns          int
+	MaxPools          int
+	KeepaliveInterval int
+	FailoverRetries   int
+	PromotionTimeout  int
+	Azure             bool
+	StatementCache    int
+	PgBouncer         bool
+	RDSProxy          bool
+	LogSQL            bool
+	AuditLog          string
 	ExpectedVersion   semver.Version
+
+	TolerateUnsupportedFeatures bool
+
+	// FeatureOverrides replace the detection of the features (see feature_overrides).
+	FeatureOverrides map[featureName]bool
 }
 
 // Client struct holding connection string
@@ -96,20 +324,48 @@ type Client struct {
 
 	databaseName string
 
-	// db is a pointer to the DB connection.  Callers are responsible for
-	// releasing their connections.
-	db *sql.DB
+	// dsn is the key of the connection pool of the database in dbRegistry.
+	dsn string
+
+	// ctx is used by all the queries, it is canceled when Terraform
+	// stops the provider (e.g.: interrupted apply) to abort them.
+	ctx context.Context
+
+	// operations is a semaphore limiting the number of concurrent operations
+	// (nil if not limited).
+	operations chan struct{}
+
+	catalogCache *catalogCache
+
+	// unreachable is the connection error if the server could not be reached
+	// when the provider was configured with offline_plan (see allowOfflinePlan).
+	unreachable error
 
 	// version is the version number of the database as determined by parsing the
 	// output of `SELECT VERSION()`.x
 	version semver.Version
 
+	// flavor is the kind of server, as detected with the version.
+	flavor serverFlavor
+
+	// The locks are shared by the copies of the client (see withTimeout).
+	*clientLocks
+}
+
+type clientLocks struct {
 	// PostgreSQL lock on pg_catalog.  Many of the operations that Terraform
 	// performs are not permitted to be concurrent.  Unlike traditional
 	// PostgreSQL tables that use MVCC, many of the PostgreSQL system
 	// catalogs look like tables, but are not in-fact able to be
 	// concurrently updated.
+	// The objects shared by the whole cluster (roles, databases) are managed
+	// with this lock held exclusively, the objects of a database are managed
+	// with this lock held for reading and the lock of their database (see
+	// lockDatabase), so operations on different databases can run concurrently.
 	catalogLock sync.RWMutex
+
+	dbLocksMutex sync.Mutex
+	dbLocks      map[string]*sync.RWMutex
 }
 
 // NewClient returns client config for the specified database.
@@ -120,26 +376,27 @@ func (c *Config) NewClient(database string) (*Client, error) {
 	dsn := c.connStr(database)
 	dbEntry, found := dbRegistry[dsn]
 	if !found {
-		db, err := sql.Open("postgres", dsn)
+		closeUnusedDBPools(c.MaxPools - 1)
+
+		connConfig, err := c.connConfig(dsn)
 		if err != nil {
-			return nil, errwrap.Wrapf("Error connecting to PostgreSQL server: {{err}}", err)
+			return nil, err
 		}
+		db := c.openDB(connConfig)
 
-		// We don't want to retain connection
-		// So when we connect on a specific database which might be managed by terraform,
-		// we don't keep opened connection in case of the db has to be dopped in the plan.
-		db.SetMaxIdleConns(0)
-		db.SetMaxOpenConns(c.MaxConns)
-
-		version, err := fingerprintCapabilities(db)
+		version, flavor, err := fingerprintCapabilities(db)
 		if err != nil {
 			db.Close()
 			return nil, errwrap.Wrapf("error detecting capabilities: {{err}}", err)
 		}
 
-		dbEntry = dbRegistryEntry{
-			db:      db,
-			version: *version,
+		dbEntry = &dbRegistryEntry{
+			database:   database,
+			db:         db,
+			connConfig: connConfig,
+			version:    *version,
+			flavor:     flavor,
+			lastUsed:   time.Now(),
 		}
 		dbRegistry[dsn] = dbEntry
 	}
@@ -147,13 +404,138 @@ func (c *Config) NewClient(database string) (*Client, error) {
 	client := Client{
 		config:       *c,
 		databaseName: database,
-		db:           dbEntry.db,
+		dsn:          dsn,
 		version:      dbEntry.version,
+		flavor:       dbEntry.flavor,
+		ctx:          context.Background(),
+		catalogCache: newCatalogCache(),
+		clientLocks:  &clientLocks{},
 	}
 
 	return &client, nil
 }
 
+// connConfig returns the configuration of the connections to the DSN,
+// with the dialer and the tracers (SQL log and audit log) of the provider.
+func (c *Config) connConfig(dsn string) (*pgx.ConnConfig, error) {
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, errwrap.Wrapf("Error connecting to PostgreSQL server: {{err}}", err)
+	}
+
+	dialer := &net.Dialer{
+		Timeout: time.Duration(c.ConnectTimeoutSec) * time.Second,
+	}
+	if c.KeepaliveInterval > 0 {
+		interval := time.Duration(c.KeepaliveInterval) * time.Second
+		dialer.KeepAliveConfig = net.KeepAliveConfig{
+			Enable:   true,
+			Idle:     interval,
+			Interval: interval,
+			Count:    tcpKeepaliveCount,
+		}
+	} else {
+		dialer.KeepAlive = -1
+	}
+	connConfig.DialFunc = dialer.DialContext
+	connConfig.Tracer = failedStatements
+	if c.LogSQL {
+		connConfig.Tracer = &sqlLogger{tracer: failedStatements}
+	}
+	if c.AuditLog != "" {
+		file, err := openAuditFile(c.AuditLog)
+		if err != nil {
+			return nil, err
+		}
+		connConfig.Tracer = &auditLogger{tracer: connConfig.Tracer, file: file}
+	}
+
+	return connConfig, nil
+}
+
+// openDB opens a connection pool with the connection configuration (see connConfig).
+// Idle connections are kept to be reused by the next operations on this database
+// and closed after dbPoolIdleTimeout.
+func (c *Config) openDB(connConfig *pgx.ConnConfig) *sql.DB {
+	db := stdlib.OpenDB(*connConfig)
+
+	db.SetMaxOpenConns(c.MaxConns)
+	db.SetMaxIdleConns(c.MaxConns)
+	db.SetConnMaxIdleTime(dbPoolIdleTimeout)
+
+	return db
+}
+
+// closeUnusedDBPools closes the connection pools which have not been used
+// since dbPoolIdleTimeout and, if more than maxPools pools are still opened,
+// the least recently used ones without connections in use.
+// dbRegistryLock must be held by the caller.
+func closeUnusedDBPools(maxPools int) {
+	var opened []*dbRegistryEntry
+	for _, entry := range dbRegistry {
+		if entry.db == nil {
+			continue
+		}
+		if entry.db.Stats().InUse == 0 && time.Since(entry.lastUsed) > dbPoolIdleTimeout {
+			closeDBRegistryEntry(entry)
+			continue
+		}
+		opened = append(opened, entry)
+	}
+
+	if maxPools <= 0 || len(opened) <= maxPools {
+		return
+	}
+
+	sort.Slice(opened, func(i, j int) bool {
+		return opened[i].lastUsed.Before(opened[j].lastUsed)
+	})
+	toClose := len(opened) - maxPools
+	for _, entry := range opened {
+		if toClose == 0 {
+			break
+		}
+		if entry.db.Stats().InUse == 0 {
+			closeDBRegistryEntry(entry)
+			toClose--
+		}
+	}
+}
+
+// closeDBPools closes all the connection pools of a database
+// (e.g.: before dropping or renaming it).
+func closeDBPools(database string) {
+	dbRegistryLock.Lock()
+	defer dbRegistryLock.Unlock()
+
+	for _, entry := range dbRegistry {
+		if entry.database == database && entry.db != nil {
+			closeDBRegistryEntry(entry)
+		}
+	}
+}
+
+// closeAllDBPools closes all the connection pools, so the next connections
+// resolve the server address again (e.g.: after a failover).
+func closeAllDBPools() {
+	dbRegistryLock.Lock()
+	defer dbRegistryLock.Unlock()
+
+	for _, entry := range dbRegistry {
+		if entry.db != nil {
+			closeDBRegistryEntry(entry)
+		}
+	}
+}
+
+func closeDBRegistryEntry(entry *dbRegistryEntry) {
+	log.Printf("[DEBUG] closing connection pool of database %s", entry.database)
+	if err := entry.db.Close(); err != nil {
+		log.Printf("[WARN] could not close connection pool of database %s: %v", entry.database, err)
+	}
+	entry.db = nil
+}
+
 // featureSupported returns true if a given feature is supported or not.  This
 // is slightly different from Client's featureSupported in that here we're
 // evaluating against the expected version, not the fingerprinted version.
@@ -164,6 +546,10 @@ func (c *Config) featureSupported(name featureName) bool {
 		panic(fmt.Sprintf("unknown feature flag %v", name))
 	}
 
+	if supported, ok := c.FeatureOverrides[name]; ok {
+		return supported
+	}
+
 	return fn(c.ExpectedVersion)
 }
 
@@ -180,56 +566,42 @@ func (c *Config) connStr(database string) string {
 			"password=%s",
 			"sslmode=%s",
 			"connect_timeout=%d",
+			"default_query_exec_mode=%s",
 		}
 
-		if c.featureSupported(featureFallbackApplicationName) {
-			dsnFmtParts = append(dsnFmtParts, "fallback_application_name=%s")
+		if c.queryExecMode() == "cache_statement" {
+			dsnFmtParts = append(dsnFmtParts, "statement_cache_capacity=%d")
 		}
 
-		dsnFmt = strings.Join(dsnFmtParts, " ")
-	}
-
-	// Quote empty strings or strings that contain whitespace
-	quote := func(s string) string {
-		b := bytes.NewBufferString(`'`)
-		b.Grow(len(s) + 2)
-		var haveWhitespace bool
-		for _, r := range s {
-			if unicode.IsSpace(r) {
-				haveWhitespace = true
-			}
-
-			switch r {
-			case '\'':
-				b.WriteString(`\'`)
-			case '\\':
-				b.WriteString(`\\`)
-			default:
-				b.WriteRune(r)
-			}
+		if c.useApplicationName() {
+			dsnFmtParts = append(dsnFmtParts, "application_name=%s")
 		}
 
-		b.WriteString(`'`)
+		dsnFmt = strings.Join(dsnFmtParts, " ")
+	}
 
-		str := b.String()
-		if haveWhitespace || len(str) == 2 {
-			return str
-		}
-		return str[1 : len(str)-1]
+	// lib/pq, the driver used previously, requires SSL if sslmode is not set.
+	sslMode := c.SSLMode
+	if sslMode == "" {
+		sslMode = "require"
 	}
 
 	{
 		logValues := []interface{}{
-			quote(c.Host),
+			quoteConnStrValue(c.Host),
 			c.Port,
-			quote(database),
-			quote(c.Username),
-			quote("<redacted>"),
-			quote(c.SSLMode),
+			quoteConnStrValue(database),
+			quoteConnStrValue(c.Username),
+			quoteConnStrValue("<redacted>"),
+			quoteConnStrValue(sslMode),
 			c.ConnectTimeoutSec,
+			c.queryExecMode(),
 		}
-		if c.featureSupported(featureFallbackApplicationName) {
-			logValues = append(logValues, quote(c.ApplicationName))
+		if c.queryExecMode() == "cache_statement" {
+			logValues = append(logValues, c.StatementCache)
+		}
+		if c.useApplicationName() {
+			logValues = append(logValues, quoteConnStrValue(c.ApplicationName))
 		}
 
 		logDSN := fmt.Sprintf(dsnFmt, logValues...)
@@ -239,16 +611,20 @@ func (c *Config) connStr(database string) string {
 	var connStr string
 	{
 		connValues := []interface{}{
-			quote(c.Host),
+			quoteConnStrValue(c.Host),
 			c.Port,
-			quote(database),
-			quote(c.Username),
-			quote(c.Password),
-			quote(c.SSLMode),
+			quoteConnStrValue(database),
+			quoteConnStrValue(c.Username),
+			quoteConnStrValue(c.Password),
+			quoteConnStrValue(sslMode),
 			c.ConnectTimeoutSec,
+			c.queryExecMode(),
+		}
+		if c.queryExecMode() == "cache_statement" {
+			connValues = append(connValues, c.StatementCache)
 		}
-		if c.featureSupported(featureFallbackApplicationName) {
-			connValues = append(connValues, quote(c.ApplicationName))
+		if c.useApplicationName() {
+			connValues = append(connValues, quoteConnStrValue(c.ApplicationName))
 		}
 		connStr = fmt.Sprintf(dsnFmt, connValues...)
 	}
@@ -256,27 +632,156 @@ func (c *Config) connStr(database string) string {
 	return connStr
 }
 
+// quoteConnStrValue quotes a value of a libpq connection string (key=value format):
+// the quotes and backslashes are escaped, the empty strings or the strings
+// containing whitespace are quoted.
+func quoteConnStrValue(s string) string {
+	b := bytes.NewBufferString(`'`)
+	b.Grow(len(s) + 2)
+	var haveWhitespace bool
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			haveWhitespace = true
+		}
+
+		switch r {
+		case '\'':
+			b.WriteString(`\'`)
+		case '\\':
+			b.WriteString(`\\`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	b.WriteString(`'`)
+
+	str := b.String()
+	if haveWhitespace || len(str) == 2 {
+		return str
+	}
+	return str[1 : len(str)-1]
+}
+
+// queryExecMode returns how pgx executes the queries with parameters.
+// PgBouncer in transaction pooling mode does not support prepared statements
+// so the parameters are interpolated by pgx instead.
+// Without cache, the statements are prepared (unnamed) before each execution.
+// RDS Proxy pins the session to its server connection when a named prepared statement
+// is created, so the statement cache is not used behind it.
+func (c *Config) queryExecMode() string {
+	switch {
+	case c.PgBouncer:
+		return "simple_protocol"
+	case c.RDSProxy:
+		return "describe_exec"
+	case c.StatementCache > 0:
+		return "cache_statement"
+	default:
+		return "describe_exec"
+	}
+}
+
+// useApplicationName returns true if the application name of the provider
+// has to be set in the DSN. pgx does not support fallback_application_name so
+// the application name is only set if PGAPPNAME is not, to keep the same behavior.
+func (c *Config) useApplicationName() bool {
+	return c.featureSupported(featureFallbackApplicationName) && os.Getenv("PGAPPNAME") == ""
+}
+
 func (c *Config) getDatabaseUsername() string {
 	if c.DatabaseUsername != "" {
 		return c.DatabaseUsername
 	}
-	return c.Username
+	return c.roleName(c.Username)
+}
+
+// azureLoginSuffix returns the @servername suffix of the Azure Single Server logins
+// (empty if not in Azure mode).
+func (c *Config) azureLoginSuffix() string {
+	if !c.Azure {
+		return ""
+	}
+	if i := strings.LastIndex(c.Username, "@"); i >= 0 {
+		return c.Username[i:]
+	}
+	return ""
+}
+
+// roleName returns the name of the role in the database of a login
+// (i.e.: without the Azure @servername suffix).
+func (c *Config) roleName(login string) string {
+	if suffix := c.azureLoginSuffix(); suffix != "" {
+		return strings.TrimSuffix(login, suffix)
+	}
+	return login
+}
+
+// stateRoleName returns the role name to store in the state: the configured one
+// if it is the login of the role read from the database, so the Azure suffix
+// does not show up as a difference.
+func (c *Config) stateRoleName(configured, role string) string {
+	if configured != role && c.roleName(configured) == role {
+		return configured
+	}
+	return role
 }
 
 // DB returns a copy to an sql.Open()'ed database connection.  Callers must
 // return their database resources.  Use of QueryRow() or Exec() is encouraged.
 // Query() must have their rows.Close()'ed.
+// The connection pool is reopened if it has been closed by the registry.
 func (c *Client) DB() *sql.DB {
-	return c.db
+	dbRegistryLock.Lock()
+	dbEntry := dbRegistry[c.dsn]
+	// The pool is only checked if no connection is in use as it would be closed under the feet
+	// of the running queries. Dead connections are also discarded by the ping itself.
+	db := dbEntry.db
+	checkPool := db != nil && db.Stats().InUse == 0 && time.Since(dbEntry.lastUsed) > dbPoolPingInterval
+	dbRegistryLock.Unlock()
+
+	// The ping can wait up to dbPoolPingTimeout, it is done without the lock
+	// so an unreachable server does not block the operations on the other databases.
+	alive := !checkPool || c.pingDB(db)
+
+	dbRegistryLock.Lock()
+	defer dbRegistryLock.Unlock()
+
+	// The pool may have been closed, reopened or used by another operation during the ping.
+	if !alive && dbEntry.db == db && db.Stats().InUse == 0 {
+		log.Printf("[WARN] Connection to database %s lost, reconnecting", dbEntry.database)
+		closeDBRegistryEntry(dbEntry)
+	}
+
+	if dbEntry.db == nil {
+		closeUnusedDBPools(c.config.MaxPools - 1)
+
+		dbEntry.db = c.config.openDB(dbEntry.connConfig)
+	}
+	dbEntry.lastUsed = time.Now()
+
+	return dbEntry.db
+}
+
+// pingDB returns true if the server answers through the connection pool.
+func (c *Client) pingDB(db *sql.DB) bool {
+	ctx, cancel := context.WithTimeout(c.ctx, dbPoolPingTimeout)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		log.Printf("[DEBUG] Ping failed: %v", err)
+		return false
+	}
+	return true
 }
 
 // fingerprintCapabilities queries PostgreSQL to populate a local catalog of
 // capabilities.  This is only run once per Client.
-func fingerprintCapabilities(db *sql.DB) (*semver.Version, error) {
+func fingerprintCapabilities(db *sql.DB) (*semver.Version, serverFlavor, error) {
 	var pgVersion string
 	err := db.QueryRow(`SELECT VERSION()`).Scan(&pgVersion)
 	if err != nil {
-		return nil, errwrap.Wrapf("error PostgreSQL version: {{err}}", err)
+		return nil, "", errwrap.Wrapf("error PostgreSQL version: {{err}}", err)
 	}
 
 	// PostgreSQL 9.2.21 on x86_64-apple-darwin16.5.0, compiled by Apple LLVM version 8.1.0 (clang-802.0.42), 64-bit
@@ -285,15 +790,78 @@ func fingerprintCapabilities(db *sql.DB) (*semver.Version, error) {
 		return unicode.IsSpace(c) || c == ','
 	})
 	if len(fields) < 2 {
-		return nil, fmt.Errorf("error determining the server version: %q", pgVersion)
+		return nil, "", fmt.Errorf("error determining the server version: %q", pgVersion)
+	}
+
+	// PostgreSQL 11.2-YB-2.18.0.0-b0 on x86_64-pc-linux-gnu, compiled by clang version 15.0.3, 64-bit
+	// The YugabyteDB release is not part of the PostgreSQL version.
+	if i := strings.Index(fields[1], "-YB-"); i != -1 {
+		fields[1] = fields[1][:i]
+	}
+
+	// EnterpriseDB 9.6.2.7 on x86_64-pc-linux-gnu, compiled by gcc (GCC) 4.4.7 20120313 (Red Hat 4.4.7-17), 64-bit
+	// The fourth number of the EDB Postgres Advanced Server version is its build number.
+	if fields[0] == "EnterpriseDB" {
+		if parts := strings.Split(fields[1], "."); len(parts) > 3 {
+			fields[1] = strings.Join(parts[:3], ".")
+		}
 	}
 
 	version, err := semver.ParseTolerant(fields[1])
 	if err != nil {
-		return nil, errwrap.Wrapf("error parsing version: {{err}}", err)
+		return nil, "", errwrap.Wrapf("error parsing version: {{err}}", err)
+	}
+
+	flavor, err := detectFlavor(db, pgVersion)
+	if err != nil {
+		return nil, "", err
 	}
+	log.Printf("[INFO] Connected to %s %s", flavor, version)
 
-	return &version, nil
+	return &version, flavor, nil
+}
+
+// detectFlavor returns the flavor of the server from its version string
+// or, if needed, the settings specific to a flavor.
+func detectFlavor(db *sql.DB, pgVersion string) (serverFlavor, error) {
+	// PostgreSQL 8.0.2 on i686-pc-linux-gnu, compiled by GCC gcc (GCC) 3.4.2 20041017 (Red Hat 3.4.2-6.fc3), Redshift 1.0.28422
+	if strings.Contains(pgVersion, "Redshift") {
+		return flavorRedshift, nil
+	}
+
+	if strings.Contains(pgVersion, "-YB-") {
+		return flavorYugabyteDB, nil
+	}
+
+	// PostgreSQL 9.4.26 (Greenplum Database 6.22.0 build commit:...) on x86_64-unknown-linux-gnu, ...
+	if strings.Contains(pgVersion, "Greenplum Database") {
+		return flavorGreenplum, nil
+	}
+
+	// PostgreSQL 15.4 (EnterpriseDB Advanced Server 15.4.0) on x86_64-pc-linux-gnu, ...
+	if strings.Contains(pgVersion, "EnterpriseDB") {
+		return flavorEDB, nil
+	}
+
+	// AlloyDB versions look like PostgreSQL ones
+	// so the presence of its settings is checked too.
+	// Supabase runs PostgreSQL, its projects are recognized by their administration role.
+	var alloyDB, supabase bool
+	err := db.QueryRow(`SELECT
+		EXISTS (SELECT 1 FROM pg_settings WHERE name LIKE 'alloydb.%'),
+		EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'supabase_admin')`,
+	).Scan(&alloyDB, &supabase)
+	if err != nil {
+		return "", errwrap.Wrapf("error detecting the server flavor: {{err}}", err)
+	}
+	if alloyDB || strings.Contains(pgVersion, "AlloyDB") {
+		return flavorAlloyDB, nil
+	}
+	if supabase {
+		return flavorSupabase, nil
+	}
+
+	return flavorPostgreSQL, nil
 }
 
 // featureSupported returns true if a given feature is supported or not. This is
@@ -306,16 +874,85 @@ func (c *Client) featureSupported(name featureName) bool {
 		panic(fmt.Sprintf("unknown feature flag %v", name))
 	}
 
+	// The overrides of the configuration prevail over the detection.
+	if supported, ok := c.config.FeatureOverrides[name]; ok {
+		return supported
+	}
+
+	for _, unsupported := range flavorUnsupportedFeatures[c.flavor] {
+		if unsupported == name {
+			return false
+		}
+	}
+
 	return fn(c.version)
 }
 
-// isSuperuser returns true if connected user is a Postgres SUPERUSER
-func (c *Client) isSuperuser() (bool, error) {
-	var superuser bool
+// tolerateUnsupportedFeature returns the error of an operation skipped because its feature
+// is not supported by the server. With tolerate_unsupported_features, the error is only
+// logged as a warning and nil is returned: it must only be used when skipping the operation
+// leaves the object in a consistent state.
+func (c *Client) tolerateUnsupportedFeature(err error) error {
+	if !c.config.TolerateUnsupportedFeatures {
+		return err
+	}
+
+	log.Printf("[WARN] tolerate_unsupported_features: %v", err)
+	return nil
+}
 
-	if err := c.db.QueryRow("SELECT rolsuper FROM pg_roles WHERE rolname = CURRENT_USER").Scan(&superuser); err != nil {
-		return false, errwrap.Wrapf("could not check if current user is superuser: {{err}}", err)
+// withTimeout returns a copy of the client whose queries are canceled after the timeout
+// (see enforceTimeouts), with the function releasing its context.
+func (c *Client) withTimeout(timeout time.Duration) (*Client, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(c.ctx, timeout)
+	client := *c
+	client.ctx = ctx
+	return &client, cancel
+}
+
+// databaseLock returns the catalog lock of the specified database
+// (the database of the provider if empty).
+func (c *Client) databaseLock(database string) *sync.RWMutex {
+	if database == "" {
+		database = c.databaseName
 	}
 
-	return superuser, nil
+	c.dbLocksMutex.Lock()
+	defer c.dbLocksMutex.Unlock()
+
+	if c.dbLocks == nil {
+		c.dbLocks = make(map[string]*sync.RWMutex)
+	}
+	lock, found := c.dbLocks[database]
+	if !found {
+		lock = &sync.RWMutex{}
+		c.dbLocks[database] = lock
+	}
+	return lock
+}
+
+// lockDatabase locks the catalog of the specified database to modify its objects
+// and returns the function to release it.
+func (c *Client) lockDatabase(database string) func() {
+	c.catalogLock.RLock()
+	lock := c.databaseLock(database)
+	lock.Lock()
+
+	return func() {
+		lock.Unlock()
+		c.catalogLock.RUnlock()
+	}
+}
+
+// rLockDatabase locks the catalog of the specified database to read its objects
+// and returns the function to release it.
+func (c *Client) rLockDatabase(database string) func() {
+	c.catalogLock.RLock()
+	lock := c.databaseLock(database)
+	lock.RLock()
+
+	return func() {
+		lock.RUnlock()
+		c.catalogLock.RUnlock()
+	}
 }
@@ -0,0 +1,372 @@
+package postgresql
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+const (
+	accessDriftDatabaseAttr    = "database"
+	accessDriftGrantAttr       = "grant"
+	accessDriftFailOnDriftAttr = "fail_on_drift"
+	accessDriftMissingAttr     = "missing"
+	accessDriftExtraAttr       = "extra"
+	accessDriftDriftedAttr     = "drifted"
+
+	accessDriftRoleAttr       = "role"
+	accessDriftObjectTypeAttr = "object_type"
+	accessDriftSchemaAttr     = "schema"
+	accessDriftObjectsAttr    = "objects"
+	accessDriftPrivilegesAttr = "privileges"
+	accessDriftObjectAttr     = "object"
+	accessDriftPrivilegeAttr  = "privilege"
+)
+
+// maxDescribedGrants is the number of missing and extra grants described in the error of fail_on_drift.
+const maxDescribedGrants = 20
+
+// accessDriftObjectTypes are the object types whose privileges can be compared.
+var accessDriftObjectTypes = []string{"database", "schema", "table", "sequence"}
+
+// accessDriftQueries return, for each object of a type, the privileges granted to the roles
+// other than its owner (the privileges of the owner are implicit): one row per privilege,
+// or a single row with NULL privilege if none is granted. The object has the default
+// privileges of its type if its ACL is NULL (e.g.: CONNECT and TEMPORARY to PUBLIC for databases).
+var accessDriftQueries = map[string]string{
+	"database": `SELECT d.datname,
+	CASE WHEN a.grantee = 0 THEN 'public' ELSE r.rolname::text END, a.privilege_type
+FROM pg_catalog.pg_database d
+LEFT JOIN LATERAL pg_catalog.aclexplode(COALESCE(d.datacl, pg_catalog.acldefault('d', d.datdba))) a ON a.grantee <> d.datdba
+LEFT JOIN pg_catalog.pg_roles r ON r.oid = a.grantee
+WHERE d.datname = ANY($1)`,
+	"schema": `SELECT n.nspname,
+	CASE WHEN a.grantee = 0 THEN 'public' ELSE r.rolname::text END, a.privilege_type
+FROM pg_catalog.pg_namespace n
+LEFT JOIN LATERAL pg_catalog.aclexplode(COALESCE(n.nspacl, pg_catalog.acldefault('n', n.nspowner))) a ON a.grantee <> n.nspowner
+LEFT JOIN pg_catalog.pg_roles r ON r.oid = a.grantee
+WHERE n.nspname = ANY($1)`,
+	"relation": `SELECT n.nspname || '.' || c.relname,
+	CASE WHEN a.grantee = 0 THEN 'public' ELSE r.rolname::text END, a.privilege_type
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+LEFT JOIN LATERAL pg_catalog.aclexplode(COALESCE(c.relacl, pg_catalog.acldefault(
+	CASE WHEN c.relkind = 'S' THEN 's' ELSE 'r' END::"char", c.relowner
+))) a ON a.grantee <> c.relowner
+LEFT JOIN pg_catalog.pg_roles r ON r.oid = a.grantee
+WHERE n.nspname = $1 AND c.relkind::text = ANY($2)
+AND (array_length($3::text[], 1) IS NULL OR c.relname = ANY($3))`,
+}
+
+// accessGrant is a privilege of a role on an object.
+type accessGrant struct {
+	role       string
+	objectType string
+	object     string
+	privilege  string
+}
+
+func (g accessGrant) String() string {
+	return fmt.Sprintf("%s on %s %s to %s", g.privilege, g.objectType, g.object, g.role)
+}
+
+func accessGrantSchema() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			accessDriftRoleAttr: {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			accessDriftObjectTypeAttr: {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			accessDriftObjectAttr: {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			accessDriftPrivilegeAttr: {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// dataSourcePostgreSQLAccessDrift compares a declared privilege matrix with the privileges
+// granted on the objects it covers, e.g. to detect the GRANTs made outside of Terraform.
+func dataSourcePostgreSQLAccessDrift() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourcePostgreSQLAccessDriftRead,
+
+		Schema: map[string]*schema.Schema{
+			accessDriftDatabaseAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The database of the schemas, tables and sequences",
+			},
+			accessDriftGrantAttr: {
+				Type:        schema.TypeList,
+				Required:    true,
+				MinItems:    1,
+				Description: "The declared privileges of the roles on the objects",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						accessDriftRoleAttr: {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "The role having the privileges (public for PUBLIC)",
+						},
+						accessDriftObjectTypeAttr: {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice(accessDriftObjectTypes, false),
+							Description:  "The type of the objects (database, schema, table or sequence)",
+						},
+						accessDriftSchemaAttr: {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "The schema of the tables or sequences",
+						},
+						accessDriftObjectsAttr: {
+							Type:        schema.TypeSet,
+							Optional:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Set:         schema.HashString,
+							Description: "The databases or schemas, or the tables or sequences of the schema (all of them if not specified)",
+						},
+						accessDriftPrivilegesAttr: {
+							Type:        schema.TypeSet,
+							Required:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Set:         schema.HashString,
+							Description: "The privileges of the role on the objects (ALL for all the privileges of the type)",
+						},
+					},
+				},
+			},
+			accessDriftFailOnDriftAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When true, the read fails if grants are missing or extra, with a description of them",
+			},
+			accessDriftMissingAttr: {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The declared privileges which are not granted",
+				Elem:        accessGrantSchema(),
+			},
+			accessDriftExtraAttr: {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The privileges granted on the covered objects which are not declared",
+				Elem:        accessGrantSchema(),
+			},
+			accessDriftDriftedAttr: {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether grants are missing or extra",
+			},
+		},
+	}
+}
+
+func dataSourcePostgreSQLAccessDriftRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := c.databaseName
+	if v, ok := d.GetOk(accessDriftDatabaseAttr); ok {
+		database = v.(string)
+	}
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	declared := map[accessGrant]bool{}
+	granted := map[accessGrant]bool{}
+	for i, raw := range d.Get(accessDriftGrantAttr).([]interface{}) {
+		grant := raw.(map[string]interface{})
+		objectType := grant[accessDriftObjectTypeAttr].(string)
+		schemaName := grant[accessDriftSchemaAttr].(string)
+		objects := setToStrings(grant[accessDriftObjectsAttr].(*schema.Set))
+
+		privileges := setToStrings(grant[accessDriftPrivilegesAttr].(*schema.Set))
+		if err := validatePrivileges(objectType, grant[accessDriftPrivilegesAttr].(*schema.Set).List()); err != nil {
+			return fmt.Errorf("%s.%d: %v", accessDriftGrantAttr, i, err)
+		}
+		if sliceContainsStr(privileges, "ALL") {
+			privileges = nil
+			for _, privilege := range allowedPrivileges[objectType] {
+				if privilege != "ALL" && (privilege != "MAINTAIN" || c.featureSupported(featureMaintainPrivilege)) {
+					privileges = append(privileges, privilege)
+				}
+			}
+		}
+
+		var query string
+		var args []interface{}
+		switch objectType {
+		case "database", "schema":
+			if len(objects) == 0 {
+				return fmt.Errorf("%s.%d: objects must be set for object type %s", accessDriftGrantAttr, i, objectType)
+			}
+			query, args = accessDriftQueries[objectType], []interface{}{objects}
+		default:
+			if schemaName == "" {
+				return fmt.Errorf("%s.%d: schema must be set for object type %s", accessDriftGrantAttr, i, objectType)
+			}
+			query, args = accessDriftQueries["relation"], []interface{}{schemaName, grantRelkinds[objectType], objects}
+		}
+
+		found, err := readGrantedPrivileges(txn, c, objectType, query, args, granted)
+		if err != nil {
+			return err
+		}
+
+		// The specified objects which do not exist are missing all the declared privileges.
+		for _, object := range objects {
+			if objectType == "table" || objectType == "sequence" {
+				object = schemaName + "." + object
+			}
+			found[object] = true
+		}
+		for object := range found {
+			for _, privilege := range privileges {
+				declared[accessGrant{
+					role:       grant[accessDriftRoleAttr].(string),
+					objectType: objectType,
+					object:     object,
+					privilege:  privilege,
+				}] = true
+			}
+		}
+	}
+
+	missing, extra := diffAccessGrants(declared, granted)
+
+	if d.Get(accessDriftFailOnDriftAttr).(bool) && len(missing)+len(extra) > 0 {
+		return describeAccessDrift(database, missing, extra)
+	}
+
+	d.Set(accessDriftDatabaseAttr, database)
+	d.Set(accessDriftMissingAttr, flattenAccessGrants(missing))
+	d.Set(accessDriftExtraAttr, flattenAccessGrants(extra))
+	d.Set(accessDriftDriftedAttr, len(missing)+len(extra) > 0)
+	d.SetId(database)
+
+	return nil
+}
+
+// readGrantedPrivileges adds the privileges granted on the objects returned by the query
+// (see accessDriftQueries) to granted and returns these objects.
+func readGrantedPrivileges(txn *sql.Tx, c *Client, objectType, query string, args []interface{}, granted map[accessGrant]bool) (map[string]bool, error) {
+	rows, err := txn.QueryContext(c.ctx, query, args...)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("could not read the privileges on the %ss: {{err}}", objectType), err)
+	}
+	defer rows.Close()
+
+	objects := map[string]bool{}
+	for rows.Next() {
+		var object string
+		var role, privilege sql.NullString
+		if err := rows.Scan(&object, &role, &privilege); err != nil {
+			return nil, errwrap.Wrapf("could not scan privilege: {{err}}", err)
+		}
+		objects[object] = true
+		if privilege.Valid {
+			granted[accessGrant{
+				role:       role.String,
+				objectType: objectType,
+				object:     object,
+				privilege:  privilege.String,
+			}] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("could not read the privileges on the %ss: {{err}}", objectType), err)
+	}
+
+	return objects, nil
+}
+
+// diffAccessGrants returns the declared grants which are not granted and the granted
+// ones which are not declared, sorted by object type, object, role and privilege.
+func diffAccessGrants(declared, granted map[accessGrant]bool) (missing, extra []accessGrant) {
+	for grant := range declared {
+		if !granted[grant] {
+			missing = append(missing, grant)
+		}
+	}
+	for grant := range granted {
+		if !declared[grant] {
+			extra = append(extra, grant)
+		}
+	}
+	sortAccessGrants(missing)
+	sortAccessGrants(extra)
+	return missing, extra
+}
+
+func sortAccessGrants(grants []accessGrant) {
+	sort.Slice(grants, func(i, j int) bool {
+		a, b := grants[i], grants[j]
+		if a.objectType != b.objectType {
+			return a.objectType < b.objectType
+		}
+		if a.object != b.object {
+			return a.object < b.object
+		}
+		if a.role != b.role {
+			return a.role < b.role
+		}
+		return a.privilege < b.privilege
+	})
+}
+
+func flattenAccessGrants(grants []accessGrant) []interface{} {
+	flattened := make([]interface{}, len(grants))
+	for i, grant := range grants {
+		flattened[i] = map[string]interface{}{
+			accessDriftRoleAttr:       grant.role,
+			accessDriftObjectTypeAttr: grant.objectType,
+			accessDriftObjectAttr:     grant.object,
+			accessDriftPrivilegeAttr:  grant.privilege,
+		}
+	}
+	return flattened
+}
+
+// describeAccessDrift returns the error of fail_on_drift, listing the missing and extra grants.
+func describeAccessDrift(database string, missing, extra []accessGrant) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "privileges in database %s differ from the declared ones (fail_on_drift): %d missing, %d extra", database, len(missing), len(extra))
+	for _, list := range []struct {
+		name   string
+		grants []accessGrant
+	}{
+		{"Missing", missing},
+		{"Extra", extra},
+	} {
+		for i, grant := range list.grants {
+			if i == maxDescribedGrants {
+				fmt.Fprintf(b, "\n  ... and %d other %s grants", len(list.grants)-maxDescribedGrants, strings.ToLower(list.name))
+				break
+			}
+			fmt.Fprintf(b, "\n  %s: %s", list.name, grant)
+		}
+	}
+
+	return errors.New(b.String())
+}
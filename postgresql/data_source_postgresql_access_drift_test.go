@@ -0,0 +1,103 @@
+package postgresql
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestDiffAccessGrants(t *testing.T) {
+	grant := func(role, object, privilege string) accessGrant {
+		return accessGrant{role: role, objectType: "table", object: object, privilege: privilege}
+	}
+
+	declared := map[accessGrant]bool{
+		grant("reader", "public.orders", "SELECT"): true,
+		grant("reader", "public.items", "SELECT"):  true,
+		grant("writer", "public.orders", "INSERT"): true,
+	}
+	granted := map[accessGrant]bool{
+		grant("reader", "public.orders", "SELECT"):  true,
+		grant("writer", "public.orders", "INSERT"):  true,
+		grant("writer", "public.orders", "DELETE"):  true,
+		grant("intruder", "public.items", "SELECT"): true,
+	}
+
+	missing, extra := diffAccessGrants(declared, granted)
+
+	if expected := []accessGrant{grant("reader", "public.items", "SELECT")}; !reflect.DeepEqual(missing, expected) {
+		t.Errorf("expected missing grants %v, got %v", expected, missing)
+	}
+	expectedExtra := []accessGrant{
+		grant("intruder", "public.items", "SELECT"),
+		grant("writer", "public.orders", "DELETE"),
+	}
+	if !reflect.DeepEqual(extra, expectedExtra) {
+		t.Errorf("expected extra grants %v, got %v", expectedExtra, extra)
+	}
+
+	err := describeAccessDrift("app", missing, extra)
+	for _, expected := range []string{
+		"privileges in database app differ from the declared ones (fail_on_drift): 1 missing, 2 extra",
+		"Missing: SELECT on table public.items to reader",
+		"Extra: DELETE on table public.orders to writer",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in the error, got: %s", expected, err)
+		}
+	}
+}
+
+func TestAccPostgresqlDataSourceAccessDrift_Basic(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, true)
+	defer teardown()
+
+	dbName, roleName := getTestDBNames(dbSuffix)
+	config := getTestConfig(t)
+	dbExecute(t, config.connStr(dbName), "CREATE TABLE test_schema.accounts (id integer)")
+	dbExecute(t, config.connStr(dbName), fmt.Sprintf("GRANT SELECT, INSERT ON test_schema.accounts TO %s", roleName))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+data "postgresql_access_drift" "test" {
+  database = "%[1]s"
+
+  grant {
+    role        = "%[2]s"
+    object_type = "schema"
+    objects     = ["test_schema"]
+    privileges  = ["USAGE"]
+  }
+
+  grant {
+    role        = "%[2]s"
+    object_type = "table"
+    schema      = "test_schema"
+    privileges  = ["SELECT", "UPDATE"]
+  }
+}
+`, dbName, roleName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.postgresql_access_drift.test", "drifted", "true"),
+					resource.TestCheckResourceAttr("data.postgresql_access_drift.test", "missing.#", "1"),
+					resource.TestCheckResourceAttr("data.postgresql_access_drift.test", "missing.0.object", "test_schema.accounts"),
+					resource.TestCheckResourceAttr("data.postgresql_access_drift.test", "missing.0.privilege", "UPDATE"),
+					resource.TestCheckResourceAttr("data.postgresql_access_drift.test", "extra.#", "1"),
+					resource.TestCheckResourceAttr("data.postgresql_access_drift.test", "extra.0.role", roleName),
+					resource.TestCheckResourceAttr("data.postgresql_access_drift.test", "extra.0.privilege", "INSERT"),
+				),
+			},
+		},
+	})
+}
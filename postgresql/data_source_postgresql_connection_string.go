@@ -0,0 +1,192 @@
+package postgresql
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+const (
+	connStrHostAttr     = "host"
+	connStrPortAttr     = "port"
+	connStrDatabaseAttr = "database"
+	connStrUsernameAttr = "username"
+	connStrPasswordAttr = "password"
+	connStrSSLModeAttr  = "sslmode"
+	connStrOptionsAttr  = "options"
+	connStrDSNAttr      = "dsn"
+	connStrURIAttr      = "uri"
+)
+
+var connStrSSLModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// connStrParam is a parameter of a libpq connection string.
+type connStrParam struct {
+	key   string
+	value string
+}
+
+// dataSourcePostgreSQLConnectionString assembles libpq connection strings from their components,
+// e.g.: for the options of the foreign servers or the connections of the subscriptions.
+// It does not connect to the server.
+func dataSourcePostgreSQLConnectionString() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourcePostgreSQLConnectionStringRead,
+
+		Schema: map[string]*schema.Schema{
+			connStrHostAttr: {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The host name, IP address or Unix socket directory of the server",
+			},
+			connStrPortAttr: {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      5432,
+				ValidateFunc: validation.IntBetween(1, 65535),
+				Description:  "The port of the server",
+			},
+			connStrDatabaseAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The database to connect to",
+			},
+			connStrUsernameAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The user to connect as",
+			},
+			connStrPasswordAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "The password of the user",
+			},
+			connStrSSLModeAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(connStrSSLModes, false),
+				Description:  "The SSL mode of the connection",
+			},
+			connStrOptionsAttr: {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The other libpq parameters (e.g.: application_name, connect_timeout, target_session_attrs)",
+			},
+			connStrDSNAttr: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The connection string in the key=value format",
+			},
+			connStrURIAttr: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The connection string in the URI format (postgresql://...)",
+			},
+		},
+	}
+}
+
+func dataSourcePostgreSQLConnectionStringRead(d *schema.ResourceData, meta interface{}) error {
+	host := d.Get(connStrHostAttr).(string)
+	port := d.Get(connStrPortAttr).(int)
+
+	params := []connStrParam{
+		{"host", host},
+		{"port", strconv.Itoa(port)},
+	}
+	for _, attr := range []struct {
+		attr string
+		key  string
+	}{
+		{connStrDatabaseAttr, "dbname"},
+		{connStrUsernameAttr, "user"},
+		{connStrPasswordAttr, "password"},
+		{connStrSSLModeAttr, "sslmode"},
+	} {
+		if v, ok := d.GetOk(attr.attr); ok {
+			params = append(params, connStrParam{attr.key, v.(string)})
+		}
+	}
+
+	options := d.Get(connStrOptionsAttr).(map[string]interface{})
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, param := range params {
+			if param.key == key {
+				return fmt.Errorf("%s cannot be set in %s, use its attribute instead", key, connStrOptionsAttr)
+			}
+		}
+		params = append(params, connStrParam{key, options[key].(string)})
+	}
+
+	d.Set(connStrDSNAttr, connStrKeywordValue(params))
+	d.Set(connStrURIAttr, connStrURI(params))
+	d.SetId(fmt.Sprintf("%s:%d/%s", host, port, d.Get(connStrDatabaseAttr).(string)))
+
+	return nil
+}
+
+// connStrKeywordValue returns the connection string of the parameters in the key=value format.
+func connStrKeywordValue(params []connStrParam) string {
+	parts := make([]string, len(params))
+	for i, param := range params {
+		parts[i] = fmt.Sprintf("%s=%s", param.key, quoteConnStrValue(param.value))
+	}
+	return strings.Join(parts, " ")
+}
+
+// connStrURI returns the connection string of the parameters in the URI format.
+// The Unix socket directories, which cannot be the host of a URI, are set in the query.
+func connStrURI(params []connStrParam) string {
+	u := &url.URL{Scheme: "postgresql", Path: "/"}
+	query := []string{}
+	var host, port, user, password string
+	var hasPassword bool
+
+	for _, param := range params {
+		switch param.key {
+		case "host":
+			host = param.value
+		case "port":
+			port = param.value
+		case "dbname":
+			u.Path = "/" + param.value
+		case "user":
+			user = param.value
+		case "password":
+			password, hasPassword = param.value, true
+		default:
+			query = append(query, url.QueryEscape(param.key)+"="+url.QueryEscape(param.value))
+		}
+	}
+
+	if strings.HasPrefix(host, "/") {
+		query = append([]string{"host=" + url.QueryEscape(host), "port=" + port}, query...)
+	} else {
+		u.Host = net.JoinHostPort(host, port)
+	}
+
+	switch {
+	case hasPassword:
+		u.User = url.UserPassword(user, password)
+	case user != "":
+		u.User = url.User(user)
+	}
+
+	u.RawQuery = strings.Join(query, "&")
+	return u.String()
+}
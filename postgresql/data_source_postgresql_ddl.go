@@ -0,0 +1,223 @@
+package postgresql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	ddlDatabaseAttr = "database"
+	ddlSchemasAttr  = "schemas"
+	ddlDDLAttr      = "ddl"
+)
+
+// ddlNamespaceFilter restricts the objects to the schemas requested in $1
+// (all the non-system schemas if $1 is empty).
+const ddlNamespaceFilter = `n.nspname NOT LIKE 'pg\_%' AND n.nspname <> 'information_schema'
+AND (array_length($1::text[], 1) IS NULL OR n.nspname = ANY($1))`
+
+func dataSourcePostgreSQLDDL() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourcePostgreSQLDDLRead,
+
+		Schema: map[string]*schema.Schema{
+			ddlDatabaseAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The database to export the DDL from",
+			},
+			ddlSchemasAttr: {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         schema.HashString,
+				Description: "The schemas to export (all the schemas of the database if not specified)",
+			},
+			ddlDDLAttr: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The schema-only DDL of the database",
+			},
+		},
+	}
+}
+
+func dataSourcePostgreSQLDDLRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if !c.featureSupported(featureDDLExport) {
+		return fmt.Errorf(
+			"postgresql_ddl data source is not supported for this Postgres version (%s)",
+			c.version,
+		)
+	}
+
+	database := c.databaseName
+	if v, ok := d.GetOk(ddlDatabaseAttr); ok {
+		database = v.(string)
+	}
+
+	schemas := []string{}
+	for _, s := range d.Get(ddlSchemasAttr).(*schema.Set).List() {
+		schemas = append(schemas, s.(string))
+	}
+	sort.Strings(schemas)
+
+	defer c.rLockDatabase(database)()
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	statements := []string{}
+	for _, query := range ddlQueries(c) {
+		rows, err := txn.QueryContext(c.ctx, query, schemas)
+		if err != nil {
+			return errwrap.Wrapf("could not export DDL: {{err}}", err)
+		}
+
+		for rows.Next() {
+			var statement string
+			if err := rows.Scan(&statement); err != nil {
+				rows.Close()
+				return errwrap.Wrapf("could not scan DDL statement: {{err}}", err)
+			}
+			statements = append(statements, statement)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return errwrap.Wrapf("could not export DDL: {{err}}", err)
+		}
+	}
+
+	ddl := strings.Join(statements, "\n\n")
+	if ddl != "" {
+		ddl += "\n"
+	}
+
+	d.Set(ddlDatabaseAttr, database)
+	d.Set(ddlDDLAttr, ddl)
+	d.SetId(strings.Join(append([]string{database}, schemas...), "."))
+
+	return nil
+}
+
+// ddlNotExtensionMember excludes the objects created by an extension
+// (they are created again with the extension).
+func ddlNotExtensionMember(catalog, oid string) string {
+	return fmt.Sprintf(`NOT EXISTS (
+    SELECT 1 FROM pg_catalog.pg_depend dep
+    WHERE dep.classid = 'pg_catalog.%s'::regclass AND dep.objid = %s AND dep.deptype = 'e'
+)`, catalog, oid)
+}
+
+// ddlQueries returns the queries generating the DDL statements, in the order
+// they are exported. Each query takes the list of schemas to export as parameter
+// and returns one statement per row, sorted by object name so the result is stable.
+func ddlQueries(c *Client) []string {
+	partitionKey := "''"
+	if c.featureSupported(featureDeclarativePartitioning) {
+		partitionKey = "CASE WHEN c.relkind = 'p' THEN ' PARTITION BY ' || pg_catalog.pg_get_partkeydef(c.oid) ELSE '' END"
+	}
+
+	queries := []string{
+		`SELECT format('CREATE SCHEMA %I;', n.nspname)
+FROM pg_catalog.pg_namespace n
+WHERE ` + ddlNamespaceFilter + ` AND ` + ddlNotExtensionMember("pg_namespace", "n.oid") + `
+ORDER BY n.nspname`,
+
+		// Sequences owned by identity columns are created with their table.
+		`SELECT format('CREATE SEQUENCE %I.%I INCREMENT BY %s MINVALUE %s MAXVALUE %s START WITH %s%s;',
+    n.nspname, c.relname, s.increment, s.minimum_value, s.maximum_value, s.start_value,
+    CASE WHEN s.cycle_option = 'YES' THEN ' CYCLE' ELSE '' END)
+FROM information_schema.sequences s
+JOIN pg_catalog.pg_namespace n ON n.nspname = s.sequence_schema
+JOIN pg_catalog.pg_class c ON c.relnamespace = n.oid AND c.relname = s.sequence_name
+WHERE ` + ddlNamespaceFilter + ` AND ` + ddlNotExtensionMember("pg_class", "c.oid") + `
+AND NOT EXISTS (
+    SELECT 1 FROM pg_catalog.pg_depend dep
+    WHERE dep.classid = 'pg_catalog.pg_class'::regclass AND dep.objid = c.oid AND dep.deptype = 'i'
+)
+ORDER BY n.nspname, c.relname`,
+
+		`SELECT format(E'CREATE TABLE %I.%I (\n%s\n)%s;', n.nspname, c.relname,
+    array_to_string(ARRAY(
+        SELECT format('    %I %s', a.attname, pg_catalog.format_type(a.atttypid, a.atttypmod))
+            || COALESCE(' DEFAULT ' || pg_catalog.pg_get_expr(ad.adbin, ad.adrelid), '')
+            || CASE WHEN a.attnotnull THEN ' NOT NULL' ELSE '' END
+        FROM pg_catalog.pg_attribute a
+        LEFT JOIN pg_catalog.pg_attrdef ad ON ad.adrelid = a.attrelid AND ad.adnum = a.attnum
+        WHERE a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+        ORDER BY a.attnum
+    ), E',\n'),
+    ` + partitionKey + `)
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'p') AND ` + ddlNamespaceFilter + ` AND ` + ddlNotExtensionMember("pg_class", "c.oid") + `
+ORDER BY n.nspname, c.relname`,
+	}
+
+	if c.featureSupported(featureDeclarativePartitioning) {
+		queries = append(queries, `SELECT format('ALTER TABLE %I.%I ATTACH PARTITION %I.%I %s;',
+    pn.nspname, p.relname, n.nspname, c.relname, pg_catalog.pg_get_expr(c.relpartbound, c.oid))
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+JOIN pg_catalog.pg_inherits i ON i.inhrelid = c.oid
+JOIN pg_catalog.pg_class p ON p.oid = i.inhparent
+JOIN pg_catalog.pg_namespace pn ON pn.oid = p.relnamespace
+WHERE c.relispartition AND c.relkind IN ('r', 'p') AND `+ddlNamespaceFilter+` AND `+ddlNotExtensionMember("pg_class", "c.oid")+`
+ORDER BY n.nspname, c.relname`)
+	}
+
+	return append(queries,
+		// The NOT NULL constraints are part of the columns.
+		// The foreign keys are exported last as they reference the other constraints.
+		`SELECT format('ALTER TABLE %I.%I ADD CONSTRAINT %I %s;',
+    n.nspname, c.relname, con.conname, pg_catalog.pg_get_constraintdef(con.oid))
+FROM pg_catalog.pg_constraint con
+JOIN pg_catalog.pg_class c ON c.oid = con.conrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE con.contype <> 'n' AND con.coninhcount = 0
+AND `+ddlNamespaceFilter+` AND `+ddlNotExtensionMember("pg_class", "c.oid")+`
+ORDER BY con.contype = 'f', n.nspname, c.relname, con.conname`,
+
+		`SELECT pg_catalog.pg_get_indexdef(i.indexrelid) || ';'
+FROM pg_catalog.pg_index i
+JOIN pg_catalog.pg_class ic ON ic.oid = i.indexrelid
+JOIN pg_catalog.pg_class c ON c.oid = i.indrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE NOT EXISTS (SELECT 1 FROM pg_catalog.pg_constraint con WHERE con.conindid = i.indexrelid)
+AND `+ddlNamespaceFilter+` AND `+ddlNotExtensionMember("pg_class", "c.oid")+`
+ORDER BY n.nspname, ic.relname`,
+
+		// pg_get_functiondef() cannot be used on aggregates.
+		`SELECT pg_catalog.pg_get_functiondef(p.oid) || ';'
+FROM pg_catalog.pg_proc p
+JOIN pg_catalog.pg_namespace n ON n.oid = p.pronamespace
+WHERE NOT EXISTS (SELECT 1 FROM pg_catalog.pg_aggregate a WHERE a.aggfnoid = p.oid)
+AND `+ddlNamespaceFilter+` AND `+ddlNotExtensionMember("pg_proc", "p.oid")+`
+ORDER BY n.nspname, p.proname, pg_catalog.pg_get_function_identity_arguments(p.oid)`,
+
+		`SELECT format(E'CREATE %sVIEW %I.%I AS\n%s',
+    CASE WHEN c.relkind = 'm' THEN 'MATERIALIZED ' ELSE '' END,
+    n.nspname, c.relname, pg_catalog.pg_get_viewdef(c.oid))
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('v', 'm') AND `+ddlNamespaceFilter+` AND `+ddlNotExtensionMember("pg_class", "c.oid")+`
+ORDER BY n.nspname, c.relname`,
+
+		`SELECT pg_catalog.pg_get_triggerdef(t.oid) || ';'
+FROM pg_catalog.pg_trigger t
+JOIN pg_catalog.pg_class c ON c.oid = t.tgrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE NOT t.tgisinternal AND `+ddlNamespaceFilter+` AND `+ddlNotExtensionMember("pg_class", "c.oid")+`
+ORDER BY n.nspname, c.relname, t.tgname`,
+	)
+}
@@ -0,0 +1,47 @@
+package postgresql
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccPostgresqlDataSourceDDL_Basic(t *testing.T) {
+	skipIfNotAcc(t)
+	testCheckCompatibleVersion(t, featureDDLExport)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, false)
+	defer teardown()
+
+	dbName, _ := getTestDBNames(dbSuffix)
+	config := getTestConfig(t)
+	dbExecute(t, config.connStr(dbName), "CREATE TABLE test_schema.accounts (id serial PRIMARY KEY, name text NOT NULL)")
+	dbExecute(t, config.connStr(dbName), "CREATE VIEW test_schema.account_names AS SELECT name FROM test_schema.accounts")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+data "postgresql_ddl" "test" {
+  database = "%s"
+  schemas  = ["test_schema"]
+}
+`, dbName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.postgresql_ddl.test", "id", fmt.Sprintf("%s.test_schema", dbName)),
+					resource.TestMatchResourceAttr("data.postgresql_ddl.test", "ddl", regexp.MustCompile(`CREATE SCHEMA test_schema;`)),
+					resource.TestMatchResourceAttr("data.postgresql_ddl.test", "ddl", regexp.MustCompile(`CREATE SEQUENCE test_schema\.accounts_id_seq `)),
+					resource.TestMatchResourceAttr("data.postgresql_ddl.test", "ddl", regexp.MustCompile(`CREATE TABLE test_schema\.accounts \(\n    id integer DEFAULT nextval`)),
+					resource.TestMatchResourceAttr("data.postgresql_ddl.test", "ddl", regexp.MustCompile(`ADD CONSTRAINT accounts_pkey PRIMARY KEY \(id\);`)),
+					resource.TestMatchResourceAttr("data.postgresql_ddl.test", "ddl", regexp.MustCompile(`CREATE VIEW test_schema\.account_names AS`)),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,230 @@
+package postgresql
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	locksDatabaseAttr   = "database"
+	locksRelationsAttr  = "relations"
+	locksFailOnLockAttr = "fail_on_locks"
+	locksLocksAttr      = "locks"
+
+	locksPIDAttr             = "pid"
+	locksLockTypeAttr        = "lock_type"
+	locksModeAttr            = "mode"
+	locksGrantedAttr         = "granted"
+	locksRelationAttr        = "relation"
+	locksUsernameAttr        = "username"
+	locksApplicationNameAttr = "application_name"
+	locksStateAttr           = "state"
+	locksQueryAttr           = "query"
+	locksXactDurationAttr    = "transaction_duration"
+)
+
+// maxDescribedSessions is the number of sessions described in the error of fail_on_locks.
+const maxDescribedSessions = 10
+
+// locksQuery returns the locks held or awaited by the other sessions in the current database,
+// on the relations of $1 only if it is not empty. The relations which do not exist are ignored.
+const locksQuery = `SELECT l.pid, l.locktype, l.mode, l.granted,
+	COALESCE(l.relation::regclass::text, ''),
+	COALESCE(a.usename::text, ''), COALESCE(a.application_name, ''), COALESCE(a.state, ''), COALESCE(a.query, ''),
+	COALESCE(EXTRACT(EPOCH FROM now() - a.xact_start)::integer, 0)
+FROM pg_catalog.pg_locks l
+LEFT JOIN pg_catalog.pg_stat_activity a ON a.pid = l.pid
+WHERE l.pid <> pg_catalog.pg_backend_pid()
+AND l.database = (SELECT oid FROM pg_catalog.pg_database WHERE datname = pg_catalog.current_database())
+AND (array_length($1::text[], 1) IS NULL OR l.relation = ANY(ARRAY(
+	SELECT pg_catalog.to_regclass(r)::oid FROM unnest($1::text[]) AS r
+)))
+ORDER BY l.granted DESC, a.xact_start, l.pid`
+
+func dataSourcePostgreSQLLocks() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourcePostgreSQLLocksRead,
+
+		Schema: map[string]*schema.Schema{
+			locksDatabaseAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The database to list the locks of",
+			},
+			locksRelationsAttr: {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         schema.HashString,
+				Description: "The relations (e.g.: public.my_table) to list the locks of (all the locks of the database if not specified)",
+			},
+			locksFailOnLockAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When true, the read fails if other sessions hold or wait for locks, with a description of these sessions",
+			},
+			locksLocksAttr: {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The locks held or awaited by the other sessions",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						locksPIDAttr: {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						locksLockTypeAttr: {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						locksModeAttr: {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						locksGrantedAttr: {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						locksRelationAttr: {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						locksUsernameAttr: {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						locksApplicationNameAttr: {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						locksStateAttr: {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						locksQueryAttr: {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						locksXactDurationAttr: {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourcePostgreSQLLocksRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := c.databaseName
+	if v, ok := d.GetOk(locksDatabaseAttr); ok {
+		database = v.(string)
+	}
+
+	relations := setToStrings(d.Get(locksRelationsAttr).(*schema.Set))
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	rows, err := txn.QueryContext(c.ctx, locksQuery, relations)
+	if err != nil {
+		return errwrap.Wrapf("could not read the locks: {{err}}", err)
+	}
+	defer rows.Close()
+
+	locks := []interface{}{}
+	for rows.Next() {
+		var pid, xactDuration int
+		var granted bool
+		var lockType, mode, relation, username, applicationName, state, query string
+		if err := rows.Scan(
+			&pid, &lockType, &mode, &granted, &relation, &username, &applicationName, &state, &query, &xactDuration,
+		); err != nil {
+			return errwrap.Wrapf("could not scan lock: {{err}}", err)
+		}
+		locks = append(locks, map[string]interface{}{
+			locksPIDAttr:             pid,
+			locksLockTypeAttr:        lockType,
+			locksModeAttr:            mode,
+			locksGrantedAttr:         granted,
+			locksRelationAttr:        relation,
+			locksUsernameAttr:        username,
+			locksApplicationNameAttr: applicationName,
+			locksStateAttr:           state,
+			locksQueryAttr:           redactStatement(query),
+			locksXactDurationAttr:    xactDuration,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return errwrap.Wrapf("could not read the locks: {{err}}", err)
+	}
+
+	if d.Get(locksFailOnLockAttr).(bool) && len(locks) > 0 {
+		return describeLocks(database, locks)
+	}
+
+	d.Set(locksDatabaseAttr, database)
+	d.Set(locksLocksAttr, locks)
+	d.SetId(strings.Join(append([]string{database}, relations...), "."))
+
+	return nil
+}
+
+// describeLocks returns the error of fail_on_locks, describing the sessions holding
+// or waiting for the locks (the locks of a session are grouped).
+func describeLocks(database string, locks []interface{}) error {
+	type session struct {
+		lock  map[string]interface{}
+		modes []string
+	}
+	sessions := map[int]*session{}
+	pids := []int{}
+	for _, l := range locks {
+		lock := l.(map[string]interface{})
+		pid := lock[locksPIDAttr].(int)
+		if _, ok := sessions[pid]; !ok {
+			sessions[pid] = &session{lock: lock}
+			pids = append(pids, pid)
+		}
+
+		mode := lock[locksModeAttr].(string)
+		if relation := lock[locksRelationAttr].(string); relation != "" {
+			mode += " on " + relation
+		}
+		if !lock[locksGrantedAttr].(bool) {
+			mode += " (waiting)"
+		}
+		sessions[pid].modes = append(sessions[pid].modes, mode)
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%d sessions hold or wait for locks in database %s (fail_on_locks):", len(pids), database)
+	for i, pid := range pids {
+		if i == maxDescribedSessions {
+			fmt.Fprintf(b, "\n  ... and %d other sessions", len(pids)-maxDescribedSessions)
+			break
+		}
+		s := sessions[pid]
+		sort.Strings(s.modes)
+		fmt.Fprintf(b, "\n  pid %d (user %s, application %q, %s, transaction running for %ds): %s\n    Query: %s",
+			pid, s.lock[locksUsernameAttr], s.lock[locksApplicationNameAttr], s.lock[locksStateAttr],
+			s.lock[locksXactDurationAttr], strings.Join(s.modes, ", "), s.lock[locksQueryAttr],
+		)
+	}
+	b.WriteString("\n  Hint: wait for these sessions to finish, or terminate them with pg_terminate_backend(pid)")
+
+	return errors.New(b.String())
+}
@@ -0,0 +1,44 @@
+package postgresql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDescribeLocks(t *testing.T) {
+	lock := func(pid int, mode, relation string, granted bool) map[string]interface{} {
+		return map[string]interface{}{
+			locksPIDAttr:             pid,
+			locksModeAttr:            mode,
+			locksGrantedAttr:         granted,
+			locksRelationAttr:        relation,
+			locksUsernameAttr:        "app",
+			locksApplicationNameAttr: "worker",
+			locksStateAttr:           "idle in transaction",
+			locksQueryAttr:           "SELECT * FROM public.orders",
+			locksXactDurationAttr:    42,
+		}
+	}
+
+	err := describeLocks("app", []interface{}{
+		lock(100, "AccessShareLock", "public.orders", true),
+		lock(100, "RowExclusiveLock", "public.items", true),
+		lock(200, "AccessExclusiveLock", "public.orders", false),
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, expected := range []string{
+		"2 sessions hold or wait for locks in database app",
+		`pid 100 (user app, application "worker", idle in transaction, transaction running for 42s): AccessShareLock on public.orders, RowExclusiveLock on public.items`,
+		"pid 200",
+		"AccessExclusiveLock on public.orders (waiting)",
+		"Query: SELECT * FROM public.orders",
+		"pg_terminate_backend",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in:\n%s", expected, err.Error())
+		}
+	}
+}
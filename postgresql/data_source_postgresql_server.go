@@ -0,0 +1,36 @@
+package postgresql
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourcePostgreSQLServer() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourcePostgreSQLServerRead,
+
+		Schema: map[string]*schema.Schema{
+			"version": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The version of the server, as detected by the provider",
+			},
+			"flavor": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The kind of PostgreSQL-compatible server (e.g.: postgresql, alloydb, redshift, yugabytedb, greenplum, edb, supabase)",
+			},
+		},
+	}
+}
+
+func dataSourcePostgreSQLServerRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	d.Set("version", c.version.String())
+	d.Set("flavor", string(c.flavor))
+	d.SetId(fmt.Sprintf("%s:%d", c.config.Host, c.config.Port))
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccPostgresqlDataSourceServer_Basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: `data "postgresql_server" "test" {}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.postgresql_server.test", "version"),
+					resource.TestCheckResourceAttr("data.postgresql_server.test", "flavor", "postgresql"),
+				),
+			},
+		},
+	})
+}
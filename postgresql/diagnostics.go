@@ -0,0 +1,207 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// sqlState describes a SQLSTATE code with a remediation hint.
+type sqlState struct {
+	name string
+	hint string
+}
+
+// sqlStates are the SQLSTATE codes for which a hint is added to the errors.
+var sqlStates = map[string]sqlState{
+	"28000": {"invalid_authorization_specification", "check the username, sslmode and pg_hba.conf rules of the server"},
+	"28P01": {"invalid_password", "check the username and password of the provider"},
+	"3D000": {"invalid_catalog_name", "the database does not exist, check the database of the resource or of the provider"},
+	"3F000": {"invalid_schema_name", "the schema does not exist, check its name (the identifiers are quoted so they are case sensitive)"},
+	"42501": {"insufficient_privilege", "the provider's user lacks a privilege: the statement may require superuser (or rds_superuser, azure_pg_admin, cloudsqlsuperuser on managed services), the CREATEROLE or CREATEDB attribute, or the ownership of the object"},
+	"42704": {"undefined_object", "the object does not exist, check its name (the identifiers are quoted so they are case sensitive)"},
+	"42883": {"undefined_function", "the function does not exist, check that the extension providing it is installed in the database"},
+	"42P01": {"undefined_table", "the relation does not exist, check its name and schema (the identifiers are quoted so they are case sensitive)"},
+	"42P04": {"duplicate_database", "the database already exists, import it in the state instead of creating it"},
+	"42P06": {"duplicate_schema", "the schema already exists, import it in the state instead of creating it"},
+	"42P07": {"duplicate_table", "the relation already exists, import it in the state instead of creating it"},
+	"42710": {"duplicate_object", "the object already exists, import it in the state instead of creating it"},
+	"2BP01": {"dependent_objects_still_exist", "other objects depend on this object, drop them or reassign them first (e.g.: REASSIGN OWNED and DROP OWNED for a role)"},
+	"55006": {"object_in_use", "the object is used by other sessions (e.g.: connections to the database), close them and retry"},
+	"0A000": {"feature_not_supported", "the feature is not supported by this server, check its PostgreSQL version and flavor"},
+	"25006": {"read_only_sql_transaction", "the server is read-only (e.g.: a standby), see the failover_retries and promotion_timeout provider attributes"},
+	"53300": {"too_many_connections", "the server has no connection slot left, lower the max_connections or max_concurrent_operations provider attributes"},
+	"40001": {"serialization_failure", "the transaction conflicted with a concurrent one, retry the apply"},
+	"40P01": {"deadlock_detected", "the transaction conflicted with a concurrent one, retry the apply"},
+}
+
+// maxRecordedStatements is the number of failed statements kept by failedStatements.
+// The statements are removed when their error is described, the oldest ones are
+// evicted if their errors are never returned to Terraform (e.g.: expected errors).
+const maxRecordedStatements = 64
+
+// failedStatements records the statements which failed with a PostgreSQL error
+// (it is the query tracer of the connections), so the errors returned to Terraform
+// can show the failing statement.
+var failedStatements = &statementRecorder{statements: make(map[*pgconn.PgError]string)}
+
+type statementRecorder struct {
+	sync.Mutex
+	statements map[*pgconn.PgError]string
+	order      []*pgconn.PgError
+}
+
+type traceStatementKey struct{}
+
+func (r *statementRecorder) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, traceStatementKey{}, data.SQL)
+}
+
+func (r *statementRecorder) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	var pgErr *pgconn.PgError
+	if !errors.As(data.Err, &pgErr) {
+		return
+	}
+	statement, _ := ctx.Value(traceStatementKey{}).(string)
+
+	r.Lock()
+	defer r.Unlock()
+
+	if len(r.order) == maxRecordedStatements {
+		delete(r.statements, r.order[0])
+		r.order = r.order[1:]
+	}
+	r.statements[pgErr] = statement
+	r.order = append(r.order, pgErr)
+}
+
+// pop returns and forgets the statement which failed with the error.
+func (r *statementRecorder) pop(pgErr *pgconn.PgError) string {
+	r.Lock()
+	defer r.Unlock()
+
+	statement, ok := r.statements[pgErr]
+	if !ok {
+		return ""
+	}
+	delete(r.statements, pgErr)
+	for i, e := range r.order {
+		if e == pgErr {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+	return statement
+}
+
+var (
+	// passwordLiteralRegexp matches the passwords in the statements
+	// (e.g.: ALTER ROLE ... PASSWORD '...' or OPTIONS (password '...')).
+	passwordLiteralRegexp = regexp.MustCompile(`(?i)(password\s+)'(?:[^']|'')*'`)
+
+	// credentialsRegexp matches the statements managing user mappings, all their options
+	// are credentials of the remote server, and the connections of the subscriptions.
+	credentialsRegexp = regexp.MustCompile(`(?is)\bUSER\s+MAPPING\b|\bSUBSCRIPTION\b.*\bCONNECTION\b`)
+	literalRegexp     = regexp.MustCompile(`'(?:[^']|'')*'`)
+)
+
+// redactStatement hides the passwords, the user mapping options and the
+// subscription connection strings of a statement.
+func redactStatement(statement string) string {
+	if credentialsRegexp.MatchString(statement) {
+		return literalRegexp.ReplaceAllString(statement, "'******'")
+	}
+	return passwordLiteralRegexp.ReplaceAllString(statement, "$1'******'")
+}
+
+// diagnosticError is a PostgreSQL error described with its context.
+// It wraps the original error so its type can still be checked (see errwrap.GetType).
+type diagnosticError struct {
+	err       error
+	pgErr     *pgconn.PgError
+	object    string
+	statement string
+}
+
+func (e *diagnosticError) Error() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%s\n\n  SQLSTATE:  %s", e.err.Error(), e.pgErr.Code)
+	state, known := sqlStates[e.pgErr.Code]
+	if known {
+		fmt.Fprintf(b, " (%s)", state.name)
+	}
+	if e.object != "" {
+		fmt.Fprintf(b, "\n  Object:    %s", e.object)
+	}
+	if e.statement != "" {
+		fmt.Fprintf(b, "\n  Statement: %s", strings.Replace(redactStatement(e.statement), "\n", "\n             ", -1))
+	}
+	if e.pgErr.Detail != "" {
+		fmt.Fprintf(b, "\n  Detail:    %s", e.pgErr.Detail)
+	}
+	if e.pgErr.Hint != "" {
+		fmt.Fprintf(b, "\n  Hint:      %s", e.pgErr.Hint)
+	} else if known {
+		fmt.Fprintf(b, "\n  Hint:      %s", state.hint)
+	}
+	return b.String()
+}
+
+func (e *diagnosticError) WrappedErrors() []error {
+	return []error{e.err}
+}
+
+func (e *diagnosticError) Unwrap() error {
+	return e.err
+}
+
+// describeError adds to a PostgreSQL error its SQLSTATE, the object of the resource,
+// the failing statement (without the passwords) and a remediation hint.
+// The other errors are returned as is.
+func describeError(err error, object string) error {
+	if err == nil {
+		return nil
+	}
+
+	pgErr, ok := errwrap.GetType(err, &pgconn.PgError{}).(*pgconn.PgError)
+	if !ok {
+		return err
+	}
+
+	return &diagnosticError{
+		err:       err,
+		pgErr:     pgErr,
+		object:    object,
+		statement: failedStatements.pop(pgErr),
+	}
+}
+
+// describeErrors wraps the functions of a resource (or data source)
+// to describe the PostgreSQL errors they return (see describeError).
+func describeErrors(name string, r *schema.Resource) {
+	wrap := func(fn func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+		if fn == nil {
+			return nil
+		}
+		return func(d *schema.ResourceData, meta interface{}) error {
+			object := name
+			if d.Id() != "" {
+				object = fmt.Sprintf("%s (%s)", name, d.Id())
+			}
+			return describeError(fn(d, meta), object)
+		}
+	}
+
+	r.Create = wrap(r.Create)
+	r.Read = wrap(r.Read)
+	r.Update = wrap(r.Update)
+	r.Delete = wrap(r.Delete)
+}
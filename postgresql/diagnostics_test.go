@@ -0,0 +1,64 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestRedactStatement(t *testing.T) {
+	cases := map[string]string{
+		`CREATE ROLE "test" LOGIN PASSWORD 'secret'`:                                       `CREATE ROLE "test" LOGIN PASSWORD '******'`,
+		`ALTER ROLE "test" password 'it''s secret' VALID UNTIL 'infinity'`:                 `ALTER ROLE "test" password '******' VALID UNTIL 'infinity'`,
+		`CREATE USER MAPPING FOR "test" SERVER "srv" OPTIONS (user 'u', password 'p')`:     `CREATE USER MAPPING FOR "test" SERVER "srv" OPTIONS (user '******', password '******')`,
+		`CREATE SUBSCRIPTION "sub" CONNECTION 'host=primary password=p' PUBLICATION "pub"`: `CREATE SUBSCRIPTION "sub" CONNECTION '******' PUBLICATION "pub"`,
+		`DROP ROLE "test"`: `DROP ROLE "test"`,
+	}
+
+	for statement, expected := range cases {
+		if actual := redactStatement(statement); actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+	}
+}
+
+func TestDescribeError(t *testing.T) {
+	if err := describeError(nil, "postgresql_role"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	otherErr := errors.New("other error")
+	if err := describeError(otherErr, "postgresql_role"); err != otherErr {
+		t.Fatalf("expected the error to be returned as is, got %v", err)
+	}
+
+	pgErr := &pgconn.PgError{Code: "2BP01", Message: `role "test" cannot be dropped because some objects depend on it`}
+	ctx := failedStatements.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: `DROP ROLE "test"`})
+	failedStatements.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: pgErr})
+
+	err := describeError(errwrap.Wrapf("Error deleting role: {{err}}", pgErr), "postgresql_role (test)")
+	for _, expected := range []string{
+		"Error deleting role: ",
+		"SQLSTATE:  2BP01 (dependent_objects_still_exist)",
+		"Object:    postgresql_role (test)",
+		`Statement: DROP ROLE "test"`,
+		"Hint:      other objects depend on this object",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected %q in error:\n%s", expected, err)
+		}
+	}
+
+	if _, ok := errwrap.GetType(err, &pgconn.PgError{}).(*pgconn.PgError); !ok {
+		t.Errorf("expected the PostgreSQL error to be wrapped")
+	}
+
+	if statement := failedStatements.pop(pgErr); statement != "" {
+		t.Errorf("expected the statement to be forgotten, got %q", statement)
+	}
+}
@@ -1,15 +1,28 @@
 package postgresql
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"log"
+	"math/big"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"database/sql"
 
+	"github.com/blang/semver"
+
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/lib/pq"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // pqQuoteLiteral returns a string literal safe for inclusion in a PostgreSQL
@@ -22,6 +35,207 @@ func pqQuoteLiteral(in string) string {
 	return in
 }
 
+// pqQuoteIdentifier quotes an identifier to be used in a query.
+func pqQuoteIdentifier(in string) string {
+	return pgx.Identifier{in}.Sanitize()
+}
+
+// maxIdentifierLength is the maximum length, in bytes, of an identifier (NAMEDATALEN - 1).
+const maxIdentifierLength = 63
+
+// unquotedIdentifierRegexp matches the identifiers which can be used without quotes.
+var unquotedIdentifierRegexp = regexp.MustCompile(`^[a-z_][a-z0-9_$]*$`)
+
+// validateIdentifier validates the name of an object at plan time.
+// PostgreSQL silently truncates the identifiers longer than 63 bytes, which would
+// produce a perpetual diff, so they are refused. The identifiers which would have to
+// be quoted in SQL (e.g.: with upper case letters) only produce a warning as the
+// provider always quotes them.
+func validateIdentifier(v interface{}, key string) (warnings []string, errors []error) {
+	name := v.(string)
+	switch {
+	case strings.ContainsRune(name, 0):
+		errors = append(errors, fmt.Errorf("%s cannot contain a NUL character", key))
+	case len(name) > maxIdentifierLength:
+		errors = append(errors, fmt.Errorf(
+			"%s %q is longer than %d bytes, PostgreSQL would truncate it to %q",
+			key, name, maxIdentifierLength, truncateIdentifier(name),
+		))
+	case name != "" && !unquotedIdentifierRegexp.MatchString(name) && !isPublicRole(name):
+		warnings = append(warnings, fmt.Sprintf(
+			"%s %q is not a valid unquoted identifier, it has to be quoted (%s) when used in SQL",
+			key, name, pqQuoteIdentifier(name),
+		))
+	}
+	return
+}
+
+// quotedIdentifiersAttr is the attribute of the resources to opt out of
+// the case folding of suppressIdentifierCaseDiff.
+const quotedIdentifiersAttr = "quoted_identifiers"
+
+// quotedIdentifiersSchema returns the schema of the quoted_identifiers attribute.
+func quotedIdentifiersSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Default:     false,
+		Description: "When true, the names only differing by their case from the names of the database are not considered equal",
+	}
+}
+
+// suppressIdentifierCaseDiff suppresses the diff between a configured identifier and
+// its lower case form read from the catalog: PostgreSQL folds the unquoted identifiers
+// to lower case, so an object created with a mixed case name in SQL (without quotes)
+// would otherwise produce a perpetual diff. The resources having quoted_identifiers
+// set compare the identifiers as they are.
+func suppressIdentifierCaseDiff(k, old, new string, d *schema.ResourceData) bool {
+	if quoted, _ := d.Get(quotedIdentifiersAttr).(bool); quoted {
+		return false
+	}
+	return old != new && old == strings.ToLower(new) && unquotedIdentifierRegexp.MatchString(old)
+}
+
+// detectExternalChangesAttr is the attribute of the resources to only check,
+// when they are refreshed, that their object still exists.
+const detectExternalChangesAttr = "detect_external_changes"
+
+// detectExternalChangesSchema returns the schema of the detect_external_changes attribute.
+func detectExternalChangesSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Default:     true,
+		Description: "When false, the refresh only checks that the object exists instead of reading all its attributes",
+	}
+}
+
+// detectExternalChanges returns true if all the attributes of the resource have to be read
+// when it is refreshed. It is always the case when detect_external_changes is not in
+// the state yet (e.g.: an imported resource) so its attributes are initialized.
+func detectExternalChanges(d *schema.ResourceData) bool {
+	v, ok := d.GetOkExists(detectExternalChangesAttr)
+	return !ok || v.(bool)
+}
+
+// oidAttr and aclAttr are the computed attributes exposing the OID and the access
+// privileges of the objects, so they can be referenced by other resources and outputs.
+const (
+	oidAttr = "oid"
+	aclAttr = "acl"
+)
+
+// oidSchema returns the schema of the oid attribute.
+func oidSchema(object string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeInt,
+		Computed:    true,
+		Description: fmt.Sprintf("The OID of the %s", object),
+	}
+}
+
+// aclSchema returns the schema of the acl attribute.
+func aclSchema(object string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		Elem:        &schema.Schema{Type: schema.TypeString},
+		Description: fmt.Sprintf("The access privileges of the %s, in the aclitem format (e.g.: role=UC/owner)", object),
+	}
+}
+
+// minServerVersionAttr is the attribute of the resources to require
+// a minimum version of the server (see checkMinServerVersion).
+const minServerVersionAttr = "min_server_version"
+
+// The attributes of the resources executing SQL in the transactions
+// of their operations (see runSQLHooks).
+const (
+	preSQLAttr  = "pre_sql"
+	postSQLAttr = "post_sql"
+)
+
+// sqlHooks are the pre_sql and post_sql of the running operation,
+// set in the context of the client by runSQLHooks.
+type sqlHooks struct {
+	pre  string
+	post string
+}
+
+type sqlHooksKey struct{}
+
+// minServerVersionSchema returns the schema of the min_server_version attribute.
+func minServerVersionSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Description:  "The minimum version of the server required by the resource (e.g.: 14 or 13.2), the plan fails with an older server",
+		ValidateFunc: validateMinServerVersion,
+	}
+}
+
+func validateMinServerVersion(v interface{}, key string) (warnings []string, errors []error) {
+	if _, err := semver.ParseTolerant(v.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("invalid version (%q) for %s: %v", v.(string), key, err))
+	}
+	return
+}
+
+// validateRoleName validates the name of a role created by the provider:
+// besides the identifier checks, PostgreSQL reserves some role names.
+func validateRoleName(v interface{}, key string) (warnings []string, errors []error) {
+	warnings, errors = validateIdentifier(v, key)
+
+	name := v.(string)
+	switch lowerName := strings.ToLower(name); {
+	case name == "":
+		errors = append(errors, fmt.Errorf("%s cannot be empty", key))
+	case lowerName == "public" || lowerName == "none":
+		errors = append(errors, fmt.Errorf("%s %q is reserved", key, name))
+	case lowerName == "current_user" || lowerName == "current_role" || lowerName == "session_user":
+		errors = append(errors, fmt.Errorf("%s %q is reserved, it refers to a role in SQL", key, name))
+	case strings.HasPrefix(name, "pg_"):
+		errors = append(errors, fmt.Errorf("%s %q is reserved, the pg_ prefix is used by the system roles", key, name))
+	}
+	return
+}
+
+// truncateIdentifier returns the identifier as truncated by PostgreSQL,
+// which does not split multibyte characters.
+func truncateIdentifier(name string) string {
+	if len(name) <= maxIdentifierLength {
+		return name
+	}
+	end := maxIdentifierLength
+	for end > 0 && !utf8.RuneStart(name[end]) {
+		end--
+	}
+	return name[:end]
+}
+
+// splitImportID splits an import ID in the parts described by format
+// (e.g.: database/schema/name). The parts are separated by slashes but,
+// if the ID contains no slash, they can also be separated by dots
+// (the format of the IDs stored in the state).
+func splitImportID(id, format string) ([]string, error) {
+	sep := "/"
+	if !strings.Contains(id, sep) {
+		sep = "."
+	}
+
+	parts := strings.Split(id, sep)
+	if len(parts) != strings.Count(format, "/")+1 {
+		return nil, fmt.Errorf("invalid import ID %q, expected format: %s", id, format)
+	}
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("invalid import ID %q, expected format: %s", id, format)
+		}
+	}
+
+	return parts, nil
+}
+
 func validateConnLimit(v interface{}, key string) (warnings []string, errors []error) {
 	value := v.(int)
 	if value < -1 {
@@ -30,9 +244,9 @@ func validateConnLimit(v interface{}, key string) (warnings []string, errors []e
 	return
 }
 
-func isRoleMember(db *sql.DB, role, member string) (bool, error) {
+func isRoleMember(ctx context.Context, db *sql.DB, role, member string) (bool, error) {
 	var _rez int
-	err := db.QueryRow(
+	err := db.QueryRowContext(ctx,
 		"SELECT 1 FROM pg_auth_members WHERE pg_get_userbyid(roleid) = $1 AND pg_get_userbyid(member) = $2",
 		role, member,
 	).Scan(&_rez)
@@ -50,12 +264,12 @@ func isRoleMember(db *sql.DB, role, member string) (bool, error) {
 // grantRoleMembership grants the role *role* to the user *member*.
 // It returns false if the grant is not needed because the user is already
 // a member of this role.
-func grantRoleMembership(db *sql.DB, role, member string) (bool, error) {
+func grantRoleMembership(ctx context.Context, db *sql.DB, role, member string) (bool, error) {
 	if member == role {
 		return false, nil
 	}
 
-	isMember, err := isRoleMember(db, role, member)
+	isMember, err := isRoleMember(ctx, db, role, member)
 	if err != nil {
 		return false, err
 	}
@@ -64,8 +278,8 @@ func grantRoleMembership(db *sql.DB, role, member string) (bool, error) {
 		return false, nil
 	}
 
-	sql := fmt.Sprintf("GRANT %s TO %s", pq.QuoteIdentifier(role), pq.QuoteIdentifier(member))
-	if _, err := db.Exec(sql); err != nil {
+	sql := fmt.Sprintf("GRANT %s TO %s", pqQuoteIdentifier(role), pqQuoteIdentifier(member))
+	if _, err := db.ExecContext(ctx, sql); err != nil {
 		return false, errwrap.Wrapf(fmt.Sprintf(
 			"Error granting role %s to %s: {{err}}", role, member,
 		), err)
@@ -73,19 +287,19 @@ func grantRoleMembership(db *sql.DB, role, member string) (bool, error) {
 	return true, nil
 }
 
-func revokeRoleMembership(db *sql.DB, role, member string) error {
+func revokeRoleMembership(ctx context.Context, db *sql.DB, role, member string) error {
 	if member == role {
 		return nil
 	}
 
-	isMember, err := isRoleMember(db, role, member)
+	isMember, err := isRoleMember(ctx, db, role, member)
 	if err != nil {
 		return err
 	}
 
 	if isMember {
-		sql := fmt.Sprintf("REVOKE %s FROM %s", pq.QuoteIdentifier(role), pq.QuoteIdentifier(member))
-		if _, err := db.Exec(sql); err != nil {
+		sql := fmt.Sprintf("REVOKE %s FROM %s", pqQuoteIdentifier(role), pqQuoteIdentifier(member))
+		if _, err := db.ExecContext(ctx, sql); err != nil {
 			return errwrap.Wrapf(fmt.Sprintf(
 				"Error revoking role %s from %s: {{err}}", role, member,
 			), err)
@@ -94,6 +308,20 @@ func revokeRoleMembership(db *sql.DB, role, member string) error {
 	return nil
 }
 
+// isPublicRole returns true if the role name refers to the PUBLIC pseudo-role.
+func isPublicRole(role string) bool {
+	return strings.ToLower(role) == "public"
+}
+
+// pqQuoteRoleName quotes a role name to be used in GRANT / REVOKE statements.
+// PUBLIC is a keyword and must not be quoted.
+func pqQuoteRoleName(role string) string {
+	if isPublicRole(role) {
+		return "PUBLIC"
+	}
+	return pqQuoteIdentifier(role)
+}
+
 func sliceContainsStr(haystack []string, needle string) bool {
 	for _, s := range haystack {
 		if s == needle {
@@ -106,8 +334,16 @@ func sliceContainsStr(haystack []string, needle string) bool {
 // allowedPrivileges is the list of privileges allowed per object types in Postgres.
 // see: https://www.postgresql.org/docs/current/sql-grant.html
 var allowedPrivileges = map[string][]string{
-	"table":    []string{"ALL", "SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER"},
+	"table":    []string{"ALL", "SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER", "MAINTAIN"},
 	"sequence": []string{"ALL", "USAGE", "SELECT", "UPDATE"},
+	// Materialized views are granted as tables
+	"materialized_view": []string{"ALL", "SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER", "MAINTAIN"},
+	"database":          []string{"ALL", "CREATE", "CONNECT", "TEMPORARY"},
+	"schema":            []string{"ALL", "CREATE", "USAGE"},
+	"tablespace":        []string{"ALL", "CREATE"},
+	"parameter":         []string{"ALL", "SET", "ALTER SYSTEM"},
+	"function":          []string{"ALL", "EXECUTE"},
+	"type":              []string{"ALL", "USAGE"},
 }
 
 // validatePrivileges checks that privileges to apply are allowed for this object type.
@@ -125,10 +361,50 @@ func validatePrivileges(objectType string, privileges []interface{}) error {
 	return nil
 }
 
-func pgArrayToSet(arr pq.ByteaArray) *schema.Set {
+// checkPrivilegesSupported checks that the privileges can be granted with the version
+// of the connected server (MAINTAIN exists since PostgreSQL 17).
+func checkPrivilegesSupported(client *Client, privileges *schema.Set) error {
+	if privileges.Contains("MAINTAIN") && !client.featureSupported(featureMaintainPrivilege) {
+		return fmt.Errorf(
+			"MAINTAIN privilege is not supported for this Postgres version (%s)",
+			client.version,
+		)
+	}
+	return nil
+}
+
+// setToPgPrivileges returns the sorted list of privileges of a Terraform set
+// so they can be used in GRANT / REVOKE statements.
+func setToPgPrivileges(s *schema.Set) []string {
+	privileges := make([]string, 0, s.Len())
+	for _, priv := range s.List() {
+		privileges = append(privileges, priv.(string))
+	}
+	sort.Strings(privileges)
+	return privileges
+}
+
+// setToStrings returns the sorted list of strings of a Terraform set,
+// so the statements are generated in a stable order.
+func setToStrings(s *schema.Set) []string {
+	values := make([]string, 0, s.Len())
+	for _, v := range s.List() {
+		values = append(values, v.(string))
+	}
+	sort.Strings(values)
+	return values
+}
+
+// pgArray returns a scanner for a PostgreSQL array into a slice (e.g.: *[]string)
+// as database/sql cannot scan arrays itself.
+func pgArray(dest interface{}) sql.Scanner {
+	return pgtype.NewMap().SQLScanner(dest)
+}
+
+func pgArrayToSet(arr []string) *schema.Set {
 	s := make([]interface{}, len(arr))
 	for i, v := range arr {
-		s[i] = string(v)
+		s[i] = v
 	}
 	return schema.NewSet(schema.HashString, s)
 }
@@ -137,24 +413,163 @@ func pgArrayToSet(arr pq.ByteaArray) *schema.Set {
 // If the database is specified and different from the one configured in the provider,
 // it will create a new connection pool if needed.
 func startTransaction(client *Client, database string) (*sql.Tx, error) {
-	if database != "" && database != client.databaseName {
-		var err error
-		client, err = client.config.NewClient(database)
-		if err != nil {
-			return nil, err
+	client, err := databaseClient(client, database)
+	if err != nil {
+		return nil, err
+	}
+	db := client.DB()
+	txn, err := db.BeginTx(client.ctx, nil)
+	if err != nil {
+		return nil, errwrap.Wrapf("could not start transaction: {{err}}", err)
+	}
+
+	if err := setStatementTimeout(client, txn); err != nil {
+		deferredRollback(txn)
+		return nil, err
+	}
+
+	if hooks, ok := client.ctx.Value(sqlHooksKey{}).(sqlHooks); ok && hooks.pre != "" {
+		if _, err := txn.ExecContext(client.ctx, hooks.pre); err != nil {
+			deferredRollback(txn)
+			return nil, errwrap.Wrapf("could not execute pre_sql: {{err}}", err)
+		}
+	}
+
+	return txn, nil
+}
+
+// commitTransaction executes the post_sql of the operation, if any (see runSQLHooks),
+// and commits the transaction started by startTransaction.
+func commitTransaction(client *Client, txn *sql.Tx) error {
+	if hooks, ok := client.ctx.Value(sqlHooksKey{}).(sqlHooks); ok && hooks.post != "" {
+		if _, err := txn.ExecContext(client.ctx, hooks.post); err != nil {
+			return errwrap.Wrapf("could not execute post_sql: {{err}}", err)
 		}
 	}
+
+	return txn.Commit()
+}
+
+// startReadTransaction starts a read-only transaction on the specified database (see startTransaction)
+// with the REPEATABLE READ isolation level: all the queries of a Read see the same snapshot,
+// so a migration running concurrently is either seen completely or not at all by this Read.
+// The snapshot is not shared with the Reads of the other resources of the refresh.
+func startReadTransaction(client *Client, database string) (*sql.Tx, error) {
+	client, err := databaseClient(client, database)
+	if err != nil {
+		return nil, err
+	}
 	db := client.DB()
-	txn, err := db.Begin()
+	txn, err := db.BeginTx(client.ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
 	if err != nil {
 		return nil, errwrap.Wrapf("could not start transaction: {{err}}", err)
 	}
 
+	if err := setStatementTimeout(client, txn); err != nil {
+		deferredRollback(txn)
+		return nil, err
+	}
+
 	return txn, nil
 }
 
-func dbExists(txn *sql.Tx, dbname string) (bool, error) {
-	err := txn.QueryRow("SELECT datname FROM pg_database WHERE datname=$1", dbname).Scan(&dbname)
+// setStatementTimeout limits the statements of the transaction to the time left before
+// the deadline of the operation (see enforceTimeouts), so the server cancels them itself
+// if the cancellation of the context cannot reach it.
+func setStatementTimeout(client *Client, txn *sql.Tx) error {
+	deadline, ok := client.ctx.Deadline()
+	if !ok {
+		return nil
+	}
+
+	timeout := time.Until(deadline).Milliseconds()
+	if timeout < 1 {
+		// 0 would disable the timeout.
+		timeout = 1
+	}
+	if _, err := txn.ExecContext(client.ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout)); err != nil {
+		return errwrap.Wrapf("could not set statement_timeout: {{err}}", err)
+	}
+	return nil
+}
+
+// databaseClient returns a client connected to the database
+// (the client itself if it's already connected to it).
+func databaseClient(client *Client, database string) (*Client, error) {
+	if database == "" || database == client.databaseName {
+		return client, nil
+	}
+
+	ctx := client.ctx
+	client, err := client.config.NewClient(database)
+	if err != nil {
+		return nil, err
+	}
+	client.ctx = ctx
+
+	return client, nil
+}
+
+// catalogCacheTTL is the time during which the existence of a catalog object
+// is cached. Objects are not expected to be dropped during an apply (except by
+// the provider itself which invalidates the cache) but they can be between two runs.
+const catalogCacheTTL = 30 * time.Second
+
+// catalogCache caches the existence of the catalog objects (roles, databases, schemas)
+// which are checked by many resources during the same run.
+// Only existing objects are cached as they can be created by other resources at any time.
+type catalogCache struct {
+	sync.Mutex
+	entries map[string]time.Time
+}
+
+func newCatalogCache() *catalogCache {
+	return &catalogCache{entries: make(map[string]time.Time)}
+}
+
+func catalogCacheKey(objectType string, names ...string) string {
+	return objectType + "/" + strings.Join(names, "/")
+}
+
+// contains returns true if the object is known to exist.
+func (c *catalogCache) contains(key string) bool {
+	c.Lock()
+	defer c.Unlock()
+	expiration, found := c.entries[key]
+	return found && time.Now().Before(expiration)
+}
+
+// add records that the object exists.
+func (c *catalogCache) add(key string) {
+	c.Lock()
+	defer c.Unlock()
+	c.entries[key] = time.Now().Add(catalogCacheTTL)
+}
+
+// exists returns if the object exists, using check if it is not cached.
+func (c *catalogCache) exists(key string, check func() (bool, error)) (bool, error) {
+	if c.contains(key) {
+		return true, nil
+	}
+
+	exists, err := check()
+	if err != nil || !exists {
+		return exists, err
+	}
+
+	c.add(key)
+	return true, nil
+}
+
+// invalidate removes an object from the cache (e.g.: when it is dropped or renamed).
+func (c *catalogCache) invalidate(key string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.entries, key)
+}
+
+func dbExists(ctx context.Context, txn *sql.Tx, dbname string) (bool, error) {
+	err := txn.QueryRowContext(ctx, "SELECT datname FROM pg_database WHERE datname=$1", dbname).Scan(&dbname)
 	switch {
 	case err == sql.ErrNoRows:
 		return false, nil
@@ -165,28 +580,279 @@ func dbExists(txn *sql.Tx, dbname string) (bool, error) {
 	return true, nil
 }
 
-func roleExists(txn *sql.Tx, rolname string) (bool, error) {
-	err := txn.QueryRow("SELECT 1 FROM pg_roles WHERE rolname=$1", rolname).Scan(&rolname)
+func schemaExists(ctx context.Context, txn *sql.Tx, schemaname string) (bool, error) {
+	err := txn.QueryRowContext(ctx, "SELECT 1 FROM pg_namespace WHERE nspname=$1", schemaname).Scan(&schemaname)
 	switch {
 	case err == sql.ErrNoRows:
 		return false, nil
 	case err != nil:
-		return false, errwrap.Wrapf("could not check if role exists: {{err}}", err)
+		return false, errwrap.Wrapf("could not check if schema exists: {{err}}", err)
 	}
 
 	return true, nil
 }
 
-func schemaExists(txn *sql.Tx, schemaname string) (bool, error) {
-	err := txn.QueryRow("SELECT 1 FROM pg_namespace WHERE nspname=$1", schemaname).Scan(&schemaname)
+// checkExtensionInstalled returns an error if the extension is not installed
+// in the database of the transaction.
+func checkExtensionInstalled(ctx context.Context, txn *sql.Tx, extName string) error {
+	var installed bool
+	if err := txn.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM pg_catalog.pg_extension WHERE extname = $1)", extName,
+	).Scan(&installed); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("could not check if extension %s is installed: {{err}}", extName), err)
+	}
+
+	if !installed {
+		return fmt.Errorf("extension %s is not installed in this database", extName)
+	}
+
+	return nil
+}
+
+// extensionSchema returns the schema in which the extension is installed
+// in the database of the transaction.
+func extensionSchema(ctx context.Context, txn *sql.Tx, extName string) (string, error) {
+	var extSchema string
+	err := txn.QueryRowContext(ctx,
+		"SELECT n.nspname FROM pg_catalog.pg_extension e JOIN pg_catalog.pg_namespace n ON n.oid = e.extnamespace WHERE e.extname = $1",
+		extName,
+	).Scan(&extSchema)
 	switch {
 	case err == sql.ErrNoRows:
-		return false, nil
+		return "", fmt.Errorf("extension %s is not installed in this database", extName)
 	case err != nil:
-		return false, errwrap.Wrapf("could not check if schema exists: {{err}}", err)
+		return "", errwrap.Wrapf(fmt.Sprintf("could not read the schema of extension %s: {{err}}", extName), err)
 	}
 
-	return true, nil
+	return extSchema, nil
+}
+
+// sameInterval returns true if both values represent the same interval
+// (e.g.: 1 day and 24 hours). Values which are not intervals are compared as strings.
+// The comparison is not done in the caller's transaction as an invalid value would abort it.
+func sameInterval(c *Client, a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	var equal bool
+	if err := c.DB().QueryRowContext(c.ctx, "SELECT $1::interval = $2::interval", a, b).Scan(&equal); err != nil {
+		return false
+	}
+
+	return equal
+}
+
+// execQueries executes the queries in the transaction with a single round trip
+// (the statements are sent together as a simple query).
+func execQueries(ctx context.Context, txn *sql.Tx, queries []string) error {
+	if len(queries) == 0 {
+		return nil
+	}
+
+	_, err := txn.ExecContext(ctx, strings.Join(queries, ";\n"))
+	return err
+}
+
+// retryableSQLStates are the SQLSTATE codes of the errors caused by concurrent
+// transactions, the transaction can succeed if it is retried.
+var retryableSQLStates = []string{
+	"40001", // serialization_failure
+	"40P01", // deadlock_detected
+}
+
+const (
+	retryMaxAttempts = 5
+	retryBaseDelay   = 100 * time.Millisecond
+
+	// failoverRetryBaseDelay is the first delay before retrying an operation
+	// which failed because the server became read-only (see failover_retries).
+	failoverRetryBaseDelay = 5 * time.Second
+
+	// promotionPollInterval is the delay between the checks of a standby
+	// server waiting for its promotion (see promotion_timeout).
+	promotionPollInterval = 5 * time.Second
+)
+
+// isRetryableError returns true if the error has been caused by a concurrent transaction.
+func isRetryableError(err error) bool {
+	pgErr, ok := errwrap.GetType(err, &pgconn.PgError{}).(*pgconn.PgError)
+	if !ok {
+		return false
+	}
+	return sliceContainsStr(retryableSQLStates, pgErr.Code)
+}
+
+// isConcurrentUpdateError returns true if the error is the internal error raised
+// when a catalog row is updated by concurrent transactions (e.g.: ALTER ROLE or GRANT
+// on the same object).
+func isConcurrentUpdateError(err error) bool {
+	pgErr, ok := errwrap.GetType(err, &pgconn.PgError{}).(*pgconn.PgError)
+	if !ok {
+		return false
+	}
+	return pgErr.Code == "XX000" && pgErr.Message == "tuple concurrently updated"
+}
+
+// isReadOnlyError returns true if the statement failed because the session is read-only,
+// which happens when the primary has been demoted (e.g.: Aurora failover) and the
+// connection still goes to it.
+func isReadOnlyError(err error) bool {
+	pgErr, ok := errwrap.GetType(err, &pgconn.PgError{}).(*pgconn.PgError)
+	if !ok {
+		return false
+	}
+	return pgErr.Code == "25006" // read_only_sql_transaction
+}
+
+// isUnreachableError returns true if the connection to the server failed without
+// an answer of the server (e.g.: network error or timeout, unlike an authentication failure).
+func isUnreachableError(err error) bool {
+	var connectErr, answered bool
+	errwrap.Walk(err, func(err error) {
+		var pgErr *pgconn.PgError
+		var pgConnectErr *pgconn.ConnectError
+		answered = answered || errors.As(err, &pgErr)
+		connectErr = connectErr || errors.As(err, &pgConnectErr)
+	})
+	return connectErr && !answered
+}
+
+// isUndefinedTableError returns true if the statement failed because a relation does not exist.
+func isUndefinedTableError(err error) bool {
+	pgErr, ok := errwrap.GetType(err, &pgconn.PgError{}).(*pgconn.PgError)
+	if !ok {
+		return false
+	}
+	return pgErr.Code == "42P01" // undefined_table
+}
+
+// retryOnTransientErrors wraps a Create / Update / Delete function to retry it,
+// with an exponential backoff, if it fails because of a concurrent transaction
+// (deadlock or serialization failure).
+// The changes of the function have to be applied in a transaction so a failed
+// attempt has no effect.
+func retryOnTransientErrors(fn func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+	return retryOnErrors(isRetryableError, fn)
+}
+
+// retryOnConcurrentUpdates is like retryOnTransientErrors but also retries
+// the function if a catalog row has been concurrently updated
+// (see isConcurrentUpdateError), which happens with roles and ACLs.
+func retryOnConcurrentUpdates(fn func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+	return retryOnErrors(func(err error) bool {
+		return isRetryableError(err) || isConcurrentUpdateError(err)
+	}, fn)
+}
+
+// retryOnFailover wraps a Create / Update / Delete function to only retry it
+// if the server became read-only (see retryOnErrors).
+func retryOnFailover(fn func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+	return retryOnErrors(func(error) bool { return false }, fn)
+}
+
+// retryOnErrors retries the function while it fails with retryable errors.
+// If the server became read-only, the connection pools are reopened (so the
+// endpoint is resolved again) and the function is retried up to failover_retries times.
+func retryOnErrors(retryable func(error) bool, fn func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+	return func(d *schema.ResourceData, meta interface{}) error {
+		client := meta.(*Client)
+		ctx := client.ctx
+		delay := retryBaseDelay
+		failoverDelay := failoverRetryBaseDelay
+		failovers := 0
+
+		for attempt := 1; ; attempt++ {
+			err := fn(d, meta)
+
+			if isReadOnlyError(err) && failovers < client.config.FailoverRetries {
+				failovers++
+				log.Printf(
+					"[WARN] server is read-only, reconnecting and retrying in %s (failover retry %d/%d): %v",
+					failoverDelay, failovers, client.config.FailoverRetries, err,
+				)
+				closeAllDBPools()
+				select {
+				case <-ctx.Done():
+					return err
+				case <-time.After(failoverDelay):
+				}
+				failoverDelay *= 2
+				// Failover retries are not counted as attempts.
+				attempt--
+				continue
+			}
+
+			if isReadOnlyError(err) {
+				if err := waitForPromotion(client, err); err != nil {
+					return err
+				}
+				attempt--
+				continue
+			}
+
+			if err == nil || attempt == retryMaxAttempts || !retryable(err) {
+				return err
+			}
+
+			log.Printf("[WARN] retrying in %s (attempt %d/%d): %v", delay, attempt, retryMaxAttempts, err)
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+	}
+}
+
+// waitForPromotion is called when an operation failed because the server is read-only.
+// If the server is a standby (e.g.: a replica of a Patroni cluster), it waits up to
+// promotion_timeout for the endpoint to reach a primary and returns nil so the operation
+// can be retried. Otherwise, it returns an error explaining that the server is a standby.
+func waitForPromotion(client *Client, err error) error {
+	inRecovery, recoveryErr := isInRecovery(client)
+	if recoveryErr != nil || !inRecovery {
+		// The session is read-only for another reason (e.g.: default_transaction_read_only).
+		return err
+	}
+
+	timeout := time.Duration(client.config.PromotionTimeout) * time.Second
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		log.Printf("[WARN] server is a standby, waiting %s for its promotion", time.Until(deadline).Round(time.Second))
+		select {
+		case <-client.ctx.Done():
+			return err
+		case <-time.After(promotionPollInterval):
+		}
+
+		// The connections are reopened as the endpoint may now reach another server.
+		closeAllDBPools()
+		if inRecovery, recoveryErr = isInRecovery(client); recoveryErr == nil && !inRecovery {
+			log.Printf("[INFO] server has been promoted, retrying")
+			return nil
+		}
+	}
+
+	if timeout > 0 {
+		return errwrap.Wrapf(fmt.Sprintf(
+			"the server is still a standby after %s, changes must be applied on the primary: {{err}}", timeout,
+		), err)
+	}
+	return errwrap.Wrapf(
+		"the server is a standby (read-only), changes must be applied on the primary (see promotion_timeout to wait for a promotion): {{err}}",
+		err,
+	)
+}
+
+// isInRecovery returns true if the server is a standby.
+func isInRecovery(client *Client) (bool, error) {
+	var inRecovery bool
+	if err := client.DB().QueryRowContext(client.ctx, "SELECT pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+		return false, err
+	}
+	return inRecovery, nil
 }
 
 // deferredRollback can be used to rollback a transaction in a defer.
@@ -201,3 +867,45 @@ func deferredRollback(txn *sql.Tx) {
 		log.Printf("[ERR] could not rollback transaction: %v", err)
 	}
 }
+
+const (
+	passwordAlphanumericChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	// passwordSpecialChars excludes the quotes, backslash, slash, @ and space
+	// which are not accepted by Redshift or need escaping in the connection strings.
+	passwordSpecialChars = "!#%*()-_=+[]{}<>:?.,^~"
+)
+
+// generatePassword returns a random password containing at least a lower case letter,
+// an upper case letter, a digit and, if special is true, a special character.
+func generatePassword(length int, special bool) (string, error) {
+	chars := passwordAlphanumericChars
+	classes := []string{"abcdefghijklmnopqrstuvwxyz", "ABCDEFGHIJKLMNOPQRSTUVWXYZ", "0123456789"}
+	if special {
+		chars += passwordSpecialChars
+		classes = append(classes, passwordSpecialChars)
+	}
+
+	charCount := big.NewInt(int64(len(chars)))
+	b := make([]byte, length)
+	for {
+		for i := range b {
+			n, err := rand.Int(rand.Reader, charCount)
+			if err != nil {
+				return "", errwrap.Wrapf("could not generate the password: {{err}}", err)
+			}
+			b[i] = chars[n.Int64()]
+		}
+
+		password := string(b)
+		complete := true
+		for _, class := range classes {
+			if !strings.ContainsAny(password, class) {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			return password, nil
+		}
+	}
+}
@@ -1,22 +1,40 @@
 package postgresql
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/blang/semver"
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/hashicorp/terraform/terraform"
 )
 
 const (
 	defaultProviderMaxOpenConnections = 4
+	defaultProviderMaxPools           = 8
+	defaultStatementCacheCapacity     = 512
+	defaultTCPKeepaliveInterval       = 30
 	defaultExpectedPostgreSQLVersion  = "9.0.0"
 )
 
+// nonTransactionalResources run statements which cannot run in a transaction
+// (CREATE DATABASE, CREATE SUBSCRIPTION, ...), they do not support pre_sql and post_sql.
+var nonTransactionalResources = []string{
+	"postgresql_database",
+	"postgresql_replication_slot",
+	"postgresql_subscription",
+}
+
 // Provider returns a terraform.ResourceProvider.
 func Provider() terraform.ResourceProvider {
-	return &schema.Provider{
+	provider := &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"host": {
 				Type:        schema.TypeString,
@@ -57,6 +75,34 @@ func Provider() terraform.ResourceProvider {
 				Description: "Database username associated to the connected user (for user name maps)",
 			},
 
+			"azure": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Azure Database for PostgreSQL Single Server compatibility: the @servername suffix of the logins is removed to get the role names",
+			},
+
+			"degrade_gracefully": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Skip, with a warning, the role attributes which cannot be enabled on the server (e.g.: superuser on managed platforms) instead of failing",
+			},
+
+			"tolerate_unsupported_features": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Skip, with a warning, the changes which are not supported by the server version when skipping them is safe, instead of failing",
+			},
+			"feature_overrides": {
+				Type:         schema.TypeMap,
+				Optional:     true,
+				Elem:         &schema.Schema{Type: schema.TypeBool},
+				ValidateFunc: validateFeatureOverrides,
+				Description:  "Force the support of features (e.g.: extension = true), whatever the version and flavor of the server",
+			},
+
 			"superuser": {
 				Type:     schema.TypeBool,
 				Optional: true,
@@ -90,6 +136,77 @@ func Provider() terraform.ResourceProvider {
 				Description:  "Maximum number of connections to establish to the database. Zero means unlimited.",
 				ValidateFunc: validateMaxConnections,
 			},
+			"max_connection_pools": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      defaultProviderMaxPools,
+				Description:  "Maximum number of databases to keep a connection pool opened to (each pool has up to max_connections connections).",
+				ValidateFunc: validateMaxConnections,
+			},
+			"max_concurrent_operations": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				Description:  "Maximum number of operations the provider runs concurrently on the server. Zero means unlimited.",
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"tcp_keepalive_interval": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      defaultTCPKeepaliveInterval,
+				Description:  "Interval, in seconds, between the TCP keepalive probes sent on idle connections. Zero disables TCP keepalive.",
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"failover_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				Description:  "Number of times an operation is retried, after reconnecting, if the server became read-only (e.g.: Aurora failover).",
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"promotion_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				Description:  "Maximum wait, in seconds, for a standby server to be promoted when an operation fails because it's read-only. Zero fails immediately.",
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"statement_cache_capacity": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      defaultStatementCacheCapacity,
+				Description:  "Maximum number of prepared statements cached by each connection. Zero disables the cache.",
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"pgbouncer": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Connect through PgBouncer in transaction pooling mode: prepared statements are not used.",
+			},
+			"rds_proxy": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Connect through Amazon RDS Proxy: the statements which pin the sessions to a server connection are not used.",
+			},
+			"log_sql": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Log the executed statements, with their passwords redacted, at the TRACE level.",
+			},
+			"audit_log": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Append the statements changing the server, with their resource, duration and outcome, to this file in the JSON Lines format.",
+			},
+			"offline_plan": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Keep the prior state, with a warning, when the server cannot be reached during the refresh instead of failing. Changes still require a connection.",
+			},
 			"expected_version": {
 				Type:         schema.TypeString,
 				Optional:     true,
@@ -99,17 +216,73 @@ func Provider() terraform.ResourceProvider {
 			},
 		},
 
+		DataSourcesMap: map[string]*schema.Resource{
+			"postgresql_access_drift":      dataSourcePostgreSQLAccessDrift(),
+			"postgresql_connection_string": dataSourcePostgreSQLConnectionString(),
+			"postgresql_ddl":               dataSourcePostgreSQLDDL(),
+			"postgresql_locks":             dataSourcePostgreSQLLocks(),
+			"postgresql_server":            dataSourcePostgreSQLServer(),
+		},
+
 		ResourcesMap: map[string]*schema.Resource{
-			"postgresql_database":           resourcePostgreSQLDatabase(),
-			"postgresql_default_privileges": resourcePostgreSQLDefaultPrivileges(),
-			"postgresql_extension":          resourcePostgreSQLExtension(),
-			"postgresql_grant":              resourcePostgreSQLGrant(),
-			"postgresql_schema":             resourcePostgreSQLSchema(),
-			"postgresql_role":               resourcePostgreSQLRole(),
+			"postgresql_app_user":             resourcePostgreSQLAppUser(),
+			"postgresql_database":             resourcePostgreSQLDatabase(),
+			"postgresql_default_privileges":   resourcePostgreSQLDefaultPrivileges(),
+			"postgresql_event_trigger":        resourcePostgreSQLEventTrigger(),
+			"postgresql_extension":            resourcePostgreSQLExtension(),
+			"postgresql_foreign_data_wrapper": resourcePostgreSQLForeignDataWrapper(),
+			"postgresql_foreign_server":       resourcePostgreSQLForeignServer(),
+			"postgresql_function":             resourcePostgreSQLFunction(),
+			"postgresql_grant":                resourcePostgreSQLGrant(),
+			"postgresql_policy":               resourcePostgreSQLPolicy(),
+			"postgresql_publication":          resourcePostgreSQLPublication(),
+			"postgresql_readonly_grants":      resourcePostgreSQLReadonlyGrants(),
+			"postgresql_replication_slot":     resourcePostgreSQLReplicationSlot(),
+			"postgresql_revoke":               resourcePostgreSQLRevoke(),
+			"postgresql_schema":               resourcePostgreSQLSchema(),
+			"postgresql_role":                 resourcePostgreSQLRole(),
+			"postgresql_row_level_security":   resourcePostgreSQLRowLevelSecurity(),
+			"postgresql_sequence":             resourcePostgreSQLSequence(),
+			"postgresql_subscription":         resourcePostgreSQLSubscription(),
+			"postgresql_transaction":          resourcePostgreSQLTransaction(),
+			"postgresql_user_mapping":         resourcePostgreSQLUserMapping(),
+
+			"postgresql_citus_distributed_table":          resourcePostgreSQLCitusDistributedTable(),
+			"postgresql_citus_reference_table":            resourcePostgreSQLCitusReferenceTable(),
+			"postgresql_partman_parent":                   resourcePostgreSQLPartmanParent(),
+			"postgresql_postgis_spatial_ref_sys":          resourcePostgreSQLPostGISSpatialRefSys(),
+			"postgresql_postgres_fdw":                     resourcePostgreSQLPostgresFDW(),
+			"postgresql_timescaledb_continuous_aggregate": resourcePostgreSQLTimescaleDBContinuousAggregate(),
+			"postgresql_timescaledb_policy":               resourcePostgreSQLTimescaleDBPolicy(),
 		},
+	}
+
+	for name, r := range provider.ResourcesMap {
+		checkResourceFlavor(name, r)
+		checkMinServerVersion(name, r)
+		if !sliceContainsStr(nonTransactionalResources, name) {
+			runSQLHooks(r)
+		}
+		limitConcurrentOperations(r)
+		describeErrors(name, r)
+		enforceTimeouts(r)
+		auditStatements(name, r)
+		allowOfflinePlan(name, r)
+	}
+
+	for name, r := range provider.DataSourcesMap {
+		describeErrors(name, r)
+		// postgresql_connection_string does not connect to the server.
+		if name != "postgresql_connection_string" {
+			allowOfflinePlan(name, r)
+		}
+	}
 
-		ConfigureFunc: providerConfigure,
+	provider.ConfigureFunc = func(d *schema.ResourceData) (interface{}, error) {
+		return providerConfigure(provider.StopContext(), d)
 	}
+
+	return provider
 }
 
 func validateConnTimeout(v interface{}, key string) (warnings []string, errors []error) {
@@ -135,7 +308,37 @@ func validateMaxConnections(v interface{}, key string) (warnings []string, error
 	return
 }
 
-func providerConfigure(d *schema.ResourceData) (interface{}, error) {
+func validateFeatureOverrides(v interface{}, key string) (warnings []string, errors []error) {
+	for name := range v.(map[string]interface{}) {
+		if _, ok := featureNames[name]; !ok {
+			names := make([]string, 0, len(featureNames))
+			for name := range featureNames {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			errors = append(errors, fmt.Errorf("unknown feature %q in %s, expected one of: %s", name, key, strings.Join(names, ", ")))
+		}
+	}
+	return
+}
+
+// expandFeatureOverrides returns the features of feature_overrides (validated by
+// validateFeatureOverrides) with their forced support.
+func expandFeatureOverrides(overrides map[string]interface{}) map[featureName]bool {
+	features := make(map[featureName]bool, len(overrides))
+	for name, v := range overrides {
+		supported, ok := v.(bool)
+		if !ok {
+			supported, _ = strconv.ParseBool(fmt.Sprint(v))
+		}
+		features[featureNames[name]] = supported
+	}
+	return features
+}
+
+// providerConfigure returns the client of the provider.
+// The queries are canceled with the context when Terraform stops the provider.
+func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, error) {
 	var sslMode string
 	if sslModeRaw, ok := d.GetOk("sslmode"); ok {
 		sslMode = sslModeRaw.(string)
@@ -155,21 +358,399 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 		Password:          d.Get("password").(string),
 		DatabaseUsername:  d.Get("database_username").(string),
 		Superuser:         d.Get("superuser").(bool),
+		DegradeGracefully: d.Get("degrade_gracefully").(bool),
 		SSLMode:           sslMode,
 		ApplicationName:   tfAppName(),
 		ConnectTimeoutSec: d.Get("connect_timeout").(int),
 		MaxConns:          d.Get("max_connections").(int),
+		MaxPools:          d.Get("max_connection_pools").(int),
+		KeepaliveInterval: d.Get("tcp_keepalive_interval").(int),
+		FailoverRetries:   d.Get("failover_retries").(int),
+		PromotionTimeout:  d.Get("promotion_timeout").(int),
+		Azure:             d.Get("azure").(bool),
+		StatementCache:    d.Get("statement_cache_capacity").(int),
+		PgBouncer:         d.Get("pgbouncer").(bool),
+		RDSProxy:          d.Get("rds_proxy").(bool),
+		LogSQL:            d.Get("log_sql").(bool),
+		AuditLog:          d.Get("audit_log").(string),
 		ExpectedVersion:   version,
+
+		TolerateUnsupportedFeatures: d.Get("tolerate_unsupported_features").(bool),
+		FeatureOverrides:            expandFeatureOverrides(d.Get("feature_overrides").(map[string]interface{})),
 	}
 
 	client, err := config.NewClient(d.Get("database").(string))
 	if err != nil {
-		return nil, errwrap.Wrapf("Error initializing PostgreSQL client: {{err}}", err)
+		if !d.Get("offline_plan").(bool) || !isUnreachableError(err) {
+			return nil, errwrap.Wrapf("Error initializing PostgreSQL client: {{err}}", err)
+		}
+
+		log.Printf("[WARN] offline_plan: PostgreSQL server %s is unreachable, the prior state of the resources is kept: %v", config.Host, err)
+		client = &Client{
+			config:       config,
+			databaseName: d.Get("database").(string),
+			catalogCache: newCatalogCache(),
+			clientLocks:  &clientLocks{},
+			unreachable:  err,
+		}
+	}
+	client.ctx = ctx
+
+	if maxOperations := d.Get("max_concurrent_operations").(int); maxOperations > 0 {
+		client.operations = make(chan struct{}, maxOperations)
 	}
 
 	return client, nil
 }
 
+// limitConcurrentOperations wraps the functions of the resource so they wait
+// for a free slot if the number of concurrent operations is limited
+// (see max_concurrent_operations).
+func limitConcurrentOperations(r *schema.Resource) {
+	wrap := func(fn func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+		if fn == nil {
+			return nil
+		}
+		return func(d *schema.ResourceData, meta interface{}) error {
+			release, err := acquireOperation(meta)
+			if err != nil {
+				return err
+			}
+			defer release()
+			return fn(d, meta)
+		}
+	}
+
+	r.Create = wrap(r.Create)
+	r.Read = wrap(r.Read)
+	r.Update = wrap(r.Update)
+	r.Delete = wrap(r.Delete)
+
+	if exists := r.Exists; exists != nil {
+		r.Exists = func(d *schema.ResourceData, meta interface{}) (bool, error) {
+			release, err := acquireOperation(meta)
+			if err != nil {
+				return false, err
+			}
+			defer release()
+			return exists(d, meta)
+		}
+	}
+
+	if customizeDiff := r.CustomizeDiff; customizeDiff != nil {
+		r.CustomizeDiff = func(d *schema.ResourceDiff, meta interface{}) error {
+			release, err := acquireOperation(meta)
+			if err != nil {
+				return err
+			}
+			defer release()
+			return customizeDiff(d, meta)
+		}
+	}
+
+	if r.Importer != nil && r.Importer.State != nil {
+		importState := r.Importer.State
+		r.Importer.State = func(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+			release, err := acquireOperation(meta)
+			if err != nil {
+				return nil, err
+			}
+			defer release()
+			return importState(d, meta)
+		}
+	}
+}
+
+// checkResourceFlavor wraps the functions of the resource so they fail
+// if the resource cannot be used with the flavor of the server (see flavorUnsupportedResources).
+func checkResourceFlavor(name string, r *schema.Resource) {
+	checkFlavor := func(meta interface{}) error {
+		client, ok := meta.(*Client)
+		if !ok || !sliceContainsStr(flavorUnsupportedResources[client.flavor], name) {
+			return nil
+		}
+		return fmt.Errorf("%s resource is not supported on %s", name, client.flavor)
+	}
+
+	wrap := func(fn func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+		if fn == nil {
+			return nil
+		}
+		return func(d *schema.ResourceData, meta interface{}) error {
+			if err := checkFlavor(meta); err != nil {
+				return err
+			}
+			return fn(d, meta)
+		}
+	}
+
+	r.Create = wrap(r.Create)
+	r.Read = wrap(r.Read)
+	r.Update = wrap(r.Update)
+	r.Delete = wrap(r.Delete)
+
+	if exists := r.Exists; exists != nil {
+		r.Exists = func(d *schema.ResourceData, meta interface{}) (bool, error) {
+			if err := checkFlavor(meta); err != nil {
+				return false, err
+			}
+			return exists(d, meta)
+		}
+	}
+
+	// The plan checks (e.g.: the available versions of an extension) query the catalog.
+	if customizeDiff := r.CustomizeDiff; customizeDiff != nil {
+		r.CustomizeDiff = func(d *schema.ResourceDiff, meta interface{}) error {
+			if err := checkFlavor(meta); err != nil {
+				return err
+			}
+			return customizeDiff(d, meta)
+		}
+	}
+
+	if r.Importer != nil && r.Importer.State != nil {
+		importState := r.Importer.State
+		r.Importer.State = func(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+			if err := checkFlavor(meta); err != nil {
+				return nil, err
+			}
+			return importState(d, meta)
+		}
+	}
+}
+
+// checkMinServerVersion adds the min_server_version attribute to the resource
+// and wraps its CustomizeDiff so the plan fails if the server is older.
+func checkMinServerVersion(name string, r *schema.Resource) {
+	r.Schema[minServerVersionAttr] = minServerVersionSchema()
+	if r.Update == nil {
+		// The other attributes force a new resource, the new min_server_version
+		// only has to be saved in the state.
+		r.Update = func(d *schema.ResourceData, meta interface{}) error {
+			return nil
+		}
+	}
+
+	customizeDiff := r.CustomizeDiff
+	r.CustomizeDiff = func(d *schema.ResourceDiff, meta interface{}) error {
+		client, ok := meta.(*Client)
+		if v, set := d.GetOk(minServerVersionAttr); ok && set {
+			// The version has been validated by the schema.
+			minVersion, _ := semver.ParseTolerant(v.(string))
+			if client.version.LT(minVersion) {
+				return fmt.Errorf(
+					"%s requires a server version %s or later (see min_server_version), the connected server is %s %s",
+					name, v.(string), client.flavor, client.version,
+				)
+			}
+		}
+
+		if customizeDiff == nil {
+			return nil
+		}
+		return customizeDiff(d, meta)
+	}
+}
+
+// runSQLHooks adds the pre_sql and post_sql attributes to the resource and wraps its
+// create, update and delete so they are executed in the transactions of the operation:
+// pre_sql after the start of each transaction (see startTransaction) and post_sql before
+// its commit (see commitTransaction).
+func runSQLHooks(r *schema.Resource) {
+	r.Schema[preSQLAttr] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Description: "SQL executed at the start of the transactions of the operations (e.g.: SET LOCAL lock_timeout = '5s')",
+	}
+	r.Schema[postSQLAttr] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Description: "SQL executed before the commit of the transactions of the operations (e.g.: NOTIFY)",
+	}
+
+	wrap := func(fn func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+		if fn == nil {
+			return nil
+		}
+		return func(d *schema.ResourceData, meta interface{}) error {
+			client, ok := meta.(*Client)
+			hooks := sqlHooks{
+				pre:  d.Get(preSQLAttr).(string),
+				post: d.Get(postSQLAttr).(string),
+			}
+			if !ok || client.ctx == nil || (hooks.pre == "" && hooks.post == "") {
+				return fn(d, meta)
+			}
+
+			hooked := *client
+			hooked.ctx = context.WithValue(client.ctx, sqlHooksKey{}, hooks)
+			return fn(d, &hooked)
+		}
+	}
+
+	r.Create = wrap(r.Create)
+	r.Update = wrap(r.Update)
+	r.Delete = wrap(r.Delete)
+}
+
+// enforceTimeouts adds the timeouts block to the resource and wraps its functions
+// so their queries are canceled when the timeout of the operation is reached.
+// The operations have no timeout by default, including the resources whose state
+// has no timeouts (see hasTimeouts).
+func enforceTimeouts(r *schema.Resource) {
+	if r.Timeouts == nil {
+		r.Timeouts = &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Duration(0)),
+			Read:   schema.DefaultTimeout(time.Duration(0)),
+			Update: schema.DefaultTimeout(time.Duration(0)),
+			Delete: schema.DefaultTimeout(time.Duration(0)),
+		}
+	}
+
+	wrap := func(key string, fn func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+		if fn == nil {
+			return nil
+		}
+		return func(d *schema.ResourceData, meta interface{}) error {
+			client, ok := meta.(*Client)
+			if !ok || !hasTimeouts(d) {
+				return fn(d, meta)
+			}
+			timeout := d.Timeout(key)
+			if timeout <= 0 {
+				return fn(d, meta)
+			}
+
+			client, cancel := client.withTimeout(timeout)
+			defer cancel()
+
+			err := fn(d, client)
+			if err != nil && client.ctx.Err() == context.DeadlineExceeded {
+				return errwrap.Wrapf(fmt.Sprintf("%s timeout (%s) reached: {{err}}", key, timeout), err)
+			}
+			return err
+		}
+	}
+
+	r.Create = wrap(schema.TimeoutCreate, r.Create)
+	r.Read = wrap(schema.TimeoutRead, r.Read)
+	r.Update = wrap(schema.TimeoutUpdate, r.Update)
+	r.Delete = wrap(schema.TimeoutDelete, r.Delete)
+}
+
+// hasTimeouts returns true if the timeouts of the resource are known: they are decoded from
+// the diff or the state, but are missing from the state of a resource imported or written by
+// an older version of the provider, for which d.Timeout returns the 20 minutes of the SDK.
+func hasTimeouts(d *schema.ResourceData) bool {
+	// The diff of a resource being created always has the timeouts (see enforceTimeouts).
+	if d.Id() == "" {
+		return true
+	}
+
+	state := d.State()
+	if state == nil {
+		return false
+	}
+	_, ok := state.Meta[schema.TimeoutKey]
+	return ok
+}
+
+// allowOfflinePlan wraps the functions of the resource for offline_plan: if the server
+// was unreachable when the provider was configured, the refresh keeps the prior state
+// and the plan is computed from it, the other operations fail.
+func allowOfflinePlan(name string, r *schema.Resource) {
+	unreachable := func(meta interface{}) error {
+		client, ok := meta.(*Client)
+		if !ok || client.unreachable == nil {
+			return nil
+		}
+		return errwrap.Wrapf(fmt.Sprintf("%s cannot be managed while the PostgreSQL server is unreachable (offline_plan): {{err}}", name), client.unreachable)
+	}
+
+	wrap := func(fn func(*schema.ResourceData, interface{}) error) func(*schema.ResourceData, interface{}) error {
+		if fn == nil {
+			return nil
+		}
+		return func(d *schema.ResourceData, meta interface{}) error {
+			if err := unreachable(meta); err != nil {
+				return err
+			}
+			return fn(d, meta)
+		}
+	}
+
+	r.Create = wrap(r.Create)
+	r.Update = wrap(r.Update)
+	r.Delete = wrap(r.Delete)
+
+	if read := r.Read; read != nil {
+		r.Read = func(d *schema.ResourceData, meta interface{}) error {
+			if err := unreachable(meta); err != nil {
+				// The data sources have no prior state.
+				if d.Id() == "" {
+					return err
+				}
+				log.Printf("[WARN] offline_plan: PostgreSQL server is unreachable, %s (%s) is not refreshed", name, d.Id())
+				return nil
+			}
+			return read(d, meta)
+		}
+	}
+
+	if exists := r.Exists; exists != nil {
+		r.Exists = func(d *schema.ResourceData, meta interface{}) (bool, error) {
+			if unreachable(meta) != nil {
+				return true, nil
+			}
+			return exists(d, meta)
+		}
+	}
+
+	if customizeDiff := r.CustomizeDiff; customizeDiff != nil {
+		r.CustomizeDiff = func(d *schema.ResourceDiff, meta interface{}) error {
+			// The checks of the plan need the server.
+			if unreachable(meta) != nil {
+				return nil
+			}
+			return customizeDiff(d, meta)
+		}
+	}
+
+	if r.Importer != nil && r.Importer.State != nil {
+		importState := r.Importer.State
+		r.Importer.State = func(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+			if err := unreachable(meta); err != nil {
+				return nil, err
+			}
+			return importState(d, meta)
+		}
+	}
+}
+
+// acquireOperation waits for a free operation slot of the client
+// and returns the function to release it. It fails if the context of the client
+// is done first (the provider is stopped or the timeout of the operation is reached).
+func acquireOperation(meta interface{}) (func(), error) {
+	client, ok := meta.(*Client)
+	if !ok || client.operations == nil {
+		return func() {}, nil
+	}
+
+	var done <-chan struct{}
+	if client.ctx != nil {
+		done = client.ctx.Done()
+	}
+
+	select {
+	case client.operations <- struct{}{}:
+	case <-done:
+		return nil, errwrap.Wrapf("Error waiting for a free operation slot (max_concurrent_operations): {{err}}", client.ctx.Err())
+	}
+	return func() {
+		<-client.operations
+	}, nil
+}
+
 func tfAppName() string {
 	return fmt.Sprintf("Terraform v%s", terraform.VersionString())
 }
@@ -1,9 +1,11 @@
 package postgresql
 
 import (
+	"context"
 	"os"
 	"testing"
 
+	"github.com/blang/semver"
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
 )
@@ -28,6 +30,79 @@ func TestProvider_impl(t *testing.T) {
 	var _ terraform.ResourceProvider = Provider()
 }
 
+func TestFeatureOverrides(t *testing.T) {
+	for feature := range featureSupported {
+		found := false
+		for _, name := range featureNames {
+			found = found || name == feature
+		}
+		if !found {
+			t.Errorf("feature %v has no name in featureNames", feature)
+		}
+	}
+
+	if _, errs := validateFeatureOverrides(map[string]interface{}{"extension": true, "unknown": false}, "feature_overrides"); len(errs) != 1 {
+		t.Errorf("expected an error for the unknown feature, got %v", errs)
+	}
+
+	client := &Client{
+		config: Config{FeatureOverrides: expandFeatureOverrides(map[string]interface{}{
+			"extension":      false,
+			"superuser_role": "true",
+		})},
+		version: semver.MustParse("16.0.0"),
+		flavor:  flavorAlloyDB,
+	}
+	if client.featureSupported(featureExtension) {
+		t.Error("expected extension to be unsupported by the override")
+	}
+	if !client.featureSupported(featureSuperuserRole) {
+		t.Error("expected superuser_role to be supported by the override despite the flavor")
+	}
+	if !client.featureSupported(featurePrivileges) {
+		t.Error("expected privileges to be detected from the version")
+	}
+}
+
+func TestAcquireOperation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &Client{ctx: ctx, operations: make(chan struct{}, 1)}
+
+	release, err := acquireOperation(client)
+	if err != nil {
+		t.Fatalf("expected a free operation slot, got %v", err)
+	}
+
+	// The second operation waits until its context is canceled.
+	cancel()
+	if _, err := acquireOperation(client); err == nil {
+		t.Error("expected an error once the context is canceled")
+	}
+
+	release()
+	if len(client.operations) != 0 {
+		t.Error("expected the operation slot to be released")
+	}
+}
+
+func TestCheckResourceFlavorCustomizeDiff(t *testing.T) {
+	called := false
+	r := &schema.Resource{
+		CustomizeDiff: func(d *schema.ResourceDiff, meta interface{}) error {
+			called = true
+			return nil
+		},
+	}
+	checkResourceFlavor("postgresql_extension", r)
+
+	if err := r.CustomizeDiff(nil, &Client{flavor: flavorRedshift}); err == nil || called {
+		t.Errorf("expected the plan to fail on Redshift without querying the catalog, got %v", err)
+	}
+	if err := r.CustomizeDiff(nil, &Client{flavor: flavorPostgreSQL}); err != nil || !called {
+		t.Errorf("expected the plan to be checked on PostgreSQL, got %v", err)
+	}
+}
+
 func testAccPreCheck(t *testing.T) {
 	var host string
 	if host = os.Getenv("PGHOST"); host == "" {
@@ -42,3 +117,36 @@ func testAccPreCheck(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestHasTimeouts(t *testing.T) {
+	var found bool
+	r := &schema.Resource{
+		Schema: map[string]*schema.Schema{},
+		Read: func(d *schema.ResourceData, meta interface{}) error {
+			found = hasTimeouts(d)
+			return nil
+		},
+	}
+	enforceTimeouts(r)
+
+	// e.g.: an imported resource, d.Timeout returns the default of the SDK.
+	if _, err := r.Refresh(&terraform.InstanceState{ID: "id"}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if found {
+		t.Error("expected no timeouts without timeouts in the state")
+	}
+
+	state := &terraform.InstanceState{
+		ID: "id",
+		Meta: map[string]interface{}{
+			schema.TimeoutKey: map[string]interface{}{schema.TimeoutRead: int64(0)},
+		},
+	}
+	if _, err := r.Refresh(state, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !found {
+		t.Error("expected the timeouts of the state")
+	}
+}
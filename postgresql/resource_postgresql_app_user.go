@@ -0,0 +1,332 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+const (
+	appUserNameAttr        = "name"
+	appUserDatabaseAttr    = "database"
+	appUserSchemaAttr      = "schema"
+	appUserPasswordAttr    = "password"
+	appUserConnLimitAttr   = "connection_limit"
+	appUserRolesAttr       = "roles"
+	appUserDropCascadeAttr = "drop_cascade"
+
+	// appUserPasswordLength is the length of the generated passwords.
+	appUserPasswordLength = 32
+)
+
+// resourcePostgreSQLAppUser creates, in a single transaction, a login role with its
+// dedicated schema (owned by the role and first in its search_path) and the CONNECT
+// privilege on the database.
+func resourcePostgreSQLAppUser() *schema.Resource {
+	return &schema.Resource{
+		Create:        retryOnTransientErrors(resourcePostgreSQLAppUserCreate),
+		Read:          resourcePostgreSQLAppUserRead,
+		Update:        retryOnTransientErrors(resourcePostgreSQLAppUserUpdate),
+		Delete:        retryOnTransientErrors(resourcePostgreSQLAppUserDelete),
+		CustomizeDiff: resourcePostgreSQLAppUserCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			appUserNameAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateRoleName,
+				Description:  "The name of the login role",
+			},
+			appUserDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database of the schema",
+			},
+			appUserSchemaAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The schema owned by the role (named after the role by default)",
+			},
+			appUserPasswordAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The password of the role (generated when not set)",
+			},
+			appUserConnLimitAttr: {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      -1,
+				ValidateFunc: validation.IntAtLeast(-1),
+				Description:  "How many concurrent connections the role can make (-1 means no limit)",
+			},
+			appUserRolesAttr: {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         schema.HashString,
+				Description: "The roles granted to the role (e.g.: for the access to shared schemas)",
+			},
+			appUserDropCascadeAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When true, the schema is dropped with the objects it contains",
+			},
+		},
+	}
+}
+
+func resourcePostgreSQLAppUserCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	roleName := d.Get(appUserNameAttr).(string)
+	database := getAppUserDatabase(d, c)
+	schemaName := getAppUserSchema(d)
+
+	password := d.Get(appUserPasswordAttr).(string)
+	if password == "" {
+		var err error
+		if password, err = generatePassword(appUserPasswordLength, false); err != nil {
+			return err
+		}
+	}
+
+	// The roles are shared by all the databases.
+	c.catalogLock.Lock()
+	defer c.catalogLock.Unlock()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	quotedRole := pqQuoteIdentifier(roleName)
+	queries := []string{
+		fmt.Sprintf(
+			"CREATE ROLE %s WITH LOGIN PASSWORD '%s' CONNECTION LIMIT %d",
+			quotedRole, pqQuoteLiteral(password), d.Get(appUserConnLimitAttr).(int),
+		),
+	}
+	for _, role := range setToStrings(d.Get(appUserRolesAttr).(*schema.Set)) {
+		queries = append(queries, fmt.Sprintf("GRANT %s TO %s", pqQuoteIdentifier(role), quotedRole))
+	}
+	queries = append(queries,
+		fmt.Sprintf("GRANT CONNECT ON DATABASE %s TO %s", pqQuoteIdentifier(database), quotedRole),
+		fmt.Sprintf("CREATE SCHEMA %s AUTHORIZATION %s", pqQuoteIdentifier(schemaName), quotedRole),
+		fmt.Sprintf(
+			"ALTER ROLE %s IN DATABASE %s SET search_path TO %s",
+			quotedRole, pqQuoteIdentifier(database), pqQuoteIdentifier(schemaName),
+		),
+	)
+
+	if err := execQueries(c.ctx, txn, queries); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error creating app user %s, no object has been created: {{err}}", roleName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("could not commit transaction: {{err}}", err)
+	}
+
+	d.Set(appUserDatabaseAttr, database)
+	d.Set(appUserSchemaAttr, schemaName)
+	d.Set(appUserPasswordAttr, password)
+	d.SetId(generateAppUserID(d))
+
+	return resourcePostgreSQLAppUserReadImpl(d, c)
+}
+
+func resourcePostgreSQLAppUserRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+	c.catalogLock.RLock()
+	defer c.catalogLock.RUnlock()
+
+	return resourcePostgreSQLAppUserReadImpl(d, c)
+}
+
+// resourcePostgreSQLAppUserReadImpl reads the role, it is removed from the state if it does not exist.
+// If only the schema is missing, it is removed from the state so the app user is replaced.
+// The password and the privileges are not read.
+func resourcePostgreSQLAppUserReadImpl(d *schema.ResourceData, c *Client) error {
+	roleName := d.Get(appUserNameAttr).(string)
+	database := getAppUserDatabase(d, c)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	var connLimit int
+	var roles []string
+	err = txn.QueryRowContext(c.ctx,
+		`SELECT r.rolconnlimit, ARRAY(
+			SELECT pg_catalog.pg_get_userbyid(m.roleid) FROM pg_catalog.pg_auth_members m WHERE m.member = r.oid
+		) FROM pg_catalog.pg_roles r WHERE r.rolname = $1`,
+		roleName,
+	).Scan(&connLimit, pgArray(&roles))
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] PostgreSQL app user (%s) not found", d.Id())
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading app user: {{err}}", err)
+	}
+
+	schemaName := getAppUserSchema(d)
+	var schemaOwner string
+	err = txn.QueryRowContext(c.ctx,
+		"SELECT pg_catalog.pg_get_userbyid(nspowner) FROM pg_catalog.pg_namespace WHERE nspname = $1", schemaName,
+	).Scan(&schemaOwner)
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] schema %s of app user %s not found", schemaName, d.Id())
+		schemaName = ""
+	case err != nil:
+		return errwrap.Wrapf("Error reading app user schema: {{err}}", err)
+	case schemaOwner != roleName:
+		log.Printf("[WARN] schema %s of app user %s is owned by %s", schemaName, d.Id(), schemaOwner)
+	}
+
+	d.Set(appUserConnLimitAttr, connLimit)
+	d.Set(appUserRolesAttr, pgArrayToSet(roles))
+	d.Set(appUserSchemaAttr, schemaName)
+	d.Set(appUserDatabaseAttr, database)
+	d.SetId(generateAppUserID(d))
+
+	return nil
+}
+
+func resourcePostgreSQLAppUserUpdate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+	c.catalogLock.Lock()
+	defer c.catalogLock.Unlock()
+
+	txn, err := startTransaction(c, getAppUserDatabase(d, c))
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	roleName := d.Get(appUserNameAttr).(string)
+	quotedRole := pqQuoteIdentifier(roleName)
+
+	var queries []string
+	if d.HasChange(appUserConnLimitAttr) {
+		queries = append(queries, fmt.Sprintf("ALTER ROLE %s CONNECTION LIMIT %d", quotedRole, d.Get(appUserConnLimitAttr).(int)))
+	}
+	if d.HasChange(appUserPasswordAttr) {
+		queries = append(queries, fmt.Sprintf("ALTER ROLE %s PASSWORD '%s'", quotedRole, pqQuoteLiteral(d.Get(appUserPasswordAttr).(string))))
+	}
+	if d.HasChange(appUserRolesAttr) {
+		oldRaw, newRaw := d.GetChange(appUserRolesAttr)
+		oldRoles, newRoles := oldRaw.(*schema.Set), newRaw.(*schema.Set)
+		for _, role := range setToStrings(oldRoles.Difference(newRoles)) {
+			queries = append(queries, fmt.Sprintf("REVOKE %s FROM %s", pqQuoteIdentifier(role), quotedRole))
+		}
+		for _, role := range setToStrings(newRoles.Difference(oldRoles)) {
+			queries = append(queries, fmt.Sprintf("GRANT %s TO %s", pqQuoteIdentifier(role), quotedRole))
+		}
+	}
+
+	if err := execQueries(c.ctx, txn, queries); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error updating app user %s: {{err}}", roleName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("could not commit transaction: {{err}}", err)
+	}
+
+	return resourcePostgreSQLAppUserReadImpl(d, c)
+}
+
+func resourcePostgreSQLAppUserDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+	c.catalogLock.Lock()
+	defer c.catalogLock.Unlock()
+
+	database := getAppUserDatabase(d, c)
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	dropBehavior := "RESTRICT"
+	if d.Get(appUserDropCascadeAttr).(bool) {
+		dropBehavior = "CASCADE"
+	}
+
+	roleName := d.Get(appUserNameAttr).(string)
+	quotedRole := pqQuoteIdentifier(roleName)
+
+	var queries []string
+	if schemaName := d.Get(appUserSchemaAttr).(string); schemaName != "" {
+		queries = append(queries, fmt.Sprintf("DROP SCHEMA IF EXISTS %s %s", pqQuoteIdentifier(schemaName), dropBehavior))
+	}
+	// The settings of the role are dropped with it.
+	queries = append(queries,
+		fmt.Sprintf("REVOKE CONNECT ON DATABASE %s FROM %s", pqQuoteIdentifier(database), quotedRole),
+		fmt.Sprintf("DROP ROLE %s", quotedRole),
+	)
+
+	if err := execQueries(c.ctx, txn, queries); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error dropping app user %s, no object has been dropped: {{err}}", roleName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("could not commit transaction: {{err}}", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// resourcePostgreSQLAppUserCustomizeDiff replaces the app user when its schema
+// has been dropped outside of Terraform (see resourcePostgreSQLAppUserReadImpl).
+func resourcePostgreSQLAppUserCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || d.Get(appUserSchemaAttr).(string) != "" {
+		return nil
+	}
+
+	if err := d.SetNew(appUserSchemaAttr, d.Get(appUserNameAttr).(string)); err != nil {
+		return err
+	}
+	return d.ForceNew(appUserSchemaAttr)
+}
+
+func getAppUserDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(appUserDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+// getAppUserSchema returns the schema of the app user, named after the role by default.
+func getAppUserSchema(d *schema.ResourceData) string {
+	if v, ok := d.GetOk(appUserSchemaAttr); ok {
+		return v.(string)
+	}
+	return d.Get(appUserNameAttr).(string)
+}
+
+func generateAppUserID(d *schema.ResourceData) string {
+	return strings.Join([]string{d.Get(appUserDatabaseAttr).(string), d.Get(appUserNameAttr).(string)}, "/")
+}
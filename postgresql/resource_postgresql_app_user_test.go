@@ -0,0 +1,86 @@
+package postgresql
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccPostgresqlAppUser_Basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPostgresqlAppUserDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "postgresql_app_user" "tenant" {
+  name             = "app_user_tenant"
+  connection_limit = 5
+}`,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPostgresqlRoleExists("app_user_tenant", nil),
+					testAccCheckPostgresqlAppUserSchema("app_user_tenant", "app_user_tenant"),
+					resource.TestCheckResourceAttr("postgresql_app_user.tenant", "schema", "app_user_tenant"),
+					resource.TestCheckResourceAttr("postgresql_app_user.tenant", "connection_limit", "5"),
+					resource.TestCheckResourceAttrSet("postgresql_app_user.tenant", "password"),
+				),
+			},
+			{
+				Config: `
+resource "postgresql_app_user" "tenant" {
+  name             = "app_user_tenant"
+  connection_limit = 10
+}`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_app_user.tenant", "connection_limit", "10"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckPostgresqlAppUserSchema(schemaName, owner string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		client := testAccProvider.Meta().(*Client)
+
+		var schemaOwner string
+		if err := client.DB().QueryRow(
+			"SELECT pg_catalog.pg_get_userbyid(nspowner) FROM pg_catalog.pg_namespace WHERE nspname = $1", schemaName,
+		).Scan(&schemaOwner); err != nil {
+			return fmt.Errorf("Error reading schema %s: %s", schemaName, err)
+		}
+		if schemaOwner != owner {
+			return fmt.Errorf("expected schema %s to be owned by %s, got %s", schemaName, owner, schemaOwner)
+		}
+		return nil
+	}
+}
+
+func testAccCheckPostgresqlAppUserDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*Client)
+
+	var exists bool
+	if err := client.DB().QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM pg_catalog.pg_roles WHERE rolname = 'app_user_tenant')",
+	).Scan(&exists); err != nil {
+		return fmt.Errorf("Error checking role: %s", err)
+	}
+	if exists {
+		return fmt.Errorf("Role app_user_tenant still exists after destroy")
+	}
+
+	exists, err := checkSchemaExists(client, "app_user_tenant")
+	if err != nil {
+		return fmt.Errorf("Error checking schema: %s", err)
+	}
+	if exists {
+		return fmt.Errorf("Schema app_user_tenant still exists after destroy")
+	}
+
+	return nil
+}
@@ -0,0 +1,334 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	citusTableDatabaseAttr           = "database"
+	citusTableSchemaAttr             = "schema"
+	citusTableNameAttr               = "table"
+	citusTableDistributionColumnAttr = "distribution_column"
+	citusTableShardCountAttr         = "shard_count"
+	citusTableColocateWithAttr       = "colocate_with"
+	citusTableColocationIDAttr       = "colocation_id"
+
+	citusExtension = "citus"
+
+	citusDistributedTable = "distributed"
+	citusReferenceTable   = "reference"
+)
+
+// citusTableSchema returns the attributes shared by the Citus table resources.
+func citusTableSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		citusTableDatabaseAttr: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Computed:     true,
+			ForceNew:     true,
+			ValidateFunc: validateIdentifier,
+			Description:  "The database of the table",
+		},
+		citusTableSchemaAttr: {
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "public",
+			ForceNew:     true,
+			ValidateFunc: validateIdentifier,
+			Description:  "The schema of the table",
+		},
+		citusTableNameAttr: {
+			Type:         schema.TypeString,
+			Required:     true,
+			ForceNew:     true,
+			ValidateFunc: validateIdentifier,
+			Description:  "The name of the table",
+		},
+		citusTableColocationIDAttr: {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "The ID of the colocation group of the table",
+		},
+	}
+}
+
+func resourcePostgreSQLCitusDistributedTable() *schema.Resource {
+	s := citusTableSchema()
+	s[citusTableDistributionColumnAttr] = &schema.Schema{
+		Type:        schema.TypeString,
+		Required:    true,
+		Description: "The column used to distribute the rows between the shards",
+	}
+	s[citusTableShardCountAttr] = &schema.Schema{
+		Type:        schema.TypeInt,
+		Optional:    true,
+		Computed:    true,
+		Description: "The number of shards of the table (citus.shard_count by default)",
+	}
+	s[citusTableColocateWithAttr] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Default:     "default",
+		Description: "The table to colocate this table with (default to colocate with the tables having the same distribution column type and shard count, none to create a new colocation group)",
+	}
+
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLCitusDistributedTableCreate),
+		Read:   resourcePostgreSQLCitusDistributedTableRead,
+		Update: retryOnTransientErrors(resourcePostgreSQLCitusDistributedTableUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLCitusTableDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLCitusTableImport,
+		},
+
+		Schema: s,
+	}
+}
+
+func resourcePostgreSQLCitusReferenceTable() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLCitusReferenceTableCreate),
+		Read:   resourcePostgreSQLCitusReferenceTableRead,
+		Delete: retryOnTransientErrors(resourcePostgreSQLCitusTableDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLCitusTableImport,
+		},
+
+		Schema: citusTableSchema(),
+	}
+}
+
+func resourcePostgreSQLCitusDistributedTableCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	query := "SELECT create_distributed_table($1, $2, colocate_with => $3"
+	args := []interface{}{
+		citusTableName(d), d.Get(citusTableDistributionColumnAttr), d.Get(citusTableColocateWithAttr),
+	}
+	if v, ok := d.GetOk(citusTableShardCountAttr); ok {
+		query += ", shard_count => $4"
+		args = append(args, v)
+	}
+	query += ")"
+
+	if err := createCitusTable(c, d, query, args...); err != nil {
+		return err
+	}
+
+	return readCitusTable(c, d, citusDistributedTable)
+}
+
+func resourcePostgreSQLCitusReferenceTableCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := createCitusTable(c, d, "SELECT create_reference_table($1)", citusTableName(d)); err != nil {
+		return err
+	}
+
+	return readCitusTable(c, d, citusReferenceTable)
+}
+
+// createCitusTable runs the query distributing the table (and its data) in the database.
+func createCitusTable(c *Client, d *schema.ResourceData, query string, args ...interface{}) error {
+	database := getCitusTableDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	if err := checkExtensionInstalled(c.ctx, txn, citusExtension); err != nil {
+		return err
+	}
+
+	if _, err := txn.ExecContext(c.ctx, query, args...); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error distributing table %s: {{err}}", citusTableName(d)), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing table distribution: {{err}}", err)
+	}
+
+	d.Set(citusTableDatabaseAttr, database)
+	d.SetId(generateCitusTableID(d))
+
+	return nil
+}
+
+func resourcePostgreSQLCitusDistributedTableRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	defer c.rLockDatabase(getCitusTableDatabase(d, c))()
+
+	return readCitusTable(c, d, citusDistributedTable)
+}
+
+func resourcePostgreSQLCitusReferenceTableRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	defer c.rLockDatabase(getCitusTableDatabase(d, c))()
+
+	return readCitusTable(c, d, citusReferenceTable)
+}
+
+// readCitusTable reads the table from citus_tables. The table is removed from the state
+// if it does not exist or if it's not a table of the expected type.
+func readCitusTable(c *Client, d *schema.ResourceData, tableType string) error {
+	database := getCitusTableDatabase(d, c)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	if err := checkExtensionInstalled(c.ctx, txn, citusExtension); err != nil {
+		return err
+	}
+
+	var citusTableType, distributionColumn string
+	var shardCount, colocationID int
+	err = txn.QueryRowContext(c.ctx,
+		"SELECT citus_table_type, distribution_column, shard_count, colocation_id FROM citus_tables WHERE table_name = to_regclass($1)",
+		citusTableName(d),
+	).Scan(&citusTableType, &distributionColumn, &shardCount, &colocationID)
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] Citus table (%s) not found", d.Id())
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading Citus table: {{err}}", err)
+	}
+
+	if citusTableType != tableType {
+		log.Printf("[WARN] Citus table (%s) is a %s table instead of a %s table", d.Id(), citusTableType, tableType)
+		d.SetId("")
+		return nil
+	}
+
+	if tableType == citusDistributedTable {
+		d.Set(citusTableDistributionColumnAttr, distributionColumn)
+		d.Set(citusTableShardCountAttr, shardCount)
+		// Only the colocation group is known on the server (e.g.: after an import).
+		if d.Get(citusTableColocateWithAttr).(string) == "" {
+			d.Set(citusTableColocateWithAttr, "default")
+		}
+	}
+	d.Set(citusTableColocationIDAttr, colocationID)
+	d.Set(citusTableDatabaseAttr, database)
+	d.SetId(generateCitusTableID(d))
+
+	return nil
+}
+
+func resourcePostgreSQLCitusDistributedTableUpdate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	args := []string{}
+	for _, attr := range []string{citusTableDistributionColumnAttr, citusTableShardCountAttr, citusTableColocateWithAttr} {
+		if !d.HasChange(attr) {
+			continue
+		}
+		args = append(args, fmt.Sprintf("%s => '%s'", attr, pqQuoteLiteral(fmt.Sprint(d.Get(attr)))))
+	}
+
+	if len(args) > 0 {
+		database := getCitusTableDatabase(d, c)
+
+		defer c.lockDatabase(database)()
+
+		txn, err := startTransaction(c, database)
+		if err != nil {
+			return err
+		}
+		defer deferredRollback(txn)
+
+		// alter_distributed_table() redistributes the data of the table.
+		query := fmt.Sprintf("SELECT alter_distributed_table($1, %s)", strings.Join(args, ", "))
+		if _, err := txn.ExecContext(c.ctx, query, citusTableName(d)); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("Error altering distributed table %s: {{err}}", citusTableName(d)), err)
+		}
+
+		if err := commitTransaction(c, txn); err != nil {
+			return errwrap.Wrapf("Error committing distributed table: {{err}}", err)
+		}
+	}
+
+	return readCitusTable(c, d, citusDistributedTable)
+}
+
+func resourcePostgreSQLCitusTableDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getCitusTableDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	// The table is undistributed only if it still exists: its data is moved back to the coordinator.
+	if _, err := txn.ExecContext(c.ctx,
+		"SELECT undistribute_table(table_name) FROM citus_tables WHERE table_name = to_regclass($1)",
+		citusTableName(d),
+	); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error undistributing table %s: {{err}}", citusTableName(d)), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing table undistribution: {{err}}", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// resourcePostgreSQLCitusTableImport imports a Citus table
+// from an ID with the database/schema/table format.
+func resourcePostgreSQLCitusTableImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), "database/schema/table")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set(citusTableDatabaseAttr, parts[0])
+	d.Set(citusTableSchemaAttr, parts[1])
+	d.Set(citusTableNameAttr, parts[2])
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// citusTableName returns the quoted and schema qualified name of the table.
+func citusTableName(d *schema.ResourceData) string {
+	return fmt.Sprintf("%s.%s",
+		pqQuoteIdentifier(d.Get(citusTableSchemaAttr).(string)), pqQuoteIdentifier(d.Get(citusTableNameAttr).(string)),
+	)
+}
+
+func getCitusTableDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(citusTableDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generateCitusTableID(d *schema.ResourceData) string {
+	return strings.Join([]string{
+		d.Get(citusTableDatabaseAttr).(string), d.Get(citusTableSchemaAttr).(string), d.Get(citusTableNameAttr).(string),
+	}, ".")
+}
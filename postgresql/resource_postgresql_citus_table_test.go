@@ -0,0 +1,77 @@
+package postgresql
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccPostgresqlCitusTable_Basic(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, false)
+	defer teardown()
+
+	createTestExtension(t, dbSuffix, citusExtension)
+
+	dbName, _ := getTestDBNames(dbSuffix)
+	config := getTestConfig(t)
+	dbExecute(t, config.connStr(dbName), "CREATE TABLE test_schema.events (tenant_id bigint, id bigint, PRIMARY KEY (tenant_id, id))")
+	dbExecute(t, config.connStr(dbName), "CREATE TABLE test_schema.countries (code text PRIMARY KEY)")
+
+	testAccConfig := func(shardCount int) string {
+		return fmt.Sprintf(`
+resource "postgresql_citus_distributed_table" "events" {
+  database            = "%s"
+  schema              = "test_schema"
+  table               = "events"
+  distribution_column = "tenant_id"
+  shard_count         = %d
+  colocate_with       = "none"
+}
+
+resource "postgresql_citus_reference_table" "countries" {
+  database = "%s"
+  schema   = "test_schema"
+  table    = "countries"
+}
+`, dbName, shardCount, dbName)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfig(4),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"postgresql_citus_distributed_table.events", "id",
+						fmt.Sprintf("%s.test_schema.events", dbName),
+					),
+					resource.TestCheckResourceAttr(
+						"postgresql_citus_distributed_table.events", "distribution_column", "tenant_id"),
+					resource.TestCheckResourceAttr(
+						"postgresql_citus_distributed_table.events", "shard_count", "4"),
+					resource.TestCheckResourceAttrSet(
+						"postgresql_citus_reference_table.countries", "colocation_id"),
+				),
+			},
+			{
+				Config: testAccConfig(8),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"postgresql_citus_distributed_table.events", "shard_count", "8"),
+				),
+			},
+			{
+				ResourceName:      "postgresql_citus_reference_table.countries",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
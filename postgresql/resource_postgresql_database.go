@@ -2,6 +2,7 @@ package postgresql
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -10,7 +11,6 @@ import (
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/lib/pq"
 )
 
 const (
@@ -28,26 +28,29 @@ const (
 
 func resourcePostgreSQLDatabase() *schema.Resource {
 	return &schema.Resource{
-		Create: resourcePostgreSQLDatabaseCreate,
+		Create: retryOnFailover(resourcePostgreSQLDatabaseCreate),
 		Read:   resourcePostgreSQLDatabaseRead,
-		Update: resourcePostgreSQLDatabaseUpdate,
-		Delete: resourcePostgreSQLDatabaseDelete,
-		Exists: resourcePostgreSQLDatabaseExists,
+		Update: retryOnFailover(resourcePostgreSQLDatabaseUpdate),
+		Delete: retryOnFailover(resourcePostgreSQLDatabaseDelete),
 		Importer: &schema.ResourceImporter{
 			State: schema.ImportStatePassthrough,
 		},
 
 		Schema: map[string]*schema.Schema{
 			dbNameAttr: {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "The PostgreSQL database name to connect to",
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateFunc:     validateIdentifier,
+				Description:      "The PostgreSQL database name to connect to",
+				DiffSuppressFunc: suppressIdentifierCaseDiff,
 			},
 			dbOwnerAttr: {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Computed:    true,
-				Description: "The ROLE which owns the database",
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				ValidateFunc:     validateIdentifier,
+				Description:      "The ROLE which owns the database",
+				DiffSuppressFunc: suppressIdentifierCaseDiff,
 			},
 			dbTemplateAttr: {
 				Type:        schema.TypeString,
@@ -102,6 +105,11 @@ func resourcePostgreSQLDatabase() *schema.Resource {
 				Computed:    true,
 				Description: "If true, then this database can be cloned by any user with CREATEDB privileges",
 			},
+			quotedIdentifiersAttr:     quotedIdentifiersSchema(),
+			detectExternalChangesAttr: detectExternalChangesSchema(),
+			waitAttr:                  waitSchema(),
+			oidAttr:                   oidSchema("database"),
+			aclAttr:                   aclSchema("database"),
 		},
 	}
 }
@@ -116,14 +124,21 @@ func resourcePostgreSQLDatabaseCreate(d *schema.ResourceData, meta interface{})
 		return err
 	}
 
-	d.SetId(d.Get(dbNameAttr).(string))
+	dbName := d.Get(dbNameAttr).(string)
+	d.SetId(dbName)
+
+	if err := waitForVisibility(c, d, "", fmt.Sprintf("database %s", dbName),
+		"SELECT EXISTS(SELECT 1 FROM pg_catalog.pg_database WHERE datname = $1)", dbName,
+	); err != nil {
+		return err
+	}
 
 	return resourcePostgreSQLDatabaseReadImpl(d, meta)
 }
 
 func createDatabase(c *Client, d *schema.ResourceData) error {
 	currentUser := c.config.getDatabaseUsername()
-	owner := d.Get(dbOwnerAttr).(string)
+	owner := c.config.roleName(d.Get(dbOwnerAttr).(string))
 
 	db := c.DB()
 
@@ -131,37 +146,37 @@ func createDatabase(c *Client, d *schema.ResourceData) error {
 	if owner != "" {
 		// Needed in order to set the owner of the db if the connection user is not a
 		// superuser
-		ownerGranted, err := grantRoleMembership(db, owner, currentUser)
+		ownerGranted, err := grantRoleMembership(c.ctx, db, owner, currentUser)
 		if err != nil {
 			return err
 		}
 		if ownerGranted {
 			defer func() {
-				err = revokeRoleMembership(db, owner, currentUser)
+				err = revokeRoleMembership(c.ctx, db, owner, currentUser)
 			}()
 		}
 	}
 
 	dbName := d.Get(dbNameAttr).(string)
 	b := bytes.NewBufferString("CREATE DATABASE ")
-	fmt.Fprint(b, pq.QuoteIdentifier(dbName))
+	fmt.Fprint(b, pqQuoteIdentifier(dbName))
 
 	// Handle each option individually and stream results into the query
 	// buffer.
 	switch v, ok := d.GetOk(dbOwnerAttr); {
 	case ok:
-		fmt.Fprint(b, " OWNER ", pq.QuoteIdentifier(v.(string)))
+		fmt.Fprint(b, " OWNER ", pqQuoteIdentifier(c.config.roleName(v.(string))))
 	default:
 		// No owner specified in the config, default to using
 		// the connecting username.
-		fmt.Fprint(b, " OWNER ", pq.QuoteIdentifier(currentUser))
+		fmt.Fprint(b, " OWNER ", pqQuoteIdentifier(currentUser))
 	}
 
 	switch v, ok := d.GetOk(dbTemplateAttr); {
 	case ok && strings.ToUpper(v.(string)) == "DEFAULT":
 		fmt.Fprint(b, " TEMPLATE DEFAULT")
 	case ok:
-		fmt.Fprint(b, " TEMPLATE ", pq.QuoteIdentifier(v.(string)))
+		fmt.Fprint(b, " TEMPLATE ", pqQuoteIdentifier(v.(string)))
 	case v.(string) == "":
 		fmt.Fprint(b, " TEMPLATE template0")
 	}
@@ -197,7 +212,7 @@ func createDatabase(c *Client, d *schema.ResourceData) error {
 	case ok && strings.ToUpper(v.(string)) == "DEFAULT":
 		fmt.Fprint(b, " TABLESPACE DEFAULT")
 	case ok:
-		fmt.Fprint(b, " TABLESPACE ", pq.QuoteIdentifier(v.(string)))
+		fmt.Fprint(b, " TABLESPACE ", pqQuoteIdentifier(v.(string)))
 	}
 
 	if c.featureSupported(featureDBAllowConnections) {
@@ -216,7 +231,7 @@ func createDatabase(c *Client, d *schema.ResourceData) error {
 	}
 
 	sql := b.String()
-	if _, err := c.DB().Exec(sql); err != nil {
+	if _, err := c.DB().ExecContext(c.ctx, sql); err != nil {
 		return errwrap.Wrapf(fmt.Sprintf("Error creating database %q: {{err}}", dbName), err)
 	}
 
@@ -231,19 +246,19 @@ func resourcePostgreSQLDatabaseDelete(d *schema.ResourceData, meta interface{})
 	defer c.catalogLock.Unlock()
 
 	currentUser := c.config.getDatabaseUsername()
-	owner := d.Get(dbOwnerAttr).(string)
+	owner := c.config.roleName(d.Get(dbOwnerAttr).(string))
 
 	var err error
 	if owner != "" {
 		// Needed in order to set the owner of the db if the connection user is not a
 		// superuser
-		ownerGranted, err := grantRoleMembership(c.DB(), owner, currentUser)
+		ownerGranted, err := grantRoleMembership(c.ctx, c.DB(), owner, currentUser)
 		if err != nil {
 			return err
 		}
 		if ownerGranted {
 			defer func() {
-				err = revokeRoleMembership(c.DB(), owner, currentUser)
+				err = revokeRoleMembership(c.ctx, c.DB(), owner, currentUser)
 			}()
 		}
 	}
@@ -263,35 +278,45 @@ func resourcePostgreSQLDatabaseDelete(d *schema.ResourceData, meta interface{})
 		return err
 	}
 
-	sql := fmt.Sprintf("DROP DATABASE %s", pq.QuoteIdentifier(dbName))
-	if _, err := c.DB().Exec(sql); err != nil {
+	// Idle connections to the database would prevent to drop it.
+	closeDBPools(dbName)
+
+	sql := fmt.Sprintf("DROP DATABASE %s", pqQuoteIdentifier(dbName))
+	if _, err := c.DB().ExecContext(c.ctx, sql); err != nil {
 		return errwrap.Wrapf("Error dropping database: {{err}}", err)
 	}
 
+	c.catalogCache.invalidate(catalogCacheKey("database", dbName))
 	d.SetId("")
 
 	// Returning err even if it's nil so defer func can modify it.
 	return err
 }
 
-func resourcePostgreSQLDatabaseExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+func resourcePostgreSQLDatabaseRead(d *schema.ResourceData, meta interface{}) error {
 	c := meta.(*Client)
 	c.catalogLock.RLock()
 	defer c.catalogLock.RUnlock()
 
-	txn, err := startTransaction(c, "")
-	if err != nil {
-		return false, err
-	}
-	defer deferredRollback(txn)
-
-	return dbExists(txn, d.Id())
-}
+	if !detectExternalChanges(d) {
+		exists, err := c.catalogCache.exists(catalogCacheKey("database", d.Id()), func() (bool, error) {
+			txn, err := startTransaction(c, "")
+			if err != nil {
+				return false, err
+			}
+			defer deferredRollback(txn)
 
-func resourcePostgreSQLDatabaseRead(d *schema.ResourceData, meta interface{}) error {
-	c := meta.(*Client)
-	c.catalogLock.RLock()
-	defer c.catalogLock.RUnlock()
+			return dbExists(c.ctx, txn, d.Id())
+		})
+		if err != nil {
+			return err
+		}
+		if !exists {
+			log.Printf("[WARN] PostgreSQL database (%s) not found", d.Id())
+			d.SetId("")
+		}
+		return nil
+	}
 
 	return resourcePostgreSQLDatabaseReadImpl(d, meta)
 }
@@ -299,9 +324,20 @@ func resourcePostgreSQLDatabaseRead(d *schema.ResourceData, meta interface{}) er
 func resourcePostgreSQLDatabaseReadImpl(d *schema.ResourceData, meta interface{}) error {
 	c := meta.(*Client)
 
+	txn, err := startReadTransaction(c, "")
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
 	dbId := d.Id()
 	var dbName, ownerName string
-	err := c.DB().QueryRow("SELECT d.datname, pg_catalog.pg_get_userbyid(d.datdba) from pg_database d WHERE datname=$1", dbId).Scan(&dbName, &ownerName)
+	var dbOID int
+	var dbACLs []string
+	err = txn.QueryRowContext(c.ctx,
+		"SELECT d.datname, d.oid::BIGINT, pg_catalog.pg_get_userbyid(d.datdba), COALESCE(d.datacl, '{}'::aclitem[])::TEXT[] from pg_database d WHERE datname=$1",
+		dbId,
+	).Scan(&dbName, &dbOID, &ownerName, pgArray(&dbACLs))
 	switch {
 	case err == sql.ErrNoRows:
 		log.Printf("[WARN] PostgreSQL database (%q) not found", dbId)
@@ -326,7 +362,7 @@ func resourcePostgreSQLDatabaseReadImpl(d *schema.ResourceData, meta interface{}
 		`FROM pg_catalog.pg_database AS d, pg_catalog.pg_tablespace AS ts ` +
 		`WHERE d.datname = $1 AND d.dattablespace = ts.oid`
 	dbSQL := fmt.Sprintf(dbSQLFmt, strings.Join(columns, ", "))
-	err = c.DB().QueryRow(dbSQL, dbId).
+	err = txn.QueryRowContext(c.ctx, dbSQL, dbId).
 		Scan(
 			&dbEncoding,
 			&dbCollation,
@@ -344,7 +380,9 @@ func resourcePostgreSQLDatabaseReadImpl(d *schema.ResourceData, meta interface{}
 	}
 
 	d.Set(dbNameAttr, dbName)
-	d.Set(dbOwnerAttr, ownerName)
+	d.Set(dbOwnerAttr, c.config.stateRoleName(d.Get(dbOwnerAttr).(string), ownerName))
+	d.Set(oidAttr, dbOID)
+	d.Set(aclAttr, dbACLs)
 	d.Set(dbEncodingAttr, dbEncoding)
 	d.Set(dbCollationAttr, dbCollation)
 	d.Set(dbCTypeAttr, dbCType)
@@ -359,7 +397,7 @@ func resourcePostgreSQLDatabaseReadImpl(d *schema.ResourceData, meta interface{}
 	if c.featureSupported(featureDBAllowConnections) {
 		var dbAllowConns bool
 		dbSQL := fmt.Sprintf(dbSQLFmt, "d.datallowconn")
-		err = c.DB().QueryRow(dbSQL, dbId).Scan(&dbAllowConns)
+		err = txn.QueryRowContext(c.ctx, dbSQL, dbId).Scan(&dbAllowConns)
 		if err != nil {
 			return errwrap.Wrapf("Error reading ALLOW_CONNECTIONS property for DATABASE: {{err}}", err)
 		}
@@ -370,7 +408,7 @@ func resourcePostgreSQLDatabaseReadImpl(d *schema.ResourceData, meta interface{}
 	if c.featureSupported(featureDBIsTemplate) {
 		var dbIsTemplate bool
 		dbSQL := fmt.Sprintf(dbSQLFmt, "d.datistemplate")
-		err = c.DB().QueryRow(dbSQL, dbId).Scan(&dbIsTemplate)
+		err = txn.QueryRowContext(c.ctx, dbSQL, dbId).Scan(&dbIsTemplate)
 		if err != nil {
 			return errwrap.Wrapf("Error reading IS_TEMPLATE property for DATABASE: {{err}}", err)
 		}
@@ -386,7 +424,12 @@ func resourcePostgreSQLDatabaseUpdate(d *schema.ResourceData, meta interface{})
 	c.catalogLock.Lock()
 	defer c.catalogLock.Unlock()
 
-	if err := setDBName(c.DB(), d); err != nil {
+	if d.HasChange(dbNameAttr) {
+		oldName, _ := d.GetChange(dbNameAttr)
+		defer c.catalogCache.invalidate(catalogCacheKey("database", oldName.(string)))
+	}
+
+	if err := setDBName(c.ctx, c.DB(), d); err != nil {
 		return err
 	}
 
@@ -394,11 +437,11 @@ func resourcePostgreSQLDatabaseUpdate(d *schema.ResourceData, meta interface{})
 		return err
 	}
 
-	if err := setDBTablespace(c.DB(), d); err != nil {
+	if err := setDBTablespace(c, d); err != nil {
 		return err
 	}
 
-	if err := setDBConnLimit(c.DB(), d); err != nil {
+	if err := setDBConnLimit(c.ctx, c.DB(), d); err != nil {
 		return err
 	}
 
@@ -415,7 +458,7 @@ func resourcePostgreSQLDatabaseUpdate(d *schema.ResourceData, meta interface{})
 	return resourcePostgreSQLDatabaseReadImpl(d, meta)
 }
 
-func setDBName(db *sql.DB, d *schema.ResourceData) error {
+func setDBName(ctx context.Context, db *sql.DB, d *schema.ResourceData) error {
 	if !d.HasChange(dbNameAttr) {
 		return nil
 	}
@@ -427,8 +470,11 @@ func setDBName(db *sql.DB, d *schema.ResourceData) error {
 		return errors.New("Error setting database name to an empty string")
 	}
 
-	sql := fmt.Sprintf("ALTER DATABASE %s RENAME TO %s", pq.QuoteIdentifier(o), pq.QuoteIdentifier(n))
-	if _, err := db.Exec(sql); err != nil {
+	// Idle connections to the database would prevent to rename it.
+	closeDBPools(o)
+
+	sql := fmt.Sprintf("ALTER DATABASE %s RENAME TO %s", pqQuoteIdentifier(o), pqQuoteIdentifier(n))
+	if _, err := db.ExecContext(ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating database name: {{err}}", err)
 	}
 	d.SetId(n)
@@ -441,7 +487,7 @@ func setDBOwner(c *Client, d *schema.ResourceData) error {
 		return nil
 	}
 
-	owner := d.Get(dbOwnerAttr).(string)
+	owner := c.config.roleName(d.Get(dbOwnerAttr).(string))
 	if owner == "" {
 		return nil
 	}
@@ -450,55 +496,62 @@ func setDBOwner(c *Client, d *schema.ResourceData) error {
 	db := c.DB()
 
 	//needed in order to set the owner of the db if the connection user is not a superuser
-	ownerGranted, err := grantRoleMembership(db, owner, currentUser)
+	ownerGranted, err := grantRoleMembership(c.ctx, db, owner, currentUser)
 	if err != nil {
 		return err
 	}
 	if ownerGranted {
 		defer func() {
-			err = revokeRoleMembership(db, owner, currentUser)
+			err = revokeRoleMembership(c.ctx, db, owner, currentUser)
 		}()
 	}
 
 	dbName := d.Get(dbNameAttr).(string)
-	sql := fmt.Sprintf("ALTER DATABASE %s OWNER TO %s", pq.QuoteIdentifier(dbName), pq.QuoteIdentifier(owner))
-	if _, err := db.Exec(sql); err != nil {
+	sql := fmt.Sprintf("ALTER DATABASE %s OWNER TO %s", pqQuoteIdentifier(dbName), pqQuoteIdentifier(owner))
+	if _, err := db.ExecContext(c.ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating database OWNER: {{err}}", err)
 	}
 
 	return err
 }
 
-func setDBTablespace(db *sql.DB, d *schema.ResourceData) error {
+func setDBTablespace(c *Client, d *schema.ResourceData) error {
 	if !d.HasChange(dbTablespaceAttr) {
 		return nil
 	}
 
+	if !c.featureSupported(featureDBSetTablespace) {
+		return fmt.Errorf(
+			"PostgreSQL client is talking with a server (%s %q) that does not support changing the tablespace of a database",
+			c.flavor, c.version.String(),
+		)
+	}
+
 	tbspName := d.Get(dbTablespaceAttr).(string)
 	dbName := d.Get(dbNameAttr).(string)
 	var sql string
 	if tbspName == "" || strings.ToUpper(tbspName) == "DEFAULT" {
-		sql = fmt.Sprintf("ALTER DATABASE %s RESET TABLESPACE", pq.QuoteIdentifier(dbName))
+		sql = fmt.Sprintf("ALTER DATABASE %s RESET TABLESPACE", pqQuoteIdentifier(dbName))
 	} else {
-		sql = fmt.Sprintf("ALTER DATABASE %s SET TABLESPACE %s", pq.QuoteIdentifier(dbName), pq.QuoteIdentifier(tbspName))
+		sql = fmt.Sprintf("ALTER DATABASE %s SET TABLESPACE %s", pqQuoteIdentifier(dbName), pqQuoteIdentifier(tbspName))
 	}
 
-	if _, err := db.Exec(sql); err != nil {
+	if _, err := c.DB().ExecContext(c.ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating database TABLESPACE: {{err}}", err)
 	}
 
 	return nil
 }
 
-func setDBConnLimit(db *sql.DB, d *schema.ResourceData) error {
+func setDBConnLimit(ctx context.Context, db *sql.DB, d *schema.ResourceData) error {
 	if !d.HasChange(dbConnLimitAttr) {
 		return nil
 	}
 
 	connLimit := d.Get(dbConnLimitAttr).(int)
 	dbName := d.Get(dbNameAttr).(string)
-	sql := fmt.Sprintf("ALTER DATABASE %s CONNECTION LIMIT = %d", pq.QuoteIdentifier(dbName), connLimit)
-	if _, err := db.Exec(sql); err != nil {
+	sql := fmt.Sprintf("ALTER DATABASE %s CONNECTION LIMIT = %d", pqQuoteIdentifier(dbName), connLimit)
+	if _, err := db.ExecContext(ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating database CONNECTION LIMIT: {{err}}", err)
 	}
 
@@ -511,13 +564,16 @@ func setDBAllowConns(c *Client, d *schema.ResourceData) error {
 	}
 
 	if !c.featureSupported(featureDBAllowConnections) {
-		return fmt.Errorf("PostgreSQL client is talking with a server (%q) that does not support database ALLOW_CONNECTIONS", c.version.String())
+		// ALLOW_CONNECTIONS cannot be read either, the configured value is kept in the state.
+		return c.tolerateUnsupportedFeature(
+			fmt.Errorf("PostgreSQL client is talking with a server (%q) that does not support database ALLOW_CONNECTIONS", c.version.String()),
+		)
 	}
 
 	allowConns := d.Get(dbAllowConnsAttr).(bool)
 	dbName := d.Get(dbNameAttr).(string)
-	sql := fmt.Sprintf("ALTER DATABASE %s ALLOW_CONNECTIONS %t", pq.QuoteIdentifier(dbName), allowConns)
-	if _, err := c.DB().Exec(sql); err != nil {
+	sql := fmt.Sprintf("ALTER DATABASE %s ALLOW_CONNECTIONS %t", pqQuoteIdentifier(dbName), allowConns)
+	if _, err := c.DB().ExecContext(c.ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating database ALLOW_CONNECTIONS: {{err}}", err)
 	}
 
@@ -529,6 +585,13 @@ func setDBIsTemplate(c *Client, d *schema.ResourceData) error {
 		return nil
 	}
 
+	if !c.featureSupported(featureDBIsTemplate) {
+		// IS_TEMPLATE cannot be read either, the configured value is kept in the state.
+		return c.tolerateUnsupportedFeature(
+			fmt.Errorf("PostgreSQL client is talking with a server (%q) that does not support database IS_TEMPLATE", c.version.String()),
+		)
+	}
+
 	if err := doSetDBIsTemplate(c, d.Get(dbNameAttr).(string), d.Get(dbIsTemplateAttr).(bool)); err != nil {
 		return errwrap.Wrapf("Error updating database IS_TEMPLATE: {{err}}", err)
 	}
@@ -541,8 +604,8 @@ func doSetDBIsTemplate(c *Client, dbName string, isTemplate bool) error {
 		return fmt.Errorf("PostgreSQL client is talking with a server (%q) that does not support database IS_TEMPLATE", c.version.String())
 	}
 
-	sql := fmt.Sprintf("ALTER DATABASE %s IS_TEMPLATE %t", pq.QuoteIdentifier(dbName), isTemplate)
-	if _, err := c.DB().Exec(sql); err != nil {
+	sql := fmt.Sprintf("ALTER DATABASE %s IS_TEMPLATE %t", pqQuoteIdentifier(dbName), isTemplate)
+	if _, err := c.DB().ExecContext(c.ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating database IS_TEMPLATE: {{err}}", err)
 	}
 
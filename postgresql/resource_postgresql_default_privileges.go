@@ -1,50 +1,87 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/helper/validation"
-
-	// Use Postgres as SQL driver
-	"github.com/lib/pq"
 )
 
+// defaultACLObjectTypes maps the object types to the object type expected
+// by acldefault() (which differs from defaclobjtype for sequences).
+var defaultACLObjectTypes = map[string]string{
+	"table":    "r",
+	"sequence": "s",
+	"function": "f",
+	"type":     "T",
+	"schema":   "n",
+}
+
 func resourcePostgreSQLDefaultPrivileges() *schema.Resource {
 	return &schema.Resource{
-		Create: resourcePostgreSQLDefaultPrivilegesCreate,
-		Update: resourcePostgreSQLDefaultPrivilegesCreate,
+		Create: retryOnConcurrentUpdates(resourcePostgreSQLDefaultPrivilegesCreate),
+		Update: retryOnConcurrentUpdates(resourcePostgreSQLDefaultPrivilegesCreate),
 		Read:   resourcePostgreSQLDefaultPrivilegesRead,
-		Delete: resourcePostgreSQLDefaultPrivilegesDelete,
+		Delete: retryOnConcurrentUpdates(resourcePostgreSQLDefaultPrivilegesDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLDefaultPrivilegesImport,
+		},
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourcePostgreSQLDefaultPrivilegesV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourcePostgreSQLDefaultPrivilegesStateUpgradeV0,
+			},
+		},
 
 		Schema: map[string]*schema.Schema{
 			"role": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The name of the role to which grant default privileges on",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateFunc:     validateIdentifier,
+				Description:      "The name of the role to which grant default privileges on (use `public` for PUBLIC)",
+				DiffSuppressFunc: suppressIdentifierCaseDiff,
 			},
 			"database": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The database to grant default privileges for this role",
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database to grant default privileges for this role",
 			},
 			"owner": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "Role for which apply default privileges (You can change default privileges only for objects that will be created by yourself or by roles that you are a member of)",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				ConflictsWith:    []string{"owners"},
+				ValidateFunc:     validateIdentifier,
+				Description:      "Role for which apply default privileges (You can change default privileges only for objects that will be created by yourself or by roles that you are a member of)",
+				DiffSuppressFunc: suppressIdentifierCaseDiff,
+			},
+			"owners": &schema.Schema{
+				Type:          schema.TypeSet,
+				Optional:      true,
+				ForceNew:      true,
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				Set:           schema.HashString,
+				ConflictsWith: []string{"owner"},
+				Description:   "Roles for which apply default privileges (instead of owner)",
 			},
 			"schema": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The database schema to set default privileges for this role",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				ValidateFunc:     validateIdentifier,
+				Description:      "The database schema to set default privileges for this role (database-wide if not specified)",
+				DiffSuppressFunc: suppressIdentifierCaseDiff,
 			},
 			"object_type": {
 				Type:     schema.TypeString,
@@ -53,8 +90,11 @@ func resourcePostgreSQLDefaultPrivileges() *schema.Resource {
 				ValidateFunc: validation.StringInSlice([]string{
 					"table",
 					"sequence",
+					"function",
+					"type",
+					"schema",
 				}, false),
-				Description: "The PostgreSQL object type to set the default privileges on (one of: table, sequence)",
+				Description: "The PostgreSQL object type to set the default privileges on (one of: table, sequence, function, type, schema)",
 			},
 			"privileges": &schema.Schema{
 				Type:        schema.TypeSet,
@@ -64,6 +104,23 @@ func resourcePostgreSQLDefaultPrivileges() *schema.Resource {
 				MinItems:    1,
 				Description: "The list of privileges to apply as default privileges",
 			},
+			"with_grant_option": {
+				Type:          schema.TypeBool,
+				Optional:      true,
+				Default:       false,
+				ConflictsWith: []string{"revoke"},
+				Description:   "Permit the grant recipient to grant it to others",
+			},
+			"revoke": {
+				Type:          schema.TypeBool,
+				Optional:      true,
+				Default:       false,
+				ForceNew:      true,
+				ConflictsWith: []string{"with_grant_option"},
+				Description:   "Revoke the privileges from the built-in default privileges instead of granting them (e.g.: EXECUTE on functions for PUBLIC)",
+			},
+			quotedIdentifiersAttr:     quotedIdentifiersSchema(),
+			detectExternalChangesAttr: detectExternalChangesSchema(),
 		},
 	}
 }
@@ -71,10 +128,13 @@ func resourcePostgreSQLDefaultPrivileges() *schema.Resource {
 func resourcePostgreSQLDefaultPrivilegesRead(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*Client)
 
-	client.catalogLock.RLock()
-	defer client.catalogLock.RUnlock()
+	if err := checkDefaultPrivilegesObjectTypeSupported(client, d); err != nil {
+		return err
+	}
+
+	defer client.rLockDatabase(d.Get("database").(string))()
 
-	exists, err := checkRoleDBSchemaExists(client, d)
+	exists, err := checkRoleDBSchemaExists(client, d, []string{d.Get("role").(string)})
 	if err != nil {
 		return err
 	}
@@ -83,13 +143,53 @@ func resourcePostgreSQLDefaultPrivilegesRead(d *schema.ResourceData, meta interf
 		return nil
 	}
 
-	txn, err := startTransaction(client, d.Get("database").(string))
+	if !detectExternalChanges(d) {
+		return nil
+	}
+
+	txn, err := startReadTransaction(client, d.Get("database").(string))
 	if err != nil {
 		return err
 	}
 	defer deferredRollback(txn)
 
-	return readRoleDefaultPrivileges(txn, d)
+	return readRoleDefaultPrivileges(client.ctx, txn, d)
+}
+
+// resourcePostgreSQLDefaultPrivilegesImport imports default privileges from an ID formatted as
+// role/database/schema/owner/object_type (schema can be empty and owners comma separated).
+func resourcePostgreSQLDefaultPrivilegesImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts := strings.Split(d.Id(), "/")
+	if len(parts) != 5 {
+		return nil, fmt.Errorf(
+			"invalid import ID %q, expected format: role/database/schema/owner/object_type", d.Id(),
+		)
+	}
+
+	if _, ok := objectTypes[parts[4]]; !ok {
+		return nil, fmt.Errorf("unsupported object type %q in import ID %q", parts[4], d.Id())
+	}
+
+	d.Set("role", parts[0])
+	d.Set("database", parts[1])
+	d.Set("schema", parts[2])
+	if owners := strings.Split(parts[3], ","); len(owners) > 1 {
+		ownersList := []interface{}{}
+		for _, owner := range owners {
+			ownersList = append(ownersList, owner)
+		}
+		d.Set("owners", schema.NewSet(schema.HashString, ownersList))
+	} else {
+		d.Set("owner", parts[3])
+	}
+	d.Set("object_type", parts[4])
+	d.Set("revoke", false)
+
+	// The privileges are read by the refresh which follows the import
+	d.Set("privileges", schema.NewSet(schema.HashString, []interface{}{}))
+	d.SetId(generateDefaultPrivilegesID(d))
+
+	return []*schema.ResourceData{d}, nil
 }
 
 func resourcePostgreSQLDefaultPrivilegesCreate(d *schema.ResourceData, meta interface{}) error {
@@ -101,8 +201,30 @@ func resourcePostgreSQLDefaultPrivilegesCreate(d *schema.ResourceData, meta inte
 
 	client := meta.(*Client)
 
-	client.catalogLock.Lock()
-	defer client.catalogLock.Unlock()
+	if err := checkDefaultPrivilegesObjectTypeSupported(client, d); err != nil {
+		return err
+	}
+
+	if len(defaultPrivilegesOwners(d)) == 0 {
+		return fmt.Errorf("one of owner or owners must be specified")
+	}
+
+	// The default privileges of the objects created by Supabase cannot be altered.
+	if err := checkSupabaseGrantees(client, append(defaultPrivilegesOwners(d), d.Get("role").(string))); err != nil {
+		return err
+	}
+
+	// Default privileges on schemas cannot be set in a schema
+	if d.Get("object_type").(string) == "schema" && d.Get("schema").(string) != "" {
+		return fmt.Errorf("cannot specify schema when object_type is schema")
+	}
+
+	// Built-in default privileges are database-wide, they cannot be revoked in a schema
+	if d.Get("revoke").(bool) && d.Get("schema").(string) != "" {
+		return fmt.Errorf("cannot specify schema when revoke is true")
+	}
+
+	defer client.lockDatabase(d.Get("database").(string))()
 
 	txn, err := startTransaction(client, database)
 	if err != nil {
@@ -110,17 +232,23 @@ func resourcePostgreSQLDefaultPrivilegesCreate(d *schema.ResourceData, meta inte
 	}
 	defer deferredRollback(txn)
 
-	// Revoke all privileges before granting otherwise reducing privileges will not work.
-	// We just have to revoke them in the same transaction so role will not lost his privileges between revoke and grant.
-	if err = revokeRoleDefaultPrivileges(txn, d); err != nil {
-		return err
+	var queries []string
+	if d.Get("revoke").(bool) {
+		queries = revokeBuiltinDefaultPrivileges(d)
+	} else {
+		// Revoke all privileges before granting otherwise reducing privileges will not work.
+		// We just have to revoke them in the same transaction so role will not lost his privileges between revoke and grant.
+		queries = []string{
+			revokeRoleDefaultPrivileges(d),
+			grantRoleDefaultPrivileges(d),
+		}
 	}
 
-	if err = grantRoleDefaultPrivileges(txn, d); err != nil {
-		return err
+	if err = execQueries(client.ctx, txn, queries); err != nil {
+		return errwrap.Wrapf("could not alter default privileges: {{err}}", err)
 	}
 
-	if err := txn.Commit(); err != nil {
+	if err := commitTransaction(client, txn); err != nil {
 		return err
 	}
 
@@ -132,14 +260,13 @@ func resourcePostgreSQLDefaultPrivilegesCreate(d *schema.ResourceData, meta inte
 	}
 	defer deferredRollback(txn)
 
-	return readRoleDefaultPrivileges(txn, d)
+	return readRoleDefaultPrivileges(client.ctx, txn, d)
 }
 
 func resourcePostgreSQLDefaultPrivilegesDelete(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*Client)
 
-	client.catalogLock.Lock()
-	defer client.catalogLock.Unlock()
+	defer client.lockDatabase(d.Get("database").(string))()
 
 	txn, err := startTransaction(client, d.Get("database").(string))
 	if err != nil {
@@ -147,54 +274,152 @@ func resourcePostgreSQLDefaultPrivilegesDelete(d *schema.ResourceData, meta inte
 	}
 	defer deferredRollback(txn)
 
-	revokeRoleDefaultPrivileges(txn, d)
-	if err := txn.Commit(); err != nil {
+	if d.Get("revoke").(bool) {
+		// Restore the built-in default privileges
+		query := alterDefaultPrivilegesQuery(d, "GRANT", setToPgPrivileges(d.Get("privileges").(*schema.Set)))
+		if _, err := txn.ExecContext(client.ctx, query); err != nil {
+			return errwrap.Wrapf("could not restore default privileges: {{err}}", err)
+		}
+	} else {
+		if _, err := txn.ExecContext(client.ctx, revokeRoleDefaultPrivileges(d)); err != nil {
+			return errwrap.Wrapf("could not revoke default privileges: {{err}}", err)
+		}
+	}
+
+	if err := commitTransaction(client, txn); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func readRoleDefaultPrivileges(txn *sql.Tx, d *schema.ResourceData) error {
+func readRoleDefaultPrivileges(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) error {
+	if d.Get("revoke").(bool) {
+		return readRevokedDefaultPrivileges(ctx, txn, d)
+	}
+
 	role := d.Get("role").(string)
-	owner := d.Get("owner").(string)
 	pgSchema := d.Get("schema").(string)
 	objectType := d.Get("object_type").(string)
 
 	// This query aggregates the list of default privileges type (prtype)
+	// and the grantable ones
 	// for the role (grantee), owner (grantor), schema (namespace name)
 	// and the specified object type (defaclobjtype).
-	query := `SELECT array_agg(prtype) FROM (
+	// Database-wide default privileges have no namespace (defaclnamespace = 0).
+	query := `SELECT array_agg(prtype), array_remove(array_agg(CASE WHEN grantable THEN prtype END), NULL) FROM (
 		SELECT defaclnamespace, (aclexplode(defaclacl)).* FROM pg_default_acl
 		WHERE defaclobjtype = $3
 	) AS t (namespace, grantor_oid, grantee_oid, prtype, grantable)
 
-	JOIN pg_namespace ON pg_namespace.oid = namespace
-	WHERE pg_get_userbyid(grantee_oid) = $1 AND nspname = $2 AND pg_get_userbyid(grantor_oid) = $4;
+	LEFT JOIN pg_namespace ON pg_namespace.oid = namespace
+	WHERE grantee_oid = CASE
+	    WHEN lower($1::text) = 'public' THEN 0::oid
+	    ELSE (SELECT oid FROM pg_roles WHERE rolname = $1::text)
+	END
+	AND COALESCE(nspname, '') = $2 AND pg_get_userbyid(grantor_oid) = $4;
 `
-	var privileges pq.ByteaArray
 
-	if err := txn.QueryRow(
-		query, role, pgSchema, objectTypes[objectType], owner,
-	).Scan(&privileges); err != nil {
-		return errwrap.Wrapf("could not read default privileges: {{err}}", err)
+	var privilegesSet *schema.Set
+	withGrantOption := true
+	drifted := false
+
+	for _, owner := range defaultPrivilegesOwners(d) {
+		var privileges, grantablePrivileges []string
+
+		if err := txn.QueryRowContext(ctx,
+			query, role, pgSchema, objectTypes[objectType], owner,
+		).Scan(pgArray(&privileges), pgArray(&grantablePrivileges)); err != nil {
+			return errwrap.Wrapf("could not read default privileges: {{err}}", err)
+		}
+
+		ownerPrivileges := pgArrayToSet(privileges)
+		withGrantOption = withGrantOption && pgArrayToSet(grantablePrivileges).Equal(ownerPrivileges)
+
+		if privilegesSet == nil {
+			privilegesSet = ownerPrivileges
+		} else if !ownerPrivileges.Equal(privilegesSet) {
+			log.Printf(
+				"[WARN] default privileges of owner %s (%v) for role %s differ from the other owners (%v)",
+				owner, ownerPrivileges.List(), role, privilegesSet.List(),
+			)
+			drifted = true
+			privilegesSet = privilegesSet.Union(ownerPrivileges)
+		}
 	}
 
 	// We consider no privileges as "not exists"
-	if len(privileges) == 0 {
+	if privilegesSet == nil || privilegesSet.Len() == 0 {
 		log.Printf("[DEBUG] no default privileges for role %s in schema %s", role, pgSchema)
 		d.SetId("")
 		return nil
 	}
 
-	privilegesSet := pgArrayToSet(privileges)
+	// If the owners have different default privileges,
+	// we return an empty privileges to force an update.
+	if drifted {
+		privilegesSet = schema.NewSet(schema.HashString, []interface{}{})
+	}
+
 	d.Set("privileges", privilegesSet)
+	d.Set("with_grant_option", withGrantOption)
 	d.SetId(generateDefaultPrivilegesID(d))
 
 	return nil
 }
 
-func grantRoleDefaultPrivileges(txn *sql.Tx, d *schema.ResourceData) error {
+// readRevokedDefaultPrivileges checks that the privileges have been revoked from
+// the default privileges of the owners. The built-in default privileges (acldefault)
+// are used if an owner has no default privileges.
+func readRevokedDefaultPrivileges(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) error {
+	role := d.Get("role").(string)
+	objectType := d.Get("object_type").(string)
+	revokedPrivileges := d.Get("privileges").(*schema.Set)
+
+	query := `SELECT array_agg(prtype) FROM (
+		SELECT (aclexplode(COALESCE(
+			(SELECT defaclacl FROM pg_default_acl
+			WHERE defaclrole = owner.oid AND defaclnamespace = 0 AND defaclobjtype = $2),
+			acldefault($3::"char", owner.oid)
+		))).*
+		FROM (SELECT oid FROM pg_roles WHERE rolname = $4) AS owner
+	) AS t (grantor_oid, grantee_oid, prtype, grantable)
+	WHERE grantee_oid = CASE
+	    WHEN lower($1::text) = 'public' THEN 0::oid
+	    ELSE (SELECT oid FROM pg_roles WHERE rolname = $1::text)
+	END;
+`
+
+	drifted := false
+	for _, owner := range defaultPrivilegesOwners(d) {
+		var privileges []string
+
+		if err := txn.QueryRowContext(ctx,
+			query, role, objectTypes[objectType], defaultACLObjectTypes[objectType], owner,
+		).Scan(pgArray(&privileges)); err != nil {
+			return errwrap.Wrapf("could not read default privileges: {{err}}", err)
+		}
+
+		if remaining := pgArrayToSet(privileges).Intersection(revokedPrivileges); remaining.Len() > 0 {
+			log.Printf(
+				"[WARN] default privileges %v on %ss of owner %s are still granted to role %s",
+				remaining.List(), objectType, owner, role,
+			)
+			drifted = true
+		}
+	}
+
+	if drifted {
+		// If some of the privileges are still granted by default,
+		// we return an empty privileges to force an update.
+		d.Set("privileges", schema.NewSet(schema.HashString, []interface{}{}))
+	}
+
+	d.SetId(generateDefaultPrivilegesID(d))
+	return nil
+}
+
+func grantRoleDefaultPrivileges(d *schema.ResourceData) string {
 	role := d.Get("role").(string)
 	pgSchema := d.Get("schema").(string)
 
@@ -209,40 +434,120 @@ func grantRoleDefaultPrivileges(txn *sql.Tx, d *schema.ResourceData) error {
 	// In that case, the only solution would be to have the PostgreSQL user used by Terraform
 	// to be also part of the database owner role.
 
-	query := fmt.Sprintf("ALTER DEFAULT PRIVILEGES FOR ROLE %s IN SCHEMA %s GRANT %s ON %sS TO %s",
-		pq.QuoteIdentifier(d.Get("owner").(string)),
-		pq.QuoteIdentifier(pgSchema),
+	query := fmt.Sprintf("ALTER DEFAULT PRIVILEGES FOR ROLE %s%s GRANT %s ON %sS TO %s",
+		quoteGrantees(defaultPrivilegesOwners(d)),
+		defaultPrivilegesSchemaClause(pgSchema),
 		strings.Join(privileges, ","),
 		strings.ToUpper(d.Get("object_type").(string)),
-		pq.QuoteIdentifier(role),
+		pqQuoteRoleName(role),
 	)
 
-	_, err := txn.Exec(
-		query,
+	if d.Get("with_grant_option").(bool) {
+		query += " WITH GRANT OPTION"
+	}
+
+	return query
+}
+
+func revokeRoleDefaultPrivileges(d *schema.ResourceData) string {
+	return fmt.Sprintf(
+		"ALTER DEFAULT PRIVILEGES FOR ROLE %s%s REVOKE ALL ON %sS FROM %s",
+		quoteGrantees(defaultPrivilegesOwners(d)),
+		defaultPrivilegesSchemaClause(d.Get("schema").(string)),
+		strings.ToUpper(d.Get("object_type").(string)),
+		pqQuoteRoleName(d.Get("role").(string)),
 	)
-	if err != nil {
-		return errwrap.Wrapf("could not alter default privileges: {{err}}", err)
+}
+
+// revokeBuiltinDefaultPrivileges returns the statements revoking the privileges from the default privileges
+// of the owner and restoring the ones which have been removed from the resource.
+func revokeBuiltinDefaultPrivileges(d *schema.ResourceData) []string {
+	oldRaw, newRaw := d.GetChange("privileges")
+	newPrivileges := newRaw.(*schema.Set)
+	queries := []string{}
+
+	if restored := setToPgPrivileges(oldRaw.(*schema.Set).Difference(newPrivileges)); len(restored) > 0 {
+		queries = append(queries, alterDefaultPrivilegesQuery(d, "GRANT", restored))
 	}
 
-	return nil
+	return append(queries, alterDefaultPrivilegesQuery(d, "REVOKE", setToPgPrivileges(newPrivileges)))
 }
 
-func revokeRoleDefaultPrivileges(txn *sql.Tx, d *schema.ResourceData) error {
-	query := fmt.Sprintf(
-		"ALTER DEFAULT PRIVILEGES FOR ROLE %s IN SCHEMA %s REVOKE ALL ON %sS FROM %s",
-		pq.QuoteIdentifier(d.Get("owner").(string)),
-		pq.QuoteIdentifier(d.Get("schema").(string)),
+// alterDefaultPrivilegesQuery returns the database-wide ALTER DEFAULT PRIVILEGES statement
+// which grants or revokes the privileges to / from the role.
+func alterDefaultPrivilegesQuery(d *schema.ResourceData, action string, privileges []string) string {
+	direction := "TO"
+	if action == "REVOKE" {
+		direction = "FROM"
+	}
+
+	return fmt.Sprintf(
+		"ALTER DEFAULT PRIVILEGES FOR ROLE %s %s %s ON %sS %s %s",
+		quoteGrantees(defaultPrivilegesOwners(d)),
+		action,
+		strings.Join(privileges, ","),
 		strings.ToUpper(d.Get("object_type").(string)),
-		pq.QuoteIdentifier(d.Get("role").(string)),
+		direction,
+		pqQuoteRoleName(d.Get("role").(string)),
 	)
+}
+
+// checkDefaultPrivilegesObjectTypeSupported checks that default privileges can be set
+// on the object type (with these privileges) with the version of the connected server.
+func checkDefaultPrivilegesObjectTypeSupported(client *Client, d *schema.ResourceData) error {
+	if err := checkPrivilegesSupported(client, d.Get("privileges").(*schema.Set)); err != nil {
+		return err
+	}
+
+	objectType := d.Get("object_type").(string)
+
+	var feature featureName
+	switch objectType {
+	case "type":
+		feature = featureDefaultPrivilegesTypes
+	case "schema":
+		feature = featureDefaultPrivilegesSchemas
+	default:
+		return nil
+	}
+
+	if !client.featureSupported(feature) {
+		return fmt.Errorf(
+			"default privileges on %ss are not supported for this Postgres version (%s)",
+			objectType, client.version,
+		)
+	}
+	return nil
+}
 
-	_, err := txn.Exec(query)
-	return err
+// defaultPrivilegesSchemaClause returns the IN SCHEMA clause of ALTER DEFAULT PRIVILEGES
+// (empty for database-wide default privileges).
+func defaultPrivilegesSchemaClause(pgSchema string) string {
+	if pgSchema == "" {
+		return ""
+	}
+	return " IN SCHEMA " + pqQuoteIdentifier(pgSchema)
+}
+
+// defaultPrivilegesOwners returns the sorted list of roles specified in the `owner` or `owners` attribute.
+func defaultPrivilegesOwners(d *schema.ResourceData) []string {
+	if owner := d.Get("owner").(string); owner != "" {
+		return []string{owner}
+	}
+
+	owners := []string{}
+	for _, owner := range d.Get("owners").(*schema.Set).List() {
+		owners = append(owners, owner.(string))
+	}
+	sort.Strings(owners)
+	return owners
 }
 
+// generateDefaultPrivilegesID returns the ID of the default privileges, which has the same format
+// as the one used for import: role/database/schema/owner/object_type
 func generateDefaultPrivilegesID(d *schema.ResourceData) string {
 	return strings.Join([]string{
 		d.Get("role").(string), d.Get("database").(string), d.Get("schema").(string),
-		d.Get("owner").(string), d.Get("object_type").(string),
-	}, "_")
+		strings.Join(defaultPrivilegesOwners(d), ","), d.Get("object_type").(string),
+	}, "/")
 }
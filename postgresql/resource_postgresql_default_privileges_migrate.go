@@ -0,0 +1,61 @@
+package postgresql
+
+import (
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// resourcePostgreSQLDefaultPrivilegesV0 is the schema of postgresql_default_privileges
+// in the version 0.4.0, used to decode the states written by this version.
+func resourcePostgreSQLDefaultPrivilegesV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"role": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"database": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"owner": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"schema": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"object_type": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"privileges": {
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+		},
+	}
+}
+
+// resourcePostgreSQLDefaultPrivilegesStateUpgradeV0 converts the ID of the default privileges
+// (role_database_schema_owner_object_type) to the /-separated format of the import IDs
+// and sets the default values of the attributes added since.
+func resourcePostgreSQLDefaultPrivilegesStateUpgradeV0(rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	rawState["id"] = strings.Join([]string{
+		stateString(rawState, "role"), stateString(rawState, "database"), stateString(rawState, "schema"),
+		stateString(rawState, "owner"), stateString(rawState, "object_type"),
+	}, "/")
+	rawState["with_grant_option"] = false
+	rawState["revoke"] = false
+
+	return rawState, nil
+}
@@ -1,6 +1,7 @@
 package postgresql
 
 import (
+	"database/sql"
 	"fmt"
 	"testing"
 
@@ -56,6 +57,229 @@ func TestAccPostgresqlDefaultPrivileges(t *testing.T) {
 					resource.TestCheckResourceAttr("postgresql_default_privileges.test_ro", "privileges.3138006342", "SELECT"),
 				),
 			},
+			{
+				ResourceName:      "postgresql_default_privileges.test_ro",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlDefaultPrivilegesNoSchema(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, true)
+	defer teardown()
+
+	config := getTestConfig(t)
+	dbName, roleName := getTestDBNames(dbSuffix)
+
+	// No schema: default privileges are applied database-wide
+	var testDPSelect = fmt.Sprintf(`
+	resource "postgresql_default_privileges" "test_ro" {
+		database    = "%s"
+		owner       = "%s"
+		role        = "%s"
+		object_type = "table"
+		privileges  = ["SELECT"]
+	}
+	`, dbName, config.Username, roleName)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featurePrivileges)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testDPSelect,
+				Check: resource.ComposeTestCheckFunc(
+					func(*terraform.State) error {
+						tables := []string{"test_schema.test_table"}
+						dropFunc := createTestTables(t, dbSuffix, tables)
+						defer dropFunc()
+
+						return testCheckTablesPrivileges(t, dbSuffix, tables, []string{"SELECT"})
+					},
+					resource.TestCheckResourceAttr("postgresql_default_privileges.test_ro", "schema", ""),
+					resource.TestCheckResourceAttr("postgresql_default_privileges.test_ro", "privileges.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlDefaultPrivilegesTypesSchemas(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, true)
+	defer teardown()
+
+	config := getTestConfig(t)
+	dbName, roleName := getTestDBNames(dbSuffix)
+
+	var testDPTypesSchemas = fmt.Sprintf(`
+	resource "postgresql_default_privileges" "test_types" {
+		database    = "%[1]s"
+		owner       = "%[2]s"
+		role        = "%[3]s"
+		schema      = "test_schema"
+		object_type = "type"
+		privileges  = ["USAGE"]
+	}
+
+	resource "postgresql_default_privileges" "test_schemas" {
+		database    = "%[1]s"
+		owner       = "%[2]s"
+		role        = "%[3]s"
+		object_type = "schema"
+		privileges  = ["USAGE"]
+	}
+	`, dbName, config.Username, roleName)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureDefaultPrivilegesSchemas)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testDPTypesSchemas,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_default_privileges.test_types", "object_type", "type"),
+					resource.TestCheckResourceAttr("postgresql_default_privileges.test_types", "privileges.#", "1"),
+					resource.TestCheckResourceAttr("postgresql_default_privileges.test_schemas", "object_type", "schema"),
+					resource.TestCheckResourceAttr("postgresql_default_privileges.test_schemas", "privileges.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlDefaultPrivilegesRevoke(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, true)
+	defer teardown()
+
+	config := getTestConfig(t)
+	dbName, roleName := getTestDBNames(dbSuffix)
+
+	// Revoke the built-in EXECUTE privilege of PUBLIC on the functions created by PGUSER
+	var testDPRevoke = fmt.Sprintf(`
+	resource "postgresql_default_privileges" "test_revoke" {
+		database    = "%s"
+		owner       = "%s"
+		role        = "public"
+		object_type = "function"
+		privileges  = ["EXECUTE"]
+		revoke      = true
+	}
+	`, dbName, config.Username)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featurePrivileges)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testDPRevoke,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_default_privileges.test_revoke", "revoke", "true"),
+					resource.TestCheckResourceAttr("postgresql_default_privileges.test_revoke", "privileges.#", "1"),
+					func(*terraform.State) error {
+						return testCheckFunctionExecutable(t, dbName, roleName, false)
+					},
+				),
+			},
+		},
+	})
+}
+
+// testCheckFunctionExecutable creates a function and checks if the role can execute it.
+func testCheckFunctionExecutable(t *testing.T, dbName, roleName string, expected bool) error {
+	config := getTestConfig(t)
+
+	db, err := sql.Open("pgx", config.connStr(dbName))
+	if err != nil {
+		t.Fatalf("could not open connection pool for db %s: %v", dbName, err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("CREATE FUNCTION test_schema.test_func() RETURNS int AS 'SELECT 1' LANGUAGE SQL"); err != nil {
+		return fmt.Errorf("could not create test function: %v", err)
+	}
+	defer db.Exec("DROP FUNCTION test_schema.test_func()")
+
+	var executable bool
+	if err := db.QueryRow(
+		"SELECT has_function_privilege($1, 'test_schema.test_func()', 'EXECUTE')", roleName,
+	).Scan(&executable); err != nil {
+		return fmt.Errorf("could not check function privilege: %v", err)
+	}
+
+	if executable != expected {
+		return fmt.Errorf("role %s can execute test function: %t (expected: %t)", roleName, executable, expected)
+	}
+	return nil
+}
+
+func TestAccPostgresqlDefaultPrivilegesOwners(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, true)
+	defer teardown()
+
+	// Only used to create a second owner role
+	ownerSuffix, teardownOwner := setupTestDatabase(t, false, true)
+	defer teardownOwner()
+
+	config := getTestConfig(t)
+	dbName, roleName := getTestDBNames(dbSuffix)
+	_, ownerName := getTestDBNames(ownerSuffix)
+
+	var testDPOwners = fmt.Sprintf(`
+	resource "postgresql_default_privileges" "test_ro" {
+		database    = "%s"
+		owners      = ["%s", "%s"]
+		role        = "%s"
+		schema      = "test_schema"
+		object_type = "table"
+		privileges  = ["SELECT"]
+	}
+	`, dbName, config.Username, ownerName, roleName)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featurePrivileges)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testDPOwners,
+				Check: resource.ComposeTestCheckFunc(
+					func(*terraform.State) error {
+						tables := []string{"test_schema.test_table"}
+						dropFunc := createTestTables(t, dbSuffix, tables)
+						defer dropFunc()
+
+						return testCheckTablesPrivileges(t, dbSuffix, tables, []string{"SELECT"})
+					},
+					resource.TestCheckResourceAttr("postgresql_default_privileges.test_ro", "owners.#", "2"),
+					resource.TestCheckResourceAttr("postgresql_default_privileges.test_ro", "privileges.#", "1"),
+				),
+			},
+			{
+				ResourceName:      "postgresql_default_privileges.test_ro",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
 		},
 	})
 }
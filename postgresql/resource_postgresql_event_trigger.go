@@ -0,0 +1,315 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+const (
+	evtNameAttr       = "name"
+	evtDatabaseAttr   = "database"
+	evtEventAttr      = "event"
+	evtFunctionAttr   = "function"
+	evtFilterTagsAttr = "filter_tags"
+	evtEnabledAttr    = "enabled"
+)
+
+var (
+	evtEvents = []string{"ddl_command_start", "ddl_command_end", "sql_drop", "table_rewrite", "login"}
+
+	// evtEnabledModes maps the modes of the enabled attribute to the codes of pg_event_trigger.evtenabled.
+	evtEnabledModes = map[string]string{
+		"origin":   "O",
+		"replica":  "R",
+		"always":   "A",
+		"disabled": "D",
+	}
+
+	// evtEnabledClauses are the clauses of ALTER EVENT TRIGGER setting the modes of the enabled attribute.
+	evtEnabledClauses = map[string]string{
+		"origin":   "ENABLE",
+		"replica":  "ENABLE REPLICA",
+		"always":   "ENABLE ALWAYS",
+		"disabled": "DISABLE",
+	}
+)
+
+// resourcePostgreSQLEventTrigger manages an event trigger, which executes a function
+// when DDL commands are run in the database (e.g.: to audit or forbid them).
+func resourcePostgreSQLEventTrigger() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLEventTriggerCreate),
+		Read:   resourcePostgreSQLEventTriggerRead,
+		Update: retryOnTransientErrors(resourcePostgreSQLEventTriggerUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLEventTriggerDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLEventTriggerImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			evtNameAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The name of the event trigger",
+			},
+			evtDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database in which the event trigger is created",
+			},
+			evtEventAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(evtEvents, false),
+				Description:  "The event firing the trigger (ddl_command_start, ddl_command_end, sql_drop, table_rewrite or login)",
+			},
+			evtFunctionAttr: {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The function (returning event_trigger) executed by the trigger, optionally qualified by its schema",
+			},
+			evtFilterTagsAttr: {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         schema.HashString,
+				Description: "The command tags firing the trigger (e.g.: DROP TABLE), all of them if not set",
+			},
+			evtEnabledAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "origin",
+				ValidateFunc: validation.StringInSlice([]string{"origin", "replica", "always", "disabled"}, false),
+				Description:  "When the trigger fires, depending on session_replication_role: origin, replica, always or disabled",
+			},
+		},
+	}
+}
+
+func resourcePostgreSQLEventTriggerCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkEventTriggerSupported(c); err != nil {
+		return err
+	}
+
+	database := getEventTriggerDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	evtName := d.Get(evtNameAttr).(string)
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "CREATE EVENT TRIGGER %s ON %s", pqQuoteIdentifier(evtName), d.Get(evtEventAttr).(string))
+	if tags := setToStrings(d.Get(evtFilterTagsAttr).(*schema.Set)); len(tags) > 0 {
+		for i, tag := range tags {
+			tags[i] = fmt.Sprintf("'%s'", pqQuoteLiteral(tag))
+		}
+		fmt.Fprintf(b, " WHEN TAG IN (%s)", strings.Join(tags, ", "))
+	}
+	fmt.Fprintf(b, " EXECUTE PROCEDURE %s()", quoteEventTriggerFunction(d.Get(evtFunctionAttr).(string)))
+
+	queries := []string{b.String()}
+	if enabled := d.Get(evtEnabledAttr).(string); enabled != "origin" {
+		queries = append(queries, fmt.Sprintf("ALTER EVENT TRIGGER %s %s", pqQuoteIdentifier(evtName), evtEnabledClauses[enabled]))
+	}
+
+	if err := execQueries(c.ctx, txn, queries); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error creating event trigger %s: {{err}}", evtName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing event trigger: {{err}}", err)
+	}
+
+	d.Set(evtDatabaseAttr, database)
+	d.SetId(generateEventTriggerID(d))
+
+	return resourcePostgreSQLEventTriggerReadImpl(d, c)
+}
+
+func resourcePostgreSQLEventTriggerRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkEventTriggerSupported(c); err != nil {
+		return err
+	}
+
+	defer c.rLockDatabase(getEventTriggerDatabase(d, c))()
+
+	return resourcePostgreSQLEventTriggerReadImpl(d, c)
+}
+
+func resourcePostgreSQLEventTriggerReadImpl(d *schema.ResourceData, c *Client) error {
+	database := getEventTriggerDatabase(d, c)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	var event, functionSchema, functionName, enabled string
+	var tags []string
+	err = txn.QueryRowContext(c.ctx, `
+SELECT e.evtevent, n.nspname, p.proname, COALESCE(e.evttags, '{}'), e.evtenabled
+FROM pg_catalog.pg_event_trigger e
+JOIN pg_catalog.pg_proc p ON p.oid = e.evtfoid
+JOIN pg_catalog.pg_namespace n ON n.oid = p.pronamespace
+WHERE e.evtname = $1`,
+		d.Get(evtNameAttr),
+	).Scan(&event, &functionSchema, &functionName, pgArray(&tags), &enabled)
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] PostgreSQL event trigger (%s) not found", d.Id())
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading event trigger: {{err}}", err)
+	}
+
+	// The function is qualified by its schema only if it is in the configuration.
+	function := functionSchema + "." + functionName
+	if configured := d.Get(evtFunctionAttr).(string); configured != "" && !strings.Contains(configured, ".") {
+		function = functionName
+	}
+
+	for mode, code := range evtEnabledModes {
+		if code == enabled {
+			d.Set(evtEnabledAttr, mode)
+		}
+	}
+	d.Set(evtEventAttr, event)
+	d.Set(evtFunctionAttr, function)
+	d.Set(evtFilterTagsAttr, pgArrayToSet(tags))
+	d.Set(evtDatabaseAttr, database)
+	d.SetId(generateEventTriggerID(d))
+
+	return nil
+}
+
+func resourcePostgreSQLEventTriggerUpdate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkEventTriggerSupported(c); err != nil {
+		return err
+	}
+
+	database := getEventTriggerDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	evtName := d.Get(evtNameAttr).(string)
+	if d.HasChange(evtEnabledAttr) {
+		query := fmt.Sprintf("ALTER EVENT TRIGGER %s %s", pqQuoteIdentifier(evtName), evtEnabledClauses[d.Get(evtEnabledAttr).(string)])
+		if _, err := txn.ExecContext(c.ctx, query); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("Error updating event trigger %s: {{err}}", evtName), err)
+		}
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing event trigger: {{err}}", err)
+	}
+
+	return resourcePostgreSQLEventTriggerReadImpl(d, c)
+}
+
+func resourcePostgreSQLEventTriggerDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkEventTriggerSupported(c); err != nil {
+		return err
+	}
+
+	database := getEventTriggerDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	evtName := d.Get(evtNameAttr).(string)
+	if _, err := txn.ExecContext(c.ctx, fmt.Sprintf("DROP EVENT TRIGGER IF EXISTS %s", pqQuoteIdentifier(evtName))); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error deleting event trigger %s: {{err}}", evtName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing event trigger deletion: {{err}}", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// resourcePostgreSQLEventTriggerImport imports an event trigger
+// from an ID with the database/name format.
+func resourcePostgreSQLEventTriggerImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), "database/name")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set(evtDatabaseAttr, parts[0])
+	d.Set(evtNameAttr, parts[1])
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func checkEventTriggerSupported(c *Client) error {
+	if !c.featureSupported(featureEventTrigger) {
+		return fmt.Errorf(
+			"postgresql_event_trigger resource is not supported for this Postgres version (%s)",
+			c.version,
+		)
+	}
+	return nil
+}
+
+// quoteEventTriggerFunction quotes the name of the function, optionally qualified by its schema.
+func quoteEventTriggerFunction(function string) string {
+	if parts := strings.SplitN(function, ".", 2); len(parts) == 2 {
+		return pqQuoteIdentifier(parts[0]) + "." + pqQuoteIdentifier(parts[1])
+	}
+	return pqQuoteIdentifier(function)
+}
+
+func getEventTriggerDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(evtDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generateEventTriggerID(d *schema.ResourceData) string {
+	return strings.Join([]string{d.Get(evtDatabaseAttr).(string), d.Get(evtNameAttr).(string)}, ".")
+}
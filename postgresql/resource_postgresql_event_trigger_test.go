@@ -0,0 +1,74 @@
+package postgresql
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccPostgresqlEventTrigger_Basic(t *testing.T) {
+	skipIfNotAcc(t)
+	testCheckCompatibleVersion(t, featureEventTrigger)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, false)
+	defer teardown()
+
+	dbName, _ := getTestDBNames(dbSuffix)
+	config := getTestConfig(t)
+	dbExecute(t, config.connStr(dbName), `
+CREATE FUNCTION test_schema.forbid_drop() RETURNS event_trigger LANGUAGE plpgsql AS $$
+BEGIN
+  RAISE EXCEPTION 'command % is forbidden', tg_tag;
+END;
+$$`)
+
+	testAccConfig := func(enabled string) string {
+		return fmt.Sprintf(`
+resource "postgresql_event_trigger" "test" {
+  database    = "%s"
+  name        = "forbid_drop"
+  event       = "ddl_command_start"
+  function    = "test_schema.forbid_drop"
+  filter_tags = ["DROP TABLE", "DROP SCHEMA"]
+  enabled     = "%s"
+}
+`, dbName, enabled)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfig("origin"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_event_trigger.test", "id", fmt.Sprintf("%s.forbid_drop", dbName)),
+					resource.TestCheckResourceAttr("postgresql_event_trigger.test", "function", "test_schema.forbid_drop"),
+					resource.TestCheckResourceAttr("postgresql_event_trigger.test", "filter_tags.#", "2"),
+					resource.TestCheckResourceAttr("postgresql_event_trigger.test", "enabled", "origin"),
+				),
+			},
+			{
+				Config: testAccConfig("disabled"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_event_trigger.test", "enabled", "disabled"),
+				),
+			},
+			{
+				Config: testAccConfig("always"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_event_trigger.test", "enabled", "always"),
+				),
+			},
+			{
+				ResourceName:      "postgresql_event_trigger.test",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("%s/forbid_drop", dbName),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
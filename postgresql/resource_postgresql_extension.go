@@ -2,32 +2,59 @@ package postgresql
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/lib/pq"
+	"github.com/hashicorp/terraform/helper/validation"
 )
 
 const (
-	extNameAttr    = "name"
-	extSchemaAttr  = "schema"
-	extVersionAttr = "version"
+	extNameAttr     = "name"
+	extSchemaAttr   = "schema"
+	extVersionAttr  = "version"
+	extCascadeAttr  = "cascade"
+	extDatabaseAttr = "database"
+	extIfNotExists  = "if_not_exists"
+	extDropCascade  = "drop_cascade"
+	extMemberAttr   = "member"
+
+	extMemberTypeAttr = "type"
+	extMemberNameAttr = "name"
+
+	extInstalledVersionAttr = "installed_version"
+	extRequiresAttr         = "requires"
+	extRequiredByAttr       = "required_by"
+	extOwnerAttr            = "owner"
+
+	// extLatestVersion can be used as version to install the latest available version
+	extLatestVersion = "latest"
 )
 
 func resourcePostgreSQLExtension() *schema.Resource {
 	return &schema.Resource{
-		Create: resourcePostgreSQLExtensionCreate,
+		Create: retryOnTransientErrors(resourcePostgreSQLExtensionCreate),
 		Read:   resourcePostgreSQLExtensionRead,
-		Update: resourcePostgreSQLExtensionUpdate,
-		Delete: resourcePostgreSQLExtensionDelete,
-		Exists: resourcePostgreSQLExtensionExists,
+		Update: retryOnTransientErrors(resourcePostgreSQLExtensionUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLExtensionDelete),
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			State: resourcePostgreSQLExtensionImport,
 		},
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourcePostgreSQLExtensionV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourcePostgreSQLExtensionStateUpgradeV0,
+			},
+		},
+		CustomizeDiff: resourcePostgreSQLExtensionCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			extNameAttr: {
@@ -36,16 +63,99 @@ func resourcePostgreSQLExtension() *schema.Resource {
 				ForceNew: true,
 			},
 			extSchemaAttr: {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				ValidateFunc:     validateIdentifier,
+				Description:      "Sets the schema of an extension",
+				DiffSuppressFunc: suppressIdentifierCaseDiff,
+			},
+			extVersionAttr: {
 				Type:        schema.TypeString,
 				Optional:    true,
 				Computed:    true,
-				Description: "Sets the schema of an extension",
+				Description: "Sets the version number of the extension (can also be latest or a version constraint, e.g.: >= 1.6)",
 			},
-			extVersionAttr: {
+			extInstalledVersionAttr: {
 				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The version number of the installed extension",
+			},
+			extRequiresAttr: {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The extensions required by this extension",
+			},
+			extRequiredByAttr: {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The installed extensions which require this extension",
+			},
+			extCascadeAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When true, will also create any extensions that this extension depends on that are not already installed",
+			},
+			extIfNotExists: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "When true, use the existing extension if it exists (when false, creation fails if the extension already exists)",
+			},
+			extDropCascade: {
+				Type:        schema.TypeBool,
 				Optional:    true,
+				Default:     false,
+				Description: "When true, will also drop all the objects that depend on the extension, and in turn all objects that depend on those objects",
+			},
+			extMemberAttr: {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Existing objects to add to the extension as member objects (ALTER EXTENSION ... ADD)",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						extMemberTypeAttr: {
+							Type:     schema.TypeString,
+							Required: true,
+							ValidateFunc: validation.StringInSlice([]string{
+								"table",
+								"view",
+								"materialized view",
+								"foreign table",
+								"sequence",
+								"function",
+								"type",
+								"schema",
+							}, false),
+							Description: "The type of the object",
+						},
+						extMemberNameAttr: {
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateExtMemberName,
+							Description:  "The schema qualified name of the object (with the argument types for functions, e.g.: public.my_func(integer))",
+						},
+					},
+				},
+			},
+			extDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "Sets the database to add the extension to",
+			},
+			quotedIdentifiersAttr: quotedIdentifiersSchema(),
+			waitAttr:              waitSchema(),
+			oidAttr:               oidSchema("extension"),
+			extOwnerAttr: {
+				Type:        schema.TypeString,
 				Computed:    true,
-				Description: "Sets the version number of the extension",
+				Description: "The owner of the extension (the role which created it)",
 			},
 		},
 	}
@@ -61,56 +171,150 @@ func resourcePostgreSQLExtensionCreate(d *schema.ResourceData, meta interface{})
 		)
 	}
 
-	c.catalogLock.Lock()
-	defer c.catalogLock.Unlock()
+	if d.Get(extMemberAttr).(*schema.Set).Len() > 0 && !c.featureSupported(featureExtensionMembers) {
+		return fmt.Errorf(
+			"managing extension member objects is not supported for this Postgres version (%s)",
+			c.version,
+		)
+	}
+
+	// Without CASCADE, the extension is still created if the extensions it requires are installed.
+	if d.Get(extCascadeAttr).(bool) && !c.featureSupported(featureExtensionCreateCascade) {
+		if err := c.tolerateUnsupportedFeature(fmt.Errorf(
+			"CREATE EXTENSION ... CASCADE is not supported for this Postgres version (%s)",
+			c.version,
+		)); err != nil {
+			return err
+		}
+	}
 
 	extName := d.Get(extNameAttr).(string)
+	database := getDatabase(d, c)
 
-	b := bytes.NewBufferString("CREATE EXTENSION IF NOT EXISTS ")
-	fmt.Fprint(b, pq.QuoteIdentifier(extName))
+	defer c.lockDatabase(database)()
+
+	b := bytes.NewBufferString("CREATE EXTENSION ")
+	if d.Get(extIfNotExists).(bool) {
+		fmt.Fprint(b, "IF NOT EXISTS ")
+	}
+	fmt.Fprint(b, pqQuoteIdentifier(extName))
 
 	if v, ok := d.GetOk(extSchemaAttr); ok {
-		fmt.Fprint(b, " SCHEMA ", pq.QuoteIdentifier(v.(string)))
+		fmt.Fprint(b, " SCHEMA ", pqQuoteIdentifier(v.(string)))
+	}
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
 	}
+	defer deferredRollback(txn)
 
 	if v, ok := d.GetOk(extVersionAttr); ok {
-		fmt.Fprint(b, " VERSION ", pq.QuoteIdentifier(v.(string)))
+		extVersion, err := resolveExtVersion(c.ctx, txn, extName, v.(string))
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(b, " VERSION ", pqQuoteIdentifier(extVersion))
+	}
+
+	if d.Get(extCascadeAttr).(bool) && c.featureSupported(featureExtensionCreateCascade) {
+		fmt.Fprint(b, " CASCADE")
 	}
 
 	sql := b.String()
-	if _, err := c.DB().Exec(sql); err != nil {
+	if _, err := txn.ExecContext(c.ctx, sql); err != nil {
 		return errwrap.Wrapf("Error creating extension: {{err}}", err)
 	}
 
-	d.SetId(extName)
+	if err := setExtMembers(c.ctx, txn, d, extName); err != nil {
+		return err
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing extension: {{err}}", err)
+	}
+
+	d.SetId(generateExtensionID(d, c))
+
+	if err := waitForVisibility(c, d, database, fmt.Sprintf("extension %s", extName),
+		"SELECT EXISTS(SELECT 1 FROM pg_catalog.pg_extension WHERE extname = $1)", extName,
+	); err != nil {
+		return err
+	}
 
 	return resourcePostgreSQLExtensionReadImpl(d, meta)
 }
 
-func resourcePostgreSQLExtensionExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+// resourcePostgreSQLExtensionCustomizeDiff validates at plan time that the requested version
+// is available on the server and that the installed version can be updated to it.
+func resourcePostgreSQLExtensionCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
 	c := meta.(*Client)
 
-	if !c.featureSupported(featureExtension) {
-		return false, fmt.Errorf(
-			"postgresql_extension resource is not supported for this Postgres version (%s)",
-			c.version,
-		)
+	if !d.HasChange(extVersionAttr) || !d.NewValueKnown(extVersionAttr) || !d.NewValueKnown(extDatabaseAttr) {
+		return nil
 	}
 
-	c.catalogLock.Lock()
-	defer c.catalogLock.Unlock()
+	requestedVersion := d.Get(extVersionAttr).(string)
+	if requestedVersion == "" || !c.featureSupported(featureExtension) {
+		return nil
+	}
 
-	var extensionName string
-	query := "SELECT extname FROM pg_catalog.pg_extension WHERE extname = $1"
-	err := c.DB().QueryRow(query, d.Id()).Scan(&extensionName)
-	switch {
-	case err == sql.ErrNoRows:
-		return false, nil
-	case err != nil:
-		return false, err
+	extName := d.Get(extNameAttr).(string)
+	database := c.databaseName
+	if v, ok := d.GetOk(extDatabaseAttr); ok {
+		database = v.(string)
+	}
+
+	defer c.rLockDatabase(database)()
+
+	// The database can be created in the same plan,
+	// in this case the version will be checked during the apply.
+	txn, err := startTransaction(c, "")
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	if exists, err := dbExists(c.ctx, txn, database); err != nil || !exists {
+		return err
+	}
+
+	dbTxn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(dbTxn)
+
+	targetVersion, err := resolveExtVersion(c.ctx, dbTxn, extName, requestedVersion)
+	if err != nil {
+		return err
+	}
+
+	if err := checkExtVersionAvailable(c.ctx, dbTxn, extName, targetVersion); err != nil {
+		return err
 	}
 
-	return true, nil
+	// The installed version is known only if the extension already exists
+	installedVersion := d.Get(extInstalledVersionAttr).(string)
+	if d.Id() == "" || installedVersion == "" || installedVersion == targetVersion {
+		return nil
+	}
+
+	var hasPath bool
+	if err := dbTxn.QueryRowContext(c.ctx,
+		"SELECT EXISTS(SELECT 1 FROM pg_catalog.pg_extension_update_paths($1) WHERE source = $2 AND target = $3 AND path IS NOT NULL)",
+		extName, installedVersion, targetVersion,
+	).Scan(&hasPath); err != nil {
+		return errwrap.Wrapf("Error reading extension update paths: {{err}}", err)
+	}
+
+	if !hasPath {
+		return fmt.Errorf(
+			"extension %s: no update path from %s to %s", extName, installedVersion, targetVersion,
+		)
+	}
+
+	return d.SetNewComputed(extInstalledVersionAttr)
 }
 
 func resourcePostgreSQLExtensionRead(d *schema.ResourceData, meta interface{}) error {
@@ -123,21 +327,53 @@ func resourcePostgreSQLExtensionRead(d *schema.ResourceData, meta interface{}) e
 		)
 	}
 
-	c.catalogLock.RLock()
-	defer c.catalogLock.RUnlock()
+	database, _ := getDBExtName(d, c)
+
+	defer c.rLockDatabase(database)()
+
+	// The extension is removed with its database.
+	exists, err := extDatabaseExists(c, database)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		log.Printf("[WARN] database %s of PostgreSQL extension (%s) not found", database, d.Id())
+		d.SetId("")
+		return nil
+	}
 
 	return resourcePostgreSQLExtensionReadImpl(d, meta)
 }
 
+// extDatabaseExists checks if the database of the extension exists.
+// Its transaction is closed before the one of the database is opened.
+func extDatabaseExists(c *Client, database string) (bool, error) {
+	txn, err := startTransaction(c, "")
+	if err != nil {
+		return false, err
+	}
+	defer deferredRollback(txn)
+
+	return dbExists(c.ctx, txn, database)
+}
+
 func resourcePostgreSQLExtensionReadImpl(d *schema.ResourceData, meta interface{}) error {
 	c := meta.(*Client)
 
-	extID := d.Id()
-	var extName, extSchema, extVersion string
-	query := `SELECT e.extname, n.nspname, e.extversion ` +
+	database, extName := getDBExtName(d, c)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	var extSchema, extVersion, extOwner string
+	var extOID int
+	query := `SELECT e.extname, e.oid::BIGINT, pg_catalog.pg_get_userbyid(e.extowner), n.nspname, e.extversion ` +
 		`FROM pg_catalog.pg_extension e, pg_catalog.pg_namespace n ` +
 		`WHERE n.oid = e.extnamespace AND e.extname = $1`
-	err := c.DB().QueryRow(query, extID).Scan(&extName, &extSchema, &extVersion)
+	err = txn.QueryRowContext(c.ctx, query, extName).Scan(&extName, &extOID, &extOwner, &extSchema, &extVersion)
 	switch {
 	case err == sql.ErrNoRows:
 		log.Printf("[WARN] PostgreSQL extension (%s) not found", d.Id())
@@ -147,10 +383,47 @@ func resourcePostgreSQLExtensionReadImpl(d *schema.ResourceData, meta interface{
 		return errwrap.Wrapf("Error reading extension: {{err}}", err)
 	}
 
+	// If the version is latest or a constraint, we keep it in the state
+	// as long as the installed version is the one it resolves to.
+	// Otherwise (e.g.: a newer version is available), the installed version
+	// is set to produce a diff and update the extension.
+	stateVersion := extVersion
+	if v := d.Get(extVersionAttr).(string); isExtVersionConstraint(v) {
+		resolvedVersion, err := resolveExtVersion(c.ctx, txn, extName, v)
+		if err != nil {
+			return err
+		}
+		if resolvedVersion == extVersion {
+			stateVersion = v
+		} else {
+			log.Printf(
+				"[DEBUG] PostgreSQL extension %s version %s resolves to %s but %s is installed",
+				extName, v, resolvedVersion, extVersion,
+			)
+		}
+	}
+
+	requires, requiredBy, err := readExtDependencies(c.ctx, txn, extName)
+	if err != nil {
+		return err
+	}
+
+	if d.Get(extMemberAttr).(*schema.Set).Len() > 0 {
+		if err := readExtMembers(c.ctx, txn, d, extName); err != nil {
+			return err
+		}
+	}
+
 	d.Set(extNameAttr, extName)
+	d.Set(oidAttr, extOID)
+	d.Set(extOwnerAttr, extOwner)
+	d.Set(extRequiresAttr, requires)
+	d.Set(extRequiredByAttr, requiredBy)
 	d.Set(extSchemaAttr, extSchema)
-	d.Set(extVersionAttr, extVersion)
-	d.SetId(extName)
+	d.Set(extVersionAttr, stateVersion)
+	d.Set(extInstalledVersionAttr, extVersion)
+	d.Set(extDatabaseAttr, database)
+	d.SetId(generateExtensionID(d, c))
 
 	return nil
 }
@@ -165,16 +438,45 @@ func resourcePostgreSQLExtensionDelete(d *schema.ResourceData, meta interface{})
 		)
 	}
 
-	c.catalogLock.Lock()
-	defer c.catalogLock.Unlock()
+	database, extName := getDBExtName(d, c)
+
+	defer c.lockDatabase(database)()
 
-	extID := d.Id()
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	sql := fmt.Sprintf("DROP EXTENSION %s", pqQuoteIdentifier(extName))
+	if d.Get(extDropCascade).(bool) {
+		var dependentObjects int
+		if err := txn.QueryRowContext(c.ctx,
+			`SELECT count(*) FROM pg_catalog.pg_depend d
+JOIN pg_catalog.pg_extension e ON e.oid = d.refobjid
+WHERE d.refclassid = 'pg_catalog.pg_extension'::regclass AND d.deptype = 'n' AND e.extname = $1`,
+			extName,
+		).Scan(&dependentObjects); err != nil {
+			return errwrap.Wrapf("Error reading extension dependent objects: {{err}}", err)
+		}
+		if dependentObjects > 0 {
+			log.Printf(
+				"[WARN] dropping extension %s with CASCADE will also drop %d dependent object(s)",
+				extName, dependentObjects,
+			)
+		}
+
+		sql += " CASCADE"
+	}
 
-	sql := fmt.Sprintf("DROP EXTENSION %s", pq.QuoteIdentifier(extID))
-	if _, err := c.DB().Exec(sql); err != nil {
+	if _, err := txn.ExecContext(c.ctx, sql); err != nil {
 		return errwrap.Wrapf("Error deleting extension: {{err}}", err)
 	}
 
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing extension deletion: {{err}}", err)
+	}
+
 	d.SetId("")
 
 	return nil
@@ -190,28 +492,53 @@ func resourcePostgreSQLExtensionUpdate(d *schema.ResourceData, meta interface{})
 		)
 	}
 
-	c.catalogLock.Lock()
-	defer c.catalogLock.Unlock()
+	database, _ := getDBExtName(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
 
 	// Can't rename a schema
 
-	if err := setExtSchema(c.DB(), d); err != nil {
+	if err := setExtSchema(txn, d, c); err != nil {
 		return err
 	}
 
-	if err := setExtVersion(c.DB(), d); err != nil {
+	if err := setExtVersion(txn, d, c); err != nil {
 		return err
 	}
 
+	if d.HasChange(extMemberAttr) {
+		if !c.featureSupported(featureExtensionMembers) {
+			return fmt.Errorf(
+				"managing extension member objects is not supported for this Postgres version (%s)",
+				c.version,
+			)
+		}
+
+		_, extName := getDBExtName(d, c)
+		if err := setExtMembers(c.ctx, txn, d, extName); err != nil {
+			return err
+		}
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error updating extension: {{err}}", err)
+	}
+
 	return resourcePostgreSQLExtensionReadImpl(d, meta)
 }
 
-func setExtSchema(db *sql.DB, d *schema.ResourceData) error {
+func setExtSchema(txn *sql.Tx, d *schema.ResourceData, c *Client) error {
 	if !d.HasChange(extSchemaAttr) {
 		return nil
 	}
 
-	extID := d.Id()
+	_, extName := getDBExtName(d, c)
 	_, nraw := d.GetChange(extSchemaAttr)
 	n := nraw.(string)
 	if n == "" {
@@ -219,34 +546,381 @@ func setExtSchema(db *sql.DB, d *schema.ResourceData) error {
 	}
 
 	sql := fmt.Sprintf("ALTER EXTENSION %s SET SCHEMA %s",
-		pq.QuoteIdentifier(extID), pq.QuoteIdentifier(n))
-	if _, err := db.Exec(sql); err != nil {
+		pqQuoteIdentifier(extName), pqQuoteIdentifier(n))
+	if _, err := txn.ExecContext(c.ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating extension SCHEMA: {{err}}", err)
 	}
 
 	return nil
 }
 
-func setExtVersion(db *sql.DB, d *schema.ResourceData) error {
+func setExtVersion(txn *sql.Tx, d *schema.ResourceData, c *Client) error {
 	if !d.HasChange(extVersionAttr) {
 		return nil
 	}
 
-	extID := d.Id()
+	_, extName := getDBExtName(d, c)
 
 	b := bytes.NewBufferString("ALTER EXTENSION ")
-	fmt.Fprintf(b, "%s UPDATE", pq.QuoteIdentifier(extID))
+	fmt.Fprintf(b, "%s UPDATE", pqQuoteIdentifier(extName))
 
 	_, nraw := d.GetChange(extVersionAttr)
 	n := nraw.(string)
 	if n != "" {
-		fmt.Fprintf(b, " TO %s", pq.QuoteIdentifier(n))
+		extVersion, err := resolveExtVersion(c.ctx, txn, extName, n)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(b, " TO %s", pqQuoteIdentifier(extVersion))
 	}
 
 	sql := b.String()
-	if _, err := db.Exec(sql); err != nil {
+	if _, err := txn.ExecContext(c.ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating extension version: {{err}}", err)
 	}
 
 	return nil
 }
+
+// isExtVersionConstraint returns true if the version is latest or a version constraint
+// (e.g.: >= 1.6) instead of a version number.
+func isExtVersionConstraint(v string) bool {
+	return v == extLatestVersion || strings.ContainsAny(v, "<>=!~,")
+}
+
+// resolveExtVersion returns the highest version of the extension available on the server
+// which matches the version constraint (or the version itself if it's a version number).
+// Available versions which are not valid version numbers (e.g.: 1.0dev) are ignored.
+func resolveExtVersion(ctx context.Context, txn *sql.Tx, extName, v string) (string, error) {
+	if !isExtVersionConstraint(v) {
+		return v, nil
+	}
+
+	var constraints version.Constraints
+	if v != extLatestVersion {
+		var err error
+		if constraints, err = version.NewConstraint(v); err != nil {
+			return "", errwrap.Wrapf(fmt.Sprintf("invalid version constraint %q: {{err}}", v), err)
+		}
+	}
+
+	rows, err := txn.QueryContext(ctx, "SELECT version FROM pg_catalog.pg_available_extension_versions WHERE name = $1", extName)
+	if err != nil {
+		return "", errwrap.Wrapf("Error reading available extension versions: {{err}}", err)
+	}
+	defer rows.Close()
+
+	var resolvedVersion string
+	var resolved *version.Version
+	for rows.Next() {
+		var availableVersion string
+		if err := rows.Scan(&availableVersion); err != nil {
+			return "", err
+		}
+
+		parsed, err := version.NewVersion(availableVersion)
+		if err != nil {
+			log.Printf("[DEBUG] ignoring version %s of extension %s: %v", availableVersion, extName, err)
+			continue
+		}
+
+		if constraints != nil && !constraints.Check(parsed) {
+			continue
+		}
+
+		if resolved == nil || parsed.GreaterThan(resolved) {
+			resolved, resolvedVersion = parsed, availableVersion
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	if resolved == nil {
+		return "", fmt.Errorf("no available version of extension %s matches %q", extName, v)
+	}
+
+	return resolvedVersion, nil
+}
+
+// setExtMembers adds the new member objects to the extension
+// and drops the ones which have been removed from the resource.
+func setExtMembers(ctx context.Context, txn *sql.Tx, d *schema.ResourceData, extName string) error {
+	oraw, nraw := d.GetChange(extMemberAttr)
+	oldMembers := oraw.(*schema.Set)
+	newMembers := nraw.(*schema.Set)
+
+	alterMembers := func(action string, members []interface{}) error {
+		for _, m := range members {
+			member := m.(map[string]interface{})
+			memberType := member[extMemberTypeAttr].(string)
+			memberName, err := quoteExtMemberName(memberType, member[extMemberNameAttr].(string))
+			if err != nil {
+				return err
+			}
+
+			sql := fmt.Sprintf(
+				"ALTER EXTENSION %s %s %s %s",
+				pqQuoteIdentifier(extName), action, strings.ToUpper(memberType), memberName,
+			)
+			if _, err := txn.ExecContext(ctx, sql); err != nil {
+				return errwrap.Wrapf(fmt.Sprintf("Error altering extension member object (%s): {{err}}", action), err)
+			}
+		}
+		return nil
+	}
+
+	if err := alterMembers("DROP", oldMembers.Difference(newMembers).List()); err != nil {
+		return err
+	}
+	return alterMembers("ADD", newMembers.Difference(oldMembers).List())
+}
+
+// extMemberCatalogs are the catalogs of the types of member objects,
+// with the function looking up the OID of an object from its quoted name
+// (the schemas are looked up by name in pg_namespace).
+var extMemberCatalogs = map[string][2]string{
+	"table":             {"pg_class", "to_regclass"},
+	"view":              {"pg_class", "to_regclass"},
+	"materialized view": {"pg_class", "to_regclass"},
+	"foreign table":     {"pg_class", "to_regclass"},
+	"sequence":          {"pg_class", "to_regclass"},
+	"function":          {"pg_proc", "to_regprocedure"},
+	"type":              {"pg_type", "to_regtype"},
+	"schema":            {"pg_namespace", ""},
+}
+
+// readExtMembers removes from the state the member objects which
+// are no longer part of the extension.
+// As an extension has a lot of member objects (created by its script),
+// only the ones managed by the resource are checked. They are looked up
+// by OID, so their names do not have to match the identity of the objects.
+func readExtMembers(ctx context.Context, txn *sql.Tx, d *schema.ResourceData, extName string) error {
+	members := []interface{}{}
+	for _, m := range d.Get(extMemberAttr).(*schema.Set).List() {
+		member := m.(map[string]interface{})
+		memberType := member[extMemberTypeAttr].(string)
+		memberName, err := quoteExtMemberName(memberType, member[extMemberNameAttr].(string))
+		if err != nil {
+			return err
+		}
+
+		catalog := extMemberCatalogs[memberType]
+		objectOID := fmt.Sprintf("%s($2)", catalog[1])
+		if memberType == "schema" {
+			// to_regnamespace only exists since PostgreSQL 9.5
+			objectOID = "(SELECT oid FROM pg_catalog.pg_namespace WHERE nspname = $2)"
+			if _, memberName, _, err = parseExtMemberName(memberType, member[extMemberNameAttr].(string)); err != nil {
+				return err
+			}
+		}
+
+		var isMember bool
+		query := fmt.Sprintf(`SELECT EXISTS (
+	SELECT 1 FROM pg_catalog.pg_depend d
+	JOIN pg_catalog.pg_extension e ON e.oid = d.refobjid
+	WHERE d.refclassid = 'pg_catalog.pg_extension'::regclass AND d.deptype = 'e' AND e.extname = $1
+	AND d.classid = 'pg_catalog.%s'::regclass AND d.objid = %s
+)`, catalog[0], objectOID)
+		if err := txn.QueryRowContext(ctx, query, extName, memberName).Scan(&isMember); err != nil {
+			return errwrap.Wrapf("Error reading extension member objects: {{err}}", err)
+		}
+
+		if isMember {
+			members = append(members, member)
+		} else {
+			log.Printf(
+				"[WARN] %s %s is not a member of extension %s",
+				memberType, member[extMemberNameAttr], extName,
+			)
+		}
+	}
+
+	return d.Set(extMemberAttr, members)
+}
+
+// parseExtMemberName splits the name of a member object into its schema, its name
+// and, for the functions, its argument types (without the parentheses).
+// The double-quoted parts are unquoted, the other ones are kept as-is.
+func parseExtMemberName(memberType, name string) (objSchema, objName, args string, err error) {
+	if memberType == "function" {
+		i := strings.Index(name, "(")
+		if i < 0 || !strings.HasSuffix(name, ")") {
+			return "", "", "", fmt.Errorf("the name of function %q must end with its argument types, e.g.: public.my_func(integer)", name)
+		}
+		name, args = name[:i], name[i+1:len(name)-1]
+	}
+
+	parts, err := splitQuotedIdentifier(name)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	switch {
+	case memberType == "schema" && len(parts) == 1:
+		return "", parts[0], "", nil
+	case memberType != "schema" && len(parts) == 2:
+		return parts[0], parts[1], args, nil
+	case memberType == "schema":
+		return "", "", "", fmt.Errorf("the name of schema %q cannot be qualified", name)
+	}
+	return "", "", "", fmt.Errorf("the name of %s %q must be qualified by its schema", memberType, name)
+}
+
+// splitQuotedIdentifier splits a dot separated identifier,
+// the parts may be double-quoted (with "" for a double quote) to contain dots.
+func splitQuotedIdentifier(identifier string) ([]string, error) {
+	var parts []string
+	var part strings.Builder
+	quoted, wasQuoted := false, false
+	for i := 0; i < len(identifier); i++ {
+		switch ch := identifier[i]; {
+		case ch == '"' && quoted && i+1 < len(identifier) && identifier[i+1] == '"':
+			part.WriteByte('"')
+			i++
+		case ch == '"':
+			quoted = !quoted
+			wasQuoted = true
+		case ch == '.' && !quoted:
+			if part.Len() == 0 && !wasQuoted {
+				return nil, fmt.Errorf("invalid identifier %q: empty part", identifier)
+			}
+			parts = append(parts, part.String())
+			part.Reset()
+			wasQuoted = false
+		default:
+			part.WriteByte(ch)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("invalid identifier %q: unterminated quote", identifier)
+	}
+	if part.Len() == 0 && !wasQuoted {
+		return nil, fmt.Errorf("invalid identifier %q: empty part", identifier)
+	}
+	return append(parts, part.String()), nil
+}
+
+// quoteExtMemberName returns the quoted name of a member object, used in the statements
+// and to look up the object. The argument types of the functions are only trimmed,
+// they are types (e.g.: character varying) and not identifiers.
+func quoteExtMemberName(memberType, name string) (string, error) {
+	objSchema, objName, args, err := parseExtMemberName(memberType, name)
+	if err != nil {
+		return "", err
+	}
+
+	switch memberType {
+	case "schema":
+		return pqQuoteIdentifier(objName), nil
+	case "function":
+		argTypes := []string{}
+		for _, arg := range strings.Split(args, ",") {
+			if arg = strings.TrimSpace(arg); arg != "" {
+				argTypes = append(argTypes, arg)
+			}
+		}
+		return fmt.Sprintf("%s.%s(%s)", pqQuoteIdentifier(objSchema), pqQuoteIdentifier(objName), strings.Join(argTypes, ", ")), nil
+	}
+	return pqQuoteIdentifier(objSchema) + "." + pqQuoteIdentifier(objName), nil
+}
+
+// validateExtMemberName checks the characters of the name of a member object,
+// its format depends on the type of the object and is checked when it is used.
+func validateExtMemberName(v interface{}, key string) (warnings []string, errors []error) {
+	if strings.ContainsAny(v.(string), ";'\x00") {
+		errors = append(errors, fmt.Errorf("%s contains an invalid character: %q", key, v.(string)))
+	}
+	return
+}
+
+// readExtDependencies returns the sorted lists of the extensions required by the extension
+// and of the extensions which require it (from the dependencies recorded in pg_depend).
+func readExtDependencies(ctx context.Context, txn *sql.Tx, extName string) ([]string, []string, error) {
+	query := `SELECT
+    ARRAY(
+        SELECT r.extname FROM pg_catalog.pg_depend d
+        JOIN pg_catalog.pg_extension r ON r.oid = d.refobjid
+        WHERE d.classid = 'pg_catalog.pg_extension'::regclass
+        AND d.refclassid = 'pg_catalog.pg_extension'::regclass
+        AND d.objid = e.oid
+        ORDER BY r.extname
+    ),
+    ARRAY(
+        SELECT r.extname FROM pg_catalog.pg_depend d
+        JOIN pg_catalog.pg_extension r ON r.oid = d.objid
+        WHERE d.classid = 'pg_catalog.pg_extension'::regclass
+        AND d.refclassid = 'pg_catalog.pg_extension'::regclass
+        AND d.refobjid = e.oid
+        ORDER BY r.extname
+    )
+FROM pg_catalog.pg_extension e WHERE e.extname = $1`
+
+	var requires, requiredBy []string
+	if err := txn.QueryRowContext(ctx, query, extName).Scan(pgArray(&requires), pgArray(&requiredBy)); err != nil {
+		return nil, nil, errwrap.Wrapf("Error reading extension dependencies: {{err}}", err)
+	}
+
+	return []string(requires), []string(requiredBy), nil
+}
+
+// checkExtVersionAvailable checks that the version of the extension is available on the server.
+func checkExtVersionAvailable(ctx context.Context, txn *sql.Tx, extName, extVersion string) error {
+	var available bool
+	if err := txn.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM pg_catalog.pg_available_extension_versions WHERE name = $1 AND version = $2)",
+		extName, extVersion,
+	).Scan(&available); err != nil {
+		return errwrap.Wrapf("Error reading available extension versions: {{err}}", err)
+	}
+
+	if !available {
+		return fmt.Errorf("version %s of extension %s is not available on the server", extVersion, extName)
+	}
+	return nil
+}
+
+// getDatabase returns the database attribute of the resource
+// or the database configured in the provider if not set.
+func getDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(extDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+// getDBExtName returns the database and the name of the extension from the ID.
+// The ID format is database.extension but only the extension name was used in the past
+// (and can still be used to import an extension of the provider's database).
+func getDBExtName(d *schema.ResourceData, c *Client) (string, string) {
+	database := getDatabase(d, c)
+	extName := d.Id()
+
+	if parts := strings.SplitN(d.Id(), ".", 2); len(parts) == 2 {
+		database, extName = parts[0], parts[1]
+	}
+
+	return database, extName
+}
+
+// resourcePostgreSQLExtensionImport imports an extension from an ID with the
+// database/extension format. The IDs of the state (database.extension or extension)
+// are also accepted.
+func resourcePostgreSQLExtensionImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if strings.Contains(d.Id(), "/") {
+		parts, err := splitImportID(d.Id(), "database/extension")
+		if err != nil {
+			return nil, err
+		}
+		d.SetId(strings.Join(parts, "."))
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func generateExtensionID(d *schema.ResourceData, c *Client) string {
+	return strings.Join([]string{
+		getDatabase(d, c), d.Get(extNameAttr).(string),
+	}, ".")
+}
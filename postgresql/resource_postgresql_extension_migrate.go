@@ -0,0 +1,45 @@
+package postgresql
+
+import (
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// resourcePostgreSQLExtensionV0 is the schema of postgresql_extension in the version 0.4.0,
+// used to decode the states written by this version.
+func resourcePostgreSQLExtensionV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			extNameAttr: {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			extSchemaAttr: {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			extVersionAttr: {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+		},
+	}
+}
+
+// resourcePostgreSQLExtensionStateUpgradeV0 sets the database of the extension
+// (the provider's database), its database qualified ID and the default values
+// of the attributes added since.
+// If the provider is not configured, the database and the ID are set by the next read.
+func resourcePostgreSQLExtensionStateUpgradeV0(rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	if c, ok := meta.(*Client); ok && c != nil {
+		rawState[extDatabaseAttr] = c.databaseName
+		rawState["id"] = c.databaseName + "." + stateString(rawState, extNameAttr)
+	}
+	rawState[extCascadeAttr] = false
+	rawState[extIfNotExists] = true
+	rawState[extDropCascade] = false
+
+	return rawState, nil
+}
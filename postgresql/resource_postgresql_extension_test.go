@@ -3,6 +3,7 @@ package postgresql
 import (
 	"database/sql"
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/resource"
@@ -31,6 +32,10 @@ func TestAccPostgresqlExtension_Basic(t *testing.T) {
 					// version 1.3 and PG 9.2 ships with pg_trgm 1.0.
 					resource.TestCheckResourceAttrSet(
 						"postgresql_extension.myextension", "version"),
+					resource.TestCheckResourceAttrSet(
+						"postgresql_extension.myextension", "oid"),
+					resource.TestCheckResourceAttrSet(
+						"postgresql_extension.myextension", "owner"),
 				),
 			},
 		},
@@ -45,7 +50,7 @@ func testAccCheckPostgresqlExtensionDestroy(s *terraform.State) error {
 			continue
 		}
 
-		exists, err := checkExtensionExists(client, rs.Primary.ID)
+		exists, err := checkExtensionExists(client, getExtensionDatabase(rs), rs.Primary.Attributes["name"])
 
 		if err != nil {
 			return fmt.Errorf("Error checking extension %s", err)
@@ -71,7 +76,7 @@ func testAccCheckPostgresqlExtensionExists(n string) resource.TestCheckFunc {
 		}
 
 		client := testAccProvider.Meta().(*Client)
-		exists, err := checkExtensionExists(client, rs.Primary.ID)
+		exists, err := checkExtensionExists(client, getExtensionDatabase(rs), rs.Primary.Attributes["name"])
 
 		if err != nil {
 			return fmt.Errorf("Error checking extension %s", err)
@@ -124,9 +129,310 @@ func TestAccPostgresqlExtension_SchemaRename(t *testing.T) {
 	})
 }
 
-func checkExtensionExists(client *Client, extensionName string) (bool, error) {
+func TestAccPostgresqlExtension_Cascade(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureExtensionCreateCascade)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPostgresqlExtensionDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPostgresqlExtensionCascadeConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPostgresqlExtensionExists("postgresql_extension.cascade"),
+					resource.TestCheckResourceAttr(
+						"postgresql_extension.cascade", "name", "earthdistance"),
+					resource.TestCheckResourceAttr(
+						"postgresql_extension.cascade", "cascade", "true"),
+					resource.TestCheckResourceAttr(
+						"postgresql_extension.cascade", "requires.#", "1"),
+					resource.TestCheckResourceAttr(
+						"postgresql_extension.cascade", "requires.0", "cube"),
+					// cube is a dependency of earthdistance
+					func(*terraform.State) error {
+						client := testAccProvider.Meta().(*Client)
+						exists, err := checkExtensionExists(client, "", "cube")
+						if err != nil {
+							return err
+						}
+						if !exists {
+							return fmt.Errorf("Extension cube has not been created")
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlExtension_Database(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, false)
+	defer teardown()
+
+	dbName, _ := getTestDBNames(dbSuffix)
+
+	testAccPostgresqlExtensionDatabaseConfig := fmt.Sprintf(`
+resource "postgresql_extension" "ext_db" {
+  name = "pg_trgm"
+  database = "%s"
+}
+`, dbName)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureExtension)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPostgresqlExtensionDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPostgresqlExtensionDatabaseConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPostgresqlExtensionExists("postgresql_extension.ext_db"),
+					resource.TestCheckResourceAttr(
+						"postgresql_extension.ext_db", "database", dbName),
+					resource.TestCheckResourceAttr(
+						"postgresql_extension.ext_db", "id", fmt.Sprintf("%s.pg_trgm", dbName)),
+				),
+			},
+			{
+				ResourceName:            "postgresql_extension.ext_db",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"cascade"},
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlExtension_LatestVersion(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureExtension)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPostgresqlExtensionDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPostgresqlExtensionLatestConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPostgresqlExtensionExists("postgresql_extension.latest"),
+					resource.TestCheckResourceAttr(
+						"postgresql_extension.latest", "version", "latest"),
+					resource.TestCheckResourceAttrSet(
+						"postgresql_extension.latest", "installed_version"),
+				),
+			},
+			{
+				Config: testAccPostgresqlExtensionConstraintConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPostgresqlExtensionExists("postgresql_extension.latest"),
+					resource.TestCheckResourceAttr(
+						"postgresql_extension.latest", "version", ">= 1.0"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlExtension_NotIfNotExists(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureExtension)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPostgresqlExtensionDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccPostgresqlExtensionStrictConfig,
+				ExpectError: regexp.MustCompile("already exists"),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlExtension_UnavailableVersion(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureExtension)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPostgresqlExtensionDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:             testAccPostgresqlExtensionUnavailableVersionConfig,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+				ExpectError:        regexp.MustCompile("version 999.0 of extension pg_trgm is not available"),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlExtension_DropCascade(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, false)
+	defer teardown()
+
+	dbName, _ := getTestDBNames(dbSuffix)
+	config := getTestConfig(t)
+
+	testAccPostgresqlExtensionDropCascadeConfig := fmt.Sprintf(`
+resource "postgresql_extension" "cascade" {
+  name = "pg_trgm"
+  database = "%s"
+  drop_cascade = true
+}
+`, dbName)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureExtension)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPostgresqlExtensionDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPostgresqlExtensionDropCascadeConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPostgresqlExtensionExists("postgresql_extension.cascade"),
+					resource.TestCheckResourceAttr(
+						"postgresql_extension.cascade", "drop_cascade", "true"),
+					// This index depends on the extension and will be dropped with it.
+					func(*terraform.State) error {
+						dbExecute(
+							t, config.connStr(dbName),
+							"CREATE TABLE test_trgm (val text); CREATE INDEX test_trgm_idx ON test_trgm USING gist (val gist_trgm_ops)",
+						)
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlExtension_Members(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, false)
+	defer teardown()
+
+	dbName, _ := getTestDBNames(dbSuffix)
+	config := getTestConfig(t)
+	dbExecute(
+		t, config.connStr(dbName),
+		`CREATE TABLE "Test_Ext_Member" (val text); CREATE FUNCTION test_ext_member_func(integer) RETURNS integer AS 'SELECT 1' LANGUAGE SQL`,
+	)
+
+	testAccPostgresqlExtensionMembersConfig := `
+resource "postgresql_extension" "members" {
+  name = "pg_trgm"
+  database = "%s"
+
+  member {
+    type = "table"
+    name = "public.Test_Ext_Member"
+  }
+%s
+}
+`
+	funcMember := `
+  member {
+    type = "function"
+    name = "public.test_ext_member_func(int4)"
+  }
+`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureExtensionMembers)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPostgresqlExtensionDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccPostgresqlExtensionMembersConfig, dbName, ""),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPostgresqlExtensionExists("postgresql_extension.members"),
+					resource.TestCheckResourceAttr(
+						"postgresql_extension.members", "member.#", "1"),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccPostgresqlExtensionMembersConfig, dbName, funcMember),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"postgresql_extension.members", "member.#", "2"),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccPostgresqlExtensionMembersConfig, dbName, ""),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"postgresql_extension.members", "member.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func TestQuoteExtMemberName(t *testing.T) {
+	tests := []struct {
+		memberType string
+		name       string
+		expected   string
+		valid      bool
+	}{
+		{"table", "public.MyTable", `"public"."MyTable"`, true},
+		{"table", `public."my.table"`, `"public"."my.table"`, true},
+		{"view", `"My""Schema".v`, `"My""Schema"."v"`, true},
+		{"function", "public.my_func(integer,  character varying)", `"public"."my_func"(integer, character varying)`, true},
+		{"function", "public.my_func()", `"public"."my_func"()`, true},
+		{"schema", "MySchema", `"MySchema"`, true},
+		{"table", "my_table", "", false},
+		{"function", "public.my_func", "", false},
+		{"schema", "public.other", "", false},
+		{"table", `public."unterminated`, "", false},
+	}
+
+	for _, test := range tests {
+		actual, err := quoteExtMemberName(test.memberType, test.name)
+		if (err == nil) != test.valid {
+			t.Errorf("quoteExtMemberName(%s, %q): expected valid %t, got error %v", test.memberType, test.name, test.valid, err)
+		} else if actual != test.expected {
+			t.Errorf("quoteExtMemberName(%s, %q): expected %s, got %s", test.memberType, test.name, test.expected, actual)
+		}
+	}
+}
+
+// getExtensionDatabase returns the database of the extension from the state
+// (empty for the provider's database).
+func getExtensionDatabase(rs *terraform.ResourceState) string {
+	return rs.Primary.Attributes["database"]
+}
+
+func checkExtensionExists(client *Client, database, extensionName string) (bool, error) {
+	txn, err := startTransaction(client, database)
+	if err != nil {
+		return false, err
+	}
+	defer deferredRollback(txn)
+
 	var _rez bool
-	err := client.DB().QueryRow("SELECT TRUE from pg_catalog.pg_extension d WHERE extname=$1", extensionName).Scan(&_rez)
+	err = txn.QueryRow("SELECT TRUE from pg_catalog.pg_extension d WHERE extname=$1", extensionName).Scan(&_rez)
 	switch {
 	case err == sql.ErrNoRows:
 		return false, nil
@@ -164,3 +470,44 @@ resource "postgresql_extension" "ext1trgm" {
   schema = "${postgresql_schema.ext1foo.name}"
 }
 `
+
+var testAccPostgresqlExtensionCascadeConfig = `
+resource "postgresql_extension" "cascade" {
+  name = "earthdistance"
+  cascade = true
+}
+`
+
+var testAccPostgresqlExtensionLatestConfig = `
+resource "postgresql_extension" "latest" {
+  name = "pg_trgm"
+  version = "latest"
+}
+`
+
+var testAccPostgresqlExtensionConstraintConfig = `
+resource "postgresql_extension" "latest" {
+  name = "pg_trgm"
+  version = ">= 1.0"
+}
+`
+
+var testAccPostgresqlExtensionStrictConfig = `
+resource "postgresql_extension" "first" {
+  name = "pg_trgm"
+}
+
+resource "postgresql_extension" "second" {
+  name = "pg_trgm"
+  if_not_exists = false
+
+  depends_on = ["postgresql_extension.first"]
+}
+`
+
+var testAccPostgresqlExtensionUnavailableVersionConfig = `
+resource "postgresql_extension" "unavailable" {
+  name = "pg_trgm"
+  version = "999.0"
+}
+`
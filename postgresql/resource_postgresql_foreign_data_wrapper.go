@@ -0,0 +1,252 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	fdwNameAttr      = "name"
+	fdwDatabaseAttr  = "database"
+	fdwHandlerAttr   = "handler"
+	fdwValidatorAttr = "validator"
+	fdwOptionsAttr   = "options"
+)
+
+// resourcePostgreSQLForeignDataWrapper manages a foreign data wrapper. The wrappers of the
+// extensions (e.g.: postgres_fdw) are created with the extension (see postgresql_extension).
+func resourcePostgreSQLForeignDataWrapper() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLForeignDataWrapperCreate),
+		Read:   resourcePostgreSQLForeignDataWrapperRead,
+		Update: retryOnTransientErrors(resourcePostgreSQLForeignDataWrapperUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLForeignDataWrapperDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLForeignDataWrapperImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			fdwNameAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The name of the foreign data wrapper",
+			},
+			fdwDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database in which the foreign data wrapper is created",
+			},
+			fdwHandlerAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The handler function of the foreign data wrapper",
+			},
+			fdwValidatorAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The validator function of the options of the foreign data wrapper",
+			},
+			fdwOptionsAttr: {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The options of the foreign data wrapper",
+			},
+		},
+	}
+}
+
+func resourcePostgreSQLForeignDataWrapperCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getForeignDataWrapperDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	fdwName := d.Get(fdwNameAttr).(string)
+
+	b := &strings.Builder{}
+	fmt.Fprint(b, "CREATE FOREIGN DATA WRAPPER ", pqQuoteIdentifier(fdwName))
+	if handler, ok := d.GetOk(fdwHandlerAttr); ok {
+		fmt.Fprint(b, " HANDLER ", handler.(string))
+	}
+	if validator, ok := d.GetOk(fdwValidatorAttr); ok {
+		fmt.Fprint(b, " VALIDATOR ", validator.(string))
+	}
+	fmt.Fprint(b, createFDWOptions(d.Get(fdwOptionsAttr).(map[string]interface{})))
+
+	if _, err := txn.ExecContext(c.ctx, b.String()); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error creating foreign data wrapper %s: {{err}}", fdwName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing foreign data wrapper: {{err}}", err)
+	}
+
+	d.Set(fdwDatabaseAttr, database)
+	d.SetId(generateForeignDataWrapperID(d))
+
+	return resourcePostgreSQLForeignDataWrapperReadImpl(d, c)
+}
+
+func resourcePostgreSQLForeignDataWrapperRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	defer c.rLockDatabase(getForeignDataWrapperDatabase(d, c))()
+
+	return resourcePostgreSQLForeignDataWrapperReadImpl(d, c)
+}
+
+func resourcePostgreSQLForeignDataWrapperReadImpl(d *schema.ResourceData, c *Client) error {
+	database := getForeignDataWrapperDatabase(d, c)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	var handler, validator string
+	var options []string
+	err = txn.QueryRowContext(c.ctx, `
+SELECT COALESCE(NULLIF(fdwhandler, 0)::regproc::text, ''), COALESCE(NULLIF(fdwvalidator, 0)::regproc::text, ''),
+	COALESCE(fdwoptions, '{}')
+FROM pg_catalog.pg_foreign_data_wrapper
+WHERE fdwname = $1`,
+		d.Get(fdwNameAttr),
+	).Scan(&handler, &validator, pgArray(&options))
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] PostgreSQL foreign data wrapper (%s) not found", d.Id())
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading foreign data wrapper: {{err}}", err)
+	}
+
+	d.Set(fdwHandlerAttr, handler)
+	d.Set(fdwValidatorAttr, validator)
+	d.Set(fdwOptionsAttr, flattenFDWOptions(options))
+	d.Set(fdwDatabaseAttr, database)
+	d.SetId(generateForeignDataWrapperID(d))
+
+	return nil
+}
+
+func resourcePostgreSQLForeignDataWrapperUpdate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getForeignDataWrapperDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	fdwName := d.Get(fdwNameAttr).(string)
+	alter := fmt.Sprintf("ALTER FOREIGN DATA WRAPPER %s ", pqQuoteIdentifier(fdwName))
+
+	var queries []string
+	if d.HasChange(fdwHandlerAttr) {
+		if handler := d.Get(fdwHandlerAttr).(string); handler != "" {
+			queries = append(queries, alter+"HANDLER "+handler)
+		} else {
+			queries = append(queries, alter+"NO HANDLER")
+		}
+	}
+	if d.HasChange(fdwValidatorAttr) {
+		if validator := d.Get(fdwValidatorAttr).(string); validator != "" {
+			queries = append(queries, alter+"VALIDATOR "+validator)
+		} else {
+			queries = append(queries, alter+"NO VALIDATOR")
+		}
+	}
+	if d.HasChange(fdwOptionsAttr) {
+		oldOptions, newOptions := d.GetChange(fdwOptionsAttr)
+		if alterOptions := alterFDWOptions(oldOptions.(map[string]interface{}), newOptions.(map[string]interface{})); alterOptions != "" {
+			queries = append(queries, fmt.Sprintf("%sOPTIONS (%s)", alter, alterOptions))
+		}
+	}
+
+	if err := execQueries(c.ctx, txn, queries); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error updating foreign data wrapper %s: {{err}}", fdwName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing foreign data wrapper: {{err}}", err)
+	}
+
+	return resourcePostgreSQLForeignDataWrapperReadImpl(d, c)
+}
+
+func resourcePostgreSQLForeignDataWrapperDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getForeignDataWrapperDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	// The wrapper is not dropped if foreign servers still use it.
+	fdwName := d.Get(fdwNameAttr).(string)
+	if _, err := txn.ExecContext(c.ctx, fmt.Sprintf("DROP FOREIGN DATA WRAPPER IF EXISTS %s", pqQuoteIdentifier(fdwName))); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error deleting foreign data wrapper %s: {{err}}", fdwName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing foreign data wrapper deletion: {{err}}", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// resourcePostgreSQLForeignDataWrapperImport imports a foreign data wrapper
+// from an ID with the database/name format.
+func resourcePostgreSQLForeignDataWrapperImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), "database/name")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set(fdwDatabaseAttr, parts[0])
+	d.Set(fdwNameAttr, parts[1])
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func getForeignDataWrapperDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(fdwDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generateForeignDataWrapperID(d *schema.ResourceData) string {
+	return strings.Join([]string{d.Get(fdwDatabaseAttr).(string), d.Get(fdwNameAttr).(string)}, ".")
+}
@@ -0,0 +1,256 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	foreignServerNameAttr     = "name"
+	foreignServerDatabaseAttr = "database"
+	foreignServerFDWAttr      = "foreign_data_wrapper"
+	foreignServerTypeAttr     = "type"
+	foreignServerVersionAttr  = "version"
+	foreignServerOptionsAttr  = "options"
+)
+
+// resourcePostgreSQLForeignServer manages a foreign server of any foreign data wrapper,
+// its user mappings are managed by postgresql_user_mapping.
+func resourcePostgreSQLForeignServer() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLForeignServerCreate),
+		Read:   resourcePostgreSQLForeignServerRead,
+		Update: retryOnTransientErrors(resourcePostgreSQLForeignServerUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLForeignServerDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLForeignServerImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			foreignServerNameAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The name of the foreign server",
+			},
+			foreignServerDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database in which the foreign server is created",
+			},
+			foreignServerFDWAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The foreign data wrapper of the server (e.g.: postgres_fdw)",
+			},
+			foreignServerTypeAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The type of the server, used by some foreign data wrappers",
+			},
+			foreignServerVersionAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The version of the server, used by some foreign data wrappers",
+			},
+			foreignServerOptionsAttr: {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The options of the server (e.g.: host, port and dbname for postgres_fdw)",
+			},
+		},
+	}
+}
+
+func resourcePostgreSQLForeignServerCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getForeignServerDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	serverName := d.Get(foreignServerNameAttr).(string)
+
+	b := &strings.Builder{}
+	fmt.Fprint(b, "CREATE SERVER ", pqQuoteIdentifier(serverName))
+	if serverType, ok := d.GetOk(foreignServerTypeAttr); ok {
+		fmt.Fprintf(b, " TYPE '%s'", pqQuoteLiteral(serverType.(string)))
+	}
+	if version, ok := d.GetOk(foreignServerVersionAttr); ok {
+		fmt.Fprintf(b, " VERSION '%s'", pqQuoteLiteral(version.(string)))
+	}
+	fmt.Fprint(b, " FOREIGN DATA WRAPPER ", pqQuoteIdentifier(d.Get(foreignServerFDWAttr).(string)))
+	fmt.Fprint(b, createFDWOptions(d.Get(foreignServerOptionsAttr).(map[string]interface{})))
+
+	if _, err := txn.ExecContext(c.ctx, b.String()); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error creating foreign server %s: {{err}}", serverName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing foreign server: {{err}}", err)
+	}
+
+	d.Set(foreignServerDatabaseAttr, database)
+	d.SetId(generateForeignServerID(d))
+
+	return resourcePostgreSQLForeignServerReadImpl(d, c)
+}
+
+func resourcePostgreSQLForeignServerRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	defer c.rLockDatabase(getForeignServerDatabase(d, c))()
+
+	return resourcePostgreSQLForeignServerReadImpl(d, c)
+}
+
+func resourcePostgreSQLForeignServerReadImpl(d *schema.ResourceData, c *Client) error {
+	database := getForeignServerDatabase(d, c)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	var fdwName, serverType, version string
+	var options []string
+	err = txn.QueryRowContext(c.ctx, `
+SELECT w.fdwname, COALESCE(s.srvtype, ''), COALESCE(s.srvversion, ''), COALESCE(s.srvoptions, '{}')
+FROM pg_catalog.pg_foreign_server s
+JOIN pg_catalog.pg_foreign_data_wrapper w ON w.oid = s.srvfdw
+WHERE s.srvname = $1`,
+		d.Get(foreignServerNameAttr),
+	).Scan(&fdwName, &serverType, &version, pgArray(&options))
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] PostgreSQL foreign server (%s) not found", d.Id())
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading foreign server: {{err}}", err)
+	}
+
+	d.Set(foreignServerFDWAttr, fdwName)
+	d.Set(foreignServerTypeAttr, serverType)
+	d.Set(foreignServerVersionAttr, version)
+	d.Set(foreignServerOptionsAttr, flattenFDWOptions(options))
+	d.Set(foreignServerDatabaseAttr, database)
+	d.SetId(generateForeignServerID(d))
+
+	return nil
+}
+
+func resourcePostgreSQLForeignServerUpdate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getForeignServerDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	serverName := d.Get(foreignServerNameAttr).(string)
+	alter := fmt.Sprintf("ALTER SERVER %s ", pqQuoteIdentifier(serverName))
+
+	var queries []string
+	if d.HasChange(foreignServerVersionAttr) {
+		if version := d.Get(foreignServerVersionAttr).(string); version != "" {
+			queries = append(queries, fmt.Sprintf("%sVERSION '%s'", alter, pqQuoteLiteral(version)))
+		} else {
+			queries = append(queries, alter+"VERSION NULL")
+		}
+	}
+	if d.HasChange(foreignServerOptionsAttr) {
+		oldOptions, newOptions := d.GetChange(foreignServerOptionsAttr)
+		if alterOptions := alterFDWOptions(oldOptions.(map[string]interface{}), newOptions.(map[string]interface{})); alterOptions != "" {
+			queries = append(queries, fmt.Sprintf("%sOPTIONS (%s)", alter, alterOptions))
+		}
+	}
+
+	if err := execQueries(c.ctx, txn, queries); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error updating foreign server %s: {{err}}", serverName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing foreign server: {{err}}", err)
+	}
+
+	return resourcePostgreSQLForeignServerReadImpl(d, c)
+}
+
+func resourcePostgreSQLForeignServerDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getForeignServerDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	// The server is not dropped if user mappings or foreign tables still use it.
+	serverName := d.Get(foreignServerNameAttr).(string)
+	if _, err := txn.ExecContext(c.ctx, fmt.Sprintf("DROP SERVER IF EXISTS %s", pqQuoteIdentifier(serverName))); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error deleting foreign server %s: {{err}}", serverName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing foreign server deletion: {{err}}", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// resourcePostgreSQLForeignServerImport imports a foreign server
+// from an ID with the database/name format.
+func resourcePostgreSQLForeignServerImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), "database/name")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set(foreignServerDatabaseAttr, parts[0])
+	d.Set(foreignServerNameAttr, parts[1])
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func getForeignServerDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(foreignServerDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generateForeignServerID(d *schema.ResourceData) string {
+	return strings.Join([]string{d.Get(foreignServerDatabaseAttr).(string), d.Get(foreignServerNameAttr).(string)}, ".")
+}
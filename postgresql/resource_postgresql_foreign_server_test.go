@@ -0,0 +1,115 @@
+package postgresql
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestCreateFDWOptions(t *testing.T) {
+	tests := []struct {
+		options  map[string]interface{}
+		expected string
+	}{
+		{map[string]interface{}{}, ""},
+		{map[string]interface{}{"port": "5432", "host": "db"}, ` OPTIONS ("host" 'db', "port" '5432')`},
+		{map[string]interface{}{"password": "it's"}, ` OPTIONS ("password" 'it''s')`},
+	}
+
+	for _, test := range tests {
+		if actual := createFDWOptions(test.options); actual != test.expected {
+			t.Errorf("createFDWOptions(%v): expected %q, got %q", test.options, test.expected, actual)
+		}
+	}
+}
+
+func TestAccPostgresqlForeignServer_Basic(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, true)
+	defer teardown()
+
+	dbName, roleName := getTestDBNames(dbSuffix)
+
+	// A wrapper without handler nor validator accepts any option,
+	// the foreign tables cannot be queried though.
+	testAccConfig := func(host, password string) string {
+		return fmt.Sprintf(`
+resource "postgresql_foreign_data_wrapper" "test" {
+  database = "%[1]s"
+  name     = "test_fdw"
+  options = {
+    debug = "true"
+  }
+}
+
+resource "postgresql_foreign_server" "test" {
+  database             = "%[1]s"
+  name                 = "remote"
+  foreign_data_wrapper = "${postgresql_foreign_data_wrapper.test.name}"
+  version              = "16"
+  options = {
+    host   = "%[2]s"
+    dbname = "app"
+  }
+}
+
+resource "postgresql_user_mapping" "test" {
+  database    = "%[1]s"
+  server_name = "${postgresql_foreign_server.test.name}"
+  user        = "%[3]s"
+  options = {
+    user     = "remote_user"
+    password = "%[4]s"
+  }
+}
+`, dbName, host, roleName, password)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfig("remote-1", "secret"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_foreign_data_wrapper.test", "id", fmt.Sprintf("%s.test_fdw", dbName)),
+					resource.TestCheckResourceAttr("postgresql_foreign_data_wrapper.test", "handler", ""),
+					resource.TestCheckResourceAttr("postgresql_foreign_data_wrapper.test", "options.debug", "true"),
+					resource.TestCheckResourceAttr("postgresql_foreign_server.test", "id", fmt.Sprintf("%s.remote", dbName)),
+					resource.TestCheckResourceAttr("postgresql_foreign_server.test", "options.host", "remote-1"),
+					resource.TestCheckResourceAttr("postgresql_user_mapping.test", "id", fmt.Sprintf("%s.remote.%s", dbName, roleName)),
+					resource.TestCheckResourceAttr("postgresql_user_mapping.test", "options.password", "secret"),
+				),
+			},
+			{
+				Config: testAccConfig("remote-2", "changed"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_foreign_server.test", "options.host", "remote-2"),
+					resource.TestCheckResourceAttr("postgresql_user_mapping.test", "options.password", "changed"),
+				),
+			},
+			{
+				ResourceName:      "postgresql_foreign_data_wrapper.test",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("%s/test_fdw", dbName),
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "postgresql_foreign_server.test",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("%s/remote", dbName),
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "postgresql_user_mapping.test",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("%s/remote/%s", dbName, roleName),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
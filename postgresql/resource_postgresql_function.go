@@ -0,0 +1,457 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+const (
+	funcNameAttr            = "name"
+	funcDatabaseAttr        = "database"
+	funcSchemaAttr          = "schema"
+	funcArgsAttr            = "args"
+	funcArgNameAttr         = "name"
+	funcArgTypeAttr         = "type"
+	funcArgModeAttr         = "mode"
+	funcArgDefaultAttr      = "default"
+	funcReturnsAttr         = "returns"
+	funcLanguageAttr        = "language"
+	funcBodyAttr            = "body"
+	funcSecurityDefinerAttr = "security_definer"
+	funcVolatilityAttr      = "volatility"
+)
+
+var (
+	funcArgModes = []string{"IN", "OUT", "INOUT", "VARIADIC"}
+
+	funcVolatilities = []string{"VOLATILE", "STABLE", "IMMUTABLE"}
+
+	// funcArgModesByCode maps the codes of pg_proc.proargmodes to the modes of the arguments.
+	funcArgModesByCode = map[string]string{"i": "IN", "o": "OUT", "b": "INOUT", "v": "VARIADIC"}
+
+	// funcVolatilitiesByCode maps the codes of pg_proc.provolatile to the volatilities.
+	funcVolatilitiesByCode = map[string]string{"v": "VOLATILE", "s": "STABLE", "i": "IMMUTABLE"}
+)
+
+// resourcePostgreSQLFunction manages a function with CREATE OR REPLACE FUNCTION.
+// A function is identified by its schema, name and the types of its input arguments,
+// so changing them (or its return type, which cannot be replaced) recreates it.
+func resourcePostgreSQLFunction() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLFunctionCreate),
+		Read:   resourcePostgreSQLFunctionRead,
+		Update: retryOnTransientErrors(resourcePostgreSQLFunctionUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLFunctionDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLFunctionImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			funcNameAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The name of the function",
+			},
+			funcDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database in which the function is created",
+			},
+			funcSchemaAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "public",
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The schema in which the function is created",
+			},
+			funcArgsAttr: {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The arguments of the function",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						funcArgNameAttr: {
+							Type:        schema.TypeString,
+							Optional:    true,
+							ForceNew:    true,
+							Description: "The name of the argument",
+						},
+						funcArgTypeAttr: {
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "The data type of the argument",
+						},
+						funcArgModeAttr: {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "IN",
+							ForceNew:     true,
+							ValidateFunc: validation.StringInSlice(funcArgModes, true),
+							Description:  "The mode of the argument (IN, OUT, INOUT or VARIADIC)",
+						},
+						funcArgDefaultAttr: {
+							Type:        schema.TypeString,
+							Optional:    true,
+							ForceNew:    true,
+							Description: "The default value of the argument (an SQL expression)",
+						},
+					},
+				},
+			},
+			funcReturnsAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "The return type of the function (e.g.: integer, SETOF text, TABLE(id int, name text)), deduced from the OUT arguments if not set",
+			},
+			funcLanguageAttr: {
+				Type:             schema.TypeString,
+				Required:         true,
+				DiffSuppressFunc: suppressCaseDiff,
+				Description:      "The language of the function (e.g.: sql, plpgsql)",
+			},
+			funcBodyAttr: {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The body of the function",
+			},
+			funcSecurityDefinerAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Execute the function with the privileges of its owner instead of the caller",
+			},
+			funcVolatilityAttr: {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          "VOLATILE",
+				ValidateFunc:     validation.StringInSlice(funcVolatilities, true),
+				DiffSuppressFunc: suppressCaseDiff,
+				Description:      "The volatility of the function (VOLATILE, STABLE or IMMUTABLE)",
+			},
+		},
+	}
+}
+
+func suppressCaseDiff(k, old, new string, d *schema.ResourceData) bool {
+	return strings.EqualFold(old, new)
+}
+
+func resourcePostgreSQLFunctionCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getFunctionDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	if err := createOrReplaceFunction(d, c, database); err != nil {
+		return err
+	}
+
+	d.Set(funcDatabaseAttr, database)
+
+	return resourcePostgreSQLFunctionReadImpl(d, c)
+}
+
+func resourcePostgreSQLFunctionRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	defer c.rLockDatabase(getFunctionDatabase(d, c))()
+
+	return resourcePostgreSQLFunctionReadImpl(d, c)
+}
+
+// resourcePostgreSQLFunctionReadImpl reads the function from pg_proc.
+// The arguments are not read back: the function is found by the types of its input arguments,
+// and the server would format them differently from the configuration (e.g.: int and integer).
+func resourcePostgreSQLFunctionReadImpl(d *schema.ResourceData, c *Client) error {
+	database := getFunctionDatabase(d, c)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	var argTypes, returns, language, body, volatility string
+	var securityDefiner bool
+	err = txn.QueryRowContext(c.ctx, `
+SELECT pg_catalog.oidvectortypes(p.proargtypes), pg_catalog.pg_get_function_result(p.oid),
+	l.lanname, p.prosrc, p.prosecdef, p.provolatile
+FROM pg_catalog.pg_proc p
+JOIN pg_catalog.pg_language l ON l.oid = p.prolang
+WHERE p.oid = pg_catalog.to_regprocedure($1)`,
+		functionSignature(d),
+	).Scan(&argTypes, &returns, &language, &body, &securityDefiner, &volatility)
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] PostgreSQL function (%s) not found", d.Id())
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading function: {{err}}", err)
+	}
+
+	// Keep the return type of the configuration if the server formats it differently.
+	if configured, ok := d.GetOk(funcReturnsAttr); ok {
+		sameType, err := isSameFunctionReturnType(c, txn, configured.(string), returns)
+		if err != nil {
+			return err
+		}
+		if sameType {
+			returns = configured.(string)
+		}
+	}
+
+	d.Set(funcReturnsAttr, returns)
+	d.Set(funcLanguageAttr, language)
+	d.Set(funcBodyAttr, body)
+	d.Set(funcSecurityDefinerAttr, securityDefiner)
+	d.Set(funcVolatilityAttr, funcVolatilitiesByCode[volatility])
+	d.Set(funcDatabaseAttr, database)
+	d.SetId(generateFunctionID(d, argTypes))
+
+	return nil
+}
+
+func resourcePostgreSQLFunctionUpdate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getFunctionDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	if err := createOrReplaceFunction(d, c, database); err != nil {
+		return err
+	}
+
+	return resourcePostgreSQLFunctionReadImpl(d, c)
+}
+
+func resourcePostgreSQLFunctionDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getFunctionDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	if _, err := txn.ExecContext(c.ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s", functionSignature(d))); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error deleting function %s: {{err}}", d.Get(funcNameAttr).(string)), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing function deletion: {{err}}", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// resourcePostgreSQLFunctionImport imports a function from an ID with the
+// database/schema/name(argument types) format, e.g.: app/public/add(integer, integer).
+// The arguments are read from the server, without their default values.
+func resourcePostgreSQLFunctionImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	c := meta.(*Client)
+
+	parts := strings.SplitN(d.Id(), "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || strings.Index(parts[2], "(") < 1 || !strings.HasSuffix(parts[2], ")") {
+		return nil, fmt.Errorf("invalid import ID %q, expected format: database/schema/name(argument types)", d.Id())
+	}
+	database, schemaName := parts[0], parts[1]
+	name, argTypesList := parts[2][:strings.Index(parts[2], "(")], parts[2][strings.Index(parts[2], "("):]
+
+	d.Set(funcDatabaseAttr, database)
+	d.Set(funcSchemaAttr, schemaName)
+	d.Set(funcNameAttr, name)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return nil, err
+	}
+	defer deferredRollback(txn)
+
+	var argNames, argModes, argTypes []string
+	err = txn.QueryRowContext(c.ctx, `
+SELECT COALESCE(p.proargnames, '{}'), COALESCE(p.proargmodes::text[], '{}'),
+	ARRAY(
+		SELECT pg_catalog.format_type(t.oid, NULL)
+		FROM unnest(COALESCE(p.proallargtypes, p.proargtypes::oid[])) WITH ORDINALITY AS t(oid, n)
+		ORDER BY t.n
+	)
+FROM pg_catalog.pg_proc p
+WHERE p.oid = pg_catalog.to_regprocedure($1)`,
+		fmt.Sprintf("%s.%s%s", pqQuoteIdentifier(schemaName), pqQuoteIdentifier(name), argTypesList),
+	).Scan(pgArray(&argNames), pgArray(&argModes), pgArray(&argTypes))
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, fmt.Errorf("function %s not found", d.Id())
+	case err != nil:
+		return nil, errwrap.Wrapf("Error reading function: {{err}}", err)
+	}
+
+	// The columns of RETURNS TABLE (mode t) are part of the return type.
+	args := []interface{}{}
+	for i, argType := range argTypes {
+		arg := map[string]interface{}{
+			funcArgTypeAttr: argType,
+			funcArgModeAttr: "IN",
+		}
+		if i < len(argNames) {
+			arg[funcArgNameAttr] = argNames[i]
+		}
+		if i < len(argModes) {
+			if argModes[i] == "t" {
+				continue
+			}
+			arg[funcArgModeAttr] = funcArgModesByCode[argModes[i]]
+		}
+		args = append(args, arg)
+	}
+	d.Set(funcArgsAttr, args)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// createOrReplaceFunction creates the function, or replaces its definition if it already exists.
+func createOrReplaceFunction(d *schema.ResourceData, c *Client, database string) error {
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	args := []string{}
+	for _, arg := range d.Get(funcArgsAttr).([]interface{}) {
+		arg := arg.(map[string]interface{})
+
+		parts := []string{strings.ToUpper(arg[funcArgModeAttr].(string))}
+		if name := arg[funcArgNameAttr].(string); name != "" {
+			parts = append(parts, pqQuoteIdentifier(name))
+		}
+		parts = append(parts, arg[funcArgTypeAttr].(string))
+		if def := arg[funcArgDefaultAttr].(string); def != "" {
+			parts = append(parts, "DEFAULT", def)
+		}
+		args = append(args, strings.Join(parts, " "))
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "CREATE OR REPLACE FUNCTION %s.%s(%s)",
+		pqQuoteIdentifier(d.Get(funcSchemaAttr).(string)),
+		pqQuoteIdentifier(d.Get(funcNameAttr).(string)),
+		strings.Join(args, ", "),
+	)
+	if returns, ok := d.GetOk(funcReturnsAttr); ok {
+		fmt.Fprint(b, " RETURNS ", returns.(string))
+	}
+	fmt.Fprint(b, " LANGUAGE ", pqQuoteIdentifier(strings.ToLower(d.Get(funcLanguageAttr).(string))))
+	fmt.Fprint(b, " ", strings.ToUpper(d.Get(funcVolatilityAttr).(string)))
+	if d.Get(funcSecurityDefinerAttr).(bool) {
+		fmt.Fprint(b, " SECURITY DEFINER")
+	} else {
+		fmt.Fprint(b, " SECURITY INVOKER")
+	}
+	fmt.Fprint(b, " AS ", dollarQuote(d.Get(funcBodyAttr).(string)))
+
+	if _, err := txn.ExecContext(c.ctx, b.String()); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error creating function %s: {{err}}", d.Get(funcNameAttr).(string)), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing function: {{err}}", err)
+	}
+
+	return nil
+}
+
+// functionSignature returns the qualified name of the function with the types
+// of its input arguments, as expected by DROP FUNCTION and regprocedure.
+func functionSignature(d *schema.ResourceData) string {
+	argTypes := []string{}
+	for _, arg := range d.Get(funcArgsAttr).([]interface{}) {
+		arg := arg.(map[string]interface{})
+		if strings.ToUpper(arg[funcArgModeAttr].(string)) != "OUT" {
+			argTypes = append(argTypes, arg[funcArgTypeAttr].(string))
+		}
+	}
+
+	return fmt.Sprintf("%s.%s(%s)",
+		pqQuoteIdentifier(d.Get(funcSchemaAttr).(string)),
+		pqQuoteIdentifier(d.Get(funcNameAttr).(string)),
+		strings.Join(argTypes, ", "),
+	)
+}
+
+// isSameFunctionReturnType returns whether the configured return type of a function is the type
+// returned by the server, which formats the types with their canonical names (e.g.: int is integer).
+func isSameFunctionReturnType(c *Client, txn *sql.Tx, configured, actual string) (bool, error) {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.Join(strings.Fields(s), " "))
+	}
+	if normalize(configured) == normalize(actual) {
+		return true, nil
+	}
+
+	// The columns of RETURNS TABLE are not normalized, to_regtype would fail to parse them.
+	if strings.HasPrefix(normalize(configured), "table") {
+		return false, nil
+	}
+
+	setOf := ""
+	if fields := strings.Fields(configured); len(fields) > 1 && strings.EqualFold(fields[0], "SETOF") {
+		setOf = "SETOF "
+		configured = strings.Join(fields[1:], " ")
+	}
+
+	var canonical sql.NullString
+	if err := txn.QueryRowContext(c.ctx, "SELECT pg_catalog.format_type(pg_catalog.to_regtype($1), NULL)", configured).Scan(&canonical); err != nil {
+		return false, errwrap.Wrapf("Error reading function return type: {{err}}", err)
+	}
+
+	return canonical.Valid && setOf+canonical.String == actual, nil
+}
+
+// dollarQuote quotes the body of a function with a dollar-quoted string
+// whose tag does not appear in the body.
+func dollarQuote(body string) string {
+	tag := "$function$"
+	for i := 1; strings.Contains(body, tag); i++ {
+		tag = fmt.Sprintf("$function_%d$", i)
+	}
+	return tag + body + tag
+}
+
+func getFunctionDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(funcDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generateFunctionID(d *schema.ResourceData, argTypes string) string {
+	return fmt.Sprintf("%s.%s.%s(%s)",
+		d.Get(funcDatabaseAttr).(string), d.Get(funcSchemaAttr).(string), d.Get(funcNameAttr).(string), argTypes,
+	)
+}
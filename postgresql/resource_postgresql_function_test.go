@@ -0,0 +1,91 @@
+package postgresql
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestDollarQuote(t *testing.T) {
+	tests := []struct {
+		body     string
+		expected string
+	}{
+		{"SELECT 1", "$function$SELECT 1$function$"},
+		{"SELECT '$function$'", "$function_1$SELECT '$function$'$function_1$"},
+		{"SELECT '$function$', '$function_1$'", "$function_2$SELECT '$function$', '$function_1$'$function_2$"},
+	}
+
+	for _, test := range tests {
+		if actual := dollarQuote(test.body); actual != test.expected {
+			t.Errorf("dollarQuote(%q): expected %q, got %q", test.body, test.expected, actual)
+		}
+	}
+}
+
+func TestAccPostgresqlFunction_Basic(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, false)
+	defer teardown()
+
+	dbName, _ := getTestDBNames(dbSuffix)
+
+	testAccConfig := func(body, volatility string) string {
+		return fmt.Sprintf(`
+resource "postgresql_function" "add" {
+  database   = "%s"
+  schema     = "test_schema"
+  name       = "add"
+  returns    = "int"
+  language   = "sql"
+  volatility = "%s"
+  body       = "%s"
+
+  args {
+    name = "a"
+    type = "int"
+  }
+  args {
+    name    = "b"
+    type    = "int"
+    default = "1"
+  }
+}
+`, dbName, volatility, body)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfig("SELECT a + b", "immutable"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_function.add", "id", fmt.Sprintf("%s.test_schema.add(integer, integer)", dbName)),
+					resource.TestCheckResourceAttr("postgresql_function.add", "returns", "int"),
+					resource.TestCheckResourceAttr("postgresql_function.add", "language", "sql"),
+					resource.TestCheckResourceAttr("postgresql_function.add", "body", "SELECT a + b"),
+					resource.TestCheckResourceAttr("postgresql_function.add", "security_definer", "false"),
+				),
+			},
+			{
+				Config: testAccConfig("SELECT a + b + 0", "STABLE"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_function.add", "body", "SELECT a + b + 0"),
+					resource.TestCheckResourceAttr("postgresql_function.add", "volatility", "STABLE"),
+				),
+			},
+			{
+				ResourceName:            "postgresql_function.add",
+				ImportState:             true,
+				ImportStateId:           fmt.Sprintf("%s/test_schema/add(integer, integer)", dbName),
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"args", "returns"},
+			},
+		},
+	})
+}
@@ -1,60 +1,148 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/helper/validation"
-
-	// Use Postgres as SQL driver
-	"github.com/lib/pq"
 )
 
+// objectTypes maps the object types to their default ACL object type (defaclobjtype in pg_default_acl).
 var objectTypes = map[string]string{
 	"table":    "r",
 	"sequence": "S",
+	"function": "f",
+	"type":     "T",
+	"schema":   "n",
+}
+
+// grantObjectTypes is the list of object types supported by postgresql_grant.
+var grantObjectTypes = []string{
+	"table",
+	"sequence",
+	"materialized_view",
+	"tablespace",
+	"parameter",
+}
+
+// grantRelkinds is the list of relation kinds (relkind in pg_class) affected by
+// GRANT ... ON ALL <object_type>S IN SCHEMA.
+// For tables, it includes views, materialized views, foreign tables and partitioned tables.
+var grantRelkinds = map[string][]string{
+	"table":             []string{"r", "v", "m", "f", "p"},
+	"sequence":          []string{"S"},
+	"materialized_view": []string{"m"},
+}
+
+// Object types which are not part of a schema. For these types the
+// privileges are granted on the objects listed in the `objects` attribute
+// (for the other types, `objects` is optional and defaults to all the objects of the schema).
+var globalObjectTypes = []string{
+	"database",
+	"schema",
+	"tablespace",
+	"parameter",
 }
 
 func resourcePostgreSQLGrant() *schema.Resource {
 	return &schema.Resource{
-		Create: resourcePostgreSQLGrantCreate,
+		Create: retryOnConcurrentUpdates(resourcePostgreSQLGrantCreate),
 		// As create revokes and grants we can use it to update too
-		Update: resourcePostgreSQLGrantCreate,
+		Update: retryOnConcurrentUpdates(resourcePostgreSQLGrantCreate),
 		Read:   resourcePostgreSQLGrantRead,
-		Delete: resourcePostgreSQLGrantDelete,
+		Delete: retryOnConcurrentUpdates(resourcePostgreSQLGrantDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLGrantImport,
+		},
+		CustomizeDiff: resourcePostgreSQLGrantCustomizeDiff,
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourcePostgreSQLGrantV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourcePostgreSQLGrantStateUpgradeV0,
+			},
+		},
 
 		Schema: map[string]*schema.Schema{
 			"role": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The name of the role to grant privileges on",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ConflictsWith:    []string{"roles"},
+				ValidateFunc:     validateIdentifier,
+				Description:      "The name of the role to grant privileges on",
+				DiffSuppressFunc: suppressIdentifierCaseDiff,
+			},
+			"roles": &schema.Schema{
+				Type:          schema.TypeSet,
+				Optional:      true,
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				Set:           schema.HashString,
+				ConflictsWith: []string{"role"},
+				Description:   "The names of the roles to grant privileges on (instead of role)",
 			},
 			"database": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The database to grant privileges on for this role",
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database to grant privileges on for this role",
 			},
 			"schema": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The database schema to grant privileges on for this role",
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				ValidateFunc:     validateIdentifier,
+				Description:      "The database schema to grant privileges on for this role (required for table and sequence)",
+				DiffSuppressFunc: suppressIdentifierCaseDiff,
 			},
 			"object_type": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
-				ValidateFunc: validation.StringInSlice([]string{
-					"table",
-					"sequence",
-				}, false),
-				Description: "The PostgreSQL object type to grant the privileges on (one of: table, sequence)",
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(grantObjectTypes, false),
+				Description:  "The PostgreSQL object type to grant the privileges on (one of: table, sequence, materialized_view, tablespace, parameter)",
+			},
+			"objects": &schema.Schema{
+				Type:        schema.TypeSet,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         schema.HashString,
+				Description: "The objects to grant privileges on (required for tablespace and parameter, all objects of the schema if not specified for the other types)",
+			},
+			"except_objects": &schema.Schema{
+				Type:          schema.TypeSet,
+				Optional:      true,
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				Set:           schema.HashString,
+				ConflictsWith: []string{"objects"},
+				Description:   "The objects of the schema to exclude from the grant",
+			},
+			"include_pattern": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"objects"},
+				Description:   "Only grant privileges on the objects of the schema whose name matches this pattern",
+			},
+			"exclude_pattern": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"objects"},
+				Description:   "Do not grant privileges on the objects of the schema whose name matches this pattern",
+			},
+			"pattern_type": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "like",
+				ValidateFunc: validation.StringInSlice([]string{"like", "regex"}, false),
+				Description:  "The syntax of include_pattern and exclude_pattern (like or regex)",
 			},
 			"privileges": &schema.Schema{
 				Type:        schema.TypeSet,
@@ -64,6 +152,26 @@ func resourcePostgreSQLGrant() *schema.Resource {
 				MinItems:    1,
 				Description: "The list of privileges to grant",
 			},
+			"with_grant_option": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Permit the grant recipient to grant it to others",
+			},
+			"grantor": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The role which grants the privileges (GRANTED BY), or CURRENT_USER. Only the privileges granted by this role are read",
+			},
+			"additive": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Only manage the specified privileges and never revoke the other privileges of the role on these objects",
+			},
+			quotedIdentifiersAttr:     quotedIdentifiersSchema(),
+			detectExternalChangesAttr: detectExternalChangesSchema(),
 		},
 	}
 }
@@ -71,6 +179,11 @@ func resourcePostgreSQLGrant() *schema.Resource {
 func resourcePostgreSQLGrantRead(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*Client)
 
+	if client.flavor == flavorRedshift {
+		defer client.rLockDatabase(d.Get("database").(string))()
+		return readRedshiftGrant(client, d)
+	}
+
 	if !client.featureSupported(featurePrivileges) {
 		return fmt.Errorf(
 			"postgresql_grant resource is not supported for this Postgres version (%s)",
@@ -78,10 +191,13 @@ func resourcePostgreSQLGrantRead(d *schema.ResourceData, meta interface{}) error
 		)
 	}
 
-	client.catalogLock.RLock()
-	defer client.catalogLock.RUnlock()
+	if err := checkGrantObjectTypeSupported(client, d); err != nil {
+		return err
+	}
+
+	defer client.rLockDatabase(d.Get("database").(string))()
 
-	exists, err := checkRoleDBSchemaExists(client, d)
+	exists, err := checkRoleDBSchemaExists(client, d, grantGrantees(d))
 	if err != nil {
 		return err
 	}
@@ -91,18 +207,144 @@ func resourcePostgreSQLGrantRead(d *schema.ResourceData, meta interface{}) error
 	}
 	d.SetId(generateGrantID(d))
 
-	txn, err := startTransaction(client, d.Get("database").(string))
+	if !detectExternalChanges(d) {
+		return nil
+	}
+
+	txn, err := startReadTransaction(client, d.Get("database").(string))
 	if err != nil {
 		return err
 	}
 	defer deferredRollback(txn)
 
-	return readRolePrivileges(txn, d)
+	return readRolePrivileges(client, txn, d)
+}
+
+// resourcePostgreSQLGrantImport imports a grant from an ID formatted as
+// role/database/schema/object_type[/objects] (roles and objects being comma separated)
+// and reconstructs the current privileges from the catalog.
+func resourcePostgreSQLGrantImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*Client)
+
+	if client.flavor == flavorRedshift {
+		return nil, fmt.Errorf("postgresql_grant import is not supported on Redshift")
+	}
+
+	if !client.featureSupported(featurePrivileges) {
+		return nil, fmt.Errorf(
+			"postgresql_grant resource is not supported for this Postgres version (%s)",
+			client.version,
+		)
+	}
+
+	parts := strings.Split(d.Id(), "/")
+	if len(parts) != 4 && len(parts) != 5 {
+		return nil, fmt.Errorf(
+			"invalid import ID %q, expected format: role/database/schema/object_type[/objects]", d.Id(),
+		)
+	}
+
+	objectType := parts[3]
+	if !sliceContainsStr(grantObjectTypes, objectType) {
+		return nil, fmt.Errorf("unsupported object type %q in import ID %q", objectType, d.Id())
+	}
+
+	objects := []interface{}{}
+	if len(parts) == 5 && parts[4] != "" {
+		for _, object := range strings.Split(parts[4], ",") {
+			objects = append(objects, object)
+		}
+	}
+
+	if roles := strings.Split(parts[0], ","); len(roles) > 1 {
+		rolesList := []interface{}{}
+		for _, role := range roles {
+			rolesList = append(rolesList, role)
+		}
+		d.Set("roles", schema.NewSet(schema.HashString, rolesList))
+	} else {
+		d.Set("role", parts[0])
+	}
+	d.Set("database", parts[1])
+	d.Set("schema", parts[2])
+	d.Set("object_type", objectType)
+	d.Set("objects", schema.NewSet(schema.HashString, objects))
+	d.Set("additive", false)
+
+	if err := validateGrantTarget(d); err != nil {
+		return nil, err
+	}
+
+	if err := checkGrantObjectTypeSupported(client, d); err != nil {
+		return nil, err
+	}
+
+	defer client.rLockDatabase(d.Get("database").(string))()
+
+	txn, err := startTransaction(client, d.Get("database").(string))
+	if err != nil {
+		return nil, err
+	}
+	defer deferredRollback(txn)
+
+	// The imported privileges are the ones that the roles have on all the objects.
+	var privilegesSet, grantableSet *schema.Set
+	query, queryArgs := rolePrivilegesQuery(client, d, grantGrantees(d))
+	rows, err := txn.QueryContext(client.ctx, query, queryArgs...)
+	if err != nil {
+		return nil, errwrap.Wrapf("could not read privileges: {{err}}", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var role, objName string
+		var privileges, grantablePrivileges []string
+
+		if err := rows.Scan(&role, &objName, pgArray(&privileges), pgArray(&grantablePrivileges)); err != nil {
+			return nil, err
+		}
+
+		if privilegesSet == nil {
+			privilegesSet = pgArrayToSet(privileges)
+			grantableSet = pgArrayToSet(grantablePrivileges)
+			continue
+		}
+
+		objPrivileges := pgArrayToSet(privileges)
+		if !objPrivileges.Equal(privilegesSet) {
+			log.Printf(
+				"[WARN] %s %s has different privileges (%v) than the other imported objects (%v) for role %s",
+				strings.ToTitle(objectType), objName, objPrivileges.List(), privilegesSet.List(), role,
+			)
+		}
+		privilegesSet = privilegesSet.Intersection(objPrivileges)
+		grantableSet = grantableSet.Intersection(pgArrayToSet(grantablePrivileges))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if privilegesSet == nil {
+		privilegesSet = schema.NewSet(schema.HashString, []interface{}{})
+		grantableSet = schema.NewSet(schema.HashString, []interface{}{})
+	}
+
+	d.Set("privileges", privilegesSet)
+	d.Set("with_grant_option", privilegesSet.Len() > 0 && grantableSet.Equal(privilegesSet))
+	d.SetId(generateGrantID(d))
+
+	return []*schema.ResourceData{d}, nil
 }
 
 func resourcePostgreSQLGrantCreate(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*Client)
 
+	if client.flavor == flavorRedshift {
+		defer client.lockDatabase(d.Get("database").(string))()
+		return createRedshiftGrant(client, d)
+	}
+
 	if !client.featureSupported(featurePrivileges) {
 		return fmt.Errorf(
 			"postgresql_grant resource is not supported for this Postgres version (%s)",
@@ -114,10 +356,25 @@ func resourcePostgreSQLGrantCreate(d *schema.ResourceData, meta interface{}) err
 		return err
 	}
 
+	if err := validateGrantTarget(d); err != nil {
+		return err
+	}
+
+	if len(grantGrantees(d)) == 0 {
+		return fmt.Errorf("one of role or roles must be specified")
+	}
+
+	if err := checkSupabaseGrantees(client, grantGrantees(d)); err != nil {
+		return err
+	}
+
+	if err := checkGrantObjectTypeSupported(client, d); err != nil {
+		return err
+	}
+
 	database := d.Get("database").(string)
 
-	client.catalogLock.Lock()
-	defer client.catalogLock.Unlock()
+	defer client.lockDatabase(database)()
 
 	txn, err := startTransaction(client, database)
 	if err != nil {
@@ -125,18 +382,42 @@ func resourcePostgreSQLGrantCreate(d *schema.ResourceData, meta interface{}) err
 	}
 	defer deferredRollback(txn)
 
-	// Revoke all privileges before granting otherwise reducing privileges will not work.
-	// We just have to revoke them in the same transaction so the role will not lost its
-	// privileges between the revoke and grant statements.
-	if err = revokeRolePrivileges(txn, d); err != nil {
+	// The objects are listed once and all the statements are executed in one round trip.
+	target, err := grantTargetClause(client.ctx, txn, d)
+	if err != nil {
 		return err
 	}
 
-	if err = grantRolePrivileges(txn, d); err != nil {
-		return err
+	keptRoles, removedRoles := grantGranteesChange(d)
+	additive := d.Get("additive").(bool)
+	queries := []string{}
+
+	// Roles which have been removed from the resource lose the privileges it managed.
+	if additive {
+		oldPrivileges, _ := d.GetChange("privileges")
+		queries = append(queries, revokeSpecifiedRolePrivileges(d, target, removedRoles, oldPrivileges.(*schema.Set))...)
+	} else {
+		queries = append(queries, revokeRolePrivileges(d, target, removedRoles)...)
+	}
+
+	if additive {
+		// In additive mode, we only revoke the privileges which have been removed
+		// from the configuration.
+		queries = append(queries, revokeRemovedRolePrivileges(d, target, keptRoles)...)
+	} else {
+		// Revoke all privileges before granting otherwise reducing privileges will not work.
+		// We just have to revoke them in the same transaction so the role will not lost its
+		// privileges between the revoke and grant statements.
+		queries = append(queries, revokeRolePrivileges(d, target, grantGrantees(d))...)
 	}
 
-	if err = txn.Commit(); err != nil {
+	queries = append(queries, grantRolePrivileges(d, target)...)
+
+	if err = execQueries(client.ctx, txn, queries); err != nil {
+		return errwrap.Wrapf("could not grant privileges: {{err}}", err)
+	}
+
+	if err = commitTransaction(client, txn); err != nil {
 		return errwrap.Wrapf("could not commit transaction: {{err}}", err)
 	}
 
@@ -148,12 +429,17 @@ func resourcePostgreSQLGrantCreate(d *schema.ResourceData, meta interface{}) err
 	}
 	defer deferredRollback(txn)
 
-	return readRolePrivileges(txn, d)
+	return readRolePrivileges(client, txn, d)
 }
 
 func resourcePostgreSQLGrantDelete(d *schema.ResourceData, meta interface{}) error {
 	client := meta.(*Client)
 
+	if client.flavor == flavorRedshift {
+		defer client.lockDatabase(d.Get("database").(string))()
+		return deleteRedshiftGrant(client, d)
+	}
+
 	if !client.featureSupported(featurePrivileges) {
 		return fmt.Errorf(
 			"postgresql_grant resource is not supported for this Postgres version (%s)",
@@ -161,8 +447,7 @@ func resourcePostgreSQLGrantDelete(d *schema.ResourceData, meta interface{}) err
 		)
 	}
 
-	client.catalogLock.Lock()
-	defer client.catalogLock.Unlock()
+	defer client.lockDatabase(d.Get("database").(string))()
 
 	txn, err := startTransaction(client, d.Get("database").(string))
 	if err != nil {
@@ -170,141 +455,659 @@ func resourcePostgreSQLGrantDelete(d *schema.ResourceData, meta interface{}) err
 	}
 	defer deferredRollback(txn)
 
-	if err = revokeRolePrivileges(txn, d); err != nil {
+	target, err := grantTargetClause(client.ctx, txn, d)
+	if err != nil {
 		return err
 	}
 
-	if err = txn.Commit(); err != nil {
+	var queries []string
+	if d.Get("additive").(bool) {
+		queries = revokeSpecifiedRolePrivileges(d, target, grantGrantees(d), d.Get("privileges").(*schema.Set))
+	} else {
+		queries = revokeRolePrivileges(d, target, grantGrantees(d))
+	}
+	if err = execQueries(client.ctx, txn, queries); err != nil {
+		return errwrap.Wrapf("could not revoke privileges: {{err}}", err)
+	}
+
+	if err = commitTransaction(client, txn); err != nil {
 		return errwrap.Wrapf("could not commit transaction: {{err}}", err)
 	}
 
 	return nil
 }
 
-func readRolePrivileges(txn *sql.Tx, d *schema.ResourceData) error {
-	// This returns, for the specified role (rolname),
-	// the list of all object of the specified type (relkind) in the specified schema (namespace)
-	// with the list of the currently applied privileges (aggregation of privilege_type)
-	//
-	// Our goal is to check that every object has the same privileges as saved in the state.
-	query := `
-SELECT pg_class.relname, array_remove(array_agg(privilege_type), NULL)
+// rolePrivilegesQuery returns the query (and its arguments) which lists,
+// for each of the roles and each targeted object, the privileges of the role
+// and the grantable ones.
+// All the roles are read at once to avoid a round trip per role.
+func rolePrivilegesQuery(client *Client, d *schema.ResourceData, roles []string) (query string, queryArgs []interface{}) {
+	switch d.Get("object_type").(string) {
+	case "tablespace":
+		// This returns, for each specified role (rolname),
+		// the list of the specified tablespaces (spcname)
+		// with the list of the currently applied privileges (aggregation of privilege_type)
+		// and the list of the grantable ones (aggregation of privilege_type where is_grantable)
+		query = `
+SELECT grantees.rolname, pg_tablespace.spcname, array_remove(array_agg(privilege_type), NULL), array_remove(array_agg(CASE WHEN is_grantable THEN privilege_type END), NULL)
+FROM pg_tablespace
+CROSS JOIN unnest($1::text[]) AS grantees (rolname)
+LEFT JOIN (
+    SELECT acls.*, pg_roles.rolname FROM (
+        SELECT spcname, (aclexplode(spcacl)).* FROM pg_tablespace
+    ) as acls
+    JOIN pg_roles on grantee = pg_roles.oid
+    WHERE rolname = ANY($1)
+    AND ($3::text = '' OR grantor = (SELECT oid FROM pg_roles WHERE rolname = $3::text))
+) privs
+USING (spcname, rolname)
+WHERE spcname = ANY($2)
+GROUP BY grantees.rolname, pg_tablespace.spcname;
+`
+		queryArgs = []interface{}{roles, grantObjects(d), grantorRoleName(client, d)}
+
+	case "parameter":
+		// Parameters are only present in pg_parameter_acl once a privilege
+		// has been granted on them, so we start from the list of requested parameters.
+		query = `
+SELECT grantees.rolname, objects.name, array_remove(array_agg(privs.privilege_type), NULL), array_remove(array_agg(CASE WHEN privs.is_grantable THEN privs.privilege_type END), NULL)
+FROM unnest($2::text[]) AS objects (name)
+CROSS JOIN unnest($1::text[]) AS grantees (rolname)
+LEFT JOIN (
+    SELECT parname, (aclexplode(paracl)).* FROM pg_parameter_acl
+) privs
+ON privs.parname = lower(objects.name)
+AND privs.grantee = (SELECT oid FROM pg_roles WHERE rolname = grantees.rolname)
+AND ($3::text = '' OR privs.grantor = (SELECT oid FROM pg_roles WHERE rolname = $3::text))
+GROUP BY grantees.rolname, objects.name;
+`
+		queryArgs = []interface{}{roles, grantObjects(d), grantorRoleName(client, d)}
+
+	default:
+		// This returns, for each specified role (rolname),
+		// the list of all object of the specified type (relkinds) in the specified schema (namespace)
+		// (or only the specified objects if any)
+		// with the list of the currently applied privileges (aggregation of privilege_type)
+		// and the list of the grantable ones (aggregation of privilege_type where is_grantable)
+		query = `
+SELECT grantees.rolname, pg_class.relname, array_remove(array_agg(privilege_type), NULL), array_remove(array_agg(CASE WHEN is_grantable THEN privilege_type END), NULL)
 FROM pg_class
 JOIN pg_namespace ON pg_namespace.oid = pg_class.relnamespace
+CROSS JOIN unnest($1::text[]) AS grantees (rolname)
 LEFT JOIN (
-    SELECT acls.* FROM (
+    SELECT acls.*, pg_roles.rolname FROM (
         SELECT relname, relnamespace, relkind, (aclexplode(relacl)).* FROM pg_class c
     ) as acls
     JOIN pg_roles on grantee = pg_roles.oid
-    WHERE rolname=$1
+    WHERE rolname = ANY($1)
+    AND ($5::text = '' OR grantor = (SELECT oid FROM pg_roles WHERE rolname = $5::text))
 ) privs
-USING (relname, relnamespace, relkind)
-WHERE nspname = $2 AND relkind = $3
-GROUP BY pg_class.relname;
+USING (relname, relnamespace, relkind, rolname)
+WHERE nspname = $2 AND relkind::text = ANY($3)
+AND (array_length($4::text[], 1) IS NULL OR relname = ANY($4))%s
+GROUP BY grantees.rolname, pg_class.relname;
 `
+		queryArgs = []interface{}{
+			roles, d.Get("schema"), grantRelkinds[d.Get("object_type").(string)], grantObjects(d),
+			grantorRoleName(client, d),
+		}
+
+		filter, filterArgs := grantFilterCondition(d, len(queryArgs))
+		query = fmt.Sprintf(query, filter)
+		queryArgs = append(queryArgs, filterArgs...)
+	}
 
+	return query, queryArgs
+}
+
+// readRolePrivileges checks that every targeted object has the expected privileges
+// for each of the roles.
+func readRolePrivileges(client *Client, txn *sql.Tx, d *schema.ResourceData) error {
 	objectType := d.Get("object_type").(string)
-	rows, err := txn.Query(
-		query, d.Get("role"), d.Get("schema"), objectTypes[objectType],
-	)
+	roles := grantGrantees(d)
+
+	// Our goal is to check that every object has the same privileges as saved in the state.
+	query, queryArgs := rolePrivilegesQuery(client, d, roles)
+	rows, err := txn.QueryContext(client.ctx, query, queryArgs...)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
+
+	foundObjects := make(map[string][]string, len(roles))
+	driftedObjects := make(map[string][]string, len(roles))
 
 	for rows.Next() {
-		var objName string
-		var privileges pq.ByteaArray
+		var role, objName string
+		var privileges, grantablePrivileges []string
 
-		if err := rows.Scan(&objName, &privileges); err != nil {
+		if err := rows.Scan(&role, &objName, pgArray(&privileges), pgArray(&grantablePrivileges)); err != nil {
 			return err
 		}
-		privilegesSet := pgArrayToSet(privileges)
+		foundObjects[role] = append(foundObjects[role], objName)
 
-		if !privilegesSet.Equal(d.Get("privileges").(*schema.Set)) {
-			// If any object doesn't have the same privileges as saved in the state,
-			// we return an empty privileges to force an update.
+		if checkObjectPrivileges(d, role, objName, privileges, grantablePrivileges) {
+			driftedObjects[role] = append(driftedObjects[role], objName)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	drifted := false
+	for _, role := range roles {
+		// Specified objects which have not been found are also considered as drifted.
+		for _, object := range grantObjects(d) {
+			if !sliceContainsStr(foundObjects[role], object) {
+				driftedObjects[role] = append(driftedObjects[role], object)
+			}
+		}
+
+		if len(driftedObjects[role]) > 0 {
 			log.Printf(
-				"[DEBUG] %s %s has not the expected privileges %v for role %s",
-				strings.ToTitle(objectType), objName, privileges, d.Get("role"),
+				"[WARN] %d %s(s) have not the expected privileges for role %s: %s",
+				len(driftedObjects[role]), objectType, role, strings.Join(driftedObjects[role], ", "),
 			)
-			d.Set("privileges", schema.NewSet(schema.HashString, []interface{}{}))
-			break
+			drifted = true
 		}
+	}
 
+	if drifted {
+		// If any object doesn't have the same privileges as saved in the state,
+		// we return an empty privileges to force an update.
+		d.Set("privileges", schema.NewSet(schema.HashString, []interface{}{}))
 	}
 
 	return nil
 }
 
-func grantRolePrivileges(txn *sql.Tx, d *schema.ResourceData) error {
-	privileges := []string{}
-	for _, priv := range d.Get("privileges").(*schema.Set).List() {
-		privileges = append(privileges, priv.(string))
+// checkObjectPrivileges checks that an object has the expected privileges
+// for the specified role and returns true if they have drifted.
+func checkObjectPrivileges(d *schema.ResourceData, role, objName string, privileges, grantablePrivileges []string) bool {
+	objectType := d.Get("object_type").(string)
+	withGrantOption := d.Get("with_grant_option").(bool)
+	additive := d.Get("additive").(bool)
+	expectedPrivileges := d.Get("privileges").(*schema.Set)
+
+	privilegesSet := pgArrayToSet(privileges)
+	grantableSet := pgArrayToSet(grantablePrivileges)
+
+	// In additive mode, the role can have more privileges than the specified ones
+	// (granted by other resources) so we only check that the specified privileges
+	// are present.
+	if additive {
+		privilegesSet = privilegesSet.Intersection(expectedPrivileges)
+		grantableSet = grantableSet.Intersection(expectedPrivileges)
+	}
+
+	// Objects created after the grant (e.g.: new tables in the schema)
+	// will not have any privilege for this role.
+	if !privilegesSet.Equal(expectedPrivileges) {
+		log.Printf(
+			"[DEBUG] %s %s has not the expected privileges %v for role %s",
+			strings.ToTitle(objectType), objName, privileges, role,
+		)
+		return true
+	}
+
+	// We don't check that privileges are not grantable in additive mode
+	// as the grant option can have been given by another resource.
+	grantable := grantableSet.Equal(privilegesSet)
+	if grantable != withGrantOption && (withGrantOption || !additive) {
+		log.Printf(
+			"[DEBUG] %s %s has not the expected grant option (%t) for role %s",
+			strings.ToTitle(objectType), objName, withGrantOption, role,
+		)
+		d.Set("with_grant_option", grantable)
+	}
+
+	return false
+}
+
+func grantRolePrivileges(d *schema.ResourceData, target string) []string {
+	if target == "" {
+		return nil
 	}
 
+	privileges := setToPgPrivileges(d.Get("privileges").(*schema.Set))
+
 	query := fmt.Sprintf(
-		"GRANT %s ON ALL %sS IN SCHEMA %s TO %s",
+		"GRANT %s ON %s TO %s",
 		strings.Join(privileges, ","),
-		strings.ToUpper(d.Get("object_type").(string)),
-		pq.QuoteIdentifier(d.Get("schema").(string)),
-		pq.QuoteIdentifier(d.Get("role").(string)),
+		target,
+		quoteGrantees(grantGrantees(d)),
 	)
 
-	_, err := txn.Exec(query)
-	return err
+	if d.Get("with_grant_option").(bool) {
+		query += " WITH GRANT OPTION"
+	}
+	query += grantedByClause(d)
+
+	return []string{query}
 }
 
-func revokeRolePrivileges(txn *sql.Tx, d *schema.ResourceData) error {
+func revokeRolePrivileges(d *schema.ResourceData, target string, roles []string) []string {
+	if target == "" || len(roles) == 0 {
+		return nil
+	}
+
 	query := fmt.Sprintf(
-		"REVOKE ALL PRIVILEGES ON ALL %sS IN SCHEMA %s FROM %s",
-		strings.ToUpper(d.Get("object_type").(string)),
-		pq.QuoteIdentifier(d.Get("schema").(string)),
-		pq.QuoteIdentifier(d.Get("role").(string)),
+		"REVOKE ALL PRIVILEGES ON %s FROM %s%s",
+		target,
+		quoteGrantees(roles),
+		grantedByClause(d),
 	)
 
-	_, err := txn.Exec(query)
-	return err
+	return []string{query}
 }
 
-func checkRoleDBSchemaExists(client *Client, d *schema.ResourceData) (bool, error) {
-	txn, err := startTransaction(client, "")
-	if err != nil {
-		return false, err
+// revokeSpecifiedRolePrivileges returns the statement revoking only the specified privileges.
+func revokeSpecifiedRolePrivileges(d *schema.ResourceData, target string, roles []string, privilegesSet *schema.Set) []string {
+	privileges := setToPgPrivileges(privilegesSet)
+	if target == "" || len(roles) == 0 || len(privileges) == 0 {
+		return nil
 	}
-	defer deferredRollback(txn)
 
-	// Check the role exists
-	role := d.Get("role").(string)
-	exists, err := roleExists(txn, role)
+	query := fmt.Sprintf(
+		"REVOKE %s ON %s FROM %s%s",
+		strings.Join(privileges, ","),
+		target,
+		quoteGrantees(roles),
+		grantedByClause(d),
+	)
+
+	return []string{query}
+}
+
+// revokeRemovedRolePrivileges returns the statements revoking the privileges which have been removed from
+// the resource and the grant option if it has been disabled.
+func revokeRemovedRolePrivileges(d *schema.ResourceData, target string, roles []string) []string {
+	if target == "" || len(roles) == 0 {
+		return nil
+	}
+
+	role := quoteGrantees(roles)
+	queries := []string{}
+
+	oldRaw, newRaw := d.GetChange("privileges")
+	newPrivileges := newRaw.(*schema.Set)
+	removed := setToPgPrivileges(oldRaw.(*schema.Set).Difference(newPrivileges))
+	if len(removed) > 0 {
+		queries = append(queries, fmt.Sprintf(
+			"REVOKE %s ON %s FROM %s%s", strings.Join(removed, ","), target, role, grantedByClause(d),
+		))
+	}
+
+	if d.HasChange("with_grant_option") && !d.Get("with_grant_option").(bool) {
+		queries = append(queries, fmt.Sprintf(
+			"REVOKE GRANT OPTION FOR %s ON %s FROM %s%s",
+			strings.Join(setToPgPrivileges(newPrivileges), ","), target, role, grantedByClause(d),
+		))
+	}
+
+	return queries
+}
+
+// grantTargetClause returns the target of the GRANT / REVOKE statements
+// (e.g.: ALL TABLES IN SCHEMA "public", TABLE "public"."orders" or TABLESPACE "fast_ssd").
+// It returns an empty string if there is no object to target.
+func grantTargetClause(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) (string, error) {
+	objectType := d.Get("object_type").(string)
+	objects := grantObjects(d)
+
+	if sliceContainsStr(globalObjectTypes, objectType) {
+		for i, object := range objects {
+			objects[i] = pqQuoteIdentifier(object)
+		}
+		return fmt.Sprintf("%s %s", strings.ToUpper(objectType), strings.Join(objects, ",")), nil
+	}
+
+	pgSchema := d.Get("schema").(string)
+
+	sqlObjectType := strings.ToUpper(objectType)
+	if objectType == "materialized_view" {
+		sqlObjectType = "TABLE"
+	}
+
+	// There is no ALL MATERIALIZED VIEWS IN SCHEMA clause and ALL ... IN SCHEMA
+	// cannot be filtered, so in these cases we have to list the objects of the schema.
+	if len(objects) == 0 && (objectType == "materialized_view" || hasGrantFilters(d)) {
+		var err error
+		if objects, err = listGrantObjects(ctx, txn, d); err != nil {
+			return "", err
+		}
+		if len(objects) == 0 {
+			log.Printf("[DEBUG] no %s matching the filters found in schema %s", objectType, pgSchema)
+			return "", nil
+		}
+	}
+
+	if len(objects) > 0 {
+		for i, object := range objects {
+			objects[i] = fmt.Sprintf("%s.%s", pqQuoteIdentifier(pgSchema), pqQuoteIdentifier(object))
+		}
+		return fmt.Sprintf("%s %s", sqlObjectType, strings.Join(objects, ",")), nil
+	}
+
+	return fmt.Sprintf(
+		"ALL %sS IN SCHEMA %s",
+		sqlObjectType,
+		pqQuoteIdentifier(pgSchema),
+	), nil
+}
+
+// listGrantObjects returns the sorted list of objects of the schema targeted by the grant
+// (i.e.: the objects of the right kinds which match the filters).
+func listGrantObjects(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) ([]string, error) {
+	pgSchema := d.Get("schema").(string)
+	queryArgs := []interface{}{pgSchema, grantRelkinds[d.Get("object_type").(string)]}
+
+	filter, filterArgs := grantFilterCondition(d, len(queryArgs))
+	query := fmt.Sprintf(`SELECT relname FROM pg_class
+JOIN pg_namespace ON pg_namespace.oid = pg_class.relnamespace
+WHERE nspname = $1 AND relkind::text = ANY($2)%s
+ORDER BY relname`, filter)
+
+	rows, err := txn.QueryContext(ctx, query, append(queryArgs, filterArgs...)...)
 	if err != nil {
-		return false, err
+		return nil, errwrap.Wrapf(fmt.Sprintf("could not list objects of schema %s: {{err}}", pgSchema), err)
 	}
-	if !exists {
-		log.Printf("[DEBUG] role %s does not exists", role)
-		return false, nil
+	defer rows.Close()
+
+	relations := []string{}
+	for rows.Next() {
+		var relation string
+		if err := rows.Scan(&relation); err != nil {
+			return nil, err
+		}
+		relations = append(relations, relation)
+	}
+
+	return relations, rows.Err()
+}
+
+// hasGrantFilters returns true if the objects of the schema are filtered with
+// except_objects, include_pattern or exclude_pattern.
+func hasGrantFilters(d *schema.ResourceData) bool {
+	_, exceptOk := d.GetOk("except_objects")
+	_, includeOk := d.GetOk("include_pattern")
+	_, excludeOk := d.GetOk("exclude_pattern")
+	return exceptOk || includeOk || excludeOk
+}
+
+// grantFilterCondition returns the SQL conditions on relname (and their arguments)
+// matching the except_objects, include_pattern and exclude_pattern attributes.
+// argsOffset is the number of arguments already used by the query.
+func grantFilterCondition(d *schema.ResourceData, argsOffset int) (string, []interface{}) {
+	operator := "LIKE"
+	if v, ok := d.GetOk("pattern_type"); ok && v.(string) == "regex" {
+		operator = "~"
+	}
+
+	conditions := []string{}
+	args := []interface{}{}
+
+	if v, ok := d.GetOk("except_objects"); ok {
+		except := []string{}
+		for _, object := range v.(*schema.Set).List() {
+			except = append(except, object.(string))
+		}
+		args = append(args, except)
+		conditions = append(conditions, fmt.Sprintf("NOT relname = ANY($%d)", argsOffset+len(args)))
 	}
 
-	// Check the database exists
+	if v, ok := d.GetOk("include_pattern"); ok {
+		args = append(args, v.(string))
+		conditions = append(conditions, fmt.Sprintf("relname %s $%d", operator, argsOffset+len(args)))
+	}
+
+	if v, ok := d.GetOk("exclude_pattern"); ok {
+		args = append(args, v.(string))
+		conditions = append(conditions, fmt.Sprintf("NOT (relname %s $%d)", operator, argsOffset+len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return "\nAND " + strings.Join(conditions, " AND "), args
+}
+
+// grantGrantees returns the sorted list of roles specified in the `role` or `roles` attribute.
+func grantGrantees(d *schema.ResourceData) []string {
+	if role := d.Get("role").(string); role != "" {
+		return []string{role}
+	}
+
+	roles := []string{}
+	for _, role := range d.Get("roles").(*schema.Set).List() {
+		roles = append(roles, role.(string))
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// grantGranteesChange returns the roles which were already targeted before the update
+// and the ones which have been removed from the resource.
+func grantGranteesChange(d *schema.ResourceData) (keptRoles, removedRoles []string) {
+	oldRoles := []string{}
+	if oldRole, _ := d.GetChange("role"); oldRole.(string) != "" {
+		oldRoles = append(oldRoles, oldRole.(string))
+	}
+	oldRolesSet, _ := d.GetChange("roles")
+	for _, role := range oldRolesSet.(*schema.Set).List() {
+		oldRoles = append(oldRoles, role.(string))
+	}
+
+	newRoles := grantGrantees(d)
+	for _, role := range oldRoles {
+		if sliceContainsStr(newRoles, role) {
+			keptRoles = append(keptRoles, role)
+		} else {
+			removedRoles = append(removedRoles, role)
+		}
+	}
+	return keptRoles, removedRoles
+}
+
+// quoteGrantees returns the comma separated list of quoted roles.
+func quoteGrantees(roles []string) string {
+	quoted := make([]string, len(roles))
+	for i, role := range roles {
+		quoted[i] = pqQuoteIdentifier(role)
+	}
+	return strings.Join(quoted, ",")
+}
+
+// grantedByClause returns the GRANTED BY clause of the GRANT / REVOKE statements
+// if a grantor is specified.
+func grantedByClause(d *schema.ResourceData) string {
+	grantor := d.Get("grantor").(string)
+	switch {
+	case grantor == "":
+		return ""
+	case isCurrentUserGrantor(grantor):
+		return " GRANTED BY " + strings.ToUpper(grantor)
+	}
+	return " GRANTED BY " + pqQuoteIdentifier(grantor)
+}
+
+// isCurrentUserGrantor returns true if the grantor is the CURRENT_USER or SESSION_USER keyword.
+func isCurrentUserGrantor(grantor string) bool {
+	return strings.EqualFold(grantor, "CURRENT_USER") || strings.EqualFold(grantor, "SESSION_USER")
+}
+
+// grantorRoleName returns the name of the role of the grantor,
+// the keywords are replaced by the connected role to read the privileges.
+func grantorRoleName(client *Client, d *schema.ResourceData) string {
+	grantor := d.Get("grantor").(string)
+	if isCurrentUserGrantor(grantor) {
+		return client.config.getDatabaseUsername()
+	}
+	return grantor
+}
+
+// checkGrantorSupported checks that the server accepts the grantor: GRANTED BY exists since
+// PostgreSQL 14 but, before PostgreSQL 16, the grantor can only be the connected role.
+func checkGrantorSupported(client *Client, grantor string) error {
+	if grantor == "" {
+		return nil
+	}
+
+	if !client.featureSupported(featureGrantedBy) {
+		return fmt.Errorf(
+			"grantor is not supported for this Postgres version (%s)",
+			client.version,
+		)
+	}
+
+	if !client.featureSupported(featureGrantedByAnyRole) &&
+		!isCurrentUserGrantor(grantor) && grantor != client.config.getDatabaseUsername() {
+		return fmt.Errorf(
+			"grantor %s is not supported for this Postgres version (%s): it must be the connected role (%s) or CURRENT_USER",
+			grantor, client.version, client.config.getDatabaseUsername(),
+		)
+	}
+
+	return nil
+}
+
+// resourcePostgreSQLGrantCustomizeDiff validates the grantor at plan time,
+// it would only be refused by the server during the apply.
+func resourcePostgreSQLGrantCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	client, ok := meta.(*Client)
+	if !ok || client.flavor == flavorRedshift || !d.NewValueKnown("grantor") {
+		return nil
+	}
+
+	return checkGrantorSupported(client, d.Get("grantor").(string))
+}
+
+// grantObjects returns the sorted list of objects specified in the `objects` attribute.
+func grantObjects(d *schema.ResourceData) []string {
+	objects := []string{}
+	for _, object := range d.Get("objects").(*schema.Set).List() {
+		objects = append(objects, object.(string))
+	}
+	sort.Strings(objects)
+	return objects
+}
+
+// checkGrantObjectTypeSupported checks that the object type (and the grantor and privileges)
+// can be managed with the version of the connected server.
+func checkGrantObjectTypeSupported(client *Client, d *schema.ResourceData) error {
+	if err := checkGrantorSupported(client, d.Get("grantor").(string)); err != nil {
+		return err
+	}
+
+	if err := checkPrivilegesSupported(client, d.Get("privileges").(*schema.Set)); err != nil {
+		return err
+	}
+
+	switch d.Get("object_type").(string) {
+	case "parameter":
+		if !client.featureSupported(featureParameterPrivileges) {
+			return fmt.Errorf(
+				"privileges on parameters are not supported for this Postgres version (%s)",
+				client.version,
+			)
+		}
+	case "materialized_view":
+		if !client.featureSupported(featureMaterializedView) {
+			return fmt.Errorf(
+				"materialized views are not supported for this Postgres version (%s)",
+				client.version,
+			)
+		}
+	}
+	return nil
+}
+
+// validateGrantTarget checks that the attributes needed to target the objects
+// are set according to the object type.
+func validateGrantTarget(d *schema.ResourceData) error {
+	objectType := d.Get("object_type").(string)
+	pgSchema := d.Get("schema").(string)
+
+	if sliceContainsStr(globalObjectTypes, objectType) {
+		if pgSchema != "" {
+			return fmt.Errorf("cannot specify schema when object_type is %s", objectType)
+		}
+		if hasGrantFilters(d) {
+			return fmt.Errorf(
+				"cannot specify except_objects, include_pattern or exclude_pattern when object_type is %s", objectType,
+			)
+		}
+		if d.Get("objects").(*schema.Set).Len() == 0 {
+			return fmt.Errorf("objects must be specified when object_type is %s", objectType)
+		}
+		return nil
+	}
+
+	if pgSchema == "" {
+		return fmt.Errorf("schema must be specified when object_type is %s", objectType)
+	}
+	return nil
+}
+
+func checkRoleDBSchemaExists(client *Client, d *schema.ResourceData, roles []string) (bool, error) {
 	database := d.Get("database").(string)
-	exists, err = dbExists(txn, database)
-	if err != nil {
-		return false, err
+
+	// Only the roles and database which are not already known to exist are checked
+	// (PUBLIC always exists), all with the same query.
+	uncheckedRoles := []string{}
+	for _, role := range roles {
+		if !isPublicRole(role) && !client.catalogCache.contains(catalogCacheKey("role", role)) {
+			uncheckedRoles = append(uncheckedRoles, role)
+		}
 	}
-	if !exists {
-		log.Printf("[DEBUG] database %s does not exists", database)
-		return false, nil
+	checkDatabase := !client.catalogCache.contains(catalogCacheKey("database", database))
+
+	if len(uncheckedRoles) > 0 || checkDatabase {
+		var missingRoles []string
+		var databaseExists bool
+		err := client.DB().QueryRowContext(client.ctx, `
+SELECT ARRAY(
+    SELECT rolname FROM unnest($1::text[]) AS roles (rolname)
+    WHERE NOT EXISTS (SELECT 1 FROM pg_roles WHERE pg_roles.rolname = roles.rolname)
+), EXISTS (SELECT 1 FROM pg_database WHERE datname = $2)`,
+			uncheckedRoles, database,
+		).Scan(pgArray(&missingRoles), &databaseExists)
+		if err != nil {
+			return false, errwrap.Wrapf("could not check if roles and database exist: {{err}}", err)
+		}
+
+		if len(missingRoles) > 0 {
+			log.Printf("[DEBUG] role(s) %s do not exist", strings.Join(missingRoles, ", "))
+			return false, nil
+		}
+		for _, role := range uncheckedRoles {
+			client.catalogCache.add(catalogCacheKey("role", role))
+		}
+
+		if !databaseExists {
+			log.Printf("[DEBUG] database %s does not exists", database)
+			return false, nil
+		}
+		client.catalogCache.add(catalogCacheKey("database", database))
 	}
 
-	// Connect on this database to check if schema exists
-	dbTxn, err := startTransaction(client, database)
-	if err != nil {
-		return false, err
+	// Schema is not needed for all object types (e.g.: tablespace)
+	pgSchema := d.Get("schema").(string)
+	if pgSchema == "" {
+		return true, nil
 	}
-	defer dbTxn.Rollback()
 
 	// Check the schema exists (the SQL connection needs to be on the right database)
-	pgSchema := d.Get("schema").(string)
-	exists, err = schemaExists(dbTxn, pgSchema)
+	exists, err := client.catalogCache.exists(catalogCacheKey("schema", database, pgSchema), func() (bool, error) {
+		dbTxn, err := startTransaction(client, database)
+		if err != nil {
+			return false, err
+		}
+		defer deferredRollback(dbTxn)
+
+		return schemaExists(client.ctx, dbTxn, pgSchema)
+	})
 	if err != nil {
 		return false, err
 	}
@@ -316,9 +1119,17 @@ func checkRoleDBSchemaExists(client *Client, d *schema.ResourceData) (bool, erro
 	return true, nil
 }
 
+// generateGrantID returns the ID of the grant, which has the same format
+// as the one used for import: role/database/schema/object_type[/objects]
 func generateGrantID(d *schema.ResourceData) string {
-	return strings.Join([]string{
-		d.Get("role").(string), d.Get("database").(string),
+	parts := []string{
+		strings.Join(grantGrantees(d), ","), d.Get("database").(string),
 		d.Get("schema").(string), d.Get("object_type").(string),
-	}, "_")
+	}
+
+	if objects := grantObjects(d); len(objects) > 0 {
+		parts = append(parts, strings.Join(objects, ","))
+	}
+
+	return strings.Join(parts, "/")
 }
@@ -0,0 +1,64 @@
+package postgresql
+
+import (
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// resourcePostgreSQLGrantV0 is the schema of postgresql_grant in the version 0.4.0,
+// used to decode the states written by this version.
+func resourcePostgreSQLGrantV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"role": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"database": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"schema": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"object_type": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"privileges": {
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+		},
+	}
+}
+
+// resourcePostgreSQLGrantStateUpgradeV0 converts the ID of the grant
+// (role_database_schema_object_type) to the /-separated format of the import IDs
+// and sets the default values of the attributes added since.
+func resourcePostgreSQLGrantStateUpgradeV0(rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	rawState["id"] = strings.Join([]string{
+		stateString(rawState, "role"), stateString(rawState, "database"),
+		stateString(rawState, "schema"), stateString(rawState, "object_type"),
+	}, "/")
+	rawState["pattern_type"] = "like"
+	rawState["with_grant_option"] = false
+	rawState["additive"] = false
+
+	return rawState, nil
+}
+
+// stateString returns the string value of an attribute of a raw state
+// (an empty string if the attribute is not set).
+func stateString(rawState map[string]interface{}, key string) string {
+	v, _ := rawState[key].(string)
+	return v
+}
@@ -0,0 +1,37 @@
+package postgresql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResourcePostgreSQLGrantStateUpgradeV0(t *testing.T) {
+	rawState := map[string]interface{}{
+		"id":          "test_role_test_db_public_table",
+		"role":        "test_role",
+		"database":    "test_db",
+		"schema":      "public",
+		"object_type": "table",
+		"privileges":  []interface{}{"SELECT"},
+	}
+
+	expected := map[string]interface{}{
+		"id":                "test_role/test_db/public/table",
+		"role":              "test_role",
+		"database":          "test_db",
+		"schema":            "public",
+		"object_type":       "table",
+		"privileges":        []interface{}{"SELECT"},
+		"pattern_type":      "like",
+		"with_grant_option": false,
+		"additive":          false,
+	}
+
+	actual, err := resourcePostgreSQLGrantStateUpgradeV0(rawState, nil)
+	if err != nil {
+		t.Fatalf("error upgrading state: %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected state %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,270 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// On Redshift, postgresql_grant manages the privileges on tables (and views).
+// The grantees can be users or groups, using the Redshift ACL notation for
+// groups (e.g.: `group analysts`). The privileges are read from the ACL of the
+// tables as aclexplode() is not available.
+
+// redshiftACLPrivileges maps the privilege letters of the Redshift ACL to their names.
+var redshiftACLPrivileges = map[byte]string{
+	'r': "SELECT",
+	'a': "INSERT",
+	'w': "UPDATE",
+	'd': "DELETE",
+	'x': "REFERENCES",
+	'R': "RULE",
+	't': "TRIGGER",
+	'D': "DROP",
+}
+
+// checkRedshiftGrantSupported checks that the grant only uses the features available on Redshift.
+func checkRedshiftGrantSupported(d *schema.ResourceData) error {
+	if objectType := d.Get("object_type").(string); objectType != "table" {
+		return fmt.Errorf("object_type %s is not supported on Redshift", objectType)
+	}
+	if d.Get("grantor").(string) != "" || d.Get("additive").(bool) || hasGrantFilters(d) {
+		return fmt.Errorf("grantor, additive, except_objects, include_pattern and exclude_pattern are not supported on Redshift")
+	}
+	return nil
+}
+
+// redshiftGrantee returns the quoted grantee, with the GROUP keyword for groups.
+func redshiftGrantee(role string) string {
+	if isPublicRole(role) {
+		return "PUBLIC"
+	}
+	if strings.HasPrefix(strings.ToLower(role), "group ") {
+		return "GROUP " + pqQuoteIdentifier(strings.TrimSpace(role[len("group "):]))
+	}
+	return pqQuoteIdentifier(role)
+}
+
+func redshiftGrantees(roles []string) string {
+	quoted := make([]string, len(roles))
+	for i, role := range roles {
+		quoted[i] = redshiftGrantee(role)
+	}
+	return strings.Join(quoted, ",")
+}
+
+// redshiftGrantTarget returns the tables targeted by the grant.
+func redshiftGrantTarget(d *schema.ResourceData) string {
+	pgSchema := d.Get("schema").(string)
+
+	objects := grantObjects(d)
+	if len(objects) == 0 {
+		return fmt.Sprintf("ALL TABLES IN SCHEMA %s", pqQuoteIdentifier(pgSchema))
+	}
+
+	for i, object := range objects {
+		objects[i] = fmt.Sprintf("%s.%s", pqQuoteIdentifier(pgSchema), pqQuoteIdentifier(object))
+	}
+	return "TABLE " + strings.Join(objects, ",")
+}
+
+func createRedshiftGrant(client *Client, d *schema.ResourceData) error {
+	if err := checkRedshiftGrantSupported(d); err != nil {
+		return err
+	}
+
+	database := d.Get("database").(string)
+	target := redshiftGrantTarget(d)
+
+	// Revoke all privileges before granting otherwise reducing privileges will not work.
+	_, removedRoles := grantGranteesChange(d)
+	roles := append(grantGrantees(d), removedRoles...)
+	queries := []string{
+		fmt.Sprintf("REVOKE ALL ON %s FROM %s", target, redshiftGrantees(roles)),
+	}
+
+	query := fmt.Sprintf(
+		"GRANT %s ON %s TO %s",
+		strings.Join(setToPgPrivileges(d.Get("privileges").(*schema.Set)), ","),
+		target,
+		redshiftGrantees(grantGrantees(d)),
+	)
+	if d.Get("with_grant_option").(bool) {
+		query += " WITH GRANT OPTION"
+	}
+	queries = append(queries, query)
+
+	txn, err := startTransaction(client, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	if err := execQueries(client.ctx, txn, queries); err != nil {
+		return errwrap.Wrapf("could not grant privileges: {{err}}", err)
+	}
+
+	if err := commitTransaction(client, txn); err != nil {
+		return errwrap.Wrapf("could not commit transaction: {{err}}", err)
+	}
+
+	d.SetId(generateGrantID(d))
+
+	return readRedshiftGrant(client, d)
+}
+
+func readRedshiftGrant(client *Client, d *schema.ResourceData) error {
+	if err := checkRedshiftGrantSupported(d); err != nil {
+		return err
+	}
+
+	txn, err := startReadTransaction(client, d.Get("database").(string))
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	d.SetId(generateGrantID(d))
+
+	return readRedshiftTablePrivileges(client.ctx, txn, d)
+}
+
+// readRedshiftTablePrivileges checks that every targeted table has the expected
+// privileges for each of the roles.
+func readRedshiftTablePrivileges(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) error {
+	rows, err := txn.QueryContext(ctx, `
+SELECT c.relname, COALESCE(array_to_string(c.relacl, chr(10)), '')
+FROM pg_catalog.pg_class c JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = $1 AND c.relkind IN ('r', 'v')`,
+		d.Get("schema"),
+	)
+	if err != nil {
+		return errwrap.Wrapf("could not read privileges: {{err}}", err)
+	}
+	defer rows.Close()
+
+	objects := grantObjects(d)
+	roles := grantGrantees(d)
+	foundObjects := []string{}
+	drifted := false
+
+	for rows.Next() {
+		var tableName, tableACL string
+		if err := rows.Scan(&tableName, &tableACL); err != nil {
+			return err
+		}
+		if len(objects) > 0 && !sliceContainsStr(objects, tableName) {
+			continue
+		}
+		foundObjects = append(foundObjects, tableName)
+
+		privileges, grantablePrivileges := parseRedshiftACL(tableACL)
+		for _, role := range roles {
+			grantee := redshiftACLGrantee(role)
+			if checkObjectPrivileges(d, role, tableName, privileges[grantee], grantablePrivileges[grantee]) {
+				drifted = true
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Specified tables which have not been found are also considered as drifted.
+	for _, object := range objects {
+		if !sliceContainsStr(foundObjects, object) {
+			drifted = true
+		}
+	}
+
+	if drifted {
+		// If any table doesn't have the same privileges as saved in the state,
+		// we return an empty privileges to force an update.
+		d.Set("privileges", schema.NewSet(schema.HashString, []interface{}{}))
+	}
+
+	return nil
+}
+
+// redshiftACLGrantee returns the grantee as written in the ACL
+// (`group name` for groups, empty for PUBLIC).
+func redshiftACLGrantee(role string) string {
+	if isPublicRole(role) {
+		return ""
+	}
+	if strings.HasPrefix(strings.ToLower(role), "group ") {
+		return "group " + strings.TrimSpace(role[len("group "):])
+	}
+	return role
+}
+
+// parseRedshiftACL returns, for each grantee of the ACL (one aclitem per line),
+// the list of its privileges and the list of the grantable ones.
+func parseRedshiftACL(acl string) (privileges, grantablePrivileges map[string][]string) {
+	privileges = map[string][]string{}
+	grantablePrivileges = map[string][]string{}
+
+	for _, item := range strings.Split(acl, "\n") {
+		// e.g.: "group analysts"=r*a/owner, the grantee can be quoted.
+		idx, quoted := -1, false
+		for i := 0; i < len(item) && idx == -1; i++ {
+			switch {
+			case item[i] == '"':
+				quoted = !quoted
+			case item[i] == '=' && !quoted:
+				idx = i
+			}
+		}
+		if idx == -1 {
+			continue
+		}
+		grantee := strings.Replace(item[:idx], `"`, "", -1)
+
+		rights := item[idx+1:]
+		if slash := strings.IndexByte(rights, '/'); slash != -1 {
+			rights = rights[:slash]
+		}
+
+		for i := 0; i < len(rights); i++ {
+			privilege, ok := redshiftACLPrivileges[rights[i]]
+			if !ok {
+				continue
+			}
+			privileges[grantee] = append(privileges[grantee], privilege)
+			if i+1 < len(rights) && rights[i+1] == '*' {
+				grantablePrivileges[grantee] = append(grantablePrivileges[grantee], privilege)
+				i++
+			}
+		}
+	}
+
+	return privileges, grantablePrivileges
+}
+
+func deleteRedshiftGrant(client *Client, d *schema.ResourceData) error {
+	if err := checkRedshiftGrantSupported(d); err != nil {
+		return err
+	}
+
+	txn, err := startTransaction(client, d.Get("database").(string))
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	query := fmt.Sprintf("REVOKE ALL ON %s FROM %s", redshiftGrantTarget(d), redshiftGrantees(grantGrantees(d)))
+	if _, err := txn.ExecContext(client.ctx, query); err != nil {
+		return errwrap.Wrapf("could not revoke privileges: {{err}}", err)
+	}
+
+	if err := commitTransaction(client, txn); err != nil {
+		return errwrap.Wrapf("could not commit transaction: {{err}}", err)
+	}
+
+	return nil
+}
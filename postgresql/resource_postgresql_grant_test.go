@@ -1,9 +1,11 @@
 package postgresql
 
 import (
+	"database/sql"
 	"fmt"
 	"testing"
 
+	"github.com/blang/semver"
 	"github.com/hashicorp/terraform/helper/resource"
 	"github.com/hashicorp/terraform/terraform"
 )
@@ -41,6 +43,17 @@ func TestAccPostgresqlGrant(t *testing.T) {
 	}
 	`, dbName, roleName)
 
+	var testGrantSelectWithGrantOption = fmt.Sprintf(`
+	resource "postgresql_grant" "test" {
+		database          = "%s"
+		role              = "%s"
+		schema            = "test_schema"
+		object_type       = "table"
+		privileges        = ["SELECT"]
+		with_grant_option = true
+	}
+	`, dbName, roleName)
+
 	resource.Test(t, resource.TestCase{
 		PreCheck: func() {
 			testAccPreCheck(t)
@@ -81,6 +94,587 @@ func TestAccPostgresqlGrant(t *testing.T) {
 					},
 				),
 			},
+			// Tables created after the grant should be detected and granted on the next apply.
+			{
+				PreConfig: func() {
+					createTestTables(t, dbSuffix, []string{"test_schema.test_table3"})
+				},
+				Config: testGrantSelect,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_grant.test", "privileges.#", "1"),
+					func(*terraform.State) error {
+						return testCheckTablesPrivileges(
+							t, dbSuffix, append(testTables, "test_schema.test_table3"), []string{"SELECT"},
+						)
+					},
+				),
+			},
+			{
+				Config: testGrantSelectWithGrantOption,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_grant.test", "privileges.#", "1"),
+					resource.TestCheckResourceAttr("postgresql_grant.test", "with_grant_option", "true"),
+					func(*terraform.State) error {
+						return testCheckTablesPrivileges(t, dbSuffix, testTables, []string{"SELECT"})
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlGrantObjects(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, true)
+	defer teardown()
+
+	testTables := []string{"test_schema.test_table", "test_schema.test_table2"}
+	createTestTables(t, dbSuffix, testTables)
+
+	dbName, roleName := getTestDBNames(dbSuffix)
+	var testGrantObjects = fmt.Sprintf(`
+	resource "postgresql_grant" "test" {
+		database    = "%s"
+		role        = "%s"
+		schema      = "test_schema"
+		object_type = "table"
+		objects     = ["test_table"]
+		privileges  = ["SELECT"]
+	}
+	`, dbName, roleName)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featurePrivileges)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testGrantObjects,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_grant.test", "objects.#", "1"),
+					resource.TestCheckResourceAttr("postgresql_grant.test", "privileges.#", "1"),
+					func(*terraform.State) error {
+						if err := testCheckTablesPrivileges(t, dbSuffix, []string{"test_schema.test_table"}, []string{"SELECT"}); err != nil {
+							return err
+						}
+						return testCheckTablesPrivileges(t, dbSuffix, []string{"test_schema.test_table2"}, []string{})
+					},
+				),
+			},
+			{
+				ResourceName:      "postgresql_grant.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlGrantAdditive(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, true)
+	defer teardown()
+
+	testTables := []string{"test_schema.test_table"}
+	createTestTables(t, dbSuffix, testTables)
+
+	dbName, roleName := getTestDBNames(dbSuffix)
+
+	// Both resources manage the same role / schema / object type
+	// and must not revoke the privileges of each other.
+	var testGrantAdditive = fmt.Sprintf(`
+	resource "postgresql_grant" "select" {
+		database    = "%[1]s"
+		role        = "%[2]s"
+		schema      = "test_schema"
+		object_type = "table"
+		privileges  = ["SELECT"]
+		additive    = true
+	}
+
+	resource "postgresql_grant" "insert" {
+		database    = "%[1]s"
+		role        = "%[2]s"
+		schema      = "test_schema"
+		object_type = "table"
+		privileges  = ["INSERT"]
+		additive    = true
+	}
+	`, dbName, roleName)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featurePrivileges)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testGrantAdditive,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_grant.select", "privileges.#", "1"),
+					resource.TestCheckResourceAttr("postgresql_grant.insert", "privileges.#", "1"),
+					func(*terraform.State) error {
+						return testCheckTablesPrivileges(t, dbSuffix, testTables, []string{"SELECT", "INSERT"})
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlGrantFilters(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, true)
+	defer teardown()
+
+	testTables := []string{"test_schema.test_table", "test_schema.test_table2", "test_schema.test_staging"}
+	createTestTables(t, dbSuffix, testTables)
+
+	dbName, roleName := getTestDBNames(dbSuffix)
+
+	var testGrantFilters = fmt.Sprintf(`
+	resource "postgresql_grant" "test" {
+		database        = "%s"
+		role            = "%s"
+		schema          = "test_schema"
+		object_type     = "table"
+		except_objects  = ["test_table2"]
+		exclude_pattern = "%%staging%%"
+		privileges      = ["SELECT"]
+	}
+	`, dbName, roleName)
+
+	var testGrantRegex = fmt.Sprintf(`
+	resource "postgresql_grant" "test" {
+		database        = "%s"
+		role            = "%s"
+		schema          = "test_schema"
+		object_type     = "table"
+		include_pattern = "^test_table[0-9]*$"
+		pattern_type    = "regex"
+		privileges      = ["SELECT"]
+	}
+	`, dbName, roleName)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featurePrivileges)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testGrantFilters,
+				Check: resource.ComposeTestCheckFunc(
+					func(*terraform.State) error {
+						return testCheckTablesPrivileges(t, dbSuffix, []string{"test_schema.test_table"}, []string{"SELECT"})
+					},
+					func(*terraform.State) error {
+						return testCheckTablesPrivileges(t, dbSuffix, []string{"test_schema.test_table2", "test_schema.test_staging"}, []string{})
+					},
+				),
+			},
+			{
+				Config: testGrantRegex,
+				Check: resource.ComposeTestCheckFunc(
+					func(*terraform.State) error {
+						return testCheckTablesPrivileges(t, dbSuffix, []string{"test_schema.test_table", "test_schema.test_table2"}, []string{"SELECT"})
+					},
+					func(*terraform.State) error {
+						return testCheckTablesPrivileges(t, dbSuffix, []string{"test_schema.test_staging"}, []string{})
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlGrantGrantor(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, true)
+	defer teardown()
+
+	testTables := []string{"test_schema.test_table"}
+	createTestTables(t, dbSuffix, testTables)
+
+	dbName, roleName := getTestDBNames(dbSuffix)
+	config := getTestConfig(t)
+
+	// The tables are owned by the user running the tests,
+	// so the privileges are granted by this user.
+	var testGrantGrantor = fmt.Sprintf(`
+	resource "postgresql_grant" "test" {
+		database    = "%s"
+		role        = "%s"
+		schema      = "test_schema"
+		object_type = "table"
+		grantor     = "%s"
+		privileges  = ["SELECT"]
+	}
+	`, dbName, roleName, config.Username)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureGrantedBy)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testGrantGrantor,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_grant.test", "grantor", config.Username),
+					resource.TestCheckResourceAttr("postgresql_grant.test", "privileges.#", "1"),
+					func(*terraform.State) error {
+						return testCheckTablesPrivileges(t, dbSuffix, testTables, []string{"SELECT"})
+					},
+				),
+			},
 		},
 	})
 }
+
+func TestCheckGrantorSupported(t *testing.T) {
+	tests := []struct {
+		version string
+		grantor string
+		valid   bool
+	}{
+		{"13.0.0", "", true},
+		{"13.0.0", "postgres", false},
+		{"14.0.0", "postgres", true},
+		{"14.0.0", "current_user", true},
+		{"15.0.0", "owner", false},
+		{"16.0.0", "owner", true},
+	}
+
+	for _, test := range tests {
+		client := &Client{
+			config:  Config{Username: "postgres"},
+			version: semver.MustParse(test.version),
+		}
+		if err := checkGrantorSupported(client, test.grantor); (err == nil) != test.valid {
+			t.Errorf("checkGrantorSupported(%s, %q): expected valid %t, got error %v", test.version, test.grantor, test.valid, err)
+		}
+	}
+}
+
+func TestAccPostgresqlGrantMaintain(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, true)
+	defer teardown()
+
+	testTables := []string{"test_schema.test_table"}
+	createTestTables(t, dbSuffix, testTables)
+
+	dbName, roleName := getTestDBNames(dbSuffix)
+	config := getTestConfig(t)
+
+	var testGrantMaintain = fmt.Sprintf(`
+	resource "postgresql_grant" "test" {
+		database    = "%s"
+		role        = "%s"
+		schema      = "test_schema"
+		object_type = "table"
+		privileges  = ["MAINTAIN", "SELECT"]
+	}
+	`, dbName, roleName)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureMaintainPrivilege)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testGrantMaintain,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_grant.test", "privileges.#", "2"),
+					func(*terraform.State) error {
+						db, err := sql.Open("pgx", config.connStr(dbName))
+						if err != nil {
+							return err
+						}
+						defer db.Close()
+
+						var maintain bool
+						if err := db.QueryRow(
+							"SELECT has_table_privilege($1, 'test_schema.test_table', 'MAINTAIN')", roleName,
+						).Scan(&maintain); err != nil {
+							return err
+						}
+						if !maintain {
+							return fmt.Errorf("expected %s to have MAINTAIN on test_schema.test_table", roleName)
+						}
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlGrantMaterializedView(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, true)
+	defer teardown()
+
+	testTables := []string{"test_schema.test_table"}
+	createTestTables(t, dbSuffix, testTables)
+
+	dbName, roleName := getTestDBNames(dbSuffix)
+	config := getTestConfig(t)
+
+	db, err := sql.Open("pgx", config.connStr(dbName))
+	if err != nil {
+		t.Fatalf("could not open connection pool for db %s: %v", dbName, err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(
+		"CREATE MATERIALIZED VIEW test_schema.test_matview AS SELECT * FROM test_schema.test_table",
+	); err != nil {
+		t.Fatalf("could not create test materialized view in db %s: %v", dbName, err)
+	}
+
+	var testGrantSelect = fmt.Sprintf(`
+	resource "postgresql_grant" "test" {
+		database    = "%s"
+		role        = "%s"
+		schema      = "test_schema"
+		object_type = "materialized_view"
+		privileges  = ["SELECT"]
+	}
+	`, dbName, roleName)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureMaterializedView)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testGrantSelect,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_grant.test", "object_type", "materialized_view"),
+					resource.TestCheckResourceAttr("postgresql_grant.test", "privileges.#", "1"),
+					func(*terraform.State) error {
+						return testCheckTablesPrivileges(t, dbSuffix, []string{"test_schema.test_matview"}, []string{"SELECT"})
+					},
+					func(*terraform.State) error {
+						return testCheckTablesPrivileges(t, dbSuffix, testTables, []string{})
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlGrantTablespace(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, true)
+	defer teardown()
+
+	dbName, roleName := getTestDBNames(dbSuffix)
+
+	// pg_default always exists so we don't need to create a tablespace
+	// (which would need a directory on the server side).
+	var testGrantCreate = fmt.Sprintf(`
+	resource "postgresql_grant" "test" {
+		database    = "%s"
+		role        = "%s"
+		object_type = "tablespace"
+		objects     = ["pg_default"]
+		privileges  = ["CREATE"]
+	}
+	`, dbName, roleName)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featurePrivileges)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testGrantCreate,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_grant.test", "objects.#", "1"),
+					resource.TestCheckResourceAttr("postgresql_grant.test", "privileges.#", "1"),
+					func(*terraform.State) error {
+						return testCheckTablespacePrivilege(t, roleName, "pg_default", "CREATE", true)
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlGrantRoles(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, true)
+	defer teardown()
+
+	// Only used to create a second role
+	role2Suffix, teardownRole2 := setupTestDatabase(t, false, true)
+	defer teardownRole2()
+
+	dbName, roleName := getTestDBNames(dbSuffix)
+	_, role2Name := getTestDBNames(role2Suffix)
+
+	var testGrantRoles = fmt.Sprintf(`
+	resource "postgresql_grant" "test" {
+		database    = "%s"
+		roles       = ["%s", "%s"]
+		object_type = "tablespace"
+		objects     = ["pg_default"]
+		privileges  = ["CREATE"]
+	}
+	`, dbName, roleName, role2Name)
+
+	var testGrantOneRole = fmt.Sprintf(`
+	resource "postgresql_grant" "test" {
+		database    = "%s"
+		roles       = ["%s"]
+		object_type = "tablespace"
+		objects     = ["pg_default"]
+		privileges  = ["CREATE"]
+	}
+	`, dbName, roleName)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featurePrivileges)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testGrantRoles,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_grant.test", "roles.#", "2"),
+					func(*terraform.State) error {
+						return testCheckTablespacePrivilege(t, roleName, "pg_default", "CREATE", true)
+					},
+					func(*terraform.State) error {
+						return testCheckTablespacePrivilege(t, role2Name, "pg_default", "CREATE", true)
+					},
+				),
+			},
+			{
+				Config: testGrantOneRole,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_grant.test", "roles.#", "1"),
+					func(*terraform.State) error {
+						return testCheckTablespacePrivilege(t, roleName, "pg_default", "CREATE", true)
+					},
+					func(*terraform.State) error {
+						return testCheckTablespacePrivilege(t, role2Name, "pg_default", "CREATE", false)
+					},
+				),
+			},
+		},
+	})
+}
+
+func testCheckTablespacePrivilege(t *testing.T, role, tablespace, privilege string, expected bool) error {
+	config := getTestConfig(t)
+
+	db, err := sql.Open("pgx", config.connStr("postgres"))
+	if err != nil {
+		t.Fatalf("could not open connection pool: %v", err)
+	}
+	defer db.Close()
+
+	var hasPrivilege bool
+	if err := db.QueryRow(
+		"SELECT has_tablespace_privilege($1, $2, $3)", role, tablespace, privilege,
+	).Scan(&hasPrivilege); err != nil {
+		return fmt.Errorf("could not check tablespace privilege: %v", err)
+	}
+
+	if hasPrivilege != expected {
+		return fmt.Errorf(
+			"role %s has privilege %s on tablespace %s: %t (expected: %t)",
+			role, privilege, tablespace, hasPrivilege, expected,
+		)
+	}
+	return nil
+}
+
+func TestAccPostgresqlGrantParameter(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, true)
+	defer teardown()
+
+	dbName, roleName := getTestDBNames(dbSuffix)
+
+	var testGrantSet = fmt.Sprintf(`
+	resource "postgresql_grant" "test" {
+		database    = "%s"
+		role        = "%s"
+		object_type = "parameter"
+		objects     = ["log_min_duration_statement"]
+		privileges  = ["SET"]
+	}
+	`, dbName, roleName)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureParameterPrivileges)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testGrantSet,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_grant.test", "objects.#", "1"),
+					resource.TestCheckResourceAttr("postgresql_grant.test", "privileges.#", "1"),
+					func(*terraform.State) error {
+						return testCheckParameterPrivilege(t, roleName, "log_min_duration_statement", "SET", true)
+					},
+				),
+			},
+		},
+	})
+}
+
+func testCheckParameterPrivilege(t *testing.T, role, parameter, privilege string, expected bool) error {
+	config := getTestConfig(t)
+
+	db, err := sql.Open("pgx", config.connStr("postgres"))
+	if err != nil {
+		t.Fatalf("could not open connection pool: %v", err)
+	}
+	defer db.Close()
+
+	var hasPrivilege bool
+	if err := db.QueryRow(
+		"SELECT has_parameter_privilege($1, $2, $3)", role, parameter, privilege,
+	).Scan(&hasPrivilege); err != nil {
+		return fmt.Errorf("could not check parameter privilege: %v", err)
+	}
+
+	if hasPrivilege != expected {
+		return fmt.Errorf(
+			"role %s has privilege %s on parameter %s: %t (expected: %t)",
+			role, privilege, parameter, hasPrivilege, expected,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	partmanDatabaseAttr           = "database"
+	partmanParentTableAttr        = "parent_table"
+	partmanControlAttr            = "control"
+	partmanIntervalAttr           = "interval"
+	partmanTypeAttr               = "type"
+	partmanPremakeAttr            = "premake"
+	partmanRetentionAttr          = "retention"
+	partmanRetentionKeepTableAttr = "retention_keep_table"
+
+	partmanExtension = "pg_partman"
+)
+
+func resourcePostgreSQLPartmanParent() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLPartmanParentCreate),
+		Read:   resourcePostgreSQLPartmanParentRead,
+		Update: retryOnTransientErrors(resourcePostgreSQLPartmanParentUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLPartmanParentDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLPartmanParentImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			partmanDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database of the partitioned table",
+			},
+			partmanParentTableAttr: {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The schema qualified name of the partitioned table (e.g.: public.events)",
+			},
+			partmanControlAttr: {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The column used to partition the table",
+			},
+			partmanIntervalAttr: {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The interval of each partition (e.g.: 1 day), or an integer for an integer control column",
+			},
+			partmanTypeAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The type of partitioning passed to create_parent() (e.g.: native for pg_partman 4, range for pg_partman 5)",
+			},
+			partmanPremakeAttr: {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				Description: "The number of partitions to create in advance",
+			},
+			partmanRetentionAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The age of the partitions to drop or detach (no retention if not specified)",
+			},
+			partmanRetentionKeepTableAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "When true, the partitions are only detached by the retention (instead of being dropped)",
+			},
+		},
+	}
+}
+
+func resourcePostgreSQLPartmanParentCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getPartmanDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	partmanSchema, err := extensionSchema(c.ctx, txn, partmanExtension)
+	if err != nil {
+		return err
+	}
+
+	args := []string{
+		fmt.Sprintf("p_parent_table := '%s'", pqQuoteLiteral(d.Get(partmanParentTableAttr).(string))),
+		fmt.Sprintf("p_control := '%s'", pqQuoteLiteral(d.Get(partmanControlAttr).(string))),
+		fmt.Sprintf("p_interval := '%s'", pqQuoteLiteral(d.Get(partmanIntervalAttr).(string))),
+	}
+	if v, ok := d.GetOk(partmanTypeAttr); ok {
+		args = append(args, fmt.Sprintf("p_type := '%s'", pqQuoteLiteral(v.(string))))
+	}
+	if v, ok := d.GetOk(partmanPremakeAttr); ok {
+		args = append(args, fmt.Sprintf("p_premake := %d", v.(int)))
+	}
+
+	query := fmt.Sprintf("SELECT %s.create_parent(%s)", pqQuoteIdentifier(partmanSchema), strings.Join(args, ", "))
+	if _, err := txn.ExecContext(c.ctx, query); err != nil {
+		return errwrap.Wrapf("Error creating partman parent: {{err}}", err)
+	}
+
+	// The retention is not a parameter of create_parent().
+	if err := setPartmanConfig(c.ctx, txn, d, partmanSchema); err != nil {
+		return err
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing partman parent: {{err}}", err)
+	}
+
+	d.Set(partmanDatabaseAttr, database)
+	d.SetId(generatePartmanParentID(d))
+
+	return resourcePostgreSQLPartmanParentReadImpl(d, c)
+}
+
+func resourcePostgreSQLPartmanParentRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	defer c.rLockDatabase(getPartmanDatabase(d, c))()
+
+	return resourcePostgreSQLPartmanParentReadImpl(d, c)
+}
+
+func resourcePostgreSQLPartmanParentReadImpl(d *schema.ResourceData, c *Client) error {
+	database := getPartmanDatabase(d, c)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	partmanSchema, err := extensionSchema(c.ctx, txn, partmanExtension)
+	if err != nil {
+		return err
+	}
+
+	var control, interval, retention string
+	var premake int
+	var retentionKeepTable bool
+	query := fmt.Sprintf(
+		"SELECT control, partition_interval, premake, COALESCE(retention, ''), retention_keep_table FROM %s.part_config WHERE parent_table = $1",
+		pqQuoteIdentifier(partmanSchema),
+	)
+	err = txn.QueryRowContext(c.ctx, query, d.Get(partmanParentTableAttr)).Scan(
+		&control, &interval, &premake, &retention, &retentionKeepTable,
+	)
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] partman parent table (%s) not found", d.Id())
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading partman parent: {{err}}", err)
+	}
+
+	// The intervals are kept as configured if they are equivalent
+	// to the ones stored by pg_partman (e.g.: 1 month and 1 mon).
+	if !sameInterval(c, d.Get(partmanIntervalAttr).(string), interval) {
+		d.Set(partmanIntervalAttr, interval)
+	}
+	if current := d.Get(partmanRetentionAttr).(string); current == "" || retention == "" || !sameInterval(c, current, retention) {
+		d.Set(partmanRetentionAttr, retention)
+	}
+
+	d.Set(partmanControlAttr, control)
+	d.Set(partmanPremakeAttr, premake)
+	d.Set(partmanRetentionKeepTableAttr, retentionKeepTable)
+	d.Set(partmanDatabaseAttr, database)
+	d.SetId(generatePartmanParentID(d))
+
+	return nil
+}
+
+func resourcePostgreSQLPartmanParentUpdate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getPartmanDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	partmanSchema, err := extensionSchema(c.ctx, txn, partmanExtension)
+	if err != nil {
+		return err
+	}
+
+	if err := setPartmanConfig(c.ctx, txn, d, partmanSchema); err != nil {
+		return err
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing partman parent: {{err}}", err)
+	}
+
+	return resourcePostgreSQLPartmanParentReadImpl(d, c)
+}
+
+// setPartmanConfig updates the configuration of the parent table in part_config.
+// The new interval is used for the partitions created after the update.
+func setPartmanConfig(ctx context.Context, txn *sql.Tx, d *schema.ResourceData, partmanSchema string) error {
+	var retention interface{}
+	if v, ok := d.GetOk(partmanRetentionAttr); ok {
+		retention = v.(string)
+	}
+
+	query := fmt.Sprintf(
+		"UPDATE %s.part_config SET partition_interval = $2, premake = COALESCE($3, premake), retention = $4, retention_keep_table = $5 WHERE parent_table = $1",
+		pqQuoteIdentifier(partmanSchema),
+	)
+
+	var premake interface{}
+	if v, ok := d.GetOk(partmanPremakeAttr); ok {
+		premake = v.(int)
+	}
+
+	if _, err := txn.ExecContext(ctx, query,
+		d.Get(partmanParentTableAttr), d.Get(partmanIntervalAttr), premake, retention, d.Get(partmanRetentionKeepTableAttr),
+	); err != nil {
+		return errwrap.Wrapf("Error updating partman config: {{err}}", err)
+	}
+
+	return nil
+}
+
+func resourcePostgreSQLPartmanParentDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getPartmanDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	partmanSchema, err := extensionSchema(c.ctx, txn, partmanExtension)
+	if err != nil {
+		return err
+	}
+
+	// The table and its partitions are kept, they are only no longer maintained by pg_partman.
+	query := fmt.Sprintf("DELETE FROM %s.part_config WHERE parent_table = $1", pqQuoteIdentifier(partmanSchema))
+	if _, err := txn.ExecContext(c.ctx, query, d.Get(partmanParentTableAttr)); err != nil {
+		return errwrap.Wrapf("Error deleting partman config: {{err}}", err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing partman config deletion: {{err}}", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// resourcePostgreSQLPartmanParentImport imports a parent table
+// from an ID with the database/schema/table format.
+func resourcePostgreSQLPartmanParentImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), "database/schema/table")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set(partmanDatabaseAttr, parts[0])
+	d.Set(partmanParentTableAttr, parts[1]+"."+parts[2])
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func getPartmanDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(partmanDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generatePartmanParentID(d *schema.ResourceData) string {
+	return strings.Join([]string{
+		d.Get(partmanDatabaseAttr).(string), d.Get(partmanParentTableAttr).(string),
+	}, ".")
+}
@@ -0,0 +1,59 @@
+package postgresql
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccPostgresqlPartmanParent_Basic(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, false)
+	defer teardown()
+
+	dbName, _ := getTestDBNames(dbSuffix)
+	config := getTestConfig(t)
+	createTestExtension(t, dbSuffix, partmanExtension)
+	dbExecute(t, config.connStr(dbName), "CREATE TABLE test_schema.events (created_at timestamptz NOT NULL, payload text) PARTITION BY RANGE (created_at)")
+
+	testAccConfig := func(premake int, retention string) string {
+		return fmt.Sprintf(`
+resource "postgresql_partman_parent" "events" {
+  database     = "%s"
+  parent_table = "test_schema.events"
+  control      = "created_at"
+  interval     = "1 day"
+  premake      = %d
+  retention    = "%s"
+}
+`, dbName, premake, retention)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfig(4, "30 days"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"postgresql_partman_parent.events", "id", fmt.Sprintf("%s.test_schema.events", dbName)),
+					resource.TestCheckResourceAttr("postgresql_partman_parent.events", "interval", "1 day"),
+					resource.TestCheckResourceAttr("postgresql_partman_parent.events", "premake", "4"),
+					resource.TestCheckResourceAttr("postgresql_partman_parent.events", "retention", "30 days"),
+				),
+			},
+			{
+				Config: testAccConfig(7, "1 month"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_partman_parent.events", "premake", "7"),
+					resource.TestCheckResourceAttr("postgresql_partman_parent.events", "retention", "1 month"),
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,367 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+const (
+	policyNameAttr      = "name"
+	policyDatabaseAttr  = "database"
+	policySchemaAttr    = "schema"
+	policyTableAttr     = "table"
+	policyCommandAttr   = "command"
+	policyRolesAttr     = "roles"
+	policyUsingAttr     = "using"
+	policyWithCheckAttr = "with_check"
+)
+
+var policyCommands = []string{"ALL", "SELECT", "INSERT", "UPDATE", "DELETE"}
+
+// resourcePostgreSQLPolicy manages a row-level security policy of a table.
+// The policies are only applied once the row-level security is enabled on the table
+// (see postgresql_row_level_security).
+func resourcePostgreSQLPolicy() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLPolicyCreate),
+		Read:   resourcePostgreSQLPolicyRead,
+		Update: retryOnTransientErrors(resourcePostgreSQLPolicyUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLPolicyDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLPolicyImport,
+		},
+		CustomizeDiff: resourcePostgreSQLPolicyCustomizeDiff,
+
+		Schema: map[string]*schema.Schema{
+			policyNameAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The name of the policy",
+			},
+			policyDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database of the table",
+			},
+			policySchemaAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "public",
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The schema of the table",
+			},
+			policyTableAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The table to which the policy applies",
+			},
+			policyCommandAttr: {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          "ALL",
+				ForceNew:         true,
+				ValidateFunc:     validation.StringInSlice(policyCommands, true),
+				DiffSuppressFunc: suppressCaseDiff,
+				Description:      "The command to which the policy applies (ALL, SELECT, INSERT, UPDATE or DELETE)",
+			},
+			policyRolesAttr: {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         schema.HashString,
+				Description: "The roles to which the policy applies, all of them (PUBLIC) if not set",
+			},
+			policyUsingAttr: {
+				Type:             schema.TypeString,
+				Optional:         true,
+				DiffSuppressFunc: suppressPolicyExpressionDiff,
+				Description:      "The expression filtering the existing rows visible to the command",
+			},
+			policyWithCheckAttr: {
+				Type:             schema.TypeString,
+				Optional:         true,
+				DiffSuppressFunc: suppressPolicyExpressionDiff,
+				Description:      "The expression the rows added or updated by the command have to match",
+			},
+		},
+	}
+}
+
+// resourcePostgreSQLPolicyCustomizeDiff replaces the policy when its expressions are removed,
+// ALTER POLICY can only change them.
+func resourcePostgreSQLPolicyCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	for _, attr := range []string{policyUsingAttr, policyWithCheckAttr} {
+		if old, new := d.GetChange(attr); old.(string) != "" && new.(string) == "" {
+			if err := d.ForceNew(attr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// suppressPolicyExpressionDiff ignores the differences of case, spacing and parentheses
+// between the configured expressions and the expressions deparsed by the server.
+func suppressPolicyExpressionDiff(k, old, new string, d *schema.ResourceData) bool {
+	return normalizePolicyExpression(old) == normalizePolicyExpression(new)
+}
+
+func normalizePolicyExpression(expression string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\r', '\n', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.ToLower(expression))
+}
+
+func resourcePostgreSQLPolicyCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkPolicySupported(c); err != nil {
+		return err
+	}
+
+	database := getPolicyDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	policyName := d.Get(policyNameAttr).(string)
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "CREATE POLICY %s ON %s FOR %s TO %s",
+		pqQuoteIdentifier(policyName), policyTable(d),
+		strings.ToUpper(d.Get(policyCommandAttr).(string)), policyRoles(d),
+	)
+	if using, ok := d.GetOk(policyUsingAttr); ok {
+		fmt.Fprintf(b, " USING (%s)", using.(string))
+	}
+	if withCheck, ok := d.GetOk(policyWithCheckAttr); ok {
+		fmt.Fprintf(b, " WITH CHECK (%s)", withCheck.(string))
+	}
+
+	if _, err := txn.ExecContext(c.ctx, b.String()); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error creating policy %s: {{err}}", policyName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing policy: {{err}}", err)
+	}
+
+	d.Set(policyDatabaseAttr, database)
+	d.SetId(generatePolicyID(d))
+
+	return resourcePostgreSQLPolicyReadImpl(d, c)
+}
+
+func resourcePostgreSQLPolicyRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkPolicySupported(c); err != nil {
+		return err
+	}
+
+	defer c.rLockDatabase(getPolicyDatabase(d, c))()
+
+	return resourcePostgreSQLPolicyReadImpl(d, c)
+}
+
+func resourcePostgreSQLPolicyReadImpl(d *schema.ResourceData, c *Client) error {
+	database := getPolicyDatabase(d, c)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	var command, using, withCheck string
+	var roles []string
+	err = txn.QueryRowContext(c.ctx, `
+SELECT cmd, roles, COALESCE(qual, ''), COALESCE(with_check, '')
+FROM pg_catalog.pg_policies
+WHERE schemaname = $1 AND tablename = $2 AND policyname = $3`,
+		d.Get(policySchemaAttr), d.Get(policyTableAttr), d.Get(policyNameAttr),
+	).Scan(&command, pgArray(&roles), &using, &withCheck)
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] PostgreSQL policy (%s) not found", d.Id())
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading policy: {{err}}", err)
+	}
+
+	// The policies applying to all the roles are listed with the public role.
+	if len(roles) == 1 && roles[0] == "public" && !d.Get(policyRolesAttr).(*schema.Set).Contains("public") {
+		roles = nil
+	}
+
+	d.Set(policyCommandAttr, command)
+	d.Set(policyRolesAttr, pgArrayToSet(roles))
+	d.Set(policyUsingAttr, using)
+	d.Set(policyWithCheckAttr, withCheck)
+	d.Set(policyDatabaseAttr, database)
+	d.SetId(generatePolicyID(d))
+
+	return nil
+}
+
+func resourcePostgreSQLPolicyUpdate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkPolicySupported(c); err != nil {
+		return err
+	}
+
+	database := getPolicyDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	oldName, newName := d.GetChange(policyNameAttr)
+	table := policyTable(d)
+
+	var queries []string
+	if d.HasChange(policyNameAttr) {
+		queries = append(queries, fmt.Sprintf("ALTER POLICY %s ON %s RENAME TO %s",
+			pqQuoteIdentifier(oldName.(string)), table, pqQuoteIdentifier(newName.(string)),
+		))
+	}
+
+	alter := fmt.Sprintf("ALTER POLICY %s ON %s", pqQuoteIdentifier(newName.(string)), table)
+	if d.HasChange(policyRolesAttr) {
+		queries = append(queries, fmt.Sprintf("%s TO %s", alter, policyRoles(d)))
+	}
+	// The removed expressions recreate the policy (see resourcePostgreSQLPolicyCustomizeDiff).
+	if d.HasChange(policyUsingAttr) {
+		queries = append(queries, fmt.Sprintf("%s USING (%s)", alter, d.Get(policyUsingAttr).(string)))
+	}
+	if d.HasChange(policyWithCheckAttr) {
+		queries = append(queries, fmt.Sprintf("%s WITH CHECK (%s)", alter, d.Get(policyWithCheckAttr).(string)))
+	}
+
+	if err := execQueries(c.ctx, txn, queries); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error updating policy %s: {{err}}", newName.(string)), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing policy: {{err}}", err)
+	}
+
+	return resourcePostgreSQLPolicyReadImpl(d, c)
+}
+
+func resourcePostgreSQLPolicyDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkPolicySupported(c); err != nil {
+		return err
+	}
+
+	database := getPolicyDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	policyName := d.Get(policyNameAttr).(string)
+	if _, err := txn.ExecContext(c.ctx, fmt.Sprintf("DROP POLICY IF EXISTS %s ON %s", pqQuoteIdentifier(policyName), policyTable(d))); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error deleting policy %s: {{err}}", policyName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing policy deletion: {{err}}", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// resourcePostgreSQLPolicyImport imports a policy from an ID
+// with the database/schema/table/name format.
+func resourcePostgreSQLPolicyImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), "database/schema/table/name")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set(policyDatabaseAttr, parts[0])
+	d.Set(policySchemaAttr, parts[1])
+	d.Set(policyTableAttr, parts[2])
+	d.Set(policyNameAttr, parts[3])
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func checkPolicySupported(c *Client) error {
+	if !c.featureSupported(featureRLS) {
+		return fmt.Errorf(
+			"postgresql_policy resource is not supported for this Postgres version (%s)",
+			c.version,
+		)
+	}
+	return nil
+}
+
+func policyTable(d *schema.ResourceData) string {
+	return pqQuoteIdentifier(d.Get(policySchemaAttr).(string)) + "." + pqQuoteIdentifier(d.Get(policyTableAttr).(string))
+}
+
+// policyRoles returns the quoted list of the roles of the policy, PUBLIC if it has none.
+func policyRoles(d *schema.ResourceData) string {
+	roles := setToStrings(d.Get(policyRolesAttr).(*schema.Set))
+	if len(roles) == 0 {
+		return "PUBLIC"
+	}
+	for i, role := range roles {
+		roles[i] = pqQuoteRoleName(role)
+	}
+	return strings.Join(roles, ", ")
+}
+
+func getPolicyDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(policyDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generatePolicyID(d *schema.ResourceData) string {
+	return strings.Join([]string{
+		d.Get(policyDatabaseAttr).(string),
+		d.Get(policySchemaAttr).(string),
+		d.Get(policyTableAttr).(string),
+		d.Get(policyNameAttr).(string),
+	}, ".")
+}
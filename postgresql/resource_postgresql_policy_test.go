@@ -0,0 +1,98 @@
+package postgresql
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestSuppressPolicyExpressionDiff(t *testing.T) {
+	tests := []struct {
+		old      string
+		new      string
+		expected bool
+	}{
+		{"(owner = CURRENT_USER)", "owner = current_user", true},
+		{"((tenant_id = 1) AND (NOT archived))", "tenant_id = 1 and not archived", true},
+		{"(owner = CURRENT_USER)", "owner = session_user", false},
+		{"", "true", false},
+	}
+
+	for _, test := range tests {
+		if actual := suppressPolicyExpressionDiff("using", test.old, test.new, nil); actual != test.expected {
+			t.Errorf("suppressPolicyExpressionDiff(%q, %q): expected %t, got %t", test.old, test.new, test.expected, actual)
+		}
+	}
+}
+
+func TestAccPostgresqlPolicy_Basic(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, true)
+	defer teardown()
+
+	createTestTables(t, dbSuffix, []string{"test_schema.documents"})
+
+	dbName, roleName := getTestDBNames(dbSuffix)
+
+	testAccConfig := func(policyName, using string, force bool) string {
+		return fmt.Sprintf(`
+resource "postgresql_row_level_security" "documents" {
+  database = "%[1]s"
+  schema   = "test_schema"
+  table    = "documents"
+  force    = %[4]t
+}
+
+resource "postgresql_policy" "documents" {
+  database = "%[1]s"
+  schema   = "test_schema"
+  table    = "documents"
+  name     = "%[2]s"
+  command  = "select"
+  roles    = ["%[5]s"]
+  using    = "%[3]s"
+}
+`, dbName, policyName, using, force, roleName)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureRLS)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfig("visible", "val IS NOT NULL", false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_row_level_security.documents", "id", fmt.Sprintf("%s.test_schema.documents", dbName)),
+					resource.TestCheckResourceAttr("postgresql_row_level_security.documents", "force", "false"),
+					resource.TestCheckResourceAttr("postgresql_policy.documents", "id", fmt.Sprintf("%s.test_schema.documents.visible", dbName)),
+					resource.TestCheckResourceAttr("postgresql_policy.documents", "command", "SELECT"),
+					resource.TestCheckResourceAttr("postgresql_policy.documents", "roles.#", "1"),
+				),
+			},
+			{
+				Config: testAccConfig("readable", "val IS NULL", true),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_row_level_security.documents", "force", "true"),
+					resource.TestCheckResourceAttr("postgresql_policy.documents", "id", fmt.Sprintf("%s.test_schema.documents.readable", dbName)),
+				),
+			},
+			{
+				ResourceName:      "postgresql_policy.documents",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("%s/test_schema/documents/readable", dbName),
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "postgresql_row_level_security.documents",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("%s/test_schema/documents", dbName),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
@@ -0,0 +1,268 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+const (
+	srsDatabaseAttr  = "database"
+	srsSRIDAttr      = "srid"
+	srsAuthNameAttr  = "auth_name"
+	srsAuthSRIDAttr  = "auth_srid"
+	srsSRTextAttr    = "srtext"
+	srsProj4TextAttr = "proj4text"
+
+	postGISExtension = "postgis"
+
+	// postGISMaxSRID is the highest SRID accepted by the spatial_ref_sys check constraint.
+	postGISMaxSRID = 998999
+)
+
+func resourcePostgreSQLPostGISSpatialRefSys() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLPostGISSpatialRefSysCreate),
+		Read:   resourcePostgreSQLPostGISSpatialRefSysRead,
+		Update: retryOnTransientErrors(resourcePostgreSQLPostGISSpatialRefSysUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLPostGISSpatialRefSysDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLPostGISSpatialRefSysImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			srsDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database in which PostGIS is installed",
+			},
+			srsSRIDAttr: {
+				Type:         schema.TypeInt,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IntBetween(1, postGISMaxSRID),
+				Description:  "The spatial reference system identifier",
+			},
+			srsAuthNameAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The name of the authority defining the spatial reference system (e.g.: EPSG)",
+			},
+			srsAuthSRIDAttr: {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "The identifier of the spatial reference system for its authority",
+			},
+			srsSRTextAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The Well-Known Text definition of the spatial reference system",
+			},
+			srsProj4TextAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The PROJ definition of the spatial reference system",
+			},
+		},
+	}
+}
+
+func resourcePostgreSQLPostGISSpatialRefSysCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getSRSDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	postGISSchema, err := extensionSchema(c.ctx, txn, postGISExtension)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(
+		"INSERT INTO %s.spatial_ref_sys (srid, auth_name, auth_srid, srtext, proj4text) VALUES ($1, $2, $3, $4, $5)",
+		pqQuoteIdentifier(postGISSchema),
+	)
+	if _, err := txn.ExecContext(c.ctx, query, srsValues(d)...); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error creating spatial reference system %d: {{err}}", d.Get(srsSRIDAttr).(int)), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing spatial reference system: {{err}}", err)
+	}
+
+	d.Set(srsDatabaseAttr, database)
+	d.SetId(generateSRSID(d))
+
+	return resourcePostgreSQLPostGISSpatialRefSysReadImpl(d, c)
+}
+
+func resourcePostgreSQLPostGISSpatialRefSysRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	defer c.rLockDatabase(getSRSDatabase(d, c))()
+
+	return resourcePostgreSQLPostGISSpatialRefSysReadImpl(d, c)
+}
+
+func resourcePostgreSQLPostGISSpatialRefSysReadImpl(d *schema.ResourceData, c *Client) error {
+	database := getSRSDatabase(d, c)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	postGISSchema, err := extensionSchema(c.ctx, txn, postGISExtension)
+	if err != nil {
+		return err
+	}
+
+	var authName, srText, proj4Text string
+	var authSRID int
+	query := fmt.Sprintf(
+		"SELECT COALESCE(auth_name, ''), COALESCE(auth_srid, 0), COALESCE(srtext, ''), COALESCE(proj4text, '') FROM %s.spatial_ref_sys WHERE srid = $1",
+		pqQuoteIdentifier(postGISSchema),
+	)
+	err = txn.QueryRowContext(c.ctx, query, d.Get(srsSRIDAttr)).Scan(&authName, &authSRID, &srText, &proj4Text)
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] PostGIS spatial reference system (%s) not found", d.Id())
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading spatial reference system: {{err}}", err)
+	}
+
+	d.Set(srsAuthNameAttr, authName)
+	d.Set(srsAuthSRIDAttr, authSRID)
+	d.Set(srsSRTextAttr, srText)
+	d.Set(srsProj4TextAttr, proj4Text)
+	d.Set(srsDatabaseAttr, database)
+	d.SetId(generateSRSID(d))
+
+	return nil
+}
+
+func resourcePostgreSQLPostGISSpatialRefSysUpdate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getSRSDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	postGISSchema, err := extensionSchema(c.ctx, txn, postGISExtension)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(
+		"UPDATE %s.spatial_ref_sys SET auth_name = $2, auth_srid = $3, srtext = $4, proj4text = $5 WHERE srid = $1",
+		pqQuoteIdentifier(postGISSchema),
+	)
+	if _, err := txn.ExecContext(c.ctx, query, srsValues(d)...); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error updating spatial reference system %d: {{err}}", d.Get(srsSRIDAttr).(int)), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing spatial reference system: {{err}}", err)
+	}
+
+	return resourcePostgreSQLPostGISSpatialRefSysReadImpl(d, c)
+}
+
+func resourcePostgreSQLPostGISSpatialRefSysDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getSRSDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	postGISSchema, err := extensionSchema(c.ctx, txn, postGISExtension)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s.spatial_ref_sys WHERE srid = $1", pqQuoteIdentifier(postGISSchema))
+	if _, err := txn.ExecContext(c.ctx, query, d.Get(srsSRIDAttr)); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error deleting spatial reference system %d: {{err}}", d.Get(srsSRIDAttr).(int)), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing spatial reference system deletion: {{err}}", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// resourcePostgreSQLPostGISSpatialRefSysImport imports a spatial reference system
+// from an ID with the database/srid format.
+func resourcePostgreSQLPostGISSpatialRefSysImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), "database/srid")
+	if err != nil {
+		return nil, err
+	}
+
+	srid, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid SRID in spatial reference system ID %q", d.Id())
+	}
+
+	d.Set(srsDatabaseAttr, parts[0])
+	d.Set(srsSRIDAttr, srid)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// srsValues returns the values of the spatial_ref_sys columns (empty values are NULL).
+func srsValues(d *schema.ResourceData) []interface{} {
+	values := []interface{}{d.Get(srsSRIDAttr)}
+	for _, attr := range []string{srsAuthNameAttr, srsAuthSRIDAttr, srsSRTextAttr, srsProj4TextAttr} {
+		v, ok := d.GetOk(attr)
+		if !ok {
+			v = nil
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+func getSRSDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(srsDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generateSRSID(d *schema.ResourceData) string {
+	return fmt.Sprintf("%s.%d", d.Get(srsDatabaseAttr).(string), d.Get(srsSRIDAttr).(int))
+}
@@ -0,0 +1,61 @@
+package postgresql
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccPostgresqlPostGISSpatialRefSys_Basic(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, false)
+	defer teardown()
+
+	createTestExtension(t, dbSuffix, postGISExtension)
+
+	dbName, _ := getTestDBNames(dbSuffix)
+
+	testAccConfig := func(proj4Text string) string {
+		return fmt.Sprintf(`
+resource "postgresql_postgis_spatial_ref_sys" "custom" {
+  database  = "%s"
+  srid      = 990001
+  auth_name = "CUSTOM"
+  auth_srid = 1
+  proj4text = "%s"
+}
+`, dbName, proj4Text)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfig("+proj=longlat +datum=WGS84 +no_defs"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"postgresql_postgis_spatial_ref_sys.custom", "id", fmt.Sprintf("%s.990001", dbName)),
+					resource.TestCheckResourceAttr("postgresql_postgis_spatial_ref_sys.custom", "auth_name", "CUSTOM"),
+					resource.TestCheckResourceAttr("postgresql_postgis_spatial_ref_sys.custom", "srtext", ""),
+				),
+			},
+			{
+				Config: testAccConfig("+proj=longlat +ellps=GRS80 +no_defs"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"postgresql_postgis_spatial_ref_sys.custom", "proj4text", "+proj=longlat +ellps=GRS80 +no_defs"),
+				),
+			},
+			{
+				ResourceName:      "postgresql_postgis_spatial_ref_sys.custom",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
@@ -0,0 +1,508 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+const (
+	pgFDWDatabaseAttr       = "database"
+	pgFDWServerNameAttr     = "server_name"
+	pgFDWHostAttr           = "host"
+	pgFDWPortAttr           = "port"
+	pgFDWDBNameAttr         = "dbname"
+	pgFDWOptionsAttr        = "options"
+	pgFDWUserAttr           = "user"
+	pgFDWRemoteUserAttr     = "remote_user"
+	pgFDWRemotePasswordAttr = "remote_password"
+
+	postgresFDWExtension = "postgres_fdw"
+)
+
+// pgFDWReservedOptions are the server options which cannot be set with the
+// options attribute: they have their own attribute, are user mapping options
+// or are not allowed by postgres_fdw.
+var pgFDWReservedOptions = map[string]string{
+	"host":                      "use the host attribute",
+	"port":                      "use the port attribute",
+	"dbname":                    "use the dbname attribute",
+	"user":                      "use the remote_user attribute",
+	"password":                  "use the remote_password attribute",
+	"client_encoding":           "it is set by postgres_fdw",
+	"fallback_application_name": "it is set by postgres_fdw",
+	"replication":               "it is not allowed by postgres_fdw",
+}
+
+func resourcePostgreSQLPostgresFDW() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLPostgresFDWCreate),
+		Read:   resourcePostgreSQLPostgresFDWRead,
+		Update: retryOnTransientErrors(resourcePostgreSQLPostgresFDWUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLPostgresFDWDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLPostgresFDWImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			pgFDWDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database in which the foreign server is created",
+			},
+			pgFDWServerNameAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The name of the foreign server",
+			},
+			pgFDWHostAttr: {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The host of the remote server",
+			},
+			pgFDWPortAttr: {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      5432,
+				ValidateFunc: validation.IntBetween(1, 65535),
+				Description:  "The port of the remote server",
+			},
+			pgFDWDBNameAttr: {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The name of the remote database",
+			},
+			pgFDWOptionsAttr: {
+				Type:         schema.TypeMap,
+				Optional:     true,
+				Elem:         &schema.Schema{Type: schema.TypeString},
+				ValidateFunc: validatePostgresFDWOptions,
+				Description:  "The other options of the foreign server (e.g.: fetch_size, use_remote_estimate, sslmode)",
+			},
+			pgFDWUserAttr: {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The local role of the user mapping (PUBLIC for all the roles)",
+			},
+			pgFDWRemoteUserAttr: {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The role used to connect to the remote server",
+			},
+			pgFDWRemotePasswordAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "The password used to connect to the remote server",
+			},
+		},
+	}
+}
+
+func validatePostgresFDWOptions(v interface{}, key string) (warnings []string, errors []error) {
+	for option := range v.(map[string]interface{}) {
+		if reason, reserved := pgFDWReservedOptions[strings.ToLower(option)]; reserved {
+			errors = append(errors, fmt.Errorf("%s cannot contain %s: %s", key, option, reason))
+		}
+	}
+	return
+}
+
+func resourcePostgreSQLPostgresFDWCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if !c.featureSupported(featureExtension) {
+		return fmt.Errorf(
+			"postgresql_postgres_fdw resource is not supported for this Postgres version (%s)",
+			c.version,
+		)
+	}
+
+	database := getPostgresFDWDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	serverName := d.Get(pgFDWServerNameAttr).(string)
+
+	serverOptions := []string{}
+	for _, option := range postgresFDWServerOptions(d) {
+		serverOptions = append(serverOptions, fmt.Sprintf("%s '%s'", pqQuoteIdentifier(option[0]), pqQuoteLiteral(option[1])))
+	}
+
+	queries := []string{
+		fmt.Sprintf("CREATE EXTENSION IF NOT EXISTS %s", pqQuoteIdentifier(postgresFDWExtension)),
+		fmt.Sprintf("CREATE SERVER %s FOREIGN DATA WRAPPER %s OPTIONS (%s)",
+			pqQuoteIdentifier(serverName), pqQuoteIdentifier(postgresFDWExtension), strings.Join(serverOptions, ", "),
+		),
+		createPostgresFDWUserMappingQuery(d),
+	}
+	if err := execQueries(c.ctx, txn, queries); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error creating foreign server %s: {{err}}", serverName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing foreign server: {{err}}", err)
+	}
+
+	d.Set(pgFDWDatabaseAttr, database)
+	d.SetId(generatePostgresFDWID(d))
+
+	return resourcePostgreSQLPostgresFDWReadImpl(d, c)
+}
+
+func resourcePostgreSQLPostgresFDWRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	defer c.rLockDatabase(getPostgresFDWDatabase(d, c))()
+
+	return resourcePostgreSQLPostgresFDWReadImpl(d, c)
+}
+
+func resourcePostgreSQLPostgresFDWReadImpl(d *schema.ResourceData, c *Client) error {
+	database := getPostgresFDWDatabase(d, c)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	var serverOptions []string
+	err = txn.QueryRowContext(c.ctx, `
+SELECT COALESCE(s.srvoptions, '{}')
+FROM pg_catalog.pg_foreign_server s
+JOIN pg_catalog.pg_foreign_data_wrapper w ON w.oid = s.srvfdw
+WHERE s.srvname = $1 AND w.fdwname = $2`,
+		d.Get(pgFDWServerNameAttr), postgresFDWExtension,
+	).Scan(pgArray(&serverOptions))
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] postgres_fdw server (%s) not found", d.Id())
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading foreign server: {{err}}", err)
+	}
+
+	options := map[string]interface{}{}
+	for option, value := range parseFDWOptions(serverOptions) {
+		switch option {
+		case pgFDWHostAttr:
+			d.Set(pgFDWHostAttr, value)
+		case pgFDWDBNameAttr:
+			d.Set(pgFDWDBNameAttr, value)
+		case pgFDWPortAttr:
+			port, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid port %q for foreign server %s", value, d.Id())
+			}
+			d.Set(pgFDWPortAttr, port)
+		default:
+			options[option] = value
+		}
+	}
+	d.Set(pgFDWOptionsAttr, options)
+
+	mappingOptions, exists, err := readPostgresFDWUserMapping(c.ctx, txn, d)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !exists:
+		// The user mapping is created again by the update.
+		log.Printf("[WARN] user mapping of %s for postgres_fdw server (%s) not found", d.Get(pgFDWUserAttr), d.Id())
+		d.Set(pgFDWRemoteUserAttr, "")
+	case mappingOptions != nil:
+		// The password is not read back to not store it in the state if it's not configured.
+		d.Set(pgFDWRemoteUserAttr, parseFDWOptions(mappingOptions)["user"])
+	}
+
+	d.Set(pgFDWDatabaseAttr, database)
+	d.SetId(generatePostgresFDWID(d))
+
+	return nil
+}
+
+func resourcePostgreSQLPostgresFDWUpdate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getPostgresFDWDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	serverName := d.Get(pgFDWServerNameAttr).(string)
+
+	oldOptions, newOptions := d.GetChange(pgFDWOptionsAttr)
+	oldServerOptions := oldOptions.(map[string]interface{})
+	newServerOptions := newOptions.(map[string]interface{})
+	for _, attr := range []string{pgFDWHostAttr, pgFDWPortAttr, pgFDWDBNameAttr} {
+		oldValue, newValue := d.GetChange(attr)
+		oldServerOptions[attr] = fmt.Sprint(oldValue)
+		newServerOptions[attr] = fmt.Sprint(newValue)
+	}
+	if alterOptions := alterFDWOptions(oldServerOptions, newServerOptions); alterOptions != "" {
+		query := fmt.Sprintf("ALTER SERVER %s OPTIONS (%s)", pqQuoteIdentifier(serverName), alterOptions)
+		if _, err := txn.ExecContext(c.ctx, query); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("Error updating foreign server %s: {{err}}", serverName), err)
+		}
+	}
+
+	if d.HasChange(pgFDWRemoteUserAttr) || d.HasChange(pgFDWRemotePasswordAttr) {
+		_, exists, err := readPostgresFDWUserMapping(c.ctx, txn, d)
+		if err != nil {
+			return err
+		}
+
+		query := createPostgresFDWUserMappingQuery(d)
+		if exists {
+			oldUser, newUser := d.GetChange(pgFDWRemoteUserAttr)
+			oldPassword, newPassword := d.GetChange(pgFDWRemotePasswordAttr)
+			query = fmt.Sprintf("ALTER USER MAPPING FOR %s SERVER %s OPTIONS (%s)",
+				pqQuoteRoleName(d.Get(pgFDWUserAttr).(string)), pqQuoteIdentifier(serverName),
+				alterFDWOptions(
+					postgresFDWUserMappingOptions(oldUser.(string), oldPassword.(string)),
+					postgresFDWUserMappingOptions(newUser.(string), newPassword.(string)),
+				),
+			)
+		}
+		if _, err := txn.ExecContext(c.ctx, query); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("Error updating user mapping of foreign server %s: {{err}}", serverName), err)
+		}
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing foreign server: {{err}}", err)
+	}
+
+	return resourcePostgreSQLPostgresFDWReadImpl(d, c)
+}
+
+func resourcePostgreSQLPostgresFDWDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getPostgresFDWDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	// The extension is kept as it may be used by other servers.
+	// The server is not dropped if foreign tables still use it.
+	serverName := d.Get(pgFDWServerNameAttr).(string)
+	queries := []string{
+		fmt.Sprintf("DROP USER MAPPING IF EXISTS FOR %s SERVER %s",
+			pqQuoteRoleName(d.Get(pgFDWUserAttr).(string)), pqQuoteIdentifier(serverName),
+		),
+		fmt.Sprintf("DROP SERVER %s", pqQuoteIdentifier(serverName)),
+	}
+	if err := execQueries(c.ctx, txn, queries); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error deleting foreign server %s: {{err}}", serverName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing foreign server deletion: {{err}}", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// resourcePostgreSQLPostgresFDWImport imports a foreign server and its user mapping
+// from an ID with the database/server_name/user format.
+func resourcePostgreSQLPostgresFDWImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), "database/server_name/user")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set(pgFDWDatabaseAttr, parts[0])
+	d.Set(pgFDWServerNameAttr, parts[1])
+	d.Set(pgFDWUserAttr, parts[2])
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// postgresFDWServerOptions returns the sorted options of the foreign server.
+func postgresFDWServerOptions(d *schema.ResourceData) [][2]string {
+	options := [][2]string{
+		{pgFDWHostAttr, d.Get(pgFDWHostAttr).(string)},
+		{pgFDWPortAttr, strconv.Itoa(d.Get(pgFDWPortAttr).(int))},
+		{pgFDWDBNameAttr, d.Get(pgFDWDBNameAttr).(string)},
+	}
+
+	others := d.Get(pgFDWOptionsAttr).(map[string]interface{})
+	names := make([]string, 0, len(others))
+	for name := range others {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		options = append(options, [2]string{name, others[name].(string)})
+	}
+
+	return options
+}
+
+func postgresFDWUserMappingOptions(user, password string) map[string]interface{} {
+	options := map[string]interface{}{"user": user}
+	if password != "" {
+		options["password"] = password
+	}
+	return options
+}
+
+func createPostgresFDWUserMappingQuery(d *schema.ResourceData) string {
+	options := []string{fmt.Sprintf("user '%s'", pqQuoteLiteral(d.Get(pgFDWRemoteUserAttr).(string)))}
+	if password, ok := d.GetOk(pgFDWRemotePasswordAttr); ok {
+		options = append(options, fmt.Sprintf("password '%s'", pqQuoteLiteral(password.(string))))
+	}
+
+	return fmt.Sprintf("CREATE USER MAPPING FOR %s SERVER %s OPTIONS (%s)",
+		pqQuoteRoleName(d.Get(pgFDWUserAttr).(string)), pqQuoteIdentifier(d.Get(pgFDWServerNameAttr).(string)),
+		strings.Join(options, ", "),
+	)
+}
+
+// readPostgresFDWUserMapping returns the options of the user mapping and if it exists.
+// The options are nil if the current user is not allowed to see them.
+func readPostgresFDWUserMapping(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) ([]string, bool, error) {
+	user := d.Get(pgFDWUserAttr).(string)
+	if isPublicRole(user) {
+		user = "public"
+	}
+
+	var options []string
+	var visible bool
+	err := txn.QueryRowContext(ctx,
+		"SELECT COALESCE(umoptions, '{}'), umoptions IS NOT NULL FROM pg_catalog.pg_user_mappings WHERE srvname = $1 AND usename = $2",
+		d.Get(pgFDWServerNameAttr), user,
+	).Scan(pgArray(&options), &visible)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, false, nil
+	case err != nil:
+		return nil, false, errwrap.Wrapf("Error reading user mapping: {{err}}", err)
+	}
+
+	if !visible {
+		return nil, true, nil
+	}
+	return options, true, nil
+}
+
+// parseFDWOptions parses the options of a foreign object (stored as name=value in the catalog).
+func parseFDWOptions(options []string) map[string]string {
+	parsed := make(map[string]string, len(options))
+	for _, option := range options {
+		parts := strings.SplitN(option, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		parsed[parts[0]] = parts[1]
+	}
+	return parsed
+}
+
+// alterFDWOptions returns the ADD / SET / DROP clauses to change the options of a foreign object.
+func alterFDWOptions(oldOptions, newOptions map[string]interface{}) string {
+	names := []string{}
+	for name := range oldOptions {
+		names = append(names, name)
+	}
+	for name := range newOptions {
+		if _, ok := oldOptions[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	clauses := []string{}
+	for _, name := range names {
+		oldValue, inOld := oldOptions[name]
+		newValue, inNew := newOptions[name]
+		switch {
+		case !inNew:
+			clauses = append(clauses, fmt.Sprintf("DROP %s", pqQuoteIdentifier(name)))
+		case !inOld:
+			clauses = append(clauses, fmt.Sprintf("ADD %s '%s'", pqQuoteIdentifier(name), pqQuoteLiteral(newValue.(string))))
+		case oldValue != newValue:
+			clauses = append(clauses, fmt.Sprintf("SET %s '%s'", pqQuoteIdentifier(name), pqQuoteLiteral(newValue.(string))))
+		}
+	}
+
+	return strings.Join(clauses, ", ")
+}
+
+// createFDWOptions returns the OPTIONS clause to create a foreign object with the options,
+// or an empty string if it has none.
+func createFDWOptions(options map[string]interface{}) string {
+	if len(options) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	clauses := make([]string, len(names))
+	for i, name := range names {
+		clauses[i] = fmt.Sprintf("%s '%s'", pqQuoteIdentifier(name), pqQuoteLiteral(options[name].(string)))
+	}
+
+	return fmt.Sprintf(" OPTIONS (%s)", strings.Join(clauses, ", "))
+}
+
+// flattenFDWOptions returns the options of a foreign object read from the catalog as a map of the state.
+func flattenFDWOptions(options []string) map[string]interface{} {
+	flattened := map[string]interface{}{}
+	for name, value := range parseFDWOptions(options) {
+		flattened[name] = value
+	}
+	return flattened
+}
+
+func getPostgresFDWDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(pgFDWDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generatePostgresFDWID(d *schema.ResourceData) string {
+	return strings.Join([]string{
+		d.Get(pgFDWDatabaseAttr).(string), d.Get(pgFDWServerNameAttr).(string), d.Get(pgFDWUserAttr).(string),
+	}, ".")
+}
@@ -0,0 +1,69 @@
+package postgresql
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccPostgresqlPostgresFDW_Basic(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, false)
+	defer teardown()
+
+	dbName, _ := getTestDBNames(dbSuffix)
+
+	testAccConfig := func(port int, fetchSize string) string {
+		return fmt.Sprintf(`
+resource "postgresql_postgres_fdw" "remote" {
+  database        = "%s"
+  server_name     = "remote"
+  host            = "remote.example.com"
+  port            = %d
+  dbname          = "app"
+  user            = "PUBLIC"
+  remote_user     = "reader"
+  remote_password = "secret"
+
+  options = {
+    fetch_size = "%s"
+  }
+}
+`, dbName, port, fetchSize)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfig(5432, "100"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"postgresql_postgres_fdw.remote", "id", fmt.Sprintf("%s.remote.PUBLIC", dbName)),
+					resource.TestCheckResourceAttr("postgresql_postgres_fdw.remote", "host", "remote.example.com"),
+					resource.TestCheckResourceAttr("postgresql_postgres_fdw.remote", "port", "5432"),
+					resource.TestCheckResourceAttr("postgresql_postgres_fdw.remote", "options.fetch_size", "100"),
+					resource.TestCheckResourceAttr("postgresql_postgres_fdw.remote", "remote_user", "reader"),
+				),
+			},
+			{
+				Config: testAccConfig(6432, "500"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_postgres_fdw.remote", "port", "6432"),
+					resource.TestCheckResourceAttr("postgresql_postgres_fdw.remote", "options.fetch_size", "500"),
+				),
+			},
+			{
+				ResourceName:            "postgresql_postgres_fdw.remote",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"remote_password"},
+			},
+		},
+	})
+}
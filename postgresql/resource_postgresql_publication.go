@@ -0,0 +1,390 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+const (
+	pubNameAttr      = "name"
+	pubDatabaseAttr  = "database"
+	pubTablesAttr    = "tables"
+	pubAllTablesAttr = "all_tables"
+	pubPublishAttr   = "publish"
+
+	pubAdoptIfExistsAttr = "adopt_if_exists"
+)
+
+// pubOperations are the operations which can be published, in the order of pg_publication.
+var pubOperations = []string{"insert", "update", "delete", "truncate"}
+
+func resourcePostgreSQLPublication() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLPublicationCreate),
+		Read:   resourcePostgreSQLPublicationRead,
+		Update: retryOnTransientErrors(resourcePostgreSQLPublicationUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLPublicationDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLPublicationImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			pubNameAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The name of the publication",
+			},
+			pubDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database in which the publication is created",
+			},
+			pubTablesAttr: {
+				Type:          schema.TypeSet,
+				Optional:      true,
+				Elem:          &schema.Schema{Type: schema.TypeString, ValidateFunc: validatePublicationTable},
+				Set:           schema.HashString,
+				ConflictsWith: []string{pubAllTablesAttr},
+				Description:   "The tables (schema.table) of the publication",
+			},
+			pubAllTablesAttr: {
+				Type:          schema.TypeBool,
+				Optional:      true,
+				Default:       false,
+				ForceNew:      true,
+				ConflictsWith: []string{pubTablesAttr},
+				Description:   "Publish the changes of all the tables of the database, including the tables created later",
+			},
+			pubPublishAttr: {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validation.StringInSlice(pubOperations, false)},
+				Set:         schema.HashString,
+				Description: "The operations published (insert, update, delete and truncate), all of them by default",
+			},
+			pubAdoptIfExistsAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When true, an existing publication with the same name is adopted in the state instead of failing the creation",
+			},
+		},
+	}
+}
+
+func validatePublicationTable(v interface{}, key string) (warnings []string, errors []error) {
+	if parts := strings.SplitN(v.(string), ".", 2); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		errors = append(errors, fmt.Errorf("%s must be qualified by its schema (schema.table), got %q", key, v.(string)))
+	}
+	return
+}
+
+func resourcePostgreSQLPublicationCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkPublicationSupported(c, d); err != nil {
+		return err
+	}
+
+	database := getPublicationDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	pubName := d.Get(pubNameAttr).(string)
+
+	if adopted, err := adoptExistingPublication(c, txn, d, database); err != nil || adopted {
+		return err
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprint(b, "CREATE PUBLICATION ", pqQuoteIdentifier(pubName))
+	if d.Get(pubAllTablesAttr).(bool) {
+		fmt.Fprint(b, " FOR ALL TABLES")
+	} else if tables := d.Get(pubTablesAttr).(*schema.Set); tables.Len() > 0 {
+		fmt.Fprint(b, " FOR TABLE ", quotePublicationTables(setToStrings(tables)))
+	}
+	if publish, ok := d.GetOk(pubPublishAttr); ok {
+		fmt.Fprintf(b, " WITH (publish = '%s')", publicationPublish(publish.(*schema.Set)))
+	}
+
+	if _, err := txn.ExecContext(c.ctx, b.String()); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error creating publication %s: {{err}}", pubName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing publication: {{err}}", err)
+	}
+
+	d.Set(pubDatabaseAttr, database)
+	d.SetId(generatePublicationID(d))
+
+	return resourcePostgreSQLPublicationReadImpl(d, c)
+}
+
+func resourcePostgreSQLPublicationRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkPublicationSupported(c, d); err != nil {
+		return err
+	}
+
+	defer c.rLockDatabase(getPublicationDatabase(d, c))()
+
+	return resourcePostgreSQLPublicationReadImpl(d, c)
+}
+
+func resourcePostgreSQLPublicationReadImpl(d *schema.ResourceData, c *Client) error {
+	database := getPublicationDatabase(d, c)
+	pubName := d.Get(pubNameAttr).(string)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	// pubtruncate exists since PostgreSQL 11.
+	truncateColumn := "false"
+	if c.featureSupported(featurePublicationTruncate) {
+		truncateColumn = "pubtruncate"
+	}
+
+	var allTables bool
+	published := make([]bool, len(pubOperations))
+	err = txn.QueryRowContext(c.ctx, fmt.Sprintf(
+		"SELECT puballtables, pubinsert, pubupdate, pubdelete, %s FROM pg_catalog.pg_publication WHERE pubname = $1",
+		truncateColumn,
+	), pubName).Scan(&allTables, &published[0], &published[1], &published[2], &published[3])
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] PostgreSQL publication (%s) not found", d.Id())
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading publication: {{err}}", err)
+	}
+
+	publish := []interface{}{}
+	for i, operation := range pubOperations {
+		if published[i] {
+			publish = append(publish, operation)
+		}
+	}
+
+	// With FOR ALL TABLES, pg_publication_tables lists all the tables of the database.
+	tables := []interface{}{}
+	if !allTables {
+		rows, err := txn.QueryContext(c.ctx,
+			"SELECT schemaname, tablename FROM pg_catalog.pg_publication_tables WHERE pubname = $1", pubName,
+		)
+		if err != nil {
+			return errwrap.Wrapf("Error reading publication tables: {{err}}", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var schemaName, tableName string
+			if err := rows.Scan(&schemaName, &tableName); err != nil {
+				return errwrap.Wrapf("Error scanning publication table: {{err}}", err)
+			}
+			tables = append(tables, schemaName+"."+tableName)
+		}
+		if err := rows.Err(); err != nil {
+			return errwrap.Wrapf("Error reading publication tables: {{err}}", err)
+		}
+	}
+
+	d.Set(pubAllTablesAttr, allTables)
+	d.Set(pubTablesAttr, schema.NewSet(schema.HashString, tables))
+	d.Set(pubPublishAttr, schema.NewSet(schema.HashString, publish))
+	d.Set(pubDatabaseAttr, database)
+	d.SetId(generatePublicationID(d))
+
+	return nil
+}
+
+func resourcePostgreSQLPublicationUpdate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkPublicationSupported(c, d); err != nil {
+		return err
+	}
+
+	database := getPublicationDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	pubName := pqQuoteIdentifier(d.Get(pubNameAttr).(string))
+
+	var queries []string
+	if d.HasChange(pubTablesAttr) {
+		oldRaw, newRaw := d.GetChange(pubTablesAttr)
+		oldTables, newTables := oldRaw.(*schema.Set), newRaw.(*schema.Set)
+		if dropped := setToStrings(oldTables.Difference(newTables)); len(dropped) > 0 {
+			queries = append(queries, fmt.Sprintf("ALTER PUBLICATION %s DROP TABLE %s", pubName, quotePublicationTables(dropped)))
+		}
+		if added := setToStrings(newTables.Difference(oldTables)); len(added) > 0 {
+			queries = append(queries, fmt.Sprintf("ALTER PUBLICATION %s ADD TABLE %s", pubName, quotePublicationTables(added)))
+		}
+	}
+	if d.HasChange(pubPublishAttr) {
+		queries = append(queries, fmt.Sprintf("ALTER PUBLICATION %s SET (publish = '%s')",
+			pubName, publicationPublish(d.Get(pubPublishAttr).(*schema.Set)),
+		))
+	}
+
+	if err := execQueries(c.ctx, txn, queries); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error updating publication %s: {{err}}", d.Get(pubNameAttr).(string)), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing publication: {{err}}", err)
+	}
+
+	return resourcePostgreSQLPublicationReadImpl(d, c)
+}
+
+func resourcePostgreSQLPublicationDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkPublicationSupported(c, d); err != nil {
+		return err
+	}
+
+	database := getPublicationDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	pubName := d.Get(pubNameAttr).(string)
+	if _, err := txn.ExecContext(c.ctx, fmt.Sprintf("DROP PUBLICATION IF EXISTS %s", pqQuoteIdentifier(pubName))); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error deleting publication %s: {{err}}", pubName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing publication deletion: {{err}}", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// resourcePostgreSQLPublicationImport imports a publication from an ID
+// with the database/name format.
+func resourcePostgreSQLPublicationImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), "database/name")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set(pubDatabaseAttr, parts[0])
+	d.Set(pubNameAttr, parts[1])
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// adoptExistingPublication adopts the publication in the state, instead of creating it, if it
+// already exists and adopt_if_exists is set. The publication is read as is: the differences with
+// the configuration are shown by the next plan (see adoptExistingRole).
+func adoptExistingPublication(c *Client, txn *sql.Tx, d *schema.ResourceData, database string) (bool, error) {
+	if !d.Get(pubAdoptIfExistsAttr).(bool) {
+		return false, nil
+	}
+
+	pubName := d.Get(pubNameAttr).(string)
+
+	var exists bool
+	if err := txn.QueryRowContext(c.ctx,
+		"SELECT EXISTS(SELECT 1 FROM pg_catalog.pg_publication WHERE pubname = $1)", pubName,
+	).Scan(&exists); err != nil {
+		return false, errwrap.Wrapf("could not check if publication exists: {{err}}", err)
+	}
+	if !exists {
+		return false, nil
+	}
+
+	log.Printf("[WARN] Publication %s already exists, adopting it in the state (%s)", pubName, pubAdoptIfExistsAttr)
+	d.Set(pubDatabaseAttr, database)
+	d.SetId(generatePublicationID(d))
+
+	return true, resourcePostgreSQLPublicationReadImpl(d, c)
+}
+
+func checkPublicationSupported(c *Client, d *schema.ResourceData) error {
+	if !c.featureSupported(featurePublication) {
+		return fmt.Errorf(
+			"postgresql_publication resource is not supported for this Postgres version (%s)",
+			c.version,
+		)
+	}
+
+	if publish, ok := d.GetOk(pubPublishAttr); ok && publish.(*schema.Set).Contains("truncate") && !c.featureSupported(featurePublicationTruncate) {
+		return fmt.Errorf(
+			"publishing truncate is not supported for this Postgres version (%s)",
+			c.version,
+		)
+	}
+	return nil
+}
+
+// quotePublicationTables returns the quoted list of the tables (schema.table).
+func quotePublicationTables(tables []string) string {
+	quoted := make([]string, len(tables))
+	for i, table := range tables {
+		parts := strings.SplitN(table, ".", 2)
+		quoted[i] = pqQuoteIdentifier(parts[0]) + "." + pqQuoteIdentifier(parts[1])
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// publicationPublish returns the value of the publish option, in the order of pubOperations.
+func publicationPublish(publish *schema.Set) string {
+	operations := []string{}
+	for _, operation := range pubOperations {
+		if publish.Contains(operation) {
+			operations = append(operations, operation)
+		}
+	}
+	return strings.Join(operations, ", ")
+}
+
+func getPublicationDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(pubDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generatePublicationID(d *schema.ResourceData) string {
+	return strings.Join([]string{d.Get(pubDatabaseAttr).(string), d.Get(pubNameAttr).(string)}, ".")
+}
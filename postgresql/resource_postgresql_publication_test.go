@@ -0,0 +1,137 @@
+package postgresql
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccPostgresqlPublication_Basic(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, false)
+	defer teardown()
+
+	createTestTables(t, dbSuffix, []string{"test_schema.orders", "test_schema.items"})
+
+	dbName, _ := getTestDBNames(dbSuffix)
+
+	testAccConfig := func(tables, publish string) string {
+		return fmt.Sprintf(`
+resource "postgresql_publication" "orders" {
+  database = "%s"
+  name     = "orders"
+  tables   = [%s]
+  publish  = [%s]
+}
+`, dbName, tables, publish)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featurePublication)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfig(`"test_schema.orders"`, `"insert", "update"`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_publication.orders", "id", fmt.Sprintf("%s.orders", dbName)),
+					resource.TestCheckResourceAttr("postgresql_publication.orders", "all_tables", "false"),
+					resource.TestCheckResourceAttr("postgresql_publication.orders", "tables.#", "1"),
+					resource.TestCheckResourceAttr("postgresql_publication.orders", "publish.#", "2"),
+				),
+			},
+			{
+				Config: testAccConfig(`"test_schema.orders", "test_schema.items"`, `"insert", "update", "delete"`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_publication.orders", "tables.#", "2"),
+					resource.TestCheckResourceAttr("postgresql_publication.orders", "publish.#", "3"),
+				),
+			},
+			{
+				ResourceName:      "postgresql_publication.orders",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("%s/orders", dbName),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlPublication_AllTables(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, false)
+	defer teardown()
+
+	createTestTables(t, dbSuffix, []string{"test_schema.orders"})
+
+	dbName, _ := getTestDBNames(dbSuffix)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featurePublication)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "postgresql_publication" "all" {
+  database   = "%s"
+  name       = "all_tables"
+  all_tables = true
+}
+`, dbName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_publication.all", "all_tables", "true"),
+					resource.TestCheckResourceAttr("postgresql_publication.all", "tables.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlPublication_AdoptIfExists(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, false)
+	defer teardown()
+
+	createTestTables(t, dbSuffix, []string{"test_schema.orders", "test_schema.items"})
+
+	dbName, _ := getTestDBNames(dbSuffix)
+
+	// The publication is created outside of Terraform, it's dropped by the destroy of the resource.
+	config := getTestConfig(t)
+	dbExecute(t, config.connStr(dbName), "CREATE PUBLICATION adopted FOR TABLE test_schema.orders")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featurePublication)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "postgresql_publication" "adopted" {
+  database        = "%s"
+  name            = "adopted"
+  tables          = ["test_schema.orders", "test_schema.items"]
+  adopt_if_exists = true
+}
+`, dbName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_publication.adopted", "id", fmt.Sprintf("%s.adopted", dbName)),
+					resource.TestCheckResourceAttr("postgresql_publication.adopted", "tables.#", "1"),
+				),
+				// The existing publication is adopted as is, the table is added by the next apply.
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
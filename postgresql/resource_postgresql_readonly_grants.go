@@ -0,0 +1,286 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	roGrantsRoleAttr     = "role"
+	roGrantsDatabaseAttr = "database"
+	roGrantsSchemasAttr  = "schemas"
+	roGrantsOwnersAttr   = "owners"
+)
+
+// resourcePostgreSQLReadonlyGrants grants the read-only access to schemas: USAGE on the schemas,
+// SELECT on their tables and sequences and the default privileges granting SELECT on the tables
+// and sequences created later (the usual combination of postgresql_grant and postgresql_default_privileges).
+func resourcePostgreSQLReadonlyGrants() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLReadonlyGrantsCreate),
+		Read:   resourcePostgreSQLReadonlyGrantsRead,
+		Update: retryOnTransientErrors(resourcePostgreSQLReadonlyGrantsUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLReadonlyGrantsDelete),
+
+		Schema: map[string]*schema.Schema{
+			roGrantsRoleAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateRoleName,
+				Description:  "The role to grant the read-only access to",
+			},
+			roGrantsDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database of the schemas",
+			},
+			roGrantsSchemasAttr: {
+				Type:        schema.TypeSet,
+				Required:    true,
+				MinItems:    1,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         schema.HashString,
+				Description: "The schemas to grant the read-only access to",
+			},
+			roGrantsOwnersAttr: {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         schema.HashString,
+				Description: "The roles creating the tables and sequences, whose default privileges are altered (the connected user by default)",
+			},
+		},
+	}
+}
+
+func resourcePostgreSQLReadonlyGrantsCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if !c.featureSupported(featurePrivileges) {
+		return fmt.Errorf(
+			"postgresql_readonly_grants resource is not supported for this Postgres version (%s)",
+			c.version,
+		)
+	}
+
+	database := getReadonlyGrantsDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	if err := execQueries(c.ctx, txn, readonlyGrantsQueries(d, "GRANT", setToStrings(d.Get(roGrantsSchemasAttr).(*schema.Set)))); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("could not grant read-only access to %s: {{err}}", d.Get(roGrantsRoleAttr).(string)), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("could not commit transaction: {{err}}", err)
+	}
+
+	d.Set(roGrantsDatabaseAttr, database)
+	d.SetId(generateReadonlyGrantsID(d))
+
+	return resourcePostgreSQLReadonlyGrantsReadImpl(d, c)
+}
+
+func resourcePostgreSQLReadonlyGrantsRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	defer c.rLockDatabase(getReadonlyGrantsDatabase(d, c))()
+
+	return resourcePostgreSQLReadonlyGrantsReadImpl(d, c)
+}
+
+// resourcePostgreSQLReadonlyGrantsReadImpl keeps in the state the schemas on which the role
+// has USAGE and SELECT on all the tables and sequences: the other ones are removed, so the
+// privileges are granted again (e.g.: on the tables created by a role which is not an owner).
+// The default privileges are not read.
+func resourcePostgreSQLReadonlyGrantsReadImpl(d *schema.ResourceData, c *Client) error {
+	database := getReadonlyGrantsDatabase(d, c)
+	role := d.Get(roGrantsRoleAttr).(string)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	var roleExists bool
+	if err := txn.QueryRowContext(c.ctx,
+		"SELECT EXISTS(SELECT 1 FROM pg_catalog.pg_roles WHERE rolname = $1)", role,
+	).Scan(&roleExists); err != nil {
+		return errwrap.Wrapf("could not check if role exists: {{err}}", err)
+	}
+	if !roleExists {
+		log.Printf("[WARN] PostgreSQL role (%s) of read-only grants (%s) not found", role, d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	query := `SELECT pg_catalog.has_schema_privilege($1, n.oid, 'USAGE') AND NOT EXISTS(
+		SELECT 1 FROM pg_catalog.pg_class c
+		WHERE c.relnamespace = n.oid AND c.relkind IN ('r', 'v', 'm', 'f', 'p', 'S')
+		AND NOT pg_catalog.has_table_privilege($1, c.oid, 'SELECT')
+	) FROM pg_catalog.pg_namespace n WHERE n.nspname = $2`
+
+	schemas := []interface{}{}
+	for _, schemaName := range setToStrings(d.Get(roGrantsSchemasAttr).(*schema.Set)) {
+		var granted bool
+		err := txn.QueryRowContext(c.ctx, query, role, schemaName).Scan(&granted)
+		switch {
+		case err == sql.ErrNoRows:
+			log.Printf("[WARN] schema %s of read-only grants (%s) not found", schemaName, d.Id())
+			continue
+		case err != nil:
+			return errwrap.Wrapf(fmt.Sprintf("could not read the privileges of %s on schema %s: {{err}}", role, schemaName), err)
+		}
+		if !granted {
+			log.Printf("[DEBUG] %s is missing read-only privileges in schema %s", role, schemaName)
+			continue
+		}
+		schemas = append(schemas, schemaName)
+	}
+
+	d.Set(roGrantsSchemasAttr, schema.NewSet(schema.HashString, schemas))
+	d.Set(roGrantsDatabaseAttr, database)
+	d.SetId(generateReadonlyGrantsID(d))
+
+	return nil
+}
+
+// resourcePostgreSQLReadonlyGrantsUpdate revokes the privileges on the removed schemas and
+// grants them on the added ones (or on the ones missing privileges, see the read).
+func resourcePostgreSQLReadonlyGrantsUpdate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getReadonlyGrantsDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	oldRaw, newRaw := d.GetChange(roGrantsSchemasAttr)
+	oldSchemas, newSchemas := oldRaw.(*schema.Set), newRaw.(*schema.Set)
+
+	var queries []string
+	// The dropped schemas have no privileges left to revoke.
+	for _, schemaName := range setToStrings(oldSchemas.Difference(newSchemas)) {
+		exists, err := schemaExists(c.ctx, txn, schemaName)
+		if err != nil {
+			return err
+		}
+		if exists {
+			queries = append(queries, readonlyGrantsQueries(d, "REVOKE", []string{schemaName})...)
+		}
+	}
+	queries = append(queries, readonlyGrantsQueries(d, "GRANT", setToStrings(newSchemas.Difference(oldSchemas)))...)
+
+	if err := execQueries(c.ctx, txn, queries); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("could not update read-only access of %s: {{err}}", d.Get(roGrantsRoleAttr).(string)), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("could not commit transaction: {{err}}", err)
+	}
+
+	return resourcePostgreSQLReadonlyGrantsReadImpl(d, c)
+}
+
+func resourcePostgreSQLReadonlyGrantsDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getReadonlyGrantsDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	var schemas []string
+	for _, schemaName := range setToStrings(d.Get(roGrantsSchemasAttr).(*schema.Set)) {
+		exists, err := schemaExists(c.ctx, txn, schemaName)
+		if err != nil {
+			return err
+		}
+		if exists {
+			schemas = append(schemas, schemaName)
+		}
+	}
+
+	if err := execQueries(c.ctx, txn, readonlyGrantsQueries(d, "REVOKE", schemas)); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("could not revoke read-only access of %s: {{err}}", d.Get(roGrantsRoleAttr).(string)), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("could not commit transaction: {{err}}", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// readonlyGrantsQueries returns the statements granting (or revoking) the read-only access
+// to the schemas: USAGE on the schema, SELECT on the existing tables and sequences and
+// the default privileges of the owners on the future ones.
+func readonlyGrantsQueries(d *schema.ResourceData, action string, schemas []string) []string {
+	role := pqQuoteRoleName(d.Get(roGrantsRoleAttr).(string))
+	preposition := "TO"
+	if action == "REVOKE" {
+		preposition = "FROM"
+	}
+
+	forRole := ""
+	if owners := setToStrings(d.Get(roGrantsOwnersAttr).(*schema.Set)); len(owners) > 0 {
+		quotedOwners := make([]string, len(owners))
+		for i, owner := range owners {
+			quotedOwners[i] = pqQuoteIdentifier(owner)
+		}
+		forRole = fmt.Sprintf(" FOR ROLE %s", strings.Join(quotedOwners, ", "))
+	}
+
+	var queries []string
+	for _, schemaName := range schemas {
+		quotedSchema := pqQuoteIdentifier(schemaName)
+		queries = append(queries,
+			fmt.Sprintf("%s USAGE ON SCHEMA %s %s %s", action, quotedSchema, preposition, role),
+			fmt.Sprintf("%s SELECT ON ALL TABLES IN SCHEMA %s %s %s", action, quotedSchema, preposition, role),
+			fmt.Sprintf("%s SELECT ON ALL SEQUENCES IN SCHEMA %s %s %s", action, quotedSchema, preposition, role),
+			fmt.Sprintf("ALTER DEFAULT PRIVILEGES%s IN SCHEMA %s %s SELECT ON TABLES %s %s", forRole, quotedSchema, action, preposition, role),
+			fmt.Sprintf("ALTER DEFAULT PRIVILEGES%s IN SCHEMA %s %s SELECT ON SEQUENCES %s %s", forRole, quotedSchema, action, preposition, role),
+		)
+	}
+	return queries
+}
+
+func getReadonlyGrantsDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(roGrantsDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generateReadonlyGrantsID(d *schema.ResourceData) string {
+	return strings.Join([]string{d.Get(roGrantsDatabaseAttr).(string), d.Get(roGrantsRoleAttr).(string)}, "/")
+}
@@ -0,0 +1,86 @@
+package postgresql
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+const testAccPostgresqlReadonlyGrantsConfig = `
+resource "postgresql_role" "reader" {
+  name = "readonly_grants_reader"
+}
+
+resource "postgresql_schema" "first" {
+  name = "readonly_grants_first"
+}
+
+resource "postgresql_schema" "second" {
+  name = "readonly_grants_second"
+}
+
+resource "postgresql_readonly_grants" "reader" {
+  role    = postgresql_role.reader.name
+  schemas = [%s]
+}
+`
+
+func TestAccPostgresqlReadonlyGrants_Basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featurePrivileges)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(testAccPostgresqlReadonlyGrantsConfig, "postgresql_schema.first.name"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_readonly_grants.reader", "schemas.#", "1"),
+					testAccCheckPostgresqlReadonlyGrants("readonly_grants_first", true),
+					testAccCheckPostgresqlReadonlyGrants("readonly_grants_second", false),
+				),
+			},
+			{
+				Config: fmt.Sprintf(testAccPostgresqlReadonlyGrantsConfig, "postgresql_schema.second.name"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_readonly_grants.reader", "schemas.#", "1"),
+					testAccCheckPostgresqlReadonlyGrants("readonly_grants_first", false),
+					testAccCheckPostgresqlReadonlyGrants("readonly_grants_second", true),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckPostgresqlReadonlyGrants(schemaName string, expected bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		client := testAccProvider.Meta().(*Client)
+
+		var usage bool
+		if err := client.DB().QueryRow(
+			"SELECT pg_catalog.has_schema_privilege('readonly_grants_reader', $1, 'USAGE')", schemaName,
+		).Scan(&usage); err != nil {
+			return fmt.Errorf("Error checking the privileges on schema %s: %s", schemaName, err)
+		}
+		if usage != expected {
+			return fmt.Errorf("expected USAGE on schema %s: %t", schemaName, expected)
+		}
+
+		var defaultACL bool
+		if err := client.DB().QueryRow(
+			`SELECT EXISTS(SELECT 1 FROM pg_catalog.pg_default_acl a
+			JOIN pg_catalog.pg_namespace n ON n.oid = a.defaclnamespace
+			WHERE n.nspname = $1 AND a.defaclacl::TEXT LIKE '%readonly_grants_reader=r/%')`, schemaName,
+		).Scan(&defaultACL); err != nil {
+			return fmt.Errorf("Error checking the default privileges in schema %s: %s", schemaName, err)
+		}
+		if defaultACL != expected {
+			return fmt.Errorf("expected default privileges in schema %s: %t", schemaName, expected)
+		}
+
+		return nil
+	}
+}
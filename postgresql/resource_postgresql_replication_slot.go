@@ -0,0 +1,235 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+const (
+	slotNameAttr     = "name"
+	slotPluginAttr   = "plugin"
+	slotDatabaseAttr = "database"
+	slotTypeAttr     = "type"
+	slotFailoverAttr = "failover"
+	slotSyncedAttr   = "synced"
+)
+
+// The names of the replication slots can only contain lower case letters, numbers and underscores.
+var replicationSlotNameRegexp = regexp.MustCompile(`^[a-z0-9_]{1,63}$`)
+
+// resourcePostgreSQLReplicationSlot manages a physical replication slot (for the standby servers)
+// or, when a plugin is set, a logical replication slot (for the logical decoding clients).
+// pg_create_logical_replication_slot cannot run in a transaction which has performed writes,
+// so the functions are called outside of a transaction.
+func resourcePostgreSQLReplicationSlot() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLReplicationSlotCreate),
+		Read:   resourcePostgreSQLReplicationSlotRead,
+		Delete: retryOnTransientErrors(resourcePostgreSQLReplicationSlotDelete),
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			slotNameAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringMatch(replicationSlotNameRegexp, "may only contain lower case letters, numbers and underscores"),
+				Description:  "The name of the replication slot",
+			},
+			slotPluginAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The output plugin of a logical replication slot (e.g.: pgoutput, wal2json). A physical replication slot is created if not set",
+			},
+			slotDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database of a logical replication slot",
+			},
+			slotTypeAttr: {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The type of the replication slot (physical or logical)",
+			},
+			slotFailoverAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				ForceNew:    true,
+				Description: "Synchronize the logical replication slot to the standby servers, so its consumer can continue after a failover",
+			},
+			slotSyncedAttr: {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the slot is a copy synchronized from the primary server (on a standby server)",
+			},
+		},
+	}
+}
+
+func resourcePostgreSQLReplicationSlotCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkReplicationSlotSupported(c); err != nil {
+		return err
+	}
+
+	slotName := d.Get(slotNameAttr).(string)
+	plugin := d.Get(slotPluginAttr).(string)
+	failover := d.Get(slotFailoverAttr).(bool)
+	database := getReplicationSlotDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	if failover && !c.featureSupported(featureFailoverSlots) {
+		return fmt.Errorf("failover is not supported for this Postgres version (%s)", c.version)
+	}
+
+	if plugin == "" {
+		if _, ok := d.GetOk(slotDatabaseAttr); ok {
+			return fmt.Errorf("database can only be set for a logical replication slot (with a plugin)")
+		}
+		if failover {
+			return fmt.Errorf("failover can only be set for a logical replication slot (with a plugin)")
+		}
+
+		if _, err := c.DB().ExecContext(c.ctx, "SELECT pg_catalog.pg_create_physical_replication_slot($1)", slotName); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("Error creating physical replication slot %s: {{err}}", slotName), err)
+		}
+	} else {
+		// A logical replication slot belongs to the database it is created from.
+		client, err := databaseClient(c, database)
+		if err != nil {
+			return err
+		}
+
+		// The failover argument only exists since PostgreSQL 17.
+		query := "SELECT pg_catalog.pg_create_logical_replication_slot($1, $2)"
+		if failover {
+			query = "SELECT pg_catalog.pg_create_logical_replication_slot($1, $2, failover => true)"
+		}
+		if _, err := client.DB().ExecContext(c.ctx, query, slotName, plugin); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("Error creating logical replication slot %s: {{err}}", slotName), err)
+		}
+	}
+
+	d.SetId(slotName)
+
+	return resourcePostgreSQLReplicationSlotReadImpl(d, c)
+}
+
+func resourcePostgreSQLReplicationSlotRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkReplicationSlotSupported(c); err != nil {
+		return err
+	}
+
+	defer c.rLockDatabase(getReplicationSlotDatabase(d, c))()
+
+	return resourcePostgreSQLReplicationSlotReadImpl(d, c)
+}
+
+func resourcePostgreSQLReplicationSlotReadImpl(d *schema.ResourceData, c *Client) error {
+	// The failover and synced columns only exist since PostgreSQL 17.
+	failoverColumns := "false, false"
+	if c.featureSupported(featureFailoverSlots) {
+		failoverColumns = "failover, synced"
+	}
+
+	var slotType, plugin, database string
+	var failover, synced bool
+	err := c.DB().QueryRowContext(c.ctx, fmt.Sprintf(
+		"SELECT slot_type, COALESCE(plugin::text, ''), COALESCE(database::text, ''), %s FROM pg_catalog.pg_replication_slots WHERE slot_name = $1",
+		failoverColumns,
+	), d.Id()).Scan(&slotType, &plugin, &database, &failover, &synced)
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] PostgreSQL replication slot (%s) not found", d.Id())
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading replication slot: {{err}}", err)
+	}
+
+	d.Set(slotNameAttr, d.Id())
+	d.Set(slotTypeAttr, slotType)
+	d.Set(slotPluginAttr, plugin)
+	d.Set(slotDatabaseAttr, database)
+	d.Set(slotFailoverAttr, failover)
+	d.Set(slotSyncedAttr, synced)
+
+	return nil
+}
+
+func resourcePostgreSQLReplicationSlotDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkReplicationSlotSupported(c); err != nil {
+		return err
+	}
+
+	slotName := d.Id()
+
+	defer c.lockDatabase(getReplicationSlotDatabase(d, c))()
+
+	var active bool
+	var activePID sql.NullInt64
+	err := c.DB().QueryRowContext(c.ctx,
+		"SELECT active, active_pid FROM pg_catalog.pg_replication_slots WHERE slot_name = $1",
+		slotName,
+	).Scan(&active, &activePID)
+	switch {
+	case err == sql.ErrNoRows:
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading replication slot: {{err}}", err)
+	}
+
+	// A slot in use by a standby server or a logical decoding client cannot be dropped,
+	// its consumer has to be stopped (or its backend terminated) first.
+	if active {
+		return fmt.Errorf(
+			"replication slot %s is active (used by the process %d), stop its consumer before deleting it",
+			slotName, activePID.Int64,
+		)
+	}
+
+	if _, err := c.DB().ExecContext(c.ctx, "SELECT pg_catalog.pg_drop_replication_slot($1)", slotName); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error deleting replication slot %s: {{err}}", slotName), err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+func getReplicationSlotDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(slotDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func checkReplicationSlotSupported(c *Client) error {
+	if !c.featureSupported(featureReplicationSlot) {
+		return fmt.Errorf(
+			"postgresql_replication_slot resource is not supported for this Postgres version (%s)",
+			c.version,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccPostgresqlReplicationSlot_Physical(t *testing.T) {
+	skipIfNotAcc(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureReplicationSlot)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPostgresqlReplicationSlotDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "postgresql_replication_slot" "standby" {
+  name = "tf_tests_standby"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_replication_slot.standby", "id", "tf_tests_standby"),
+					resource.TestCheckResourceAttr("postgresql_replication_slot.standby", "type", "physical"),
+					resource.TestCheckResourceAttr("postgresql_replication_slot.standby", "plugin", ""),
+					resource.TestCheckResourceAttr("postgresql_replication_slot.standby", "database", ""),
+					resource.TestCheckResourceAttr("postgresql_replication_slot.standby", "failover", "false"),
+					resource.TestCheckResourceAttr("postgresql_replication_slot.standby", "synced", "false"),
+				),
+			},
+			{
+				ResourceName:      "postgresql_replication_slot.standby",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlReplicationSlot_Failover(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, false)
+	defer teardown()
+
+	dbName, _ := getTestDBNames(dbSuffix)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureFailoverSlots)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPostgresqlReplicationSlotDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "postgresql_replication_slot" "orders" {
+  name     = "tf_tests_failover_%s"
+  database = "%s"
+  plugin   = "pgoutput"
+  failover = true
+}
+`, dbSuffix, dbName),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_replication_slot.orders", "type", "logical"),
+					resource.TestCheckResourceAttr("postgresql_replication_slot.orders", "failover", "true"),
+					// Only the slots synchronized on a standby server are synced.
+					resource.TestCheckResourceAttr("postgresql_replication_slot.orders", "synced", "false"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckPostgresqlReplicationSlotDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "postgresql_replication_slot" {
+			continue
+		}
+
+		var slotName string
+		err := client.DB().QueryRow(
+			"SELECT slot_name FROM pg_catalog.pg_replication_slots WHERE slot_name = $1", rs.Primary.ID,
+		).Scan(&slotName)
+		switch {
+		case err == sql.ErrNoRows:
+			continue
+		case err != nil:
+			return fmt.Errorf("Error checking replication slot %s", err)
+		}
+
+		return fmt.Errorf("Replication slot %s still exists after destroy", slotName)
+	}
+
+	return nil
+}
@@ -0,0 +1,313 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+// revokeACLQueries returns, for each object type, the name of the objects
+// with their exploded ACL. The default ACL is used if the object has no ACL
+// (e.g.: PUBLIC has CONNECT and TEMPORARY on databases by default).
+//
+// Parameters are: $2 the list of objects, $3 the schema, $4 the list of relkinds.
+var revokeACLQueries = map[string]string{
+	"database": `SELECT datname, (aclexplode(COALESCE(datacl, acldefault('d', datdba)))).*
+FROM pg_database WHERE datname = ANY($2)`,
+	"schema": `SELECT nspname, (aclexplode(COALESCE(nspacl, acldefault('n', nspowner)))).*
+FROM pg_namespace WHERE nspname = ANY($2)`,
+	"tablespace": `SELECT spcname, (aclexplode(COALESCE(spcacl, acldefault('t', spcowner)))).*
+FROM pg_tablespace WHERE spcname = ANY($2)`,
+	"table": `SELECT relname, (aclexplode(COALESCE(relacl, acldefault('r', relowner)))).*
+FROM pg_class JOIN pg_namespace ON pg_namespace.oid = pg_class.relnamespace
+WHERE nspname = $3 AND relkind::text = ANY($4)
+AND (array_length($2::text[], 1) IS NULL OR relname = ANY($2))`,
+	"sequence": `SELECT relname, (aclexplode(COALESCE(relacl, acldefault('s', relowner)))).*
+FROM pg_class JOIN pg_namespace ON pg_namespace.oid = pg_class.relnamespace
+WHERE nspname = $3 AND relkind::text = ANY($4)
+AND (array_length($2::text[], 1) IS NULL OR relname = ANY($2))`,
+}
+
+func resourcePostgreSQLRevoke() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLRevokeCreate),
+		// As create only revokes we can use it to update too
+		Update: retryOnTransientErrors(resourcePostgreSQLRevokeCreate),
+		Read:   resourcePostgreSQLRevokeRead,
+		Delete: retryOnTransientErrors(resourcePostgreSQLRevokeDelete),
+
+		Schema: map[string]*schema.Schema{
+			"role": {
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateFunc:     validateIdentifier,
+				Description:      "The name of the role to revoke privileges from (use `public` for PUBLIC)",
+				DiffSuppressFunc: suppressIdentifierCaseDiff,
+			},
+			"database": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database to revoke privileges on for this role",
+			},
+			"schema": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				ValidateFunc:     validateIdentifier,
+				Description:      "The database schema to revoke privileges on for this role (required for table and sequence)",
+				DiffSuppressFunc: suppressIdentifierCaseDiff,
+			},
+			"object_type": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+				ValidateFunc: validation.StringInSlice([]string{
+					"database",
+					"schema",
+					"table",
+					"sequence",
+					"tablespace",
+				}, false),
+				Description: "The PostgreSQL object type to revoke the privileges on (one of: database, schema, table, sequence, tablespace)",
+			},
+			"objects": &schema.Schema{
+				Type:        schema.TypeSet,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         schema.HashString,
+				Description: "The objects to revoke privileges on (required for database, schema and tablespace, all objects of the schema if not specified for table and sequence)",
+			},
+			"privileges": &schema.Schema{
+				Type:        schema.TypeSet,
+				Required:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         schema.HashString,
+				MinItems:    1,
+				Description: "The list of privileges to revoke",
+			},
+			"restore_on_destroy": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Grant the privileges back to the role when the resource is destroyed",
+			},
+			quotedIdentifiersAttr:     quotedIdentifiersSchema(),
+			detectExternalChangesAttr: detectExternalChangesSchema(),
+		},
+	}
+}
+
+func resourcePostgreSQLRevokeRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+
+	if !client.featureSupported(featureACLDefault) {
+		return fmt.Errorf(
+			"postgresql_revoke resource is not supported for this Postgres version (%s)",
+			client.version,
+		)
+	}
+
+	defer client.rLockDatabase(d.Get("database").(string))()
+
+	exists, err := checkRoleDBSchemaExists(client, d, []string{d.Get("role").(string)})
+	if err != nil {
+		return err
+	}
+	if !exists {
+		d.SetId("")
+		return nil
+	}
+	d.SetId(generateGrantID(d))
+
+	if !detectExternalChanges(d) {
+		return nil
+	}
+
+	txn, err := startReadTransaction(client, d.Get("database").(string))
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	return readRevokedPrivileges(client.ctx, txn, d)
+}
+
+func resourcePostgreSQLRevokeCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+
+	if !client.featureSupported(featureACLDefault) {
+		return fmt.Errorf(
+			"postgresql_revoke resource is not supported for this Postgres version (%s)",
+			client.version,
+		)
+	}
+
+	if err := validatePrivileges(d.Get("object_type").(string), d.Get("privileges").(*schema.Set).List()); err != nil {
+		return err
+	}
+
+	if err := validateGrantTarget(d); err != nil {
+		return err
+	}
+
+	if err := checkSupabaseGrantees(client, []string{d.Get("role").(string)}); err != nil {
+		return err
+	}
+
+	database := d.Get("database").(string)
+
+	defer client.lockDatabase(database)()
+
+	txn, err := startTransaction(client, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	target, err := grantTargetClause(client.ctx, txn, d)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(
+		"REVOKE %s ON %s FROM %s",
+		strings.Join(setToPgPrivileges(d.Get("privileges").(*schema.Set)), ","),
+		target,
+		pqQuoteRoleName(d.Get("role").(string)),
+	)
+	if _, err := txn.ExecContext(client.ctx, query); err != nil {
+		return errwrap.Wrapf("could not revoke privileges: {{err}}", err)
+	}
+
+	if err = commitTransaction(client, txn); err != nil {
+		return errwrap.Wrapf("could not commit transaction: {{err}}", err)
+	}
+
+	d.SetId(generateGrantID(d))
+
+	txn, err = startTransaction(client, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	return readRevokedPrivileges(client.ctx, txn, d)
+}
+
+func resourcePostgreSQLRevokeDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*Client)
+
+	if !client.featureSupported(featureACLDefault) {
+		return fmt.Errorf(
+			"postgresql_revoke resource is not supported for this Postgres version (%s)",
+			client.version,
+		)
+	}
+
+	if !d.Get("restore_on_destroy").(bool) {
+		return nil
+	}
+
+	defer client.lockDatabase(d.Get("database").(string))()
+
+	txn, err := startTransaction(client, d.Get("database").(string))
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	target, err := grantTargetClause(client.ctx, txn, d)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(
+		"GRANT %s ON %s TO %s",
+		strings.Join(setToPgPrivileges(d.Get("privileges").(*schema.Set)), ","),
+		target,
+		pqQuoteRoleName(d.Get("role").(string)),
+	)
+	if _, err := txn.ExecContext(client.ctx, query); err != nil {
+		return errwrap.Wrapf("could not restore privileges: {{err}}", err)
+	}
+
+	if err = commitTransaction(client, txn); err != nil {
+		return errwrap.Wrapf("could not commit transaction: {{err}}", err)
+	}
+
+	return nil
+}
+
+func readRevokedPrivileges(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) error {
+	objectType := d.Get("object_type").(string)
+
+	// This returns, for the specified role (or PUBLIC),
+	// the list of objects on which the role still has some privileges
+	// with the list of these privileges.
+	query := fmt.Sprintf(`
+SELECT objname, array_agg(privilege_type)
+FROM (%s) AS acls (objname, grantor, grantee, privilege_type, is_grantable)
+WHERE grantee = CASE
+    WHEN lower($1::text) = 'public' THEN 0::oid
+    ELSE (SELECT oid FROM pg_roles WHERE rolname = $1::text)
+END
+GROUP BY objname;
+`, revokeACLQueries[objectType])
+
+	queryArgs := []interface{}{d.Get("role"), grantObjects(d)}
+	if !sliceContainsStr(globalObjectTypes, objectType) {
+		queryArgs = append(queryArgs, d.Get("schema"), grantRelkinds[objectType])
+	}
+
+	rows, err := txn.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return errwrap.Wrapf("could not read privileges: {{err}}", err)
+	}
+	defer rows.Close()
+
+	revokedPrivileges := d.Get("privileges").(*schema.Set)
+	driftedObjects := []string{}
+
+	for rows.Next() {
+		var objName string
+		var privileges []string
+
+		if err := rows.Scan(&objName, pgArray(&privileges)); err != nil {
+			return err
+		}
+
+		if remaining := pgArrayToSet(privileges).Intersection(revokedPrivileges); remaining.Len() > 0 {
+			log.Printf(
+				"[DEBUG] %s %s still has privileges %v for role %s",
+				strings.ToTitle(objectType), objName, remaining.List(), d.Get("role"),
+			)
+			driftedObjects = append(driftedObjects, objName)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(driftedObjects) > 0 {
+		// If any object has some of the privileges which should have been revoked,
+		// we return an empty privileges to force an update.
+		log.Printf(
+			"[WARN] %d %s(s) have privileges which should be revoked from role %s: %s",
+			len(driftedObjects), objectType, d.Get("role"), strings.Join(driftedObjects, ", "),
+		)
+		d.Set("privileges", schema.NewSet(schema.HashString, []interface{}{}))
+	}
+
+	return nil
+}
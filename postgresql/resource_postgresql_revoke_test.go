@@ -0,0 +1,80 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccPostgresqlRevoke(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, true)
+	defer teardown()
+
+	dbName, _ := getTestDBNames(dbSuffix)
+
+	var testRevokePublicConnect = fmt.Sprintf(`
+	resource "postgresql_revoke" "test" {
+		database    = "%[1]s"
+		role        = "public"
+		object_type = "database"
+		objects     = ["%[1]s"]
+		privileges  = ["CONNECT", "TEMPORARY"]
+	}
+	`, dbName)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureACLDefault)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckRevokeRestored(t, dbName),
+		Steps: []resource.TestStep{
+			{
+				Config: testRevokePublicConnect,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_revoke.test", "privileges.#", "2"),
+					func(*terraform.State) error {
+						return testCheckPublicDatabasePrivilege(t, dbName, false)
+					},
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckRevokeRestored(t *testing.T, dbName string) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		return testCheckPublicDatabasePrivilege(t, dbName, true)
+	}
+}
+
+func testCheckPublicDatabasePrivilege(t *testing.T, dbName string, expected bool) error {
+	config := getTestConfig(t)
+
+	db, err := sql.Open("pgx", config.connStr("postgres"))
+	if err != nil {
+		t.Fatalf("could not open connection pool: %v", err)
+	}
+	defer db.Close()
+
+	var hasPrivilege bool
+	query := `SELECT COUNT(*) > 0 FROM (
+		SELECT (aclexplode(COALESCE(datacl, acldefault('d', datdba)))).* FROM pg_database WHERE datname = $1
+	) acls WHERE grantee = 0 AND privilege_type = 'CONNECT'`
+	if err := db.QueryRow(query, dbName).Scan(&hasPrivilege); err != nil {
+		return fmt.Errorf("could not check database privilege: %v", err)
+	}
+
+	if hasPrivilege != expected {
+		return fmt.Errorf(
+			"PUBLIC has CONNECT on database %s: %t (expected: %t)", dbName, hasPrivilege, expected,
+		)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package postgresql
 
 import (
+	"context"
 	"crypto/md5"
 	"database/sql"
 	"encoding/hex"
@@ -11,25 +12,33 @@ import (
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/lib/pq"
+	"github.com/hashicorp/terraform/helper/validation"
 )
 
 const (
-	roleBypassRLSAttr         = "bypass_row_level_security"
-	roleConnLimitAttr         = "connection_limit"
-	roleCreateDBAttr          = "create_database"
-	roleCreateRoleAttr        = "create_role"
-	roleEncryptedPassAttr     = "encrypted_password"
-	roleInheritAttr           = "inherit"
-	roleLoginAttr             = "login"
-	roleNameAttr              = "name"
-	rolePasswordAttr          = "password"
-	roleReplicationAttr       = "replication"
-	roleSkipDropRoleAttr      = "skip_drop_role"
-	roleSkipReassignOwnedAttr = "skip_reassign_owned"
-	roleSuperuserAttr         = "superuser"
-	roleValidUntilAttr        = "valid_until"
-	roleRolesAttr             = "roles"
+	roleBypassRLSAttr          = "bypass_row_level_security"
+	roleConnLimitAttr          = "connection_limit"
+	roleCreateDBAttr           = "create_database"
+	roleCreateRoleAttr         = "create_role"
+	roleDeletionProtectionAttr = "deletion_protection"
+	roleEncryptedPassAttr      = "encrypted_password"
+	roleInheritAttr            = "inherit"
+	roleLoginAttr              = "login"
+	roleNameAttr               = "name"
+	rolePasswordAttr           = "password"
+	roleRenameProtectionAttr   = "rename_protection"
+	roleReplicationAttr        = "replication"
+	roleSkipDropRoleAttr       = "skip_drop_role"
+	roleSkipReassignOwnedAttr  = "skip_reassign_owned"
+	roleSuperuserAttr          = "superuser"
+	roleValidUntilAttr         = "valid_until"
+	roleRolesAttr              = "roles"
+	roleAdoptIfExistsAttr      = "adopt_if_exists"
+	roleGeneratePasswordAttr   = "generate_password"
+	roleGeneratedPasswordAttr  = "generated_password"
+
+	roleGeneratePasswordLengthAttr  = "length"
+	roleGeneratePasswordSpecialAttr = "special"
 
 	// Deprecated options
 	roleDepEncryptedAttr = "encrypted"
@@ -37,26 +46,59 @@ const (
 
 func resourcePostgreSQLRole() *schema.Resource {
 	return &schema.Resource{
-		Create: resourcePostgreSQLRoleCreate,
+		Create: retryOnConcurrentUpdates(resourcePostgreSQLRoleCreate),
 		Read:   resourcePostgreSQLRoleRead,
-		Update: resourcePostgreSQLRoleUpdate,
-		Delete: resourcePostgreSQLRoleDelete,
-		Exists: resourcePostgreSQLRoleExists,
+		Update: retryOnConcurrentUpdates(resourcePostgreSQLRoleUpdate),
+		Delete: retryOnConcurrentUpdates(resourcePostgreSQLRoleDelete),
 		Importer: &schema.ResourceImporter{
 			State: schema.ImportStatePassthrough,
 		},
+		CustomizeDiff: resourcePostgreSQLRoleCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			roleNameAttr: {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "The name of the role",
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateFunc:     validateRoleName,
+				Description:      "The name of the role",
+				DiffSuppressFunc: suppressIdentifierCaseDiff,
 			},
 			rolePasswordAttr: {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{roleGeneratePasswordAttr},
+				Description:   "Sets the role's password",
+			},
+			roleGeneratePasswordAttr: {
+				Type:          schema.TypeList,
+				Optional:      true,
+				MaxItems:      1,
+				ConflictsWith: []string{rolePasswordAttr},
+				Description:   "Generates a random password for the role, exported in generated_password (changing the block generates a new password)",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						roleGeneratePasswordLengthAttr: {
+							Type:         schema.TypeInt,
+							Optional:     true,
+							Default:      32,
+							ValidateFunc: validation.IntBetween(16, 64),
+							Description:  "The length of the password",
+						},
+						roleGeneratePasswordSpecialAttr: {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Whether the password contains special characters (otherwise only letters and digits)",
+						},
+					},
+				},
+			},
+			roleGeneratedPasswordAttr: {
 				Type:        schema.TypeString,
-				Optional:    true,
+				Computed:    true,
 				Sensitive:   true,
-				Description: "Sets the role's password",
+				Description: "The password generated for the role (see generate_password)",
 			},
 			roleDepEncryptedAttr: {
 				Type:       schema.TypeString,
@@ -132,6 +174,24 @@ func resourcePostgreSQLRole() *schema.Resource {
 				Default:     false,
 				Description: "Determine whether a role bypasses every row-level security (RLS) policy",
 			},
+			roleResourceQueueAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The resource queue of the role (Greenplum only)",
+			},
+			roleResourceGroupAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The resource group of the role (Greenplum only)",
+			},
+			roleProfileAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The profile of the role (EDB Postgres Advanced Server only)",
+			},
 			roleSkipDropRoleAttr: {
 				Type:        schema.TypeBool,
 				Optional:    true,
@@ -144,6 +204,28 @@ func resourcePostgreSQLRole() *schema.Resource {
 				Default:     false,
 				Description: "Skip actually running the REASSIGN OWNED command when removing a role from PostgreSQL",
 			},
+			roleDeletionProtectionAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When true, the role cannot be dropped (it has to be set to false and applied first)",
+			},
+			roleRenameProtectionAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When true, the role cannot be renamed (it has to be set to false and applied first)",
+			},
+			roleAdoptIfExistsAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When true, an existing role with the same name is adopted in the state instead of failing the creation",
+			},
+			quotedIdentifiersAttr:     quotedIdentifiersSchema(),
+			detectExternalChangesAttr: detectExternalChangesSchema(),
+			waitAttr:                  waitSchema(),
+			oidAttr:                   oidSchema("role"),
 		},
 	}
 }
@@ -153,7 +235,28 @@ func resourcePostgreSQLRoleCreate(d *schema.ResourceData, meta interface{}) erro
 	c.catalogLock.Lock()
 	defer c.catalogLock.Unlock()
 
-	txn, err := c.DB().Begin()
+	if err := generateRolePassword(d); err != nil {
+		return err
+	}
+
+	if adopted, err := adoptExistingRole(c, d); err != nil || adopted {
+		return err
+	}
+
+	if c.flavor == flavorRedshift {
+		return createRedshiftUser(c, d)
+	}
+
+	if err := checkSupabaseRoleResource(c, d); err != nil {
+		return err
+	}
+
+	currentUserSuperuser, err := degradedCurrentUserSuperuser(c)
+	if err != nil {
+		return err
+	}
+
+	txn, err := startTransaction(c, "")
 	if err != nil {
 		return err
 	}
@@ -179,7 +282,6 @@ func resourcePostgreSQLRoleCreate(d *schema.ResourceData, meta interface{}) erro
 		sqlKeyDisable string
 	}
 	boolOpts := []boolOptType{
-		{roleSuperuserAttr, "SUPERUSER", "NOSUPERUSER"},
 		{roleCreateDBAttr, "CREATEDB", "NOCREATEDB"},
 		{roleCreateRoleAttr, "CREATEROLE", "NOCREATEROLE"},
 		{roleInheritAttr, "INHERIT", "NOINHERIT"},
@@ -188,18 +290,28 @@ func resourcePostgreSQLRoleCreate(d *schema.ResourceData, meta interface{}) erro
 		// {roleEncryptedPassAttr, "ENCRYPTED", "UNENCRYPTED"},
 	}
 
-	if c.featureSupported(featureRLS) {
-		boolOpts = append(boolOpts, boolOptType{roleBypassRLSAttr, "BYPASSRLS", "NOBYPASSRLS"})
-	}
-
-	if c.featureSupported(featureReplication) {
-		boolOpts = append(boolOpts, boolOptType{roleReplicationAttr, "REPLICATION", "NOREPLICATION"})
+	for _, opt := range []boolOptType{
+		{roleSuperuserAttr, "SUPERUSER", "NOSUPERUSER"},
+		{roleBypassRLSAttr, "BYPASSRLS", "NOBYPASSRLS"},
+		{roleReplicationAttr, "REPLICATION", "NOREPLICATION"},
+	} {
+		apply, err := checkPrivilegedRoleAttr(c, d, opt.hclKey, currentUserSuperuser)
+		if err != nil {
+			return err
+		}
+		if apply {
+			boolOpts = append(boolOpts, opt)
+		}
 	}
 
 	createOpts := make([]string, 0, len(stringOpts)+len(intOpts)+len(boolOpts))
 
 	for _, opt := range stringOpts {
 		v, ok := d.GetOk(opt.hclKey)
+		if opt.hclKey == rolePasswordAttr {
+			v = rolePassword(d)
+			ok = v != ""
+		}
 		if !ok {
 			continue
 		}
@@ -226,7 +338,7 @@ func resourcePostgreSQLRoleCreate(d *schema.ResourceData, meta interface{}) erro
 					createOpts = append(createOpts, fmt.Sprintf("%s '%s'", opt.sqlKey, pqQuoteLiteral(val)))
 				}
 			default:
-				createOpts = append(createOpts, fmt.Sprintf("%s %s", opt.sqlKey, pq.QuoteIdentifier(val)))
+				createOpts = append(createOpts, fmt.Sprintf("%s %s", opt.sqlKey, pqQuoteIdentifier(val)))
 			}
 		}
 	}
@@ -250,6 +362,20 @@ func resourcePostgreSQLRoleCreate(d *schema.ResourceData, meta interface{}) erro
 		createOpts = append(createOpts, valStr)
 	}
 
+	greenplumOpts, err := greenplumRoleOptions(c, d, true)
+	if err != nil {
+		return err
+	}
+	createOpts = append(createOpts, greenplumOpts...)
+
+	profileOpt, err := edbRoleProfileOption(c, d, true)
+	if err != nil {
+		return err
+	}
+	if profileOpt != "" {
+		createOpts = append(createOpts, profileOpt)
+	}
+
 	roleName := d.Get(roleNameAttr).(string)
 	createStr := strings.Join(createOpts, " ")
 	if len(createOpts) > 0 {
@@ -261,30 +387,54 @@ func resourcePostgreSQLRoleCreate(d *schema.ResourceData, meta interface{}) erro
 		}
 	}
 
-	sql := fmt.Sprintf("CREATE ROLE %s%s", pq.QuoteIdentifier(roleName), createStr)
-	if _, err := txn.Exec(sql); err != nil {
+	sql := fmt.Sprintf("CREATE ROLE %s%s", pqQuoteIdentifier(roleName), createStr)
+	if _, err := txn.ExecContext(c.ctx, sql); err != nil {
 		return errwrap.Wrapf(fmt.Sprintf("error creating role %s: {{err}}", roleName), err)
 	}
 
-	if err = grantRoles(txn, d); err != nil {
+	if err = grantRoles(c.ctx, txn, d); err != nil {
 		return err
 	}
 
-	if err = txn.Commit(); err != nil {
+	if err = commitTransaction(c, txn); err != nil {
 		return errwrap.Wrapf("could not commit transaction: {{err}}", err)
 	}
 
 	d.SetId(roleName)
 
+	if err := waitForVisibility(c, d, "", fmt.Sprintf("role %s", roleName),
+		"SELECT EXISTS(SELECT 1 FROM pg_catalog.pg_roles WHERE rolname = $1)", roleName,
+	); err != nil {
+		return err
+	}
+
 	return resourcePostgreSQLRoleReadImpl(c, d)
 }
 
 func resourcePostgreSQLRoleDelete(d *schema.ResourceData, meta interface{}) error {
 	c := meta.(*Client)
+
+	// The state is checked as the configuration is not available on destroy.
+	if d.Get(roleDeletionProtectionAttr).(bool) {
+		return fmt.Errorf(
+			"cannot drop role %s: %s is enabled, set it to false and apply before destroying the role",
+			d.Get(roleNameAttr).(string), roleDeletionProtectionAttr,
+		)
+	}
+
 	c.catalogLock.Lock()
 	defer c.catalogLock.Unlock()
 
-	txn, err := c.DB().Begin()
+	if c.flavor == flavorRedshift {
+		defer c.catalogCache.invalidate(catalogCacheKey("role", d.Get(roleNameAttr).(string)))
+		return deleteRedshiftUser(c, d)
+	}
+
+	if err := checkSupabaseRole(c, d.Get(roleNameAttr).(string)); err != nil {
+		return err
+	}
+
+	txn, err := startTransaction(c, "")
 	if err != nil {
 		return err
 	}
@@ -295,49 +445,51 @@ func resourcePostgreSQLRoleDelete(d *schema.ResourceData, meta interface{}) erro
 	queries := make([]string, 0, 3)
 	if !d.Get(roleSkipReassignOwnedAttr).(bool) {
 		if c.featureSupported(featureReassignOwnedCurrentUser) {
-			queries = append(queries, fmt.Sprintf("REASSIGN OWNED BY %s TO CURRENT_USER", pq.QuoteIdentifier(roleName)))
+			queries = append(queries, fmt.Sprintf("REASSIGN OWNED BY %s TO CURRENT_USER", pqQuoteIdentifier(roleName)))
 		} else {
-			queries = append(queries, fmt.Sprintf("REASSIGN OWNED BY %s TO %s", pq.QuoteIdentifier(roleName), pq.QuoteIdentifier(c.config.getDatabaseUsername())))
+			queries = append(queries, fmt.Sprintf("REASSIGN OWNED BY %s TO %s", pqQuoteIdentifier(roleName), pqQuoteIdentifier(c.config.getDatabaseUsername())))
 		}
-		queries = append(queries, fmt.Sprintf("DROP OWNED BY %s", pq.QuoteIdentifier(roleName)))
+		queries = append(queries, fmt.Sprintf("DROP OWNED BY %s", pqQuoteIdentifier(roleName)))
 	}
 
 	if !d.Get(roleSkipDropRoleAttr).(bool) {
-		queries = append(queries, fmt.Sprintf("DROP ROLE %s", pq.QuoteIdentifier(roleName)))
+		queries = append(queries, fmt.Sprintf("DROP ROLE %s", pqQuoteIdentifier(roleName)))
 	}
 
 	if len(queries) > 0 {
 		for _, query := range queries {
-			if _, err := txn.Exec(query); err != nil {
+			if _, err := txn.ExecContext(c.ctx, query); err != nil {
 				return errwrap.Wrapf("Error deleting role: {{err}}", err)
 			}
 		}
 
-		if err := txn.Commit(); err != nil {
+		if err := commitTransaction(c, txn); err != nil {
 			return errwrap.Wrapf("Error committing schema: {{err}}", err)
 		}
 	}
 
+	c.catalogCache.invalidate(catalogCacheKey("role", roleName))
 	d.SetId("")
 
 	return nil
 }
 
-func resourcePostgreSQLRoleExists(d *schema.ResourceData, meta interface{}) (bool, error) {
-	c := meta.(*Client)
-	c.catalogLock.RLock()
-	defer c.catalogLock.RUnlock()
+// resourcePostgreSQLRoleCustomizeDiff refuses at plan time to rename a role
+// protected by rename_protection (as set in the state).
+func resourcePostgreSQLRoleCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.HasChange(roleNameAttr) {
+		return nil
+	}
 
-	var roleName string
-	err := c.DB().QueryRow("SELECT rolname FROM pg_catalog.pg_roles WHERE rolname=$1", d.Id()).Scan(&roleName)
-	switch {
-	case err == sql.ErrNoRows:
-		return false, nil
-	case err != nil:
-		return false, err
+	if protected, _ := d.GetChange(roleRenameProtectionAttr); protected.(bool) {
+		oldName, newName := d.GetChange(roleNameAttr)
+		return fmt.Errorf(
+			"cannot rename role %s to %s: %s is enabled, set it to false and apply before renaming the role",
+			oldName.(string), newName.(string), roleRenameProtectionAttr,
+		)
 	}
 
-	return true, nil
+	return nil
 }
 
 func resourcePostgreSQLRoleRead(d *schema.ResourceData, meta interface{}) error {
@@ -345,19 +497,138 @@ func resourcePostgreSQLRoleRead(d *schema.ResourceData, meta interface{}) error
 	c.catalogLock.RLock()
 	defer c.catalogLock.RUnlock()
 
+	if !detectExternalChanges(d) {
+		exists, err := roleExists(c, d.Id())
+		if err != nil {
+			return err
+		}
+		if !exists {
+			log.Printf("[WARN] PostgreSQL ROLE (%s) not found", d.Id())
+			d.SetId("")
+		}
+		return nil
+	}
+
 	return resourcePostgreSQLRoleReadImpl(c, d)
 }
 
+// adoptExistingRole adopts the role in the state, instead of creating it, if it already
+// exists and adopt_if_exists is set. The role is read as is: the differences with the
+// configuration are shown by the next plan and applied as an update, except the generated
+// password which is set right away as it is only known by the state.
+func adoptExistingRole(c *Client, d *schema.ResourceData) (bool, error) {
+	if !d.Get(roleAdoptIfExistsAttr).(bool) {
+		return false, nil
+	}
+
+	roleName := d.Get(roleNameAttr).(string)
+	exists, err := roleExists(c, roleName)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	if err := checkSupabaseRole(c, roleName); err != nil {
+		return false, err
+	}
+
+	log.Printf("[WARN] Role %s already exists, adopting it in the state (%s)", roleName, roleAdoptIfExistsAttr)
+
+	if password := d.Get(roleGeneratedPasswordAttr).(string); password != "" {
+		if err := setAdoptedRolePassword(c, roleName, password); err != nil {
+			return false, err
+		}
+	}
+
+	d.SetId(roleName)
+
+	return true, resourcePostgreSQLRoleReadImpl(c, d)
+}
+
+// setAdoptedRolePassword sets the generated password of an adopted role.
+func setAdoptedRolePassword(c *Client, roleName, password string) error {
+	txn, err := startTransaction(c, "")
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	statement := "ALTER ROLE"
+	if c.flavor == flavorRedshift {
+		statement = "ALTER USER"
+	}
+	query := fmt.Sprintf("%s %s PASSWORD '%s'", statement, pqQuoteIdentifier(roleName), pqQuoteLiteral(password))
+	if _, err := txn.ExecContext(c.ctx, query); err != nil {
+		return errwrap.Wrapf("Error setting the generated password of the adopted role: {{err}}", err)
+	}
+
+	return commitTransaction(c, txn)
+}
+
+// generateRolePassword generates the password of the role if it has a generate_password block,
+// the generated password is forgotten when the block is removed.
+func generateRolePassword(d *schema.ResourceData) error {
+	if d.Get(roleGeneratePasswordAttr+".#").(int) == 0 {
+		d.Set(roleGeneratedPasswordAttr, "")
+		return nil
+	}
+
+	password, err := generatePassword(
+		d.Get(roleGeneratePasswordAttr+".0."+roleGeneratePasswordLengthAttr).(int),
+		d.Get(roleGeneratePasswordAttr+".0."+roleGeneratePasswordSpecialAttr).(bool),
+	)
+	if err != nil {
+		return err
+	}
+	d.Set(roleGeneratedPasswordAttr, password)
+	return nil
+}
+
+// rolePassword returns the password to set: the generated one or the configured one.
+func rolePassword(d *schema.ResourceData) string {
+	if password := d.Get(roleGeneratedPasswordAttr).(string); password != "" {
+		return password
+	}
+	return d.Get(rolePasswordAttr).(string)
+}
+
+// roleExists checks if the role exists (its existence is cached).
+func roleExists(c *Client, roleName string) (bool, error) {
+	return c.catalogCache.exists(catalogCacheKey("role", roleName), func() (bool, error) {
+		query := "SELECT EXISTS(SELECT 1 FROM pg_catalog.pg_roles WHERE rolname = $1)"
+		if c.flavor == flavorRedshift {
+			query = "SELECT EXISTS(SELECT 1 FROM pg_catalog.pg_user WHERE usename = $1)"
+		}
+
+		var exists bool
+		if err := c.DB().QueryRowContext(c.ctx, query, roleName).Scan(&exists); err != nil {
+			return false, errwrap.Wrapf("could not check if role exists: {{err}}", err)
+		}
+		return exists, nil
+	})
+}
+
 func resourcePostgreSQLRoleReadImpl(c *Client, d *schema.ResourceData) error {
+	if c.flavor == flavorRedshift {
+		return readRedshiftUser(c, d)
+	}
+
+	txn, err := startReadTransaction(c, "")
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
 	var roleSuperuser, roleInherit, roleCreateRole, roleCreateDB, roleCanLogin, roleReplication, roleBypassRLS bool
-	var roleConnLimit int
+	var currentUserSuperuser bool
+	var roleConnLimit, roleOID int
 	var roleName, roleValidUntil string
-	var roleRoles pq.ByteaArray
+	var roleRoles []string
 
 	roleID := d.Id()
 
 	columns := []string{
 		"rolname",
+		"oid::BIGINT",
 		"rolsuper",
 		"rolinherit",
 		"rolcreaterole",
@@ -368,8 +639,10 @@ func resourcePostgreSQLRoleReadImpl(c *Client, d *schema.ResourceData) error {
 	}
 
 	values := []interface{}{
-		&roleRoles,
+		pgArray(&roleRoles),
+		&currentUserSuperuser,
 		&roleName,
+		&roleOID,
 		&roleSuperuser,
 		&roleInherit,
 		&roleCreateRole,
@@ -389,14 +662,16 @@ func resourcePostgreSQLRoleReadImpl(c *Client, d *schema.ResourceData) error {
 		values = append(values, &roleBypassRLS)
 	}
 
+	// The membership and the superuser status of the connected user (needed to read the password)
+	// are fetched in the same query to avoid extra round trips.
 	roleSQL := fmt.Sprintf(`SELECT ARRAY(
 			SELECT pg_get_userbyid(roleid) FROM pg_catalog.pg_auth_members members WHERE member = pg_roles.oid
-		), %s
+		), (SELECT rolsuper FROM pg_catalog.pg_roles WHERE rolname = CURRENT_USER), %s
 		FROM pg_catalog.pg_roles WHERE rolname=$1`,
 		// select columns
 		strings.Join(columns, ", "),
 	)
-	err := c.DB().QueryRow(roleSQL, roleID).Scan(values...)
+	err = txn.QueryRowContext(c.ctx, roleSQL, roleID).Scan(values...)
 
 	switch {
 	case err == sql.ErrNoRows:
@@ -408,6 +683,7 @@ func resourcePostgreSQLRoleReadImpl(c *Client, d *schema.ResourceData) error {
 	}
 
 	d.Set(roleNameAttr, roleName)
+	d.Set(oidAttr, roleOID)
 	d.Set(roleConnLimitAttr, roleConnLimit)
 	d.Set(roleCreateDBAttr, roleCreateDB)
 	d.Set(roleCreateRoleAttr, roleCreateRole)
@@ -416,15 +692,53 @@ func resourcePostgreSQLRoleReadImpl(c *Client, d *schema.ResourceData) error {
 	d.Set(roleLoginAttr, roleCanLogin)
 	d.Set(roleSkipDropRoleAttr, d.Get(roleSkipDropRoleAttr).(bool))
 	d.Set(roleSkipReassignOwnedAttr, d.Get(roleSkipReassignOwnedAttr).(bool))
+	d.Set(roleDeletionProtectionAttr, d.Get(roleDeletionProtectionAttr).(bool))
+	d.Set(roleRenameProtectionAttr, d.Get(roleRenameProtectionAttr).(bool))
 	d.Set(roleSuperuserAttr, roleSuperuser)
 	d.Set(roleValidUntilAttr, roleValidUntil)
 	d.Set(roleReplicationAttr, roleReplication)
-	d.Set(roleReplicationAttr, roleBypassRLS)
+	d.Set(roleBypassRLSAttr, roleBypassRLS)
+
+	// With degrade_gracefully, the privileged attributes which cannot be enabled
+	// are kept as configured to not produce a diff at each plan.
+	if c.config.DegradeGracefully {
+		for attr, enabled := range map[string]bool{
+			roleSuperuserAttr:   roleSuperuser,
+			roleReplicationAttr: roleReplication,
+			roleBypassRLSAttr:   roleBypassRLS,
+		} {
+			reason := privilegedRoleAttrUnavailable(c, attr, currentUserSuperuser)
+			if enabled || reason == "" {
+				continue
+			}
+			if configured, ok := d.GetOk(attr); ok && configured.(bool) {
+				log.Printf("[WARN] degrade_gracefully: %s is not enabled on role %s: %s", attr, roleName, reason)
+				d.Set(attr, true)
+			}
+		}
+	}
 	d.Set(roleRolesAttr, pgArrayToSet(roleRoles))
 
+	if c.flavor == flavorGreenplum {
+		if err := readGreenplumRoleResources(c.ctx, txn, d, roleName); err != nil {
+			return err
+		}
+	}
+
+	if c.flavor == flavorEDB {
+		if err := readEDBRoleProfile(c.ctx, txn, d, roleName); err != nil {
+			return err
+		}
+	}
+
 	d.SetId(roleName)
 
-	password, err := readRolePassword(c, d, roleCanLogin)
+	// The generated password is not read: it would be set in the password attribute.
+	if d.Get(roleGeneratePasswordAttr+".#").(int) > 0 {
+		return nil
+	}
+
+	password, err := readRolePassword(c, txn, d, roleCanLogin, currentUserSuperuser)
 	if err != nil {
 		return err
 	}
@@ -435,7 +749,7 @@ func resourcePostgreSQLRoleReadImpl(c *Client, d *schema.ResourceData) error {
 
 // readRolePassword reads password either from Postgres if admin user is a superuser
 // or only from Terraform state.
-func readRolePassword(c *Client, d *schema.ResourceData, roleCanLogin bool) (string, error) {
+func readRolePassword(c *Client, txn *sql.Tx, d *schema.ResourceData, roleCanLogin, currentUserSuperuser bool) (string, error) {
 	statePassword := d.Get(rolePasswordAttr).(string)
 
 	// Role which cannot login does not have password in pg_shadow.
@@ -447,11 +761,7 @@ func readRolePassword(c *Client, d *schema.ResourceData, roleCanLogin bool) (str
 
 	// Otherwise we check if connected user is really a superuser
 	// (in order to warn user instead of having a permission denied error)
-	superuser, err := c.isSuperuser()
-	if err != nil {
-		return "", err
-	}
-	if !superuser {
+	if !currentUserSuperuser {
 		return "", fmt.Errorf(
 			"could not read role password from Postgres as "+
 				"connected user %s is not a SUPERUSER. "+
@@ -462,7 +772,7 @@ func readRolePassword(c *Client, d *schema.ResourceData, roleCanLogin bool) (str
 	}
 
 	var rolePassword string
-	err = c.DB().QueryRow("SELECT COALESCE(passwd, '') FROM pg_catalog.pg_shadow AS s WHERE s.usename = $1", d.Id()).Scan(&rolePassword)
+	err := txn.QueryRowContext(c.ctx, "SELECT COALESCE(passwd, '') FROM pg_catalog.pg_shadow AS s WHERE s.usename = $1", d.Id()).Scan(&rolePassword)
 	switch {
 	case err == sql.ErrNoRows:
 		// They don't have a password
@@ -492,73 +802,168 @@ func resourcePostgreSQLRoleUpdate(d *schema.ResourceData, meta interface{}) erro
 	c.catalogLock.Lock()
 	defer c.catalogLock.Unlock()
 
-	txn, err := c.DB().Begin()
+	if d.HasChange(roleGeneratePasswordAttr) {
+		if err := generateRolePassword(d); err != nil {
+			return err
+		}
+	}
+
+	if c.flavor == flavorRedshift {
+		if d.HasChange(roleNameAttr) {
+			oldName, _ := d.GetChange(roleNameAttr)
+			defer c.catalogCache.invalidate(catalogCacheKey("role", oldName.(string)))
+		}
+		return updateRedshiftUser(c, d)
+	}
+
+	if err := checkSupabaseRoleResource(c, d); err != nil {
+		return err
+	}
+
+	currentUserSuperuser, err := degradedCurrentUserSuperuser(c)
+	if err != nil {
+		return err
+	}
+
+	txn, err := startTransaction(c, "")
 	if err != nil {
 		return err
 	}
 	defer deferredRollback(txn)
 
-	if err := setRoleName(txn, d); err != nil {
+	if err := setRoleName(c.ctx, txn, d); err != nil {
 		return err
 	}
 
-	if err := setRolePassword(txn, d); err != nil {
+	if err := setRolePassword(c.ctx, txn, d); err != nil {
 		return err
 	}
 
-	if err := setRoleBypassRLS(c, txn, d); err != nil {
+	if err := setRoleBypassRLS(c, txn, d, currentUserSuperuser); err != nil {
 		return err
 	}
 
-	if err := setRoleConnLimit(txn, d); err != nil {
+	if err := setRoleConnLimit(c.ctx, txn, d); err != nil {
 		return err
 	}
 
-	if err := setRoleCreateDB(txn, d); err != nil {
+	if err := setRoleCreateDB(c.ctx, txn, d); err != nil {
 		return err
 	}
 
-	if err := setRoleCreateRole(txn, d); err != nil {
+	if err := setRoleCreateRole(c.ctx, txn, d); err != nil {
 		return err
 	}
 
-	if err := setRoleInherit(txn, d); err != nil {
+	if err := setRoleInherit(c.ctx, txn, d); err != nil {
 		return err
 	}
 
-	if err := setRoleLogin(txn, d); err != nil {
+	if err := setRoleLogin(c.ctx, txn, d); err != nil {
 		return err
 	}
 
-	if err := setRoleReplication(txn, d); err != nil {
+	if err := setRoleReplication(c, txn, d, currentUserSuperuser); err != nil {
 		return err
 	}
 
-	if err := setRoleSuperuser(txn, d); err != nil {
+	if err := setRoleSuperuser(c, txn, d, currentUserSuperuser); err != nil {
 		return err
 	}
 
-	if err := setRoleValidUntil(txn, d); err != nil {
+	if err := setRoleValidUntil(c.ctx, txn, d); err != nil {
+		return err
+	}
+
+	if err := setRoleGreenplumResources(c, txn, d); err != nil {
+		return err
+	}
+
+	if err := setRoleEDBProfile(c, txn, d); err != nil {
 		return err
 	}
 
 	// applying roles: let's revoke all / grant the right ones
-	if err = revokeRoles(txn, d); err != nil {
+	if err = revokeRoles(c.ctx, txn, d); err != nil {
 		return err
 	}
 
-	if err = grantRoles(txn, d); err != nil {
+	if err = grantRoles(c.ctx, txn, d); err != nil {
 		return err
 	}
 
-	if err = txn.Commit(); err != nil {
+	if err = commitTransaction(c, txn); err != nil {
 		return errwrap.Wrapf("could not commit transaction: {{err}}", err)
 	}
 
+	if d.HasChange(roleNameAttr) {
+		oldName, _ := d.GetChange(roleNameAttr)
+		c.catalogCache.invalidate(catalogCacheKey("role", oldName.(string)))
+	}
+
 	return resourcePostgreSQLRoleReadImpl(c, d)
 }
 
-func setRoleName(txn *sql.Tx, d *schema.ResourceData) error {
+// privilegedRoleAttrFeatures are the role attributes which can only be enabled by a superuser,
+// with the feature the server needs to support them.
+var privilegedRoleAttrFeatures = map[string]featureName{
+	roleSuperuserAttr:   featureSuperuserRole,
+	roleReplicationAttr: featureReplication,
+	roleBypassRLSAttr:   featureRLS,
+}
+
+// degradedCurrentUserSuperuser returns whether the connected user is a superuser.
+// It's only checked with degrade_gracefully (true is returned otherwise).
+func degradedCurrentUserSuperuser(c *Client) (bool, error) {
+	if !c.config.DegradeGracefully {
+		return true, nil
+	}
+
+	var superuser bool
+	if err := c.DB().QueryRowContext(c.ctx,
+		"SELECT rolsuper FROM pg_catalog.pg_roles WHERE rolname = CURRENT_USER",
+	).Scan(&superuser); err != nil {
+		return false, errwrap.Wrapf("could not check if the current user is a superuser: {{err}}", err)
+	}
+
+	return superuser, nil
+}
+
+// privilegedRoleAttrUnavailable returns why the privileged attribute cannot be enabled
+// (an empty string if it can be).
+func privilegedRoleAttrUnavailable(c *Client, attr string, currentUserSuperuser bool) string {
+	switch {
+	case !c.featureSupported(privilegedRoleAttrFeatures[attr]):
+		return fmt.Sprintf("the server (%s %q) does not support it", c.flavor, c.version.String())
+	case !currentUserSuperuser:
+		return "the connected user is not a superuser"
+	}
+	return ""
+}
+
+// checkPrivilegedRoleAttr returns whether the privileged attribute has to be set in the statement.
+// Enabling an attribute which is not available fails, unless degrade_gracefully is set:
+// in this case, the attribute is skipped with a warning.
+func checkPrivilegedRoleAttr(c *Client, d *schema.ResourceData, attr string, currentUserSuperuser bool) (bool, error) {
+	reason := privilegedRoleAttrUnavailable(c, attr, currentUserSuperuser)
+	if reason == "" {
+		return true, nil
+	}
+
+	if !d.Get(attr).(bool) {
+		// The attributes are disabled by default and cannot have been enabled by this user.
+		return false, nil
+	}
+
+	if !c.config.DegradeGracefully {
+		return false, fmt.Errorf("%s cannot be enabled on role %s: %s", attr, d.Get(roleNameAttr).(string), reason)
+	}
+
+	log.Printf("[WARN] degrade_gracefully: skipping %s on role %s: %s", attr, d.Get(roleNameAttr).(string), reason)
+	return false, nil
+}
+
+func setRoleName(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) error {
 	if !d.HasChange(roleNameAttr) {
 		return nil
 	}
@@ -570,8 +975,8 @@ func setRoleName(txn *sql.Tx, d *schema.ResourceData) error {
 		return errors.New("Error setting role name to an empty string")
 	}
 
-	sql := fmt.Sprintf("ALTER ROLE %s RENAME TO %s", pq.QuoteIdentifier(o), pq.QuoteIdentifier(n))
-	if _, err := txn.Exec(sql); err != nil {
+	sql := fmt.Sprintf("ALTER ROLE %s RENAME TO %s", pqQuoteIdentifier(o), pqQuoteIdentifier(n))
+	if _, err := txn.ExecContext(ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating role NAME: {{err}}", err)
 	}
 
@@ -580,30 +985,30 @@ func setRoleName(txn *sql.Tx, d *schema.ResourceData) error {
 	return nil
 }
 
-func setRolePassword(txn *sql.Tx, d *schema.ResourceData) error {
+func setRolePassword(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) error {
 	// If role is renamed, password is reset (as the md5 sum is also base on the role name)
 	// so we need to update it
-	if !d.HasChange(rolePasswordAttr) && !d.HasChange(roleNameAttr) {
+	if !d.HasChange(rolePasswordAttr) && !d.HasChange(roleNameAttr) && !d.HasChange(roleGeneratePasswordAttr) {
 		return nil
 	}
 
 	roleName := d.Get(roleNameAttr).(string)
-	password := d.Get(rolePasswordAttr).(string)
+	password := rolePassword(d)
 
-	sql := fmt.Sprintf("ALTER ROLE %s PASSWORD '%s'", pq.QuoteIdentifier(roleName), pqQuoteLiteral(password))
-	if _, err := txn.Exec(sql); err != nil {
+	sql := fmt.Sprintf("ALTER ROLE %s PASSWORD '%s'", pqQuoteIdentifier(roleName), pqQuoteLiteral(password))
+	if _, err := txn.ExecContext(ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating role password: {{err}}", err)
 	}
 	return nil
 }
 
-func setRoleBypassRLS(c *Client, txn *sql.Tx, d *schema.ResourceData) error {
+func setRoleBypassRLS(c *Client, txn *sql.Tx, d *schema.ResourceData, currentUserSuperuser bool) error {
 	if !d.HasChange(roleBypassRLSAttr) {
 		return nil
 	}
 
-	if !c.featureSupported(featureRLS) {
-		return fmt.Errorf("PostgreSQL client is talking with a server (%q) that does not support PostgreSQL Row-Level Security", c.version.String())
+	if apply, err := checkPrivilegedRoleAttr(c, d, roleBypassRLSAttr, currentUserSuperuser); err != nil || !apply {
+		return err
 	}
 
 	bypassRLS := d.Get(roleBypassRLSAttr).(bool)
@@ -612,30 +1017,30 @@ func setRoleBypassRLS(c *Client, txn *sql.Tx, d *schema.ResourceData) error {
 		tok = "BYPASSRLS"
 	}
 	roleName := d.Get(roleNameAttr).(string)
-	sql := fmt.Sprintf("ALTER ROLE %s WITH %s", pq.QuoteIdentifier(roleName), tok)
-	if _, err := txn.Exec(sql); err != nil {
+	sql := fmt.Sprintf("ALTER ROLE %s WITH %s", pqQuoteIdentifier(roleName), tok)
+	if _, err := txn.ExecContext(c.ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating role BYPASSRLS: {{err}}", err)
 	}
 
 	return nil
 }
 
-func setRoleConnLimit(txn *sql.Tx, d *schema.ResourceData) error {
+func setRoleConnLimit(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) error {
 	if !d.HasChange(roleConnLimitAttr) {
 		return nil
 	}
 
 	connLimit := d.Get(roleConnLimitAttr).(int)
 	roleName := d.Get(roleNameAttr).(string)
-	sql := fmt.Sprintf("ALTER ROLE %s CONNECTION LIMIT %d", pq.QuoteIdentifier(roleName), connLimit)
-	if _, err := txn.Exec(sql); err != nil {
+	sql := fmt.Sprintf("ALTER ROLE %s CONNECTION LIMIT %d", pqQuoteIdentifier(roleName), connLimit)
+	if _, err := txn.ExecContext(ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating role CONNECTION LIMIT: {{err}}", err)
 	}
 
 	return nil
 }
 
-func setRoleCreateDB(txn *sql.Tx, d *schema.ResourceData) error {
+func setRoleCreateDB(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) error {
 	if !d.HasChange(roleCreateDBAttr) {
 		return nil
 	}
@@ -646,15 +1051,15 @@ func setRoleCreateDB(txn *sql.Tx, d *schema.ResourceData) error {
 		tok = "CREATEDB"
 	}
 	roleName := d.Get(roleNameAttr).(string)
-	sql := fmt.Sprintf("ALTER ROLE %s WITH %s", pq.QuoteIdentifier(roleName), tok)
-	if _, err := txn.Exec(sql); err != nil {
+	sql := fmt.Sprintf("ALTER ROLE %s WITH %s", pqQuoteIdentifier(roleName), tok)
+	if _, err := txn.ExecContext(ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating role CREATEDB: {{err}}", err)
 	}
 
 	return nil
 }
 
-func setRoleCreateRole(txn *sql.Tx, d *schema.ResourceData) error {
+func setRoleCreateRole(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) error {
 	if !d.HasChange(roleCreateRoleAttr) {
 		return nil
 	}
@@ -665,15 +1070,15 @@ func setRoleCreateRole(txn *sql.Tx, d *schema.ResourceData) error {
 		tok = "CREATEROLE"
 	}
 	roleName := d.Get(roleNameAttr).(string)
-	sql := fmt.Sprintf("ALTER ROLE %s WITH %s", pq.QuoteIdentifier(roleName), tok)
-	if _, err := txn.Exec(sql); err != nil {
+	sql := fmt.Sprintf("ALTER ROLE %s WITH %s", pqQuoteIdentifier(roleName), tok)
+	if _, err := txn.ExecContext(ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating role CREATEROLE: {{err}}", err)
 	}
 
 	return nil
 }
 
-func setRoleInherit(txn *sql.Tx, d *schema.ResourceData) error {
+func setRoleInherit(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) error {
 	if !d.HasChange(roleInheritAttr) {
 		return nil
 	}
@@ -684,15 +1089,15 @@ func setRoleInherit(txn *sql.Tx, d *schema.ResourceData) error {
 		tok = "INHERIT"
 	}
 	roleName := d.Get(roleNameAttr).(string)
-	sql := fmt.Sprintf("ALTER ROLE %s WITH %s", pq.QuoteIdentifier(roleName), tok)
-	if _, err := txn.Exec(sql); err != nil {
+	sql := fmt.Sprintf("ALTER ROLE %s WITH %s", pqQuoteIdentifier(roleName), tok)
+	if _, err := txn.ExecContext(ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating role INHERIT: {{err}}", err)
 	}
 
 	return nil
 }
 
-func setRoleLogin(txn *sql.Tx, d *schema.ResourceData) error {
+func setRoleLogin(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) error {
 	if !d.HasChange(roleLoginAttr) {
 		return nil
 	}
@@ -703,53 +1108,61 @@ func setRoleLogin(txn *sql.Tx, d *schema.ResourceData) error {
 		tok = "LOGIN"
 	}
 	roleName := d.Get(roleNameAttr).(string)
-	sql := fmt.Sprintf("ALTER ROLE %s WITH %s", pq.QuoteIdentifier(roleName), tok)
-	if _, err := txn.Exec(sql); err != nil {
+	sql := fmt.Sprintf("ALTER ROLE %s WITH %s", pqQuoteIdentifier(roleName), tok)
+	if _, err := txn.ExecContext(ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating role LOGIN: {{err}}", err)
 	}
 
 	return nil
 }
 
-func setRoleReplication(txn *sql.Tx, d *schema.ResourceData) error {
+func setRoleReplication(c *Client, txn *sql.Tx, d *schema.ResourceData, currentUserSuperuser bool) error {
 	if !d.HasChange(roleReplicationAttr) {
 		return nil
 	}
 
+	if apply, err := checkPrivilegedRoleAttr(c, d, roleReplicationAttr, currentUserSuperuser); err != nil || !apply {
+		return err
+	}
+
 	replication := d.Get(roleReplicationAttr).(bool)
 	tok := "NOREPLICATION"
 	if replication {
 		tok = "REPLICATION"
 	}
 	roleName := d.Get(roleNameAttr).(string)
-	sql := fmt.Sprintf("ALTER ROLE %s WITH %s", pq.QuoteIdentifier(roleName), tok)
-	if _, err := txn.Exec(sql); err != nil {
+	sql := fmt.Sprintf("ALTER ROLE %s WITH %s", pqQuoteIdentifier(roleName), tok)
+	if _, err := txn.ExecContext(c.ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating role REPLICATION: {{err}}", err)
 	}
 
 	return nil
 }
 
-func setRoleSuperuser(txn *sql.Tx, d *schema.ResourceData) error {
+func setRoleSuperuser(c *Client, txn *sql.Tx, d *schema.ResourceData, currentUserSuperuser bool) error {
 	if !d.HasChange(roleSuperuserAttr) {
 		return nil
 	}
 
+	if apply, err := checkPrivilegedRoleAttr(c, d, roleSuperuserAttr, currentUserSuperuser); err != nil || !apply {
+		return err
+	}
+
 	superuser := d.Get(roleSuperuserAttr).(bool)
 	tok := "NOSUPERUSER"
 	if superuser {
 		tok = "SUPERUSER"
 	}
 	roleName := d.Get(roleNameAttr).(string)
-	sql := fmt.Sprintf("ALTER ROLE %s WITH %s", pq.QuoteIdentifier(roleName), tok)
-	if _, err := txn.Exec(sql); err != nil {
+	sql := fmt.Sprintf("ALTER ROLE %s WITH %s", pqQuoteIdentifier(roleName), tok)
+	if _, err := txn.ExecContext(c.ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating role SUPERUSER: {{err}}", err)
 	}
 
 	return nil
 }
 
-func setRoleValidUntil(txn *sql.Tx, d *schema.ResourceData) error {
+func setRoleValidUntil(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) error {
 	if !d.HasChange(roleValidUntilAttr) {
 		return nil
 	}
@@ -762,15 +1175,15 @@ func setRoleValidUntil(txn *sql.Tx, d *schema.ResourceData) error {
 	}
 
 	roleName := d.Get(roleNameAttr).(string)
-	sql := fmt.Sprintf("ALTER ROLE %s VALID UNTIL '%s'", pq.QuoteIdentifier(roleName), pqQuoteLiteral(validUntil))
-	if _, err := txn.Exec(sql); err != nil {
+	sql := fmt.Sprintf("ALTER ROLE %s VALID UNTIL '%s'", pqQuoteIdentifier(roleName), pqQuoteLiteral(validUntil))
+	if _, err := txn.ExecContext(ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating role VALID UNTIL: {{err}}", err)
 	}
 
 	return nil
 }
 
-func revokeRoles(txn *sql.Tx, d *schema.ResourceData) error {
+func revokeRoles(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) error {
 	role := d.Get(roleNameAttr).(string)
 
 	query := `SELECT pg_get_userbyid(roleid)
@@ -778,7 +1191,7 @@ func revokeRoles(txn *sql.Tx, d *schema.ResourceData) error {
 		JOIN pg_catalog.pg_roles ON members.member = pg_roles.oid
 		WHERE rolname = $1`
 
-	rows, err := txn.Query(query, role)
+	rows, err := txn.QueryContext(ctx, query, role)
 	if err != nil {
 		return errwrap.Wrapf(fmt.Sprintf("could not get roles list for role %s: {{err}}", role), err)
 	}
@@ -791,17 +1204,16 @@ func revokeRoles(txn *sql.Tx, d *schema.ResourceData) error {
 		if err = rows.Scan(&grantedRole); err != nil {
 			return errwrap.Wrapf(fmt.Sprintf("could not scan role name for role %s: {{err}}", role), err)
 		}
-		// We cannot revoke directly here as it shares the same cursor (with Tx)
-		// and rows.Next seems to retrieve result row by row.
-		// see: https://github.com/lib/pq/issues/81
+		// We cannot revoke directly here as it shares the same connection (with Tx)
+		// which is busy until all the rows have been read.
 		grantedRoles = append(grantedRoles, grantedRole)
 	}
 
 	for _, grantedRole := range grantedRoles {
-		query = fmt.Sprintf("REVOKE %s FROM %s", pq.QuoteIdentifier(grantedRole), pq.QuoteIdentifier(role))
+		query = fmt.Sprintf("REVOKE %s FROM %s", pqQuoteIdentifier(grantedRole), pqQuoteIdentifier(role))
 
 		log.Printf("[DEBUG] revoking role %s from %s", grantedRole, role)
-		if _, err := txn.Exec(query); err != nil {
+		if _, err := txn.ExecContext(ctx, query); err != nil {
 			return errwrap.Wrapf(fmt.Sprintf("could not revoke role %s from %s: {{err}}", string(grantedRole), role), err)
 		}
 	}
@@ -809,14 +1221,14 @@ func revokeRoles(txn *sql.Tx, d *schema.ResourceData) error {
 	return nil
 }
 
-func grantRoles(txn *sql.Tx, d *schema.ResourceData) error {
+func grantRoles(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) error {
 	role := d.Get(roleNameAttr).(string)
 
 	for _, grantingRole := range d.Get("roles").(*schema.Set).List() {
 		query := fmt.Sprintf(
-			"GRANT %s TO %s", pq.QuoteIdentifier(grantingRole.(string)), pq.QuoteIdentifier(role),
+			"GRANT %s TO %s", pqQuoteIdentifier(grantingRole.(string)), pqQuoteIdentifier(role),
 		)
-		if _, err := txn.Exec(query); err != nil {
+		if _, err := txn.ExecContext(ctx, query); err != nil {
 			return errwrap.Wrapf(fmt.Sprintf("could not grant role %s to %s: {{err}}", grantingRole, role), err)
 		}
 	}
@@ -0,0 +1,74 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// On EDB Postgres Advanced Server, a profile defines the password
+// and the failed login policies of the roles it's assigned to.
+
+const (
+	roleProfileAttr = "profile"
+
+	// edbDefaultProfile is the profile assigned to the roles created without a profile.
+	edbDefaultProfile = "default"
+)
+
+// edbRoleProfileOption returns the PROFILE option of CREATE ROLE / ALTER ROLE
+// if the profile has changed (or is configured if create is true).
+func edbRoleProfileOption(c *Client, d *schema.ResourceData, create bool) (string, error) {
+	profile := d.Get(roleProfileAttr).(string)
+	if create && profile == "" || !create && !d.HasChange(roleProfileAttr) {
+		return "", nil
+	}
+
+	if c.flavor != flavorEDB {
+		if profile == "" {
+			return "", nil
+		}
+		return "", fmt.Errorf("%s is only supported on EDB Postgres Advanced Server", roleProfileAttr)
+	}
+
+	if profile == "" {
+		profile = edbDefaultProfile
+	}
+
+	return fmt.Sprintf("PROFILE %s", pqQuoteIdentifier(profile)), nil
+}
+
+func setRoleEDBProfile(c *Client, txn *sql.Tx, d *schema.ResourceData) error {
+	opt, err := edbRoleProfileOption(c, d, false)
+	if err != nil || opt == "" {
+		return err
+	}
+
+	sql := fmt.Sprintf("ALTER ROLE %s %s", pqQuoteIdentifier(d.Get(roleNameAttr).(string)), opt)
+	if _, err := txn.ExecContext(c.ctx, sql); err != nil {
+		return errwrap.Wrapf("Error updating role profile: {{err}}", err)
+	}
+
+	return nil
+}
+
+// readEDBRoleProfile reads the profile of the role.
+func readEDBRoleProfile(ctx context.Context, txn *sql.Tx, d *schema.ResourceData, roleName string) error {
+	var profile string
+	if err := txn.QueryRowContext(ctx, `
+SELECT COALESCE(p.prfname, '')
+FROM pg_catalog.pg_roles r
+LEFT JOIN pg_catalog.edb_profile p ON p.oid = r.rolprofile
+WHERE r.rolname = $1`,
+		roleName,
+	).Scan(&profile); err != nil {
+		return errwrap.Wrapf("Error reading role profile: {{err}}", err)
+	}
+
+	d.Set(roleProfileAttr, profile)
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// On Greenplum, the resources used by the queries of a role are limited
+// either by a resource queue or by a resource group (depending on the
+// gp_resource_manager setting of the cluster).
+
+const (
+	roleResourceQueueAttr = "resource_queue"
+	roleResourceGroupAttr = "resource_group"
+)
+
+// greenplumRoleOptions returns the RESOURCE QUEUE / RESOURCE GROUP options of
+// CREATE ROLE / ALTER ROLE which have changed (the configured ones if create is true).
+func greenplumRoleOptions(c *Client, d *schema.ResourceData, create bool) ([]string, error) {
+	opts := []string{}
+
+	for _, opt := range []struct {
+		hclKey string
+		sqlKey string
+	}{
+		{roleResourceQueueAttr, "RESOURCE QUEUE"},
+		{roleResourceGroupAttr, "RESOURCE GROUP"},
+	} {
+		value := d.Get(opt.hclKey).(string)
+		if create && value == "" || !create && !d.HasChange(opt.hclKey) {
+			continue
+		}
+
+		if c.flavor != flavorGreenplum {
+			if value == "" {
+				continue
+			}
+			return nil, fmt.Errorf("%s is only supported on Greenplum", opt.hclKey)
+		}
+
+		// NONE assigns the default queue / group.
+		if value == "" {
+			opts = append(opts, fmt.Sprintf("%s NONE", opt.sqlKey))
+		} else {
+			opts = append(opts, fmt.Sprintf("%s %s", opt.sqlKey, pqQuoteIdentifier(value)))
+		}
+	}
+
+	return opts, nil
+}
+
+func setRoleGreenplumResources(c *Client, txn *sql.Tx, d *schema.ResourceData) error {
+	opts, err := greenplumRoleOptions(c, d, false)
+	if err != nil || len(opts) == 0 {
+		return err
+	}
+
+	for _, opt := range opts {
+		sql := fmt.Sprintf("ALTER ROLE %s %s", pqQuoteIdentifier(d.Get(roleNameAttr).(string)), opt)
+		if _, err := txn.ExecContext(c.ctx, sql); err != nil {
+			return errwrap.Wrapf("Error updating role resources: {{err}}", err)
+		}
+	}
+
+	return nil
+}
+
+// readGreenplumRoleResources reads the resource queue and the resource group of the role.
+func readGreenplumRoleResources(ctx context.Context, txn *sql.Tx, d *schema.ResourceData, roleName string) error {
+	var resourceQueue, resourceGroup string
+	if err := txn.QueryRowContext(ctx, `
+SELECT COALESCE(q.rsqname, ''), COALESCE(g.rsgname, '')
+FROM pg_catalog.pg_roles r
+LEFT JOIN pg_catalog.pg_resqueue q ON q.oid = r.rolresqueue
+LEFT JOIN pg_catalog.pg_resgroup g ON g.oid = r.rolresgroup
+WHERE r.rolname = $1`,
+		roleName,
+	).Scan(&resourceQueue, &resourceGroup); err != nil {
+		return errwrap.Wrapf("Error reading role resources: {{err}}", err)
+	}
+
+	d.Set(roleResourceQueueAttr, resourceQueue)
+	d.Set(roleResourceGroupAttr, resourceGroup)
+
+	return nil
+}
@@ -0,0 +1,238 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// On Redshift, postgresql_role manages users (CREATE USER) and the roles
+// they are member of are groups (ALTER GROUP ... ADD USER).
+// Users can always log in so the login attribute is ignored.
+
+// checkRedshiftUserAttributes checks that the role attributes which do not exist
+// on Redshift keep their default value.
+func checkRedshiftUserAttributes(d *schema.ResourceData) error {
+	unsupported := []struct {
+		attr         string
+		defaultValue bool
+	}{
+		{roleCreateRoleAttr, false},
+		{roleInheritAttr, true},
+		{roleReplicationAttr, false},
+		{roleBypassRLSAttr, false},
+	}
+
+	for _, opt := range unsupported {
+		if d.Get(opt.attr).(bool) != opt.defaultValue {
+			return fmt.Errorf("%s cannot be set to %t on Redshift", opt.attr, !opt.defaultValue)
+		}
+	}
+	return nil
+}
+
+// redshiftUserOptions returns the options of CREATE USER / ALTER USER
+// which have changed (all of them if create is true).
+func redshiftUserOptions(d *schema.ResourceData, create bool) []string {
+	opts := []string{}
+	changed := func(attr string) bool {
+		return create || d.HasChange(attr)
+	}
+
+	if changed(rolePasswordAttr) || changed(roleGeneratePasswordAttr) || (!create && d.HasChange(roleNameAttr)) {
+		// Redshift salts the password with the user name so it has to be set again on rename.
+		switch password := rolePassword(d); {
+		case password == "" || strings.ToUpper(password) == "NULL":
+			if !create {
+				opts = append(opts, "PASSWORD DISABLE")
+			}
+		default:
+			opts = append(opts, fmt.Sprintf("PASSWORD '%s'", pqQuoteLiteral(password)))
+		}
+	}
+	if create && len(opts) == 0 {
+		opts = append(opts, "PASSWORD DISABLE")
+	}
+
+	if changed(roleCreateDBAttr) {
+		opts = append(opts, boolOption(d.Get(roleCreateDBAttr).(bool), "CREATEDB", "NOCREATEDB"))
+	}
+	if changed(roleSuperuserAttr) {
+		opts = append(opts, boolOption(d.Get(roleSuperuserAttr).(bool), "CREATEUSER", "NOCREATEUSER"))
+	}
+	if changed(roleValidUntilAttr) {
+		validUntil := d.Get(roleValidUntilAttr).(string)
+		if validUntil == "" || strings.ToLower(validUntil) == "infinity" {
+			validUntil = "infinity"
+		}
+		opts = append(opts, fmt.Sprintf("VALID UNTIL '%s'", pqQuoteLiteral(validUntil)))
+	}
+	if changed(roleConnLimitAttr) {
+		if connLimit := d.Get(roleConnLimitAttr).(int); connLimit < 0 {
+			opts = append(opts, "CONNECTION LIMIT UNLIMITED")
+		} else {
+			opts = append(opts, fmt.Sprintf("CONNECTION LIMIT %d", connLimit))
+		}
+	}
+
+	return opts
+}
+
+func boolOption(value bool, enable, disable string) string {
+	if value {
+		return enable
+	}
+	return disable
+}
+
+func createRedshiftUser(c *Client, d *schema.ResourceData) error {
+	if err := checkRedshiftUserAttributes(d); err != nil {
+		return err
+	}
+
+	userName := d.Get(roleNameAttr).(string)
+	queries := []string{
+		fmt.Sprintf("CREATE USER %s %s", pqQuoteIdentifier(userName), strings.Join(redshiftUserOptions(d, true), " ")),
+	}
+	for _, group := range d.Get(roleRolesAttr).(*schema.Set).List() {
+		queries = append(queries, fmt.Sprintf("ALTER GROUP %s ADD USER %s", pqQuoteIdentifier(group.(string)), pqQuoteIdentifier(userName)))
+	}
+
+	// ALTER GROUP cannot run in a transaction block on Redshift.
+	for _, query := range queries {
+		if _, err := c.DB().ExecContext(c.ctx, query); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("error creating user %s: {{err}}", userName), err)
+		}
+	}
+
+	d.SetId(userName)
+
+	return readRedshiftUser(c, d)
+}
+
+func readRedshiftUser(c *Client, d *schema.ResourceData) error {
+	var userName, validUntil, connLimit string
+	var superuser, createDB bool
+	var userSysID int
+
+	userID := d.Id()
+	err := c.DB().QueryRowContext(c.ctx,
+		"SELECT usename, usesysid, usesuper, usecreatedb, COALESCE(valuntil::TEXT, 'infinity'), useconnlimit FROM pg_catalog.pg_user WHERE usename = $1",
+		userID,
+	).Scan(&userName, &userSysID, &superuser, &createDB, &validUntil, &connLimit)
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] Redshift user (%s) not found", userID)
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading user: {{err}}", err)
+	}
+
+	groups, err := readRedshiftUserGroups(c, userName)
+	if err != nil {
+		return err
+	}
+
+	connLimitValue := -1
+	if connLimit != "" && strings.ToUpper(connLimit) != "UNLIMITED" {
+		if connLimitValue, err = strconv.Atoi(connLimit); err != nil {
+			return errwrap.Wrapf("Error parsing the user connection limit: {{err}}", err)
+		}
+	}
+
+	d.Set(roleNameAttr, userName)
+	d.Set(oidAttr, userSysID)
+	d.Set(roleSuperuserAttr, superuser)
+	d.Set(roleCreateDBAttr, createDB)
+	d.Set(roleValidUntilAttr, validUntil)
+	d.Set(roleConnLimitAttr, connLimitValue)
+	d.Set(roleRolesAttr, pgArrayToSet(groups))
+	d.Set(roleLoginAttr, d.Get(roleLoginAttr).(bool))
+	d.Set(roleEncryptedPassAttr, true)
+	d.SetId(userName)
+
+	return nil
+}
+
+// readRedshiftUserGroups returns the groups the user is member of.
+func readRedshiftUserGroups(c *Client, userName string) ([]string, error) {
+	rows, err := c.DB().QueryContext(c.ctx,
+		"SELECT groname FROM pg_catalog.pg_group, pg_catalog.pg_user WHERE usename = $1 AND usesysid = ANY(grolist)",
+		userName,
+	)
+	if err != nil {
+		return nil, errwrap.Wrapf(fmt.Sprintf("could not get groups of user %s: {{err}}", userName), err)
+	}
+	defer rows.Close()
+
+	groups := []string{}
+	for rows.Next() {
+		var group string
+		if err := rows.Scan(&group); err != nil {
+			return nil, errwrap.Wrapf(fmt.Sprintf("could not scan group of user %s: {{err}}", userName), err)
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, rows.Err()
+}
+
+func updateRedshiftUser(c *Client, d *schema.ResourceData) error {
+	if err := checkRedshiftUserAttributes(d); err != nil {
+		return err
+	}
+
+	queries := []string{}
+
+	if d.HasChange(roleNameAttr) {
+		oldName, newName := d.GetChange(roleNameAttr)
+		queries = append(queries, fmt.Sprintf("ALTER USER %s RENAME TO %s", pqQuoteIdentifier(oldName.(string)), pqQuoteIdentifier(newName.(string))))
+	}
+
+	userName := d.Get(roleNameAttr).(string)
+	if opts := redshiftUserOptions(d, false); len(opts) > 0 {
+		queries = append(queries, fmt.Sprintf("ALTER USER %s %s", pqQuoteIdentifier(userName), strings.Join(opts, " ")))
+	}
+
+	if d.HasChange(roleRolesAttr) {
+		oldGroups, newGroups := d.GetChange(roleRolesAttr)
+		for _, group := range oldGroups.(*schema.Set).Difference(newGroups.(*schema.Set)).List() {
+			queries = append(queries, fmt.Sprintf("ALTER GROUP %s DROP USER %s", pqQuoteIdentifier(group.(string)), pqQuoteIdentifier(userName)))
+		}
+		for _, group := range newGroups.(*schema.Set).Difference(oldGroups.(*schema.Set)).List() {
+			queries = append(queries, fmt.Sprintf("ALTER GROUP %s ADD USER %s", pqQuoteIdentifier(group.(string)), pqQuoteIdentifier(userName)))
+		}
+	}
+
+	for _, query := range queries {
+		if _, err := c.DB().ExecContext(c.ctx, query); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("error updating user %s: {{err}}", userName), err)
+		}
+	}
+
+	d.SetId(userName)
+
+	return readRedshiftUser(c, d)
+}
+
+func deleteRedshiftUser(c *Client, d *schema.ResourceData) error {
+	userName := d.Get(roleNameAttr).(string)
+
+	// Redshift has no REASSIGN OWNED / DROP OWNED, the objects of the user have
+	// to be dropped or transferred beforehand.
+	if !d.Get(roleSkipDropRoleAttr).(bool) {
+		if _, err := c.DB().ExecContext(c.ctx, fmt.Sprintf("DROP USER %s", pqQuoteIdentifier(userName))); err != nil {
+			return errwrap.Wrapf("Error deleting user: {{err}}", err)
+		}
+	}
+
+	d.SetId("")
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package postgresql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// On Supabase, the roles used by the platform are reserved: the postgres user
+// cannot alter them and they must not be dropped. Only the API roles can be
+// granted privileges or memberships by the projects.
+
+// supabaseAPIRoles are the roles used by the Supabase APIs (PostgREST, Realtime, ...).
+var supabaseAPIRoles = []string{
+	"anon",
+	"authenticated",
+	"service_role",
+}
+
+// supabaseReservedRoles are the roles created and managed by Supabase.
+var supabaseReservedRoles = append([]string{
+	"authenticator",
+	"dashboard_user",
+	"pgbouncer",
+	"pgsodium_keyholder",
+	"pgsodium_keyiduser",
+	"pgsodium_keymaker",
+	"supabase_admin",
+	"supabase_auth_admin",
+	"supabase_functions_admin",
+	"supabase_read_only_user",
+	"supabase_realtime_admin",
+	"supabase_replication_admin",
+	"supabase_storage_admin",
+}, supabaseAPIRoles...)
+
+// checkSupabaseRole returns an error if the role is reserved by Supabase.
+func checkSupabaseRole(c *Client, roleName string) error {
+	if c.flavor != flavorSupabase || !sliceContainsStr(supabaseReservedRoles, roleName) {
+		return nil
+	}
+	return fmt.Errorf("role %s is reserved by Supabase and cannot be managed", roleName)
+}
+
+// checkSupabaseGrantees returns an error if some roles are reserved by Supabase
+// and are not API roles, which are the only ones the projects can use
+// as grantees or as parent roles.
+func checkSupabaseGrantees(c *Client, roles []string) error {
+	if c.flavor != flavorSupabase {
+		return nil
+	}
+
+	for _, role := range roles {
+		if sliceContainsStr(supabaseReservedRoles, role) && !sliceContainsStr(supabaseAPIRoles, role) {
+			return fmt.Errorf(
+				"role %s is reserved by Supabase, only %s can be used", role, strings.Join(supabaseAPIRoles, ", "),
+			)
+		}
+	}
+	return nil
+}
+
+// checkSupabaseRoleResource checks that a postgresql_role neither manages
+// a reserved role nor makes the role member of a reserved role.
+func checkSupabaseRoleResource(c *Client, d *schema.ResourceData) error {
+	oldName, newName := d.GetChange(roleNameAttr)
+	for _, roleName := range []string{oldName.(string), newName.(string)} {
+		if err := checkSupabaseRole(c, roleName); err != nil {
+			return err
+		}
+	}
+
+	roles := []string{}
+	for _, role := range d.Get(roleRolesAttr).(*schema.Set).List() {
+		roles = append(roles, role.(string))
+	}
+	return checkSupabaseGrantees(c, roles)
+}
@@ -4,7 +4,9 @@ import (
 	"database/sql"
 	"fmt"
 	"reflect"
+	"regexp"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/terraform/helper/resource"
@@ -62,6 +64,195 @@ func TestAccPostgresqlRole_Basic(t *testing.T) {
 	})
 }
 
+func TestAccPostgresqlRole_InvalidName(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config:      `resource "postgresql_role" "reserved" { name = "pg_app" }`,
+				ExpectError: regexp.MustCompile(`the pg_ prefix is used by the system roles`),
+			},
+			{
+				Config: fmt.Sprintf(
+					`resource "postgresql_role" "too_long" { name = "%s" }`, strings.Repeat("a", maxIdentifierLength+1),
+				),
+				ExpectError: regexp.MustCompile(`is longer than 63 bytes`),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlRole_MinServerVersion(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "postgresql_role" "min_version" {
+  name               = "min_version"
+  min_server_version = "999"
+}`,
+				ExpectError: regexp.MustCompile(`postgresql_role requires a server version 999 or later`),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlRole_AdoptIfExists(t *testing.T) {
+	skipIfNotAcc(t)
+
+	config := getTestConfig(t)
+	dsn := config.connStr("postgres")
+
+	// The role is created outside of Terraform, it's dropped by the destroy of the resource.
+	dbExecute(t, dsn, "CREATE ROLE adopted_role LOGIN CONNECTION LIMIT 5")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPostgresqlRoleDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "postgresql_role" "adopted_role" {
+  name             = "adopted_role"
+  login            = true
+  connection_limit = 10
+  adopt_if_exists  = true
+}`,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPostgresqlRoleExists("adopted_role", nil),
+					resource.TestCheckResourceAttr("postgresql_role.adopted_role", "adopt_if_exists", "true"),
+				),
+				// The existing role is adopted as is, the connection limit is updated by the next apply.
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlRole_AdoptIfExistsGeneratePassword(t *testing.T) {
+	skipIfNotAcc(t)
+
+	config := getTestConfig(t)
+	dsn := config.connStr("postgres")
+
+	// The role is created outside of Terraform with another password, it's replaced by the generated one.
+	dbExecute(t, dsn, "CREATE ROLE adopted_generated LOGIN PASSWORD 'initial'")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPostgresqlRoleDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "postgresql_role" "adopted_generated" {
+  name            = "adopted_generated"
+  login           = true
+  adopt_if_exists = true
+
+  generate_password {}
+}`,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPostgresqlRoleExists("adopted_generated", nil),
+					func(s *terraform.State) error {
+						password := s.RootModule().Resources["postgresql_role.adopted_generated"].Primary.Attributes["generated_password"]
+						if password == "" {
+							return fmt.Errorf("expected a generated password for the adopted role")
+						}
+						return testAccCheckRoleCanLogin(t, "adopted_generated", password)(s)
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlRole_GeneratePassword(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPostgresqlRoleDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "postgresql_role" "generated_password" {
+  name  = "generated_password"
+  login = true
+
+  generate_password {
+    length  = 40
+    special = true
+  }
+}`,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPostgresqlRoleExists("generated_password", nil),
+					resource.TestCheckResourceAttr("postgresql_role.generated_password", "password", ""),
+					func(s *terraform.State) error {
+						password := s.RootModule().Resources["postgresql_role.generated_password"].Primary.Attributes["generated_password"]
+						if len(password) != 40 {
+							return fmt.Errorf("expected a generated password of 40 characters, got %d", len(password))
+						}
+						return testAccCheckRoleCanLogin(t, "generated_password", password)(s)
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlRole_Protection(t *testing.T) {
+	config := `
+resource "postgresql_role" "protected_role" {
+  name                = "%s"
+  deletion_protection = %t
+  rename_protection   = %t
+}
+`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPostgresqlRoleDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(config, "protected_role", true, true),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPostgresqlRoleExists("protected_role", nil),
+					resource.TestCheckResourceAttr("postgresql_role.protected_role", "deletion_protection", "true"),
+					resource.TestCheckResourceAttr("postgresql_role.protected_role", "rename_protection", "true"),
+				),
+			},
+			{
+				Config:      fmt.Sprintf(config, "protected_role_renamed", true, true),
+				ExpectError: regexp.MustCompile(`rename_protection is enabled`),
+			},
+			{
+				// The protections have to be disabled for the role to be destroyed.
+				Config: fmt.Sprintf(config, "protected_role", false, false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_role.protected_role", "deletion_protection", "false"),
+				),
+			},
+		},
+	})
+}
+
 func TestAccPostgresqlRole_Update(t *testing.T) {
 
 	var configCreate = `
@@ -203,7 +394,7 @@ func testAccCheckRoleCanLogin(t *testing.T, role, password string) resource.Test
 		config := getTestConfig(t)
 		config.Username = role
 		config.Password = password
-		db, err := sql.Open("postgres", config.connStr("postgres"))
+		db, err := sql.Open("pgx", config.connStr("postgres"))
 		if err != nil {
 			return fmt.Errorf("could not open SQL connection: %v", err)
 		}
@@ -0,0 +1,274 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	rlsDatabaseAttr = "database"
+	rlsSchemaAttr   = "schema"
+	rlsTableAttr    = "table"
+	rlsForceAttr    = "force"
+)
+
+// resourcePostgreSQLRowLevelSecurity enables the row-level security of a table,
+// which is disabled again when the resource is deleted.
+func resourcePostgreSQLRowLevelSecurity() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLRowLevelSecurityCreate),
+		Read:   resourcePostgreSQLRowLevelSecurityRead,
+		Update: retryOnTransientErrors(resourcePostgreSQLRowLevelSecurityUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLRowLevelSecurityDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLRowLevelSecurityImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			rlsDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database of the table",
+			},
+			rlsSchemaAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "public",
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The schema of the table",
+			},
+			rlsTableAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The table on which the row-level security is enabled",
+			},
+			rlsForceAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Apply the policies to the owner of the table too",
+			},
+		},
+	}
+}
+
+func resourcePostgreSQLRowLevelSecurityCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkRowLevelSecuritySupported(c); err != nil {
+		return err
+	}
+
+	database := getRowLevelSecurityDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	table := rowLevelSecurityTable(d)
+	if err := execQueries(c.ctx, txn, []string{
+		fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY", table),
+		fmt.Sprintf("ALTER TABLE %s %s", table, rowLevelSecurityForce(d)),
+	}); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error enabling row-level security on %s: {{err}}", table), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing row-level security: {{err}}", err)
+	}
+
+	d.Set(rlsDatabaseAttr, database)
+	d.SetId(generateRowLevelSecurityID(d))
+
+	return resourcePostgreSQLRowLevelSecurityReadImpl(d, c)
+}
+
+func resourcePostgreSQLRowLevelSecurityRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkRowLevelSecuritySupported(c); err != nil {
+		return err
+	}
+
+	defer c.rLockDatabase(getRowLevelSecurityDatabase(d, c))()
+
+	return resourcePostgreSQLRowLevelSecurityReadImpl(d, c)
+}
+
+func resourcePostgreSQLRowLevelSecurityReadImpl(d *schema.ResourceData, c *Client) error {
+	database := getRowLevelSecurityDatabase(d, c)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	var enabled, force bool
+	err = txn.QueryRowContext(c.ctx, `
+SELECT c.relrowsecurity, c.relforcerowsecurity
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname = $1 AND c.relname = $2`,
+		d.Get(rlsSchemaAttr), d.Get(rlsTableAttr),
+	).Scan(&enabled, &force)
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] PostgreSQL table (%s) not found", d.Id())
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading row-level security: {{err}}", err)
+	}
+
+	// The row-level security disabled outside of Terraform is enabled again.
+	if !enabled {
+		log.Printf("[WARN] PostgreSQL row-level security of table (%s) is disabled", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	d.Set(rlsForceAttr, force)
+	d.Set(rlsDatabaseAttr, database)
+	d.SetId(generateRowLevelSecurityID(d))
+
+	return nil
+}
+
+func resourcePostgreSQLRowLevelSecurityUpdate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkRowLevelSecuritySupported(c); err != nil {
+		return err
+	}
+
+	database := getRowLevelSecurityDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	table := rowLevelSecurityTable(d)
+	if _, err := txn.ExecContext(c.ctx, fmt.Sprintf("ALTER TABLE %s %s", table, rowLevelSecurityForce(d))); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error updating row-level security on %s: {{err}}", table), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing row-level security: {{err}}", err)
+	}
+
+	return resourcePostgreSQLRowLevelSecurityReadImpl(d, c)
+}
+
+func resourcePostgreSQLRowLevelSecurityDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkRowLevelSecuritySupported(c); err != nil {
+		return err
+	}
+
+	database := getRowLevelSecurityDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	// The table may have been dropped with its policies.
+	var exists bool
+	if err := txn.QueryRowContext(c.ctx,
+		"SELECT EXISTS (SELECT 1 FROM pg_catalog.pg_class c JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace WHERE n.nspname = $1 AND c.relname = $2)",
+		d.Get(rlsSchemaAttr), d.Get(rlsTableAttr),
+	).Scan(&exists); err != nil {
+		return errwrap.Wrapf("Error reading table: {{err}}", err)
+	}
+
+	if exists {
+		table := rowLevelSecurityTable(d)
+		if err := execQueries(c.ctx, txn, []string{
+			fmt.Sprintf("ALTER TABLE %s NO FORCE ROW LEVEL SECURITY", table),
+			fmt.Sprintf("ALTER TABLE %s DISABLE ROW LEVEL SECURITY", table),
+		}); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("Error disabling row-level security on %s: {{err}}", table), err)
+		}
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing row-level security: {{err}}", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// resourcePostgreSQLRowLevelSecurityImport imports the row-level security of a table
+// from an ID with the database/schema/table format.
+func resourcePostgreSQLRowLevelSecurityImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), "database/schema/table")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set(rlsDatabaseAttr, parts[0])
+	d.Set(rlsSchemaAttr, parts[1])
+	d.Set(rlsTableAttr, parts[2])
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func checkRowLevelSecuritySupported(c *Client) error {
+	if !c.featureSupported(featureRLS) {
+		return fmt.Errorf(
+			"postgresql_row_level_security resource is not supported for this Postgres version (%s)",
+			c.version,
+		)
+	}
+	return nil
+}
+
+func rowLevelSecurityTable(d *schema.ResourceData) string {
+	return pqQuoteIdentifier(d.Get(rlsSchemaAttr).(string)) + "." + pqQuoteIdentifier(d.Get(rlsTableAttr).(string))
+}
+
+func rowLevelSecurityForce(d *schema.ResourceData) string {
+	if d.Get(rlsForceAttr).(bool) {
+		return "FORCE ROW LEVEL SECURITY"
+	}
+	return "NO FORCE ROW LEVEL SECURITY"
+}
+
+func getRowLevelSecurityDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(rlsDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generateRowLevelSecurityID(d *schema.ResourceData) string {
+	return strings.Join([]string{
+		d.Get(rlsDatabaseAttr).(string), d.Get(rlsSchemaAttr).(string), d.Get(rlsTableAttr).(string),
+	}, ".")
+}
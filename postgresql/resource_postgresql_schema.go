@@ -2,6 +2,7 @@ package postgresql
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -11,15 +12,15 @@ import (
 
 	"github.com/hashicorp/errwrap"
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/lib/pq"
 	"github.com/sean-/postgresql-acl"
 )
 
 const (
-	schemaNameAttr    = "name"
-	schemaOwnerAttr   = "owner"
-	schemaPolicyAttr  = "policy"
-	schemaIfNotExists = "if_not_exists"
+	schemaNameAttr     = "name"
+	schemaDatabaseAttr = "database"
+	schemaOwnerAttr    = "owner"
+	schemaPolicyAttr   = "policy"
+	schemaIfNotExists  = "if_not_exists"
 
 	schemaPolicyCreateAttr          = "create"
 	schemaPolicyCreateWithGrantAttr = "create_with_grant"
@@ -30,26 +31,45 @@ const (
 
 func resourcePostgreSQLSchema() *schema.Resource {
 	return &schema.Resource{
-		Create: resourcePostgreSQLSchemaCreate,
+		Create: retryOnTransientErrors(resourcePostgreSQLSchemaCreate),
 		Read:   resourcePostgreSQLSchemaRead,
-		Update: resourcePostgreSQLSchemaUpdate,
-		Delete: resourcePostgreSQLSchemaDelete,
-		Exists: resourcePostgreSQLSchemaExists,
+		Update: retryOnTransientErrors(resourcePostgreSQLSchemaUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLSchemaDelete),
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			State: resourcePostgreSQLSchemaImport,
+		},
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourcePostgreSQLSchemaV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourcePostgreSQLSchemaStateUpgradeV0,
+			},
 		},
 
 		Schema: map[string]*schema.Schema{
 			schemaNameAttr: {
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "The name of the schema",
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateFunc:     validateIdentifier,
+				Description:      "The name of the schema",
+				DiffSuppressFunc: suppressIdentifierCaseDiff,
+			},
+			schemaDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database in which to create the schema",
 			},
 			schemaOwnerAttr: {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Computed:    true,
-				Description: "The ROLE name who owns the schema",
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				ValidateFunc:     validateIdentifier,
+				Description:      "The ROLE name who owns the schema",
+				DiffSuppressFunc: suppressIdentifierCaseDiff,
 			},
 			schemaIfNotExists: {
 				Type:        schema.TypeBool,
@@ -101,6 +121,11 @@ func resourcePostgreSQLSchema() *schema.Resource {
 					},
 				},
 			},
+			quotedIdentifiersAttr:     quotedIdentifiersSchema(),
+			detectExternalChangesAttr: detectExternalChangesSchema(),
+			waitAttr:                  waitSchema(),
+			oidAttr:                   oidSchema("schema"),
+			aclAttr:                   aclSchema("schema"),
 		},
 	}
 }
@@ -118,11 +143,11 @@ func resourcePostgreSQLSchemaCreate(d *schema.ResourceData, meta interface{}) er
 				fmt.Fprint(b, "IF NOT EXISTS ")
 			}
 		}
-		fmt.Fprint(b, pq.QuoteIdentifier(schemaName))
+		fmt.Fprint(b, pqQuoteIdentifier(schemaName))
 
 		switch v, ok := d.GetOk(schemaOwnerAttr); {
 		case ok:
-			fmt.Fprint(b, " AUTHORIZATION ", pq.QuoteIdentifier(v.(string)))
+			fmt.Fprint(b, " AUTHORIZATION ", pqQuoteIdentifier(c.config.roleName(v.(string))))
 		}
 		queries = append(queries, b.String())
 	}
@@ -155,36 +180,46 @@ func resourcePostgreSQLSchemaCreate(d *schema.ResourceData, meta interface{}) er
 		queries = append(queries, policy.Grants(schemaName)...)
 	}
 
-	c.catalogLock.Lock()
-	defer c.catalogLock.Unlock()
+	database := getSchemaDatabase(d, c)
+
+	defer c.lockDatabase(database)()
 
-	txn, err := c.DB().Begin()
+	txn, err := startTransaction(c, database)
 	if err != nil {
 		return err
 	}
 	defer deferredRollback(txn)
 
 	for _, query := range queries {
-		if _, err = txn.Exec(query); err != nil {
+		if _, err = txn.ExecContext(c.ctx, query); err != nil {
 			return errwrap.Wrapf(fmt.Sprintf("Error creating schema %s: {{err}}", schemaName), err)
 		}
 	}
 
-	if err := txn.Commit(); err != nil {
+	if err := commitTransaction(c, txn); err != nil {
 		return errwrap.Wrapf("Error committing schema: {{err}}", err)
 	}
 
-	d.SetId(schemaName)
+	d.Set(schemaDatabaseAttr, database)
+	d.SetId(generateSchemaID(d))
+
+	if err := waitForVisibility(c, d, database, fmt.Sprintf("schema %s", schemaName),
+		"SELECT EXISTS(SELECT 1 FROM pg_catalog.pg_namespace WHERE nspname = $1)", schemaName,
+	); err != nil {
+		return err
+	}
 
 	return resourcePostgreSQLSchemaReadImpl(d, meta)
 }
 
 func resourcePostgreSQLSchemaDelete(d *schema.ResourceData, meta interface{}) error {
 	c := meta.(*Client)
-	c.catalogLock.Lock()
-	defer c.catalogLock.Unlock()
 
-	txn, err := c.DB().Begin()
+	database := getSchemaDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
 	if err != nil {
 		return err
 	}
@@ -193,41 +228,47 @@ func resourcePostgreSQLSchemaDelete(d *schema.ResourceData, meta interface{}) er
 	schemaName := d.Get(schemaNameAttr).(string)
 
 	// NOTE(sean@): Deliberately not performing a cascading drop.
-	sql := fmt.Sprintf("DROP SCHEMA %s", pq.QuoteIdentifier(schemaName))
-	if _, err = txn.Exec(sql); err != nil {
+	sql := fmt.Sprintf("DROP SCHEMA %s", pqQuoteIdentifier(schemaName))
+	if _, err = txn.ExecContext(c.ctx, sql); err != nil {
 		return errwrap.Wrapf("Error deleting schema: {{err}}", err)
 	}
 
-	if err := txn.Commit(); err != nil {
+	if err := commitTransaction(c, txn); err != nil {
 		return errwrap.Wrapf("Error committing schema: {{err}}", err)
 	}
 
+	c.catalogCache.invalidate(catalogCacheKey("schema", database, schemaName))
 	d.SetId("")
 
 	return nil
 }
 
-func resourcePostgreSQLSchemaExists(d *schema.ResourceData, meta interface{}) (bool, error) {
+func resourcePostgreSQLSchemaRead(d *schema.ResourceData, meta interface{}) error {
 	c := meta.(*Client)
-	c.catalogLock.RLock()
-	defer c.catalogLock.RUnlock()
+	database := getSchemaDatabase(d, c)
 
-	var schemaName string
-	err := c.DB().QueryRow("SELECT n.nspname FROM pg_catalog.pg_namespace n WHERE n.nspname=$1", d.Id()).Scan(&schemaName)
-	switch {
-	case err == sql.ErrNoRows:
-		return false, nil
-	case err != nil:
-		return false, errwrap.Wrapf("Error reading schema: {{err}}", err)
-	}
+	defer c.rLockDatabase(database)()
 
-	return true, nil
-}
+	if !detectExternalChanges(d) {
+		schemaName := d.Get(schemaNameAttr).(string)
+		exists, err := c.catalogCache.exists(catalogCacheKey("schema", database, schemaName), func() (bool, error) {
+			txn, err := startTransaction(c, database)
+			if err != nil {
+				return false, err
+			}
+			defer deferredRollback(txn)
 
-func resourcePostgreSQLSchemaRead(d *schema.ResourceData, meta interface{}) error {
-	c := meta.(*Client)
-	c.catalogLock.RLock()
-	defer c.catalogLock.RUnlock()
+			return schemaExists(c.ctx, txn, schemaName)
+		})
+		if err != nil {
+			return err
+		}
+		if !exists {
+			log.Printf("[WARN] PostgreSQL schema (%s) not found", d.Id())
+			d.SetId("")
+		}
+		return nil
+	}
 
 	return resourcePostgreSQLSchemaReadImpl(d, meta)
 }
@@ -235,13 +276,22 @@ func resourcePostgreSQLSchemaRead(d *schema.ResourceData, meta interface{}) erro
 func resourcePostgreSQLSchemaReadImpl(d *schema.ResourceData, meta interface{}) error {
 	c := meta.(*Client)
 
-	schemaId := d.Id()
+	database := getSchemaDatabase(d, c)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	schemaId := d.Get(schemaNameAttr).(string)
 	var schemaName, schemaOwner string
+	var schemaOID int
 	var schemaACLs []string
-	err := c.DB().QueryRow("SELECT n.nspname, pg_catalog.pg_get_userbyid(n.nspowner), COALESCE(n.nspacl, '{}'::aclitem[])::TEXT[] FROM pg_catalog.pg_namespace n WHERE n.nspname=$1", schemaId).Scan(&schemaName, &schemaOwner, pq.Array(&schemaACLs))
+	err = txn.QueryRowContext(c.ctx, "SELECT n.nspname, n.oid::BIGINT, pg_catalog.pg_get_userbyid(n.nspowner), COALESCE(n.nspacl, '{}'::aclitem[])::TEXT[] FROM pg_catalog.pg_namespace n WHERE n.nspname=$1", schemaId).Scan(&schemaName, &schemaOID, &schemaOwner, pgArray(&schemaACLs))
 	switch {
 	case err == sql.ErrNoRows:
-		log.Printf("[WARN] PostgreSQL schema (%s) not found", schemaId)
+		log.Printf("[WARN] PostgreSQL schema (%s) not found", d.Id())
 		d.SetId("")
 		return nil
 	case err != nil:
@@ -271,43 +321,53 @@ func resourcePostgreSQLSchemaReadImpl(d *schema.ResourceData, meta interface{})
 		}
 
 		d.Set(schemaNameAttr, schemaName)
-		d.Set(schemaOwnerAttr, schemaOwner)
-		d.SetId(schemaName)
+		d.Set(schemaOwnerAttr, c.config.stateRoleName(d.Get(schemaOwnerAttr).(string), schemaOwner))
+		d.Set(oidAttr, schemaOID)
+		d.Set(aclAttr, schemaACLs)
+		d.Set(schemaDatabaseAttr, database)
+		d.SetId(generateSchemaID(d))
 		return nil
 	}
 }
 
 func resourcePostgreSQLSchemaUpdate(d *schema.ResourceData, meta interface{}) error {
 	c := meta.(*Client)
-	c.catalogLock.Lock()
-	defer c.catalogLock.Unlock()
 
-	txn, err := c.DB().Begin()
+	database := getSchemaDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
 	if err != nil {
 		return err
 	}
 	defer deferredRollback(txn)
 
-	if err := setSchemaName(txn, d); err != nil {
+	if err := setSchemaName(c.ctx, txn, d); err != nil {
 		return err
 	}
 
-	if err := setSchemaOwner(txn, d); err != nil {
+	if err := setSchemaOwner(c, txn, d); err != nil {
 		return err
 	}
 
-	if err := setSchemaPolicy(txn, d); err != nil {
+	if err := setSchemaPolicy(c.ctx, txn, d); err != nil {
 		return err
 	}
 
-	if err := txn.Commit(); err != nil {
+	if err := commitTransaction(c, txn); err != nil {
 		return errwrap.Wrapf("Error committing schema: {{err}}", err)
 	}
 
+	if d.HasChange(schemaNameAttr) {
+		oldName, _ := d.GetChange(schemaNameAttr)
+		c.catalogCache.invalidate(catalogCacheKey("schema", database, oldName.(string)))
+	}
+
 	return resourcePostgreSQLSchemaReadImpl(d, meta)
 }
 
-func setSchemaName(txn *sql.Tx, d *schema.ResourceData) error {
+func setSchemaName(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) error {
 	if !d.HasChange(schemaNameAttr) {
 		return nil
 	}
@@ -319,16 +379,16 @@ func setSchemaName(txn *sql.Tx, d *schema.ResourceData) error {
 		return errors.New("Error setting schema name to an empty string")
 	}
 
-	sql := fmt.Sprintf("ALTER SCHEMA %s RENAME TO %s", pq.QuoteIdentifier(o), pq.QuoteIdentifier(n))
-	if _, err := txn.Exec(sql); err != nil {
+	sql := fmt.Sprintf("ALTER SCHEMA %s RENAME TO %s", pqQuoteIdentifier(o), pqQuoteIdentifier(n))
+	if _, err := txn.ExecContext(ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating schema NAME: {{err}}", err)
 	}
-	d.SetId(n)
+	d.SetId(generateSchemaID(d))
 
 	return nil
 }
 
-func setSchemaOwner(txn *sql.Tx, d *schema.ResourceData) error {
+func setSchemaOwner(c *Client, txn *sql.Tx, d *schema.ResourceData) error {
 	if !d.HasChange(schemaOwnerAttr) {
 		return nil
 	}
@@ -340,15 +400,15 @@ func setSchemaOwner(txn *sql.Tx, d *schema.ResourceData) error {
 		return errors.New("Error setting schema owner to an empty string")
 	}
 
-	sql := fmt.Sprintf("ALTER SCHEMA %s OWNER TO %s", pq.QuoteIdentifier(o), pq.QuoteIdentifier(n))
-	if _, err := txn.Exec(sql); err != nil {
+	sql := fmt.Sprintf("ALTER SCHEMA %s OWNER TO %s", pqQuoteIdentifier(o), pqQuoteIdentifier(c.config.roleName(n)))
+	if _, err := txn.ExecContext(c.ctx, sql); err != nil {
 		return errwrap.Wrapf("Error updating schema OWNER: {{err}}", err)
 	}
 
 	return nil
 }
 
-func setSchemaPolicy(txn *sql.Tx, d *schema.ResourceData) error {
+func setSchemaPolicy(ctx context.Context, txn *sql.Tx, d *schema.ResourceData) error {
 	if !d.HasChange(schemaPolicyAttr) {
 		return nil
 	}
@@ -369,7 +429,7 @@ func setSchemaPolicy(txn *sql.Tx, d *schema.ResourceData) error {
 		// to prevent revoking against it not existing.
 		if rolePolicy.Role != "" {
 			var foundUser bool
-			err := txn.QueryRow(`SELECT TRUE FROM pg_catalog.pg_user WHERE usename = $1`, rolePolicy.Role).Scan(&foundUser)
+			err := txn.QueryRowContext(ctx, `SELECT TRUE FROM pg_catalog.pg_user WHERE usename = $1`, rolePolicy.Role).Scan(&foundUser)
 			switch {
 			case err == sql.ErrNoRows:
 				// Don't execute this role's REVOKEs because the role
@@ -408,7 +468,7 @@ func setSchemaPolicy(txn *sql.Tx, d *schema.ResourceData) error {
 	}
 
 	for _, query := range queries {
-		if _, err := txn.Exec(query); err != nil {
+		if _, err := txn.ExecContext(ctx, query); err != nil {
 			return errwrap.Wrapf("Error updating schema DCL: {{err}}", err)
 		}
 	}
@@ -473,6 +533,43 @@ func schemaChangedPolicies(old, new []interface{}) (dropped, added, update, unch
 	return droppedRoles, addedRoles, updatedRoles, unchangedRoles
 }
 
+// resourcePostgreSQLSchemaImport imports a schema from an ID with the database/schema format.
+// The ID of the state (database.schema) is also accepted, as well as the name of a schema
+// of the provider's database.
+func resourcePostgreSQLSchemaImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	c := meta.(*Client)
+
+	database, schemaName := c.databaseName, d.Id()
+	if strings.Contains(d.Id(), "/") {
+		parts, err := splitImportID(d.Id(), "database/schema")
+		if err != nil {
+			return nil, err
+		}
+		database, schemaName = parts[0], parts[1]
+	} else if parts := strings.SplitN(d.Id(), ".", 2); len(parts) == 2 {
+		database, schemaName = parts[0], parts[1]
+	}
+
+	d.Set(schemaDatabaseAttr, database)
+	d.Set(schemaNameAttr, schemaName)
+	d.SetId(generateSchemaID(d))
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func getSchemaDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(schemaDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generateSchemaID(d *schema.ResourceData) string {
+	return strings.Join([]string{
+		d.Get(schemaDatabaseAttr).(string), d.Get(schemaNameAttr).(string),
+	}, ".")
+}
+
 func schemaPolicyToHCL(s *acl.Schema) map[string]interface{} {
 	return map[string]interface{}{
 		schemaPolicyRoleAttr:            s.Role,
@@ -0,0 +1,74 @@
+package postgresql
+
+import (
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// resourcePostgreSQLSchemaV0 is the schema of postgresql_schema before the database
+// attribute was added, used to decode the states written by the previous versions.
+func resourcePostgreSQLSchemaV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			schemaNameAttr: {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			schemaOwnerAttr: {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+			},
+			schemaIfNotExists: {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			schemaPolicyAttr: {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						schemaPolicyCreateAttr: {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+						schemaPolicyCreateWithGrantAttr: {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+						schemaPolicyRoleAttr: {
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "",
+						},
+						schemaPolicyUsageAttr: {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+						schemaPolicyUsageWithGrantAttr: {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// resourcePostgreSQLSchemaStateUpgradeV0 sets the database of the schema
+// (the provider's database) and its database qualified ID.
+// If the provider is not configured, they are set by the next read.
+func resourcePostgreSQLSchemaStateUpgradeV0(rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	if c, ok := meta.(*Client); ok && c != nil {
+		rawState[schemaDatabaseAttr] = c.databaseName
+		rawState["id"] = c.databaseName + "." + stateString(rawState, schemaNameAttr)
+	}
+
+	return rawState, nil
+}
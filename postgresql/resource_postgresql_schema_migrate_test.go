@@ -0,0 +1,40 @@
+package postgresql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResourcePostgreSQLSchemaStateUpgradeV0(t *testing.T) {
+	newState := func() map[string]interface{} {
+		return map[string]interface{}{
+			"id":            "test_schema",
+			"name":          "test_schema",
+			"if_not_exists": true,
+		}
+	}
+
+	expected := map[string]interface{}{
+		"id":            "test_db.test_schema",
+		"name":          "test_schema",
+		"database":      "test_db",
+		"if_not_exists": true,
+	}
+
+	actual, err := resourcePostgreSQLSchemaStateUpgradeV0(newState(), &Client{databaseName: "test_db"})
+	if err != nil {
+		t.Fatalf("error upgrading state: %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected state %v, got %v", expected, actual)
+	}
+
+	// Without a configured provider, the database is set by the next read.
+	actual, err = resourcePostgreSQLSchemaStateUpgradeV0(newState(), nil)
+	if err != nil {
+		t.Fatalf("error upgrading state: %v", err)
+	}
+	if !reflect.DeepEqual(actual, newState()) {
+		t.Fatalf("expected state %v, got %v", newState(), actual)
+	}
+}
@@ -3,6 +3,7 @@ package postgresql
 import (
 	"database/sql"
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/errwrap"
@@ -36,6 +37,7 @@ func TestAccPostgresqlSchema_Basic(t *testing.T) {
 					resource.TestCheckResourceAttr("postgresql_schema.test2", "policy.1948480595.usage", "true"),
 					resource.TestCheckResourceAttr("postgresql_schema.test2", "policy.1948480595.usage_with_grant", "false"),
 					resource.TestCheckResourceAttr("postgresql_schema.test2", "policy.1948480595.role", "role_all_without_grant"),
+					resource.TestCheckResourceAttrSet("postgresql_schema.test2", "oid"),
 
 					resource.TestCheckResourceAttr("postgresql_schema.test3", "name", "baz"),
 					resource.TestCheckResourceAttr("postgresql_schema.test3", "owner", "role_all_without_grant"),
@@ -49,6 +51,16 @@ func TestAccPostgresqlSchema_Basic(t *testing.T) {
 					resource.TestCheckResourceAttr("postgresql_schema.test3", "policy.1948480595.role", "role_all_without_grant"),
 				),
 			},
+			{
+				ResourceName: "postgresql_schema.test1",
+				ImportState:  true,
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					rs := s.RootModule().Resources["postgresql_schema.test1"]
+					return fmt.Sprintf("%s/%s", rs.Primary.Attributes["database"], rs.Primary.Attributes["name"]), nil
+				},
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{schemaIfNotExists},
+			},
 		},
 	})
 }
@@ -162,6 +174,48 @@ func TestAccPostgresqlSchema_AddPolicy(t *testing.T) {
 	})
 }
 
+func TestAccPostgresqlSchema_SQLHooks(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPostgresqlSchemaDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: `
+resource "postgresql_schema" "hooked" {
+  name     = "hooked"
+  pre_sql  = "SET LOCAL lock_timeout = '5s'"
+  post_sql = "COMMENT ON SCHEMA hooked IS 'created with hooks'"
+}`,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPostgresqlSchemaExists("postgresql_schema.hooked", "hooked"),
+					func(s *terraform.State) error {
+						client := testAccProvider.Meta().(*Client)
+						var comment string
+						if err := client.DB().QueryRow(
+							"SELECT COALESCE(obj_description(oid, 'pg_namespace'), '') FROM pg_catalog.pg_namespace WHERE nspname = 'hooked'",
+						).Scan(&comment); err != nil {
+							return fmt.Errorf("Error reading the comment of schema hooked: %s", err)
+						}
+						if comment != "created with hooks" {
+							return fmt.Errorf("expected the comment set by post_sql, got %q", comment)
+						}
+						return nil
+					},
+				),
+			},
+			{
+				Config: `
+resource "postgresql_schema" "failing_hook" {
+  name     = "failing_hook"
+  post_sql = "SELECT pg_catalog.no_such_function()"
+}`,
+				ExpectError: regexp.MustCompile(`could not execute post_sql`),
+			},
+		},
+	})
+}
+
 func testAccCheckPostgresqlSchemaDestroy(s *terraform.State) error {
 	client := testAccProvider.Meta().(*Client)
 
@@ -170,7 +224,7 @@ func testAccCheckPostgresqlSchemaDestroy(s *terraform.State) error {
 			continue
 		}
 
-		exists, err := checkSchemaExists(client, rs.Primary.ID)
+		exists, err := checkSchemaExists(client, rs.Primary.Attributes["name"])
 		if err != nil {
 			return fmt.Errorf("Error checking schema %s", err)
 		}
@@ -200,7 +254,7 @@ func testAccCheckPostgresqlSchemaExists(n string, schemaName string) resource.Te
 		}
 
 		client := testAccProvider.Meta().(*Client)
-		exists, err := checkSchemaExists(client, rs.Primary.ID)
+		exists, err := checkSchemaExists(client, actualSchemaName)
 
 		if err != nil {
 			return fmt.Errorf("Error checking schema %s", err)
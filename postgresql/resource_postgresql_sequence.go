@@ -0,0 +1,417 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	seqNameAttr      = "name"
+	seqDatabaseAttr  = "database"
+	seqSchemaAttr    = "schema"
+	seqStartAttr     = "start"
+	seqIncrementAttr = "increment"
+	seqMinValueAttr  = "min_value"
+	seqMaxValueAttr  = "max_value"
+	seqCacheAttr     = "cache"
+	seqCycleAttr     = "cycle"
+	seqOwnerAttr     = "owner"
+	seqOwnedByAttr   = "owned_by"
+)
+
+// resourcePostgreSQLSequence manages a standalone sequence, the sequences of the serial
+// and identity columns are managed with their tables.
+func resourcePostgreSQLSequence() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLSequenceCreate),
+		Read:   resourcePostgreSQLSequenceRead,
+		Update: retryOnTransientErrors(resourcePostgreSQLSequenceUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLSequenceDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLSequenceImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			seqNameAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The name of the sequence",
+			},
+			seqDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database in which the sequence is created",
+			},
+			seqSchemaAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "public",
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The schema in which the sequence is created",
+			},
+			seqStartAttr: {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				Description: "The first value of the sequence, min_value (or max_value for a descending sequence) by default",
+			},
+			seqIncrementAttr: {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     1,
+				Description: "The value added to the current value to get the next one, negative for a descending sequence",
+			},
+			seqMinValueAttr: {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				Description: "The minimum value of the sequence",
+			},
+			seqMaxValueAttr: {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				Description: "The maximum value of the sequence",
+			},
+			seqCacheAttr: {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     1,
+				Description: "The number of values preallocated by the sessions",
+			},
+			seqCycleAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether the sequence wraps around when it reaches its limit",
+			},
+			seqOwnerAttr: {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				ValidateFunc:     validateIdentifier,
+				DiffSuppressFunc: suppressIdentifierCaseDiff,
+				Description:      "The ROLE name who owns the sequence",
+			},
+			seqOwnedByAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateSequenceOwnedBy,
+				Description:  "The column (table.column) owning the sequence, which is dropped with it",
+			},
+		},
+	}
+}
+
+func resourcePostgreSQLSequenceCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkSequenceSupported(c); err != nil {
+		return err
+	}
+
+	database := getSequenceDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	seqName := quoteSequenceName(d)
+
+	b := &strings.Builder{}
+	fmt.Fprint(b, "CREATE SEQUENCE ", seqName)
+	fmt.Fprintf(b, " INCREMENT BY %d", d.Get(seqIncrementAttr).(int))
+	if v, ok := d.GetOk(seqMinValueAttr); ok {
+		fmt.Fprintf(b, " MINVALUE %d", v.(int))
+	}
+	if v, ok := d.GetOk(seqMaxValueAttr); ok {
+		fmt.Fprintf(b, " MAXVALUE %d", v.(int))
+	}
+	if v, ok := d.GetOk(seqStartAttr); ok {
+		fmt.Fprintf(b, " START WITH %d", v.(int))
+	}
+	fmt.Fprintf(b, " CACHE %d", d.Get(seqCacheAttr).(int))
+	if d.Get(seqCycleAttr).(bool) {
+		fmt.Fprint(b, " CYCLE")
+	}
+
+	// The owner is changed before the column is set:
+	// a sequence must have the same owner as its table.
+	queries := []string{b.String()}
+	if owner, ok := d.GetOk(seqOwnerAttr); ok {
+		queries = append(queries, fmt.Sprintf("ALTER SEQUENCE %s OWNER TO %s", seqName, pqQuoteIdentifier(c.config.roleName(owner.(string)))))
+	}
+	if ownedBy, ok := d.GetOk(seqOwnedByAttr); ok {
+		queries = append(queries, fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s", seqName, quoteSequenceOwnedBy(d, ownedBy.(string))))
+	}
+
+	if err := execQueries(c.ctx, txn, queries); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error creating sequence %s: {{err}}", seqName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing sequence: {{err}}", err)
+	}
+
+	d.Set(seqDatabaseAttr, database)
+	d.SetId(generateSequenceID(d))
+
+	return resourcePostgreSQLSequenceReadImpl(d, c)
+}
+
+func resourcePostgreSQLSequenceRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkSequenceSupported(c); err != nil {
+		return err
+	}
+
+	defer c.rLockDatabase(getSequenceDatabase(d, c))()
+
+	return resourcePostgreSQLSequenceReadImpl(d, c)
+}
+
+func resourcePostgreSQLSequenceReadImpl(d *schema.ResourceData, c *Client) error {
+	database := getSequenceDatabase(d, c)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	schemaName := d.Get(seqSchemaAttr).(string)
+	seqName := d.Get(seqNameAttr).(string)
+
+	var owner string
+	var start, increment, minValue, maxValue, cache int64
+	var cycle bool
+	err = txn.QueryRowContext(c.ctx, `
+SELECT sequenceowner, start_value, increment_by, min_value, max_value, cache_size, cycle
+FROM pg_catalog.pg_sequences
+WHERE schemaname = $1 AND sequencename = $2`,
+		schemaName, seqName,
+	).Scan(&owner, &start, &increment, &minValue, &maxValue, &cache, &cycle)
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] PostgreSQL sequence (%s) not found", d.Id())
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading sequence: {{err}}", err)
+	}
+
+	// The column owning the sequence is the auto dependency of the sequence on it,
+	// its table is in the same schema.
+	var ownedBy string
+	err = txn.QueryRowContext(c.ctx, `
+SELECT t.relname || '.' || a.attname
+FROM pg_catalog.pg_depend dep
+JOIN pg_catalog.pg_class s ON s.oid = dep.objid
+JOIN pg_catalog.pg_namespace n ON n.oid = s.relnamespace
+JOIN pg_catalog.pg_class t ON t.oid = dep.refobjid
+JOIN pg_catalog.pg_attribute a ON a.attrelid = t.oid AND a.attnum = dep.refobjsubid
+WHERE dep.classid = 'pg_catalog.pg_class'::regclass AND dep.refclassid = 'pg_catalog.pg_class'::regclass
+	AND dep.deptype = 'a' AND n.nspname = $1 AND s.relname = $2`,
+		schemaName, seqName,
+	).Scan(&ownedBy)
+	if err != nil && err != sql.ErrNoRows {
+		return errwrap.Wrapf("Error reading the column owning the sequence: {{err}}", err)
+	}
+
+	d.Set(seqStartAttr, start)
+	d.Set(seqIncrementAttr, increment)
+	d.Set(seqMinValueAttr, minValue)
+	d.Set(seqMaxValueAttr, maxValue)
+	d.Set(seqCacheAttr, cache)
+	d.Set(seqCycleAttr, cycle)
+	d.Set(seqOwnerAttr, c.config.stateRoleName(d.Get(seqOwnerAttr).(string), owner))
+	d.Set(seqOwnedByAttr, ownedBy)
+	d.Set(seqDatabaseAttr, database)
+	d.SetId(generateSequenceID(d))
+
+	return nil
+}
+
+func resourcePostgreSQLSequenceUpdate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkSequenceSupported(c); err != nil {
+		return err
+	}
+
+	database := getSequenceDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	seqName := quoteSequenceName(d)
+	alter := fmt.Sprintf("ALTER SEQUENCE %s ", seqName)
+
+	// START WITH only changes the value used by ALTER SEQUENCE ... RESTART,
+	// the current value of the sequence is kept.
+	var clauses []string
+	if d.HasChange(seqIncrementAttr) {
+		clauses = append(clauses, fmt.Sprintf("INCREMENT BY %d", d.Get(seqIncrementAttr).(int)))
+	}
+	if d.HasChange(seqMinValueAttr) {
+		clauses = append(clauses, fmt.Sprintf("MINVALUE %d", d.Get(seqMinValueAttr).(int)))
+	}
+	if d.HasChange(seqMaxValueAttr) {
+		clauses = append(clauses, fmt.Sprintf("MAXVALUE %d", d.Get(seqMaxValueAttr).(int)))
+	}
+	if d.HasChange(seqStartAttr) {
+		clauses = append(clauses, fmt.Sprintf("START WITH %d", d.Get(seqStartAttr).(int)))
+	}
+	if d.HasChange(seqCacheAttr) {
+		clauses = append(clauses, fmt.Sprintf("CACHE %d", d.Get(seqCacheAttr).(int)))
+	}
+	if d.HasChange(seqCycleAttr) {
+		if d.Get(seqCycleAttr).(bool) {
+			clauses = append(clauses, "CYCLE")
+		} else {
+			clauses = append(clauses, "NO CYCLE")
+		}
+	}
+
+	var queries []string
+	if len(clauses) > 0 {
+		queries = append(queries, alter+strings.Join(clauses, " "))
+	}
+
+	// The sequence is detached from its column before changing its owner,
+	// it cannot have another owner than its table.
+	oldOwnedBy, newOwnedBy := d.GetChange(seqOwnedByAttr)
+	if d.HasChange(seqOwnedByAttr) && oldOwnedBy.(string) != "" {
+		queries = append(queries, alter+"OWNED BY NONE")
+	}
+	if d.HasChange(seqOwnerAttr) {
+		if owner := d.Get(seqOwnerAttr).(string); owner != "" {
+			queries = append(queries, alter+"OWNER TO "+pqQuoteIdentifier(c.config.roleName(owner)))
+		}
+	}
+	if d.HasChange(seqOwnedByAttr) && newOwnedBy.(string) != "" {
+		queries = append(queries, alter+"OWNED BY "+quoteSequenceOwnedBy(d, newOwnedBy.(string)))
+	}
+
+	if err := execQueries(c.ctx, txn, queries); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error updating sequence %s: {{err}}", seqName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing sequence: {{err}}", err)
+	}
+
+	return resourcePostgreSQLSequenceReadImpl(d, c)
+}
+
+func resourcePostgreSQLSequenceDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkSequenceSupported(c); err != nil {
+		return err
+	}
+
+	database := getSequenceDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	// The sequence is not dropped if column defaults still use it.
+	seqName := quoteSequenceName(d)
+	if _, err := txn.ExecContext(c.ctx, fmt.Sprintf("DROP SEQUENCE IF EXISTS %s", seqName)); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error deleting sequence %s: {{err}}", seqName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing sequence deletion: {{err}}", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// resourcePostgreSQLSequenceImport imports a sequence
+// from an ID with the database/schema/name format.
+func resourcePostgreSQLSequenceImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), "database/schema/name")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set(seqDatabaseAttr, parts[0])
+	d.Set(seqSchemaAttr, parts[1])
+	d.Set(seqNameAttr, parts[2])
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func checkSequenceSupported(c *Client) error {
+	if !c.featureSupported(featureSequence) {
+		return fmt.Errorf(
+			"postgresql_sequence resource is not supported for this Postgres version (%s)",
+			c.version,
+		)
+	}
+	return nil
+}
+
+// validateSequenceOwnedBy checks the column owning a sequence has the table.column format.
+func validateSequenceOwnedBy(v interface{}, key string) (warnings []string, errors []error) {
+	if parts := strings.Split(v.(string), "."); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		errors = append(errors, fmt.Errorf("%s must have the table.column format, got: %q", key, v.(string)))
+	}
+	return
+}
+
+func quoteSequenceName(d *schema.ResourceData) string {
+	return pqQuoteIdentifier(d.Get(seqSchemaAttr).(string)) + "." + pqQuoteIdentifier(d.Get(seqNameAttr).(string))
+}
+
+// quoteSequenceOwnedBy quotes the column owning the sequence,
+// its table is in the schema of the sequence.
+func quoteSequenceOwnedBy(d *schema.ResourceData, ownedBy string) string {
+	parts := strings.SplitN(ownedBy, ".", 2)
+	return strings.Join([]string{
+		pqQuoteIdentifier(d.Get(seqSchemaAttr).(string)), pqQuoteIdentifier(parts[0]), pqQuoteIdentifier(parts[1]),
+	}, ".")
+}
+
+func getSequenceDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(seqDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generateSequenceID(d *schema.ResourceData) string {
+	return strings.Join([]string{
+		d.Get(seqDatabaseAttr).(string), d.Get(seqSchemaAttr).(string), d.Get(seqNameAttr).(string),
+	}, ".")
+}
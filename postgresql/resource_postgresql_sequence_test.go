@@ -0,0 +1,97 @@
+package postgresql
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestValidateSequenceOwnedBy(t *testing.T) {
+	tests := []struct {
+		ownedBy string
+		valid   bool
+	}{
+		{"accounts.id", true},
+		{"accounts", false},
+		{"public.accounts.id", false},
+		{"accounts.", false},
+	}
+
+	for _, test := range tests {
+		_, errs := validateSequenceOwnedBy(test.ownedBy, "owned_by")
+		if valid := len(errs) == 0; valid != test.valid {
+			t.Errorf("validateSequenceOwnedBy(%q): expected valid %t, got errors %v", test.ownedBy, test.valid, errs)
+		}
+	}
+}
+
+func TestAccPostgresqlSequence_Basic(t *testing.T) {
+	skipIfNotAcc(t)
+	testCheckCompatibleVersion(t, featureSequence)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, true)
+	defer teardown()
+
+	dbName, roleName := getTestDBNames(dbSuffix)
+	config := getTestConfig(t)
+	dbExecute(t, config.connStr(dbName), "CREATE TABLE test_schema.invoices (number bigint)")
+	dbExecute(t, config.connStr(dbName), fmt.Sprintf("ALTER TABLE test_schema.invoices OWNER TO %s", roleName))
+
+	testAccConfig := func(settings string) string {
+		return fmt.Sprintf(`
+resource "postgresql_sequence" "test" {
+  database = "%s"
+  schema   = "test_schema"
+  name     = "invoice_numbers"
+  %s
+}
+`, dbName, settings)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfig(`start = 1000`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_sequence.test", "id", fmt.Sprintf("%s.test_schema.invoice_numbers", dbName)),
+					resource.TestCheckResourceAttr("postgresql_sequence.test", "start", "1000"),
+					resource.TestCheckResourceAttr("postgresql_sequence.test", "increment", "1"),
+					resource.TestCheckResourceAttr("postgresql_sequence.test", "min_value", "1"),
+					resource.TestCheckResourceAttr("postgresql_sequence.test", "max_value", "9223372036854775807"),
+					resource.TestCheckResourceAttr("postgresql_sequence.test", "cycle", "false"),
+					resource.TestCheckResourceAttr("postgresql_sequence.test", "owned_by", ""),
+				),
+			},
+			{
+				Config: testAccConfig(fmt.Sprintf(`
+  start     = 1000
+  increment = 10
+  max_value = 99999
+  cache     = 20
+  cycle     = true
+  owner     = "%s"
+  owned_by  = "invoices.number"
+`, roleName)),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_sequence.test", "increment", "10"),
+					resource.TestCheckResourceAttr("postgresql_sequence.test", "max_value", "99999"),
+					resource.TestCheckResourceAttr("postgresql_sequence.test", "cache", "20"),
+					resource.TestCheckResourceAttr("postgresql_sequence.test", "cycle", "true"),
+					resource.TestCheckResourceAttr("postgresql_sequence.test", "owner", roleName),
+					resource.TestCheckResourceAttr("postgresql_sequence.test", "owned_by", "invoices.number"),
+				),
+			},
+			{
+				ResourceName:      "postgresql_sequence.test",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("%s/test_schema/invoice_numbers", dbName),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
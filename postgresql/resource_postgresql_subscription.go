@@ -0,0 +1,421 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+const (
+	subNameAttr              = "name"
+	subDatabaseAttr          = "database"
+	subConnInfoAttr          = "conninfo"
+	subPublicationsAttr      = "publications"
+	subSlotNameAttr          = "slot_name"
+	subCreateSlotAttr        = "create_slot"
+	subEnabledAttr           = "enabled"
+	subSynchronousCommitAttr = "synchronous_commit"
+	subWaitForSyncAttr       = "wait_for_sync"
+	subFailoverAttr          = "failover"
+
+	// defaultSubscriptionSyncTimeout bounds the wait for the synchronization of the tables
+	// when the operation has no timeout (see enforceTimeouts).
+	defaultSubscriptionSyncTimeout = 20 * time.Minute
+	subscriptionSyncPollInterval   = 2 * time.Second
+)
+
+var subSynchronousCommitValues = []string{"off", "local", "remote_write", "remote_apply", "on"}
+
+// resourcePostgreSQLSubscription manages a subscription of logical replication.
+// CREATE SUBSCRIPTION (creating the replication slot), ALTER SUBSCRIPTION ... SET PUBLICATION
+// (refreshing the tables) and DROP SUBSCRIPTION (dropping the slot) cannot run in a transaction
+// block, so the statements are executed one by one outside of a transaction.
+func resourcePostgreSQLSubscription() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLSubscriptionCreate),
+		Read:   resourcePostgreSQLSubscriptionRead,
+		Update: retryOnTransientErrors(resourcePostgreSQLSubscriptionUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLSubscriptionDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLSubscriptionImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			subNameAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The name of the subscription",
+			},
+			subDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database in which the subscription is created",
+			},
+			subConnInfoAttr: {
+				Type:        schema.TypeString,
+				Required:    true,
+				Sensitive:   true,
+				Description: "The connection string to the publisher (e.g.: host=primary dbname=app user=replicator password=...)",
+			},
+			subPublicationsAttr: {
+				Type:        schema.TypeSet,
+				Required:    true,
+				MinItems:    1,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         schema.HashString,
+				Description: "The publications of the publisher to subscribe to",
+			},
+			subSlotNameAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "The replication slot on the publisher (the name of the subscription by default)",
+			},
+			subCreateSlotAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				ForceNew:    true,
+				Description: "Create the replication slot on the publisher (when false, the slot must already exist)",
+			},
+			subEnabledAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Whether the subscription replicates the changes",
+			},
+			subSynchronousCommitAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringInSlice(subSynchronousCommitValues, false),
+				Description:  "The synchronous_commit of the apply worker (off by default)",
+			},
+			subWaitForSyncAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Wait until the initial copy of the tables is done when the subscription is created or its publications are changed",
+			},
+			subFailoverAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Synchronize the replication slot of the subscription to the standby servers of the publisher, so the subscription can continue after a failover",
+			},
+		},
+	}
+}
+
+func resourcePostgreSQLSubscriptionCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkSubscriptionSupported(c, d); err != nil {
+		return err
+	}
+
+	database := getSubscriptionDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	client, err := databaseClient(c, database)
+	if err != nil {
+		return err
+	}
+
+	subName := d.Get(subNameAttr).(string)
+
+	options := []string{
+		fmt.Sprintf("enabled = %t", d.Get(subEnabledAttr).(bool)),
+		fmt.Sprintf("create_slot = %t", d.Get(subCreateSlotAttr).(bool)),
+	}
+	if v, ok := d.GetOk(subSlotNameAttr); ok {
+		options = append(options, fmt.Sprintf("slot_name = '%s'", pqQuoteLiteral(v.(string))))
+	}
+	if v, ok := d.GetOk(subSynchronousCommitAttr); ok {
+		options = append(options, fmt.Sprintf("synchronous_commit = '%s'", v.(string)))
+	}
+	if d.Get(subFailoverAttr).(bool) {
+		options = append(options, "failover = true")
+	}
+
+	query := fmt.Sprintf("CREATE SUBSCRIPTION %s CONNECTION '%s' PUBLICATION %s WITH (%s)",
+		pqQuoteIdentifier(subName), pqQuoteLiteral(d.Get(subConnInfoAttr).(string)),
+		quoteSubscriptionPublications(d), strings.Join(options, ", "),
+	)
+	if _, err := client.DB().ExecContext(c.ctx, query); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error creating subscription %s: {{err}}", subName), err)
+	}
+
+	d.Set(subDatabaseAttr, database)
+	d.SetId(generateSubscriptionID(d))
+
+	if err := waitForSubscriptionSync(client, d); err != nil {
+		return err
+	}
+
+	return resourcePostgreSQLSubscriptionReadImpl(d, c)
+}
+
+func resourcePostgreSQLSubscriptionRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkSubscriptionSupported(c, d); err != nil {
+		return err
+	}
+
+	defer c.rLockDatabase(getSubscriptionDatabase(d, c))()
+
+	return resourcePostgreSQLSubscriptionReadImpl(d, c)
+}
+
+// resourcePostgreSQLSubscriptionReadImpl reads the subscription from pg_subscription.
+// The connection string is not read back: only the superusers can read it and it contains the password.
+func resourcePostgreSQLSubscriptionReadImpl(d *schema.ResourceData, c *Client) error {
+	database := getSubscriptionDatabase(d, c)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	// subfailover exists since PostgreSQL 17.
+	failoverColumn := "false"
+	if c.featureSupported(featureFailoverSlots) {
+		failoverColumn = "s.subfailover"
+	}
+
+	var enabled, failover bool
+	var slotName, synchronousCommit string
+	var publications []string
+	err = txn.QueryRowContext(c.ctx, fmt.Sprintf(`
+SELECT s.subenabled, COALESCE(s.subslotname::text, ''), s.subsynccommit, s.subpublications, %s
+FROM pg_catalog.pg_subscription s
+JOIN pg_catalog.pg_database d ON d.oid = s.subdbid
+WHERE s.subname = $1 AND d.datname = pg_catalog.current_database()`, failoverColumn),
+		d.Get(subNameAttr),
+	).Scan(&enabled, &slotName, &synchronousCommit, pgArray(&publications), &failover)
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] PostgreSQL subscription (%s) not found", d.Id())
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading subscription: {{err}}", err)
+	}
+
+	d.Set(subEnabledAttr, enabled)
+	d.Set(subSlotNameAttr, slotName)
+	d.Set(subSynchronousCommitAttr, synchronousCommit)
+	d.Set(subFailoverAttr, failover)
+	d.Set(subPublicationsAttr, pgArrayToSet(publications))
+	d.Set(subDatabaseAttr, database)
+	d.SetId(generateSubscriptionID(d))
+
+	return nil
+}
+
+func resourcePostgreSQLSubscriptionUpdate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkSubscriptionSupported(c, d); err != nil {
+		return err
+	}
+
+	database := getSubscriptionDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	client, err := databaseClient(c, database)
+	if err != nil {
+		return err
+	}
+
+	subName := d.Get(subNameAttr).(string)
+	alter := fmt.Sprintf("ALTER SUBSCRIPTION %s ", pqQuoteIdentifier(subName))
+	enabled := d.Get(subEnabledAttr).(bool)
+
+	var queries []string
+	if d.HasChange(subConnInfoAttr) {
+		queries = append(queries, alter+fmt.Sprintf("CONNECTION '%s'", pqQuoteLiteral(d.Get(subConnInfoAttr).(string))))
+	}
+	if d.HasChange(subSynchronousCommitAttr) {
+		queries = append(queries, alter+fmt.Sprintf("SET (synchronous_commit = '%s')", d.Get(subSynchronousCommitAttr).(string)))
+	}
+	// The failover option can only be changed while the subscription is disabled.
+	if d.HasChange(subFailoverAttr) {
+		wasEnabled, _ := d.GetChange(subEnabledAttr)
+		if wasEnabled.(bool) {
+			queries = append(queries, alter+"DISABLE")
+		}
+		queries = append(queries, alter+fmt.Sprintf("SET (failover = %t)", d.Get(subFailoverAttr).(bool)))
+		if wasEnabled.(bool) {
+			queries = append(queries, alter+"ENABLE")
+		}
+	}
+	// The tables of the publications are refreshed by an enabled subscription only,
+	// so it is enabled before and disabled after changing the publications.
+	if d.HasChange(subEnabledAttr) && enabled {
+		queries = append(queries, alter+"ENABLE")
+	}
+	if d.HasChange(subPublicationsAttr) {
+		queries = append(queries, alter+fmt.Sprintf("SET PUBLICATION %s WITH (refresh = %t)", quoteSubscriptionPublications(d), enabled))
+	}
+	if d.HasChange(subEnabledAttr) && !enabled {
+		queries = append(queries, alter+"DISABLE")
+	}
+
+	for _, query := range queries {
+		if _, err := client.DB().ExecContext(c.ctx, query); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("Error updating subscription %s: {{err}}", subName), err)
+		}
+	}
+
+	if d.HasChange(subPublicationsAttr) || d.HasChange(subEnabledAttr) {
+		if err := waitForSubscriptionSync(client, d); err != nil {
+			return err
+		}
+	}
+
+	return resourcePostgreSQLSubscriptionReadImpl(d, c)
+}
+
+func resourcePostgreSQLSubscriptionDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	if err := checkSubscriptionSupported(c, d); err != nil {
+		return err
+	}
+
+	database := getSubscriptionDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	client, err := databaseClient(c, database)
+	if err != nil {
+		return err
+	}
+
+	// The replication slot is dropped on the publisher with the subscription.
+	subName := d.Get(subNameAttr).(string)
+	if _, err := client.DB().ExecContext(c.ctx, fmt.Sprintf("DROP SUBSCRIPTION IF EXISTS %s", pqQuoteIdentifier(subName))); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error deleting subscription %s: {{err}}", subName), err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// resourcePostgreSQLSubscriptionImport imports a subscription from an ID with the database/name format.
+// The connection string cannot be read back, so it has to be set in the configuration.
+func resourcePostgreSQLSubscriptionImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), "database/name")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set(subDatabaseAttr, parts[0])
+	d.Set(subNameAttr, parts[1])
+	d.Set(subCreateSlotAttr, true)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func checkSubscriptionSupported(c *Client, d *schema.ResourceData) error {
+	if !c.featureSupported(featureSubscription) {
+		return fmt.Errorf(
+			"postgresql_subscription resource is not supported for this Postgres version (%s)",
+			c.version,
+		)
+	}
+
+	if d.Get(subFailoverAttr).(bool) && !c.featureSupported(featureFailoverSlots) {
+		return fmt.Errorf(
+			"failover is not supported for this Postgres version (%s)",
+			c.version,
+		)
+	}
+	return nil
+}
+
+// waitForSubscriptionSync polls pg_subscription_rel, if wait_for_sync is set and the subscription
+// is enabled, until all the tables of the subscription are synchronized (srsubstate r, ready).
+// The wait is bounded by the timeout of the operation, or by defaultSubscriptionSyncTimeout.
+func waitForSubscriptionSync(client *Client, d *schema.ResourceData) error {
+	if !d.Get(subWaitForSyncAttr).(bool) || !d.Get(subEnabledAttr).(bool) {
+		return nil
+	}
+
+	subName := d.Get(subNameAttr).(string)
+	start := time.Now()
+	deadline, ok := client.ctx.Deadline()
+	if !ok {
+		deadline = start.Add(defaultSubscriptionSyncTimeout)
+	}
+
+	for {
+		var pending int
+		err := client.DB().QueryRowContext(client.ctx, `
+SELECT count(*)
+FROM pg_catalog.pg_subscription_rel r
+JOIN pg_catalog.pg_subscription s ON s.oid = r.srsubid
+JOIN pg_catalog.pg_database d ON d.oid = s.subdbid
+WHERE s.subname = $1 AND d.datname = pg_catalog.current_database() AND r.srsubstate <> 'r'`,
+			subName,
+		).Scan(&pending)
+		if err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("could not check the synchronization of subscription %s: {{err}}", subName), err)
+		}
+		if pending == 0 {
+			return nil
+		}
+
+		if time.Now().Add(subscriptionSyncPollInterval).After(deadline) {
+			return fmt.Errorf("%d table(s) of subscription %s are still not synchronized after %s",
+				pending, subName, time.Since(start).Round(time.Second),
+			)
+		}
+
+		log.Printf("[DEBUG] %d table(s) of subscription %s are not synchronized yet, checking again in %s",
+			pending, subName, subscriptionSyncPollInterval,
+		)
+		select {
+		case <-client.ctx.Done():
+			return client.ctx.Err()
+		case <-time.After(subscriptionSyncPollInterval):
+		}
+	}
+}
+
+func quoteSubscriptionPublications(d *schema.ResourceData) string {
+	publications := setToStrings(d.Get(subPublicationsAttr).(*schema.Set))
+	for i, publication := range publications {
+		publications[i] = pqQuoteIdentifier(publication)
+	}
+	return strings.Join(publications, ", ")
+}
+
+func getSubscriptionDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(subDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generateSubscriptionID(d *schema.ResourceData) string {
+	return strings.Join([]string{d.Get(subDatabaseAttr).(string), d.Get(subNameAttr).(string)}, ".")
+}
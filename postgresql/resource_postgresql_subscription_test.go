@@ -0,0 +1,204 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccPostgresqlSubscription_Basic(t *testing.T) {
+	skipIfNotAcc(t)
+
+	pubSuffix, pubTeardown := setupTestDatabase(t, true, false)
+	defer pubTeardown()
+	subSuffix, subTeardown := setupTestDatabase(t, true, false)
+	defer subTeardown()
+
+	createTestTables(t, pubSuffix, []string{"test_schema.orders"})
+	createTestTables(t, subSuffix, []string{"test_schema.orders"})
+
+	pubDBName, _ := getTestDBNames(pubSuffix)
+	subDBName, _ := getTestDBNames(subSuffix)
+	config := getTestConfig(t)
+
+	testAccConfig := func(publications string, enabled bool) string {
+		return fmt.Sprintf(`
+resource "postgresql_subscription" "orders" {
+  database     = "%s"
+  name         = "orders"
+  conninfo     = "%s"
+  publications = [%s]
+  slot_name    = "orders_%s"
+  create_slot  = false
+  enabled      = %t
+}
+`, subDBName, config.connStr(pubDBName), publications, subSuffix, enabled)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureSubscription)
+			// The slot is created beforehand: creating it from a subscription of the same cluster blocks.
+			dbExecute(t, config.connStr(pubDBName), "CREATE PUBLICATION orders FOR TABLE test_schema.orders")
+			dbExecute(t, config.connStr(pubDBName), "CREATE PUBLICATION items FOR TABLE test_schema.orders")
+			dbExecute(t, config.connStr(pubDBName), fmt.Sprintf(
+				"SELECT pg_catalog.pg_create_logical_replication_slot('orders_%s', 'pgoutput')", subSuffix,
+			))
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfig(`"orders"`, false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_subscription.orders", "id", fmt.Sprintf("%s.orders", subDBName)),
+					resource.TestCheckResourceAttr("postgresql_subscription.orders", "enabled", "false"),
+					resource.TestCheckResourceAttr("postgresql_subscription.orders", "publications.#", "1"),
+					resource.TestCheckResourceAttr("postgresql_subscription.orders", "synchronous_commit", "off"),
+					resource.TestCheckResourceAttr("postgresql_subscription.orders", "failover", "false"),
+				),
+			},
+			{
+				Config: testAccConfig(`"orders", "items"`, false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_subscription.orders", "publications.#", "2"),
+				),
+			},
+			{
+				ResourceName:            "postgresql_subscription.orders",
+				ImportState:             true,
+				ImportStateId:           fmt.Sprintf("%s/orders", subDBName),
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"conninfo", "create_slot"},
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlSubscription_WaitForSync(t *testing.T) {
+	skipIfNotAcc(t)
+
+	pubSuffix, pubTeardown := setupTestDatabase(t, true, false)
+	defer pubTeardown()
+	subSuffix, subTeardown := setupTestDatabase(t, true, false)
+	defer subTeardown()
+
+	createTestTables(t, pubSuffix, []string{"test_schema.orders"})
+	createTestTables(t, subSuffix, []string{"test_schema.orders"})
+
+	pubDBName, _ := getTestDBNames(pubSuffix)
+	subDBName, _ := getTestDBNames(subSuffix)
+	config := getTestConfig(t)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureSubscription)
+			dbExecute(t, config.connStr(pubDBName), "INSERT INTO test_schema.orders VALUES ('a'), ('b')")
+			dbExecute(t, config.connStr(pubDBName), "CREATE PUBLICATION orders FOR TABLE test_schema.orders")
+			dbExecute(t, config.connStr(pubDBName), fmt.Sprintf(
+				"SELECT pg_catalog.pg_create_logical_replication_slot('orders_sync_%s', 'pgoutput')", subSuffix,
+			))
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "postgresql_subscription" "orders" {
+  database      = "%s"
+  name          = "orders_sync"
+  conninfo      = "%s"
+  publications  = ["orders"]
+  slot_name     = "orders_sync_%s"
+  create_slot   = false
+  wait_for_sync = true
+}
+`, subDBName, config.connStr(pubDBName), subSuffix),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_subscription.orders", "wait_for_sync", "true"),
+					testAccCheckSubscriptionRows(config.connStr(subDBName), "test_schema.orders", 2),
+				),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlSubscription_Failover(t *testing.T) {
+	skipIfNotAcc(t)
+
+	pubSuffix, pubTeardown := setupTestDatabase(t, true, false)
+	defer pubTeardown()
+	subSuffix, subTeardown := setupTestDatabase(t, true, false)
+	defer subTeardown()
+
+	createTestTables(t, pubSuffix, []string{"test_schema.orders"})
+	createTestTables(t, subSuffix, []string{"test_schema.orders"})
+
+	pubDBName, _ := getTestDBNames(pubSuffix)
+	subDBName, _ := getTestDBNames(subSuffix)
+	config := getTestConfig(t)
+
+	testAccConfig := func(failover bool) string {
+		return fmt.Sprintf(`
+resource "postgresql_subscription" "orders" {
+  database     = "%s"
+  name         = "orders_failover"
+  conninfo     = "%s"
+  publications = ["orders"]
+  slot_name    = "orders_failover_%s"
+  create_slot  = false
+  enabled      = false
+  failover     = %t
+}
+`, subDBName, config.connStr(pubDBName), subSuffix, failover)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			testCheckCompatibleVersion(t, featureFailoverSlots)
+			dbExecute(t, config.connStr(pubDBName), "CREATE PUBLICATION orders FOR TABLE test_schema.orders")
+			dbExecute(t, config.connStr(pubDBName), fmt.Sprintf(
+				"SELECT pg_catalog.pg_create_logical_replication_slot('orders_failover_%s', 'pgoutput', failover => true)", subSuffix,
+			))
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfig(true),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_subscription.orders", "failover", "true"),
+				),
+			},
+			{
+				Config: testAccConfig(false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("postgresql_subscription.orders", "failover", "false"),
+				),
+			},
+		},
+	})
+}
+
+// testAccCheckSubscriptionRows checks that the rows of the publisher have been copied in the table.
+func testAccCheckSubscriptionRows(dsn, table string, expected int) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		db, err := sql.Open("pgx", dsn)
+		if err != nil {
+			return fmt.Errorf("could not open connection pool: %v", err)
+		}
+		defer db.Close()
+
+		var count int
+		if err := db.QueryRow(fmt.Sprintf("SELECT count(*) FROM %s", table)).Scan(&count); err != nil {
+			return fmt.Errorf("could not count the rows of %s: %v", table, err)
+		}
+		if count != expected {
+			return fmt.Errorf("expected %d rows in %s once synchronized, got %d", expected, table, count)
+		}
+		return nil
+	}
+}
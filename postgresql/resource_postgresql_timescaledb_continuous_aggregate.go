@@ -0,0 +1,248 @@
+package postgresql
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	caggNameAttr             = "name"
+	caggSchemaAttr           = "schema"
+	caggDatabaseAttr         = "database"
+	caggQueryAttr            = "query"
+	caggMaterializedOnlyAttr = "materialized_only"
+	caggWithDataAttr         = "with_data"
+
+	timescaleDBExtension = "timescaledb"
+)
+
+func resourcePostgreSQLTimescaleDBContinuousAggregate() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLTimescaleDBContinuousAggregateCreate),
+		Read:   resourcePostgreSQLTimescaleDBContinuousAggregateRead,
+		Update: retryOnTransientErrors(resourcePostgreSQLTimescaleDBContinuousAggregateUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLTimescaleDBContinuousAggregateDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLTimescaleDBContinuousAggregateImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			caggNameAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The name of the continuous aggregate",
+			},
+			caggSchemaAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "public",
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The schema of the continuous aggregate",
+			},
+			caggDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database of the continuous aggregate",
+			},
+			caggQueryAttr: {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The SELECT query of the continuous aggregate (with a time_bucket() on the time column of the hypertable)",
+			},
+			caggMaterializedOnlyAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Computed:    true,
+				Description: "When true, only the materialized data is returned (real-time aggregation is disabled)",
+			},
+			caggWithDataAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "When true, the continuous aggregate is refreshed when it's created",
+			},
+		},
+	}
+}
+
+func resourcePostgreSQLTimescaleDBContinuousAggregateCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getCaggDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	b := bytes.NewBufferString("CREATE MATERIALIZED VIEW ")
+	fmt.Fprintf(b, "%s.%s WITH (timescaledb.continuous",
+		pqQuoteIdentifier(d.Get(caggSchemaAttr).(string)), pqQuoteIdentifier(d.Get(caggNameAttr).(string)),
+	)
+	if v, ok := d.GetOkExists(caggMaterializedOnlyAttr); ok {
+		fmt.Fprintf(b, ", timescaledb.materialized_only = %t", v.(bool))
+	}
+	fmt.Fprintf(b, ") AS %s", strings.TrimRight(strings.TrimSpace(d.Get(caggQueryAttr).(string)), ";"))
+	if d.Get(caggWithDataAttr).(bool) {
+		fmt.Fprint(b, " WITH DATA")
+	} else {
+		fmt.Fprint(b, " WITH NO DATA")
+	}
+
+	dbClient, err := databaseClient(c, database)
+	if err != nil {
+		return err
+	}
+
+	// A continuous aggregate created with data cannot be created in a transaction block.
+	if _, err := dbClient.DB().ExecContext(c.ctx, b.String()); err != nil {
+		return errwrap.Wrapf("Error creating continuous aggregate: {{err}}", err)
+	}
+
+	d.Set(caggDatabaseAttr, database)
+	d.SetId(generateCaggID(d))
+
+	return resourcePostgreSQLTimescaleDBContinuousAggregateReadImpl(d, c)
+}
+
+func resourcePostgreSQLTimescaleDBContinuousAggregateRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	defer c.rLockDatabase(getCaggDatabase(d, c))()
+
+	return resourcePostgreSQLTimescaleDBContinuousAggregateReadImpl(d, c)
+}
+
+func resourcePostgreSQLTimescaleDBContinuousAggregateReadImpl(d *schema.ResourceData, c *Client) error {
+	database := getCaggDatabase(d, c)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	if err := checkExtensionInstalled(c.ctx, txn, timescaleDBExtension); err != nil {
+		return err
+	}
+
+	var materializedOnly bool
+	err = txn.QueryRowContext(c.ctx,
+		"SELECT materialized_only FROM timescaledb_information.continuous_aggregates WHERE view_schema = $1 AND view_name = $2",
+		d.Get(caggSchemaAttr), d.Get(caggNameAttr),
+	).Scan(&materializedOnly)
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] PostgreSQL continuous aggregate (%s) not found", d.Id())
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading continuous aggregate: {{err}}", err)
+	}
+
+	// The query is not read back as PostgreSQL rewrites the view definition.
+	d.Set(caggMaterializedOnlyAttr, materializedOnly)
+	d.Set(caggDatabaseAttr, database)
+	d.SetId(generateCaggID(d))
+
+	return nil
+}
+
+func resourcePostgreSQLTimescaleDBContinuousAggregateUpdate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getCaggDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	if d.HasChange(caggMaterializedOnlyAttr) {
+		txn, err := startTransaction(c, database)
+		if err != nil {
+			return err
+		}
+		defer deferredRollback(txn)
+
+		sql := fmt.Sprintf("ALTER MATERIALIZED VIEW %s.%s SET (timescaledb.materialized_only = %t)",
+			pqQuoteIdentifier(d.Get(caggSchemaAttr).(string)), pqQuoteIdentifier(d.Get(caggNameAttr).(string)),
+			d.Get(caggMaterializedOnlyAttr).(bool),
+		)
+		if _, err := txn.ExecContext(c.ctx, sql); err != nil {
+			return errwrap.Wrapf("Error updating continuous aggregate: {{err}}", err)
+		}
+
+		if err := commitTransaction(c, txn); err != nil {
+			return errwrap.Wrapf("Error committing continuous aggregate: {{err}}", err)
+		}
+	}
+
+	return resourcePostgreSQLTimescaleDBContinuousAggregateReadImpl(d, c)
+}
+
+func resourcePostgreSQLTimescaleDBContinuousAggregateDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getCaggDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	// The policies of the continuous aggregate are dropped with it.
+	sql := fmt.Sprintf("DROP MATERIALIZED VIEW %s.%s",
+		pqQuoteIdentifier(d.Get(caggSchemaAttr).(string)), pqQuoteIdentifier(d.Get(caggNameAttr).(string)),
+	)
+	if _, err := txn.ExecContext(c.ctx, sql); err != nil {
+		return errwrap.Wrapf("Error deleting continuous aggregate: {{err}}", err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing continuous aggregate deletion: {{err}}", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// resourcePostgreSQLTimescaleDBContinuousAggregateImport imports a continuous aggregate
+// from an ID with the database/schema/name format.
+func resourcePostgreSQLTimescaleDBContinuousAggregateImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), "database/schema/name")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set(caggDatabaseAttr, parts[0])
+	d.Set(caggSchemaAttr, parts[1])
+	d.Set(caggNameAttr, parts[2])
+	d.Set(caggWithDataAttr, true)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func getCaggDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(caggDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generateCaggID(d *schema.ResourceData) string {
+	return strings.Join([]string{
+		d.Get(caggDatabaseAttr).(string), d.Get(caggSchemaAttr).(string), d.Get(caggNameAttr).(string),
+	}, ".")
+}
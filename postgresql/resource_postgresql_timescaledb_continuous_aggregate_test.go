@@ -0,0 +1,120 @@
+package postgresql
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccPostgresqlTimescaleDBContinuousAggregate_Basic(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, false)
+	defer teardown()
+
+	createTestExtension(t, dbSuffix, timescaleDBExtension)
+
+	dbName, _ := getTestDBNames(dbSuffix)
+	config := getTestConfig(t)
+	dbExecute(t, config.connStr(dbName), "CREATE TABLE test_schema.conditions (time timestamptz NOT NULL, temperature double precision)")
+	dbExecute(t, config.connStr(dbName), "SELECT create_hypertable('test_schema.conditions', 'time')")
+
+	testAccConfig := func(materializedOnly bool) string {
+		return fmt.Sprintf(`
+resource "postgresql_timescaledb_continuous_aggregate" "daily" {
+  database          = "%s"
+  schema            = "test_schema"
+  name              = "conditions_daily"
+  materialized_only = %t
+  query             = <<EOT
+SELECT time_bucket('1 day', time) AS day, avg(temperature) AS temperature
+FROM test_schema.conditions
+GROUP BY day
+EOT
+}
+
+resource "postgresql_timescaledb_policy" "refresh" {
+  database          = "%s"
+  schema            = "test_schema"
+  relation          = "${postgresql_timescaledb_continuous_aggregate.daily.name}"
+  type              = "refresh"
+  start_offset      = "1 month"
+  end_offset        = "1 day"
+  schedule_interval = "1 hour"
+}
+
+resource "postgresql_timescaledb_policy" "retention" {
+  database   = "%s"
+  schema     = "test_schema"
+  relation   = "conditions"
+  type       = "retention"
+  drop_after = "90 days"
+}
+`, dbName, materializedOnly, dbName, dbName)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckTimescaleDBContinuousAggregateDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfig(true),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"postgresql_timescaledb_continuous_aggregate.daily", "id",
+						fmt.Sprintf("%s.test_schema.conditions_daily", dbName),
+					),
+					resource.TestCheckResourceAttr(
+						"postgresql_timescaledb_continuous_aggregate.daily", "materialized_only", "true"),
+					resource.TestCheckResourceAttrSet("postgresql_timescaledb_policy.refresh", "job_id"),
+					resource.TestCheckResourceAttr(
+						"postgresql_timescaledb_policy.refresh", "schedule_interval", "1 hour"),
+					resource.TestCheckResourceAttr(
+						"postgresql_timescaledb_policy.retention", "drop_after", "90 days"),
+				),
+			},
+			{
+				Config: testAccConfig(false),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"postgresql_timescaledb_continuous_aggregate.daily", "materialized_only", "false"),
+				),
+			},
+		},
+	})
+}
+
+func testAccCheckTimescaleDBContinuousAggregateDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*Client)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "postgresql_timescaledb_continuous_aggregate" {
+			continue
+		}
+
+		txn, err := startTransaction(client, rs.Primary.Attributes["database"])
+		if err != nil {
+			return err
+		}
+		defer deferredRollback(txn)
+
+		var exists bool
+		if err := txn.QueryRow(
+			"SELECT EXISTS(SELECT 1 FROM timescaledb_information.continuous_aggregates WHERE view_schema = $1 AND view_name = $2)",
+			rs.Primary.Attributes["schema"], rs.Primary.Attributes["name"],
+		).Scan(&exists); err != nil {
+			return fmt.Errorf("Error checking continuous aggregate %s", err)
+		}
+
+		if exists {
+			return fmt.Errorf("Continuous aggregate still exists after destroy")
+		}
+	}
+
+	return nil
+}
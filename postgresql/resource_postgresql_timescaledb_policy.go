@@ -0,0 +1,386 @@
+package postgresql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+const (
+	tsPolicyDatabaseAttr         = "database"
+	tsPolicySchemaAttr           = "schema"
+	tsPolicyRelationAttr         = "relation"
+	tsPolicyTypeAttr             = "type"
+	tsPolicyDropAfterAttr        = "drop_after"
+	tsPolicyCompressAfterAttr    = "compress_after"
+	tsPolicyStartOffsetAttr      = "start_offset"
+	tsPolicyEndOffsetAttr        = "end_offset"
+	tsPolicyScheduleIntervalAttr = "schedule_interval"
+	tsPolicyJobIDAttr            = "job_id"
+)
+
+// timescaleDBPolicy describes how a type of policy is managed.
+type timescaleDBPolicy struct {
+	// addFunction and removeFunction add and remove the policy of a relation.
+	addFunction    string
+	removeFunction string
+	// procName is the name of the job procedure (proc_name in timescaledb_information.jobs).
+	procName string
+	// params are the attributes passed to addFunction and read from the job config.
+	params []string
+}
+
+var timescaleDBPolicies = map[string]timescaleDBPolicy{
+	"retention": {
+		addFunction:    "add_retention_policy",
+		removeFunction: "remove_retention_policy",
+		procName:       "policy_retention",
+		params:         []string{tsPolicyDropAfterAttr},
+	},
+	"compression": {
+		addFunction:    "add_compression_policy",
+		removeFunction: "remove_compression_policy",
+		procName:       "policy_compression",
+		params:         []string{tsPolicyCompressAfterAttr},
+	},
+	"refresh": {
+		addFunction:    "add_continuous_aggregate_policy",
+		removeFunction: "remove_continuous_aggregate_policy",
+		procName:       "policy_refresh_continuous_aggregate",
+		params:         []string{tsPolicyStartOffsetAttr, tsPolicyEndOffsetAttr},
+	},
+}
+
+func resourcePostgreSQLTimescaleDBPolicy() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLTimescaleDBPolicyCreate),
+		Read:   resourcePostgreSQLTimescaleDBPolicyRead,
+		Update: retryOnTransientErrors(resourcePostgreSQLTimescaleDBPolicyUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLTimescaleDBPolicyDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLTimescaleDBPolicyImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			tsPolicyDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database of the hypertable or continuous aggregate",
+			},
+			tsPolicySchemaAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "public",
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The schema of the hypertable or continuous aggregate",
+			},
+			tsPolicyRelationAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The hypertable or continuous aggregate the policy applies to",
+			},
+			tsPolicyTypeAttr: {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+				ValidateFunc: validation.StringInSlice([]string{
+					"retention",
+					"compression",
+					"refresh",
+				}, false),
+				Description: "The type of policy (one of: retention, compression, refresh)",
+			},
+			tsPolicyDropAfterAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The age of the chunks to drop (retention policy)",
+			},
+			tsPolicyCompressAfterAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The age of the chunks to compress (compression policy)",
+			},
+			tsPolicyStartOffsetAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The start of the refresh window relative to the current time, NULL for the oldest data (refresh policy)",
+			},
+			tsPolicyEndOffsetAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The end of the refresh window relative to the current time, NULL for the newest data (refresh policy)",
+			},
+			tsPolicyScheduleIntervalAttr: {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The interval between the runs of the policy (required for refresh policies)",
+			},
+			tsPolicyJobIDAttr: {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The ID of the background job of the policy",
+			},
+		},
+	}
+}
+
+// tsPolicyValue returns the SQL value of a policy parameter:
+// an integer for hypertables with an integer time column, an interval otherwise.
+func tsPolicyValue(value string) string {
+	if strings.ToUpper(value) == "NULL" {
+		return "NULL"
+	}
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return value
+	}
+	return fmt.Sprintf("INTERVAL '%s'", pqQuoteLiteral(value))
+}
+
+func resourcePostgreSQLTimescaleDBPolicyCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	policyType := d.Get(tsPolicyTypeAttr).(string)
+	policy := timescaleDBPolicies[policyType]
+
+	args := []string{fmt.Sprintf("'%s'::regclass", pqQuoteLiteral(tsPolicyRelation(d)))}
+	for _, param := range policy.params {
+		v, ok := d.GetOk(param)
+		if !ok {
+			return fmt.Errorf("%s is required for %s policies", param, policyType)
+		}
+		args = append(args, fmt.Sprintf("%s => %s", param, tsPolicyValue(v.(string))))
+	}
+	if v, ok := d.GetOk(tsPolicyScheduleIntervalAttr); ok {
+		args = append(args, fmt.Sprintf("schedule_interval => INTERVAL '%s'", pqQuoteLiteral(v.(string))))
+	} else if policyType == "refresh" {
+		return fmt.Errorf("%s is required for refresh policies", tsPolicyScheduleIntervalAttr)
+	}
+
+	database := getTSPolicyDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	if err := checkExtensionInstalled(c.ctx, txn, timescaleDBExtension); err != nil {
+		return err
+	}
+
+	var jobID int
+	query := fmt.Sprintf("SELECT %s(%s)", policy.addFunction, strings.Join(args, ", "))
+	if err := txn.QueryRowContext(c.ctx, query).Scan(&jobID); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error adding %s policy: {{err}}", policyType), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing policy: {{err}}", err)
+	}
+
+	d.Set(tsPolicyDatabaseAttr, database)
+	d.SetId(generateTSPolicyID(d))
+
+	return resourcePostgreSQLTimescaleDBPolicyReadImpl(d, c)
+}
+
+func resourcePostgreSQLTimescaleDBPolicyRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	defer c.rLockDatabase(getTSPolicyDatabase(d, c))()
+
+	return resourcePostgreSQLTimescaleDBPolicyReadImpl(d, c)
+}
+
+func resourcePostgreSQLTimescaleDBPolicyReadImpl(d *schema.ResourceData, c *Client) error {
+	database := getTSPolicyDatabase(d, c)
+	policyType := d.Get(tsPolicyTypeAttr).(string)
+	policy := timescaleDBPolicies[policyType]
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	if err := checkExtensionInstalled(c.ctx, txn, timescaleDBExtension); err != nil {
+		return err
+	}
+
+	// The jobs of the continuous aggregates are attached to their materialization hypertable.
+	var jobID int
+	var scheduleInterval, jobConfig string
+	err = txn.QueryRowContext(c.ctx, `
+SELECT j.job_id, j.schedule_interval::text, j.config::text
+FROM timescaledb_information.jobs j
+WHERE j.proc_name = $1 AND (
+    (j.hypertable_schema = $2 AND j.hypertable_name = $3)
+    OR EXISTS (
+        SELECT 1 FROM timescaledb_information.continuous_aggregates ca
+        WHERE ca.view_schema = $2 AND ca.view_name = $3
+        AND ca.materialization_hypertable_schema = j.hypertable_schema
+        AND ca.materialization_hypertable_name = j.hypertable_name
+    )
+)`,
+		policy.procName, d.Get(tsPolicySchemaAttr), d.Get(tsPolicyRelationAttr),
+	).Scan(&jobID, &scheduleInterval, &jobConfig)
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] TimescaleDB %s policy (%s) not found", policyType, d.Id())
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf(fmt.Sprintf("Error reading %s policy: {{err}}", policyType), err)
+	}
+
+	config := map[string]interface{}{}
+	decoder := json.NewDecoder(strings.NewReader(jobConfig))
+	decoder.UseNumber()
+	if err := decoder.Decode(&config); err != nil {
+		return errwrap.Wrapf("Error parsing policy config: {{err}}", err)
+	}
+
+	// The interval values are kept as configured if they are equivalent
+	// to the ones returned by the server (e.g.: 1 day and 24:00:00).
+	for _, param := range policy.params {
+		value := "NULL"
+		if v, ok := config[param]; ok && v != nil {
+			value = fmt.Sprint(v)
+		}
+		if current := d.Get(param).(string); current == "" || !sameInterval(c, current, value) {
+			d.Set(param, value)
+		}
+	}
+	if current := d.Get(tsPolicyScheduleIntervalAttr).(string); current == "" || !sameInterval(c, current, scheduleInterval) {
+		d.Set(tsPolicyScheduleIntervalAttr, scheduleInterval)
+	}
+
+	d.Set(tsPolicyJobIDAttr, jobID)
+	d.Set(tsPolicyDatabaseAttr, database)
+	d.SetId(generateTSPolicyID(d))
+
+	return nil
+}
+
+func resourcePostgreSQLTimescaleDBPolicyUpdate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getTSPolicyDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	// All the other attributes force a new policy.
+	if d.HasChange(tsPolicyScheduleIntervalAttr) {
+		txn, err := startTransaction(c, database)
+		if err != nil {
+			return err
+		}
+		defer deferredRollback(txn)
+
+		if _, err := txn.ExecContext(c.ctx,
+			"SELECT alter_job($1, schedule_interval => $2::interval)",
+			d.Get(tsPolicyJobIDAttr), d.Get(tsPolicyScheduleIntervalAttr),
+		); err != nil {
+			return errwrap.Wrapf("Error updating policy schedule interval: {{err}}", err)
+		}
+
+		if err := commitTransaction(c, txn); err != nil {
+			return errwrap.Wrapf("Error committing policy: {{err}}", err)
+		}
+	}
+
+	return resourcePostgreSQLTimescaleDBPolicyReadImpl(d, c)
+}
+
+func resourcePostgreSQLTimescaleDBPolicyDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getTSPolicyDatabase(d, c)
+	policyType := d.Get(tsPolicyTypeAttr).(string)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	// The policy may have been dropped with its relation.
+	query := fmt.Sprintf("SELECT %s($1::regclass, if_exists => true)", timescaleDBPolicies[policyType].removeFunction)
+	if _, err := txn.ExecContext(c.ctx, query, tsPolicyRelation(d)); err != nil {
+		if isUndefinedTableError(err) {
+			d.SetId("")
+			return nil
+		}
+		return errwrap.Wrapf(fmt.Sprintf("Error removing %s policy: {{err}}", policyType), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing policy deletion: {{err}}", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// resourcePostgreSQLTimescaleDBPolicyImport imports a policy
+// from an ID with the database/schema/relation/type format.
+func resourcePostgreSQLTimescaleDBPolicyImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), "database/schema/relation/type")
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := timescaleDBPolicies[parts[3]]; !ok {
+		return nil, fmt.Errorf("invalid policy type %q", parts[3])
+	}
+
+	d.Set(tsPolicyDatabaseAttr, parts[0])
+	d.Set(tsPolicySchemaAttr, parts[1])
+	d.Set(tsPolicyRelationAttr, parts[2])
+	d.Set(tsPolicyTypeAttr, parts[3])
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// tsPolicyRelation returns the quoted and schema qualified name of the relation.
+func tsPolicyRelation(d *schema.ResourceData) string {
+	return fmt.Sprintf("%s.%s",
+		pqQuoteIdentifier(d.Get(tsPolicySchemaAttr).(string)), pqQuoteIdentifier(d.Get(tsPolicyRelationAttr).(string)),
+	)
+}
+
+func getTSPolicyDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(tsPolicyDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generateTSPolicyID(d *schema.ResourceData) string {
+	return strings.Join([]string{
+		d.Get(tsPolicyDatabaseAttr).(string), d.Get(tsPolicySchemaAttr).(string),
+		d.Get(tsPolicyRelationAttr).(string), d.Get(tsPolicyTypeAttr).(string),
+	}, ".")
+}
@@ -0,0 +1,69 @@
+package postgresql
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+)
+
+func TestAccPostgresqlTimescaleDBPolicy_Compression(t *testing.T) {
+	skipIfNotAcc(t)
+
+	dbSuffix, teardown := setupTestDatabase(t, true, false)
+	defer teardown()
+
+	createTestExtension(t, dbSuffix, timescaleDBExtension)
+
+	dbName, _ := getTestDBNames(dbSuffix)
+	config := getTestConfig(t)
+	dbExecute(t, config.connStr(dbName), "CREATE TABLE test_schema.metrics (time timestamptz NOT NULL, value double precision)")
+	dbExecute(t, config.connStr(dbName), "SELECT create_hypertable('test_schema.metrics', 'time')")
+	dbExecute(t, config.connStr(dbName), "ALTER TABLE test_schema.metrics SET (timescaledb.compress)")
+
+	testAccConfig := func(scheduleInterval string) string {
+		return fmt.Sprintf(`
+resource "postgresql_timescaledb_policy" "compression" {
+  database          = "%s"
+  schema            = "test_schema"
+  relation          = "metrics"
+  type              = "compression"
+  compress_after    = "7 days"
+  schedule_interval = "%s"
+}
+`, dbName, scheduleInterval)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccConfig("12 hours"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"postgresql_timescaledb_policy.compression", "id",
+						fmt.Sprintf("%s.test_schema.metrics.compression", dbName),
+					),
+					resource.TestCheckResourceAttr(
+						"postgresql_timescaledb_policy.compression", "compress_after", "7 days"),
+					resource.TestCheckResourceAttrSet("postgresql_timescaledb_policy.compression", "job_id"),
+				),
+			},
+			{
+				Config: testAccConfig("1 day"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(
+						"postgresql_timescaledb_policy.compression", "schedule_interval", "1 day"),
+				),
+			},
+			{
+				ResourceName:      "postgresql_timescaledb_policy.compression",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
@@ -0,0 +1,426 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+const (
+	txnNameAttr        = "name"
+	txnDatabaseAttr    = "database"
+	txnRoleAttr        = "role"
+	txnSchemaAttr      = "schema"
+	txnGrantAttr       = "grant"
+	txnDropCascadeAttr = "drop_cascade"
+
+	txnRoleNameAttr     = "name"
+	txnRoleLoginAttr    = "login"
+	txnRolePasswordAttr = "password"
+	txnRoleRolesAttr    = "roles"
+
+	txnSchemaNameAttr  = "name"
+	txnSchemaOwnerAttr = "owner"
+
+	txnGrantRoleAttr       = "role"
+	txnGrantSchemaAttr     = "schema"
+	txnGrantObjectTypeAttr = "object_type"
+	txnGrantPrivilegesAttr = "privileges"
+)
+
+// txnGrantObjectTypes are the object types of the grant blocks, the privileges
+// of the table, sequence and function types are granted on all the objects of the schema.
+var txnGrantObjectTypes = []string{"schema", "table", "sequence", "function"}
+
+func resourcePostgreSQLTransaction() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLTransactionCreate),
+		Read:   resourcePostgreSQLTransactionRead,
+		Update: resourcePostgreSQLTransactionUpdate,
+		Delete: retryOnTransientErrors(resourcePostgreSQLTransactionDelete),
+
+		Schema: map[string]*schema.Schema{
+			txnNameAttr: {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The name of the set of objects (e.g.: the tenant), used in the ID of the resource",
+			},
+			txnDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database of the schemas and grants",
+			},
+			txnRoleAttr: {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The roles to create",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						txnRoleNameAttr: {
+							Type:         schema.TypeString,
+							Required:     true,
+							ForceNew:     true,
+							ValidateFunc: validateRoleName,
+							Description:  "The name of the role",
+						},
+						txnRoleLoginAttr: {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							ForceNew:    true,
+							Default:     false,
+							Description: "Whether the role can log in",
+						},
+						txnRolePasswordAttr: {
+							Type:        schema.TypeString,
+							Optional:    true,
+							ForceNew:    true,
+							Sensitive:   true,
+							Description: "The password of the role",
+						},
+						txnRoleRolesAttr: {
+							Type:        schema.TypeSet,
+							Optional:    true,
+							ForceNew:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Set:         schema.HashString,
+							Description: "The roles granted to the role",
+						},
+					},
+				},
+			},
+			txnSchemaAttr: {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The schemas to create",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						txnSchemaNameAttr: {
+							Type:         schema.TypeString,
+							Required:     true,
+							ForceNew:     true,
+							ValidateFunc: validateIdentifier,
+							Description:  "The name of the schema",
+						},
+						txnSchemaOwnerAttr: {
+							Type:        schema.TypeString,
+							Optional:    true,
+							ForceNew:    true,
+							Description: "The owner of the schema (the connected user by default)",
+						},
+					},
+				},
+			},
+			txnGrantAttr: {
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The privileges to grant, after the roles and the schemas are created",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						txnGrantRoleAttr: {
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "The role to grant the privileges to",
+						},
+						txnGrantSchemaAttr: {
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "The schema of the objects",
+						},
+						txnGrantObjectTypeAttr: {
+							Type:         schema.TypeString,
+							Required:     true,
+							ForceNew:     true,
+							ValidateFunc: validation.StringInSlice(txnGrantObjectTypes, false),
+							Description:  "The type of the objects (schema, table, sequence or function)",
+						},
+						txnGrantPrivilegesAttr: {
+							Type:        schema.TypeSet,
+							Required:    true,
+							ForceNew:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Set:         schema.HashString,
+							Description: "The privileges to grant",
+						},
+					},
+				},
+			},
+			txnDropCascadeAttr: {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When true, the schemas are dropped with the objects they contain",
+			},
+		},
+	}
+}
+
+func resourcePostgreSQLTransactionCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	for _, grant := range txnBlocks(d, txnGrantAttr) {
+		if err := validatePrivileges(grant[txnGrantObjectTypeAttr].(string), grant[txnGrantPrivilegesAttr].(*schema.Set).List()); err != nil {
+			return err
+		}
+	}
+
+	// The roles are shared by all the databases.
+	c.catalogLock.Lock()
+	defer c.catalogLock.Unlock()
+
+	database := getTransactionDatabase(d, c)
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	if err := execTransactionStatements(c, txn, createTransactionStatements(d)); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error creating %s, no object has been created: {{err}}", d.Get(txnNameAttr).(string)), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("could not commit transaction: {{err}}", err)
+	}
+
+	d.Set(txnDatabaseAttr, database)
+	d.SetId(generateTransactionID(d))
+
+	return resourcePostgreSQLTransactionReadImpl(d, c)
+}
+
+func resourcePostgreSQLTransactionRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+	c.catalogLock.RLock()
+	defer c.catalogLock.RUnlock()
+
+	return resourcePostgreSQLTransactionReadImpl(d, c)
+}
+
+// resourcePostgreSQLTransactionReadImpl checks that the roles and the schemas still exist:
+// the missing ones are removed from the state so all the objects are created again.
+// The grants are not read.
+func resourcePostgreSQLTransactionReadImpl(d *schema.ResourceData, c *Client) error {
+	database := getTransactionDatabase(d, c)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	roles := []interface{}{}
+	for _, role := range txnBlocks(d, txnRoleAttr) {
+		var exists bool
+		if err := txn.QueryRowContext(c.ctx,
+			"SELECT EXISTS(SELECT 1 FROM pg_catalog.pg_roles WHERE rolname = $1)", role[txnRoleNameAttr],
+		).Scan(&exists); err != nil {
+			return errwrap.Wrapf("could not check if role exists: {{err}}", err)
+		}
+		if !exists {
+			log.Printf("[WARN] role %s of %s not found", role[txnRoleNameAttr], d.Id())
+			continue
+		}
+		roles = append(roles, role)
+	}
+
+	schemas := []interface{}{}
+	for _, s := range txnBlocks(d, txnSchemaAttr) {
+		exists, err := schemaExists(c.ctx, txn, s[txnSchemaNameAttr].(string))
+		if err != nil {
+			return err
+		}
+		if !exists {
+			log.Printf("[WARN] schema %s of %s not found", s[txnSchemaNameAttr], d.Id())
+			continue
+		}
+		schemas = append(schemas, s)
+	}
+
+	if len(roles) == 0 && len(schemas) == 0 && len(txnBlocks(d, txnRoleAttr))+len(txnBlocks(d, txnSchemaAttr)) > 0 {
+		log.Printf("[WARN] PostgreSQL transaction (%s) not found", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	d.Set(txnRoleAttr, roles)
+	d.Set(txnSchemaAttr, schemas)
+	d.Set(txnDatabaseAttr, database)
+	d.SetId(generateTransactionID(d))
+
+	return nil
+}
+
+// resourcePostgreSQLTransactionUpdate only saves drop_cascade, the other attributes force a new resource.
+func resourcePostgreSQLTransactionUpdate(d *schema.ResourceData, meta interface{}) error {
+	return nil
+}
+
+func resourcePostgreSQLTransactionDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+	c.catalogLock.Lock()
+	defer c.catalogLock.Unlock()
+
+	txn, err := startTransaction(c, getTransactionDatabase(d, c))
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	if err := execTransactionStatements(c, txn, dropTransactionStatements(d)); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error dropping %s, no object has been dropped: {{err}}", d.Get(txnNameAttr).(string)), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("could not commit transaction: {{err}}", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// createTransactionStatements returns the statements creating the roles, then the schemas
+// (which may be owned by the roles), then granting the privileges.
+func createTransactionStatements(d *schema.ResourceData) []string {
+	var statements []string
+
+	for _, role := range txnBlocks(d, txnRoleAttr) {
+		roleName := role[txnRoleNameAttr].(string)
+		options := []string{"NOLOGIN"}
+		if role[txnRoleLoginAttr].(bool) {
+			options[0] = "LOGIN"
+		}
+		if password := role[txnRolePasswordAttr].(string); password != "" {
+			options = append(options, fmt.Sprintf("PASSWORD '%s'", pqQuoteLiteral(password)))
+		}
+		statements = append(statements, fmt.Sprintf("CREATE ROLE %s WITH %s", pqQuoteIdentifier(roleName), strings.Join(options, " ")))
+
+		for _, grantedRole := range role[txnRoleRolesAttr].(*schema.Set).List() {
+			statements = append(statements, fmt.Sprintf(
+				"GRANT %s TO %s", pqQuoteIdentifier(grantedRole.(string)), pqQuoteIdentifier(roleName),
+			))
+		}
+	}
+
+	for _, s := range txnBlocks(d, txnSchemaAttr) {
+		statement := fmt.Sprintf("CREATE SCHEMA %s", pqQuoteIdentifier(s[txnSchemaNameAttr].(string)))
+		if owner := s[txnSchemaOwnerAttr].(string); owner != "" {
+			statement += fmt.Sprintf(" AUTHORIZATION %s", pqQuoteIdentifier(owner))
+		}
+		statements = append(statements, statement)
+	}
+
+	for _, grant := range txnBlocks(d, txnGrantAttr) {
+		statements = append(statements, fmt.Sprintf(
+			"GRANT %s ON %s TO %s",
+			strings.Join(setToPgPrivileges(grant[txnGrantPrivilegesAttr].(*schema.Set)), ","),
+			txnGrantObjects(grant),
+			pqQuoteRoleName(grant[txnGrantRoleAttr].(string)),
+		))
+	}
+
+	return statements
+}
+
+// dropTransactionStatements returns the statements undoing createTransactionStatements,
+// in the reverse order. The privileges are revoked so the roles can be dropped.
+func dropTransactionStatements(d *schema.ResourceData) []string {
+	var statements []string
+
+	dropBehavior := "RESTRICT"
+	if d.Get(txnDropCascadeAttr).(bool) {
+		dropBehavior = "CASCADE"
+	}
+
+	schemas := txnBlocks(d, txnSchemaAttr)
+	dropped := make(map[string]bool, len(schemas))
+	for _, s := range schemas {
+		dropped[s[txnSchemaNameAttr].(string)] = true
+	}
+
+	for _, grant := range txnBlocks(d, txnGrantAttr) {
+		// The privileges on the objects of the dropped schemas are dropped with them.
+		if dropped[grant[txnGrantSchemaAttr].(string)] {
+			continue
+		}
+		statements = append(statements, fmt.Sprintf(
+			"REVOKE %s ON %s FROM %s",
+			strings.Join(setToPgPrivileges(grant[txnGrantPrivilegesAttr].(*schema.Set)), ","),
+			txnGrantObjects(grant),
+			pqQuoteRoleName(grant[txnGrantRoleAttr].(string)),
+		))
+	}
+
+	for i := len(schemas) - 1; i >= 0; i-- {
+		statements = append(statements, fmt.Sprintf(
+			"DROP SCHEMA IF EXISTS %s %s", pqQuoteIdentifier(schemas[i][txnSchemaNameAttr].(string)), dropBehavior,
+		))
+	}
+
+	roles := txnBlocks(d, txnRoleAttr)
+	for i := len(roles) - 1; i >= 0; i-- {
+		statements = append(statements, fmt.Sprintf("DROP ROLE IF EXISTS %s", pqQuoteIdentifier(roles[i][txnRoleNameAttr].(string))))
+	}
+
+	return statements
+}
+
+func execTransactionStatements(c *Client, txn *sql.Tx, statements []string) error {
+	for _, statement := range statements {
+		if _, err := txn.ExecContext(c.ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// txnGrantObjects returns the objects of a grant block, as used in GRANT / REVOKE.
+func txnGrantObjects(grant map[string]interface{}) string {
+	schemaName := pqQuoteIdentifier(grant[txnGrantSchemaAttr].(string))
+	switch grant[txnGrantObjectTypeAttr].(string) {
+	case "table":
+		return fmt.Sprintf("ALL TABLES IN SCHEMA %s", schemaName)
+	case "sequence":
+		return fmt.Sprintf("ALL SEQUENCES IN SCHEMA %s", schemaName)
+	case "function":
+		return fmt.Sprintf("ALL FUNCTIONS IN SCHEMA %s", schemaName)
+	}
+	return fmt.Sprintf("SCHEMA %s", schemaName)
+}
+
+// txnBlocks returns the blocks of a nested attribute.
+func txnBlocks(d *schema.ResourceData, attr string) []map[string]interface{} {
+	var blocks []map[string]interface{}
+	for _, v := range d.Get(attr).([]interface{}) {
+		if block, ok := v.(map[string]interface{}); ok {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+func getTransactionDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(txnDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generateTransactionID(d *schema.ResourceData) string {
+	return strings.Join([]string{d.Get(txnDatabaseAttr).(string), d.Get(txnNameAttr).(string)}, "/")
+}
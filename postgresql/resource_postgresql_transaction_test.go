@@ -0,0 +1,136 @@
+package postgresql
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+const testAccPostgresqlTransactionConfig = `
+resource "postgresql_transaction" "tenant" {
+  name = "tenant_a"
+
+  role {
+    name     = "tenant_a_owner"
+    login    = true
+    password = "secret"
+  }
+
+  role {
+    name  = "tenant_a_reader"
+    roles = ["tenant_a_owner"]
+  }
+
+  schema {
+    name  = "tenant_a"
+    owner = "tenant_a_owner"
+  }
+
+  grant {
+    role        = "tenant_a_reader"
+    schema      = "tenant_a"
+    object_type = "schema"
+    privileges  = ["USAGE"]
+  }
+
+  grant {
+    role        = "tenant_a_reader"
+    schema      = "tenant_a"
+    object_type = "table"
+    privileges  = ["SELECT"]
+  }
+}
+`
+
+func TestAccPostgresqlTransaction_Basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPostgresqlTransactionDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccPostgresqlTransactionConfig,
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckPostgresqlTransactionObjects(true),
+					resource.TestCheckResourceAttr("postgresql_transaction.tenant", "role.#", "2"),
+					resource.TestCheckResourceAttr("postgresql_transaction.tenant", "schema.#", "1"),
+					resource.TestCheckResourceAttr("postgresql_transaction.tenant", "grant.#", "2"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccPostgresqlTransaction_Rollback(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+		},
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckPostgresqlTransactionDestroy,
+		Steps: []resource.TestStep{
+			{
+				// The grant to an unknown role fails after the role and the schema are created.
+				Config: `
+resource "postgresql_transaction" "tenant" {
+  name = "tenant_a"
+
+  role {
+    name = "tenant_a_owner"
+  }
+
+  schema {
+    name  = "tenant_a"
+    owner = "tenant_a_owner"
+  }
+
+  grant {
+    role        = "tenant_a_unknown"
+    schema      = "tenant_a"
+    object_type = "schema"
+    privileges  = ["USAGE"]
+  }
+}
+`,
+				ExpectError: regexp.MustCompile("no object has been created"),
+			},
+		},
+	})
+}
+
+func testAccCheckPostgresqlTransactionDestroy(s *terraform.State) error {
+	return testAccCheckPostgresqlTransactionObjects(false)(s)
+}
+
+func testAccCheckPostgresqlTransactionObjects(expected bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		client := testAccProvider.Meta().(*Client)
+
+		for _, roleName := range []string{"tenant_a_owner", "tenant_a_reader"} {
+			var exists bool
+			if err := client.DB().QueryRow(
+				"SELECT EXISTS(SELECT 1 FROM pg_catalog.pg_roles WHERE rolname = $1)", roleName,
+			).Scan(&exists); err != nil {
+				return fmt.Errorf("Error checking role %s: %s", roleName, err)
+			}
+			if exists != expected {
+				return fmt.Errorf("expected role %s to exist: %t", roleName, expected)
+			}
+		}
+
+		exists, err := checkSchemaExists(client, "tenant_a")
+		if err != nil {
+			return fmt.Errorf("Error checking schema: %s", err)
+		}
+		if exists != expected {
+			return fmt.Errorf("expected schema tenant_a to exist: %t", expected)
+		}
+
+		return nil
+	}
+}
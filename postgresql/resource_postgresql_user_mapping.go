@@ -0,0 +1,237 @@
+package postgresql
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const (
+	userMappingDatabaseAttr   = "database"
+	userMappingServerNameAttr = "server_name"
+	userMappingUserAttr       = "user"
+	userMappingOptionsAttr    = "options"
+)
+
+// resourcePostgreSQLUserMapping manages the user mapping of a role (or PUBLIC) for a foreign server.
+// Its options usually contain the credentials of the remote server, they are redacted from the
+// logged statements (see redactStatement).
+func resourcePostgreSQLUserMapping() *schema.Resource {
+	return &schema.Resource{
+		Create: retryOnTransientErrors(resourcePostgreSQLUserMappingCreate),
+		Read:   resourcePostgreSQLUserMappingRead,
+		Update: retryOnTransientErrors(resourcePostgreSQLUserMappingUpdate),
+		Delete: retryOnTransientErrors(resourcePostgreSQLUserMappingDelete),
+		Importer: &schema.ResourceImporter{
+			State: resourcePostgreSQLUserMappingImport,
+		},
+
+		Schema: map[string]*schema.Schema{
+			userMappingDatabaseAttr: {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The database of the foreign server",
+			},
+			userMappingServerNameAttr: {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validateIdentifier,
+				Description:  "The name of the foreign server",
+			},
+			userMappingUserAttr: {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The local role of the user mapping (PUBLIC for all the roles)",
+			},
+			userMappingOptionsAttr: {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Sensitive:   true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The options of the user mapping (e.g.: user and password for postgres_fdw)",
+			},
+		},
+	}
+}
+
+func resourcePostgreSQLUserMappingCreate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getUserMappingDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	serverName := d.Get(userMappingServerNameAttr).(string)
+
+	query := fmt.Sprintf("CREATE USER MAPPING FOR %s SERVER %s%s",
+		pqQuoteRoleName(d.Get(userMappingUserAttr).(string)), pqQuoteIdentifier(serverName),
+		createFDWOptions(d.Get(userMappingOptionsAttr).(map[string]interface{})),
+	)
+	if _, err := txn.ExecContext(c.ctx, query); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error creating user mapping for foreign server %s: {{err}}", serverName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing user mapping: {{err}}", err)
+	}
+
+	d.Set(userMappingDatabaseAttr, database)
+	d.SetId(generateUserMappingID(d))
+
+	return resourcePostgreSQLUserMappingReadImpl(d, c)
+}
+
+func resourcePostgreSQLUserMappingRead(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	defer c.rLockDatabase(getUserMappingDatabase(d, c))()
+
+	return resourcePostgreSQLUserMappingReadImpl(d, c)
+}
+
+func resourcePostgreSQLUserMappingReadImpl(d *schema.ResourceData, c *Client) error {
+	database := getUserMappingDatabase(d, c)
+
+	txn, err := startReadTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	user := d.Get(userMappingUserAttr).(string)
+	if isPublicRole(user) {
+		user = "public"
+	}
+
+	// pg_user_mappings only shows the options to the owner of the server
+	// (with USAGE on it) and to the superusers.
+	var options []string
+	var visible bool
+	err = txn.QueryRowContext(c.ctx,
+		"SELECT COALESCE(umoptions, '{}'), umoptions IS NOT NULL FROM pg_catalog.pg_user_mappings WHERE srvname = $1 AND usename = $2",
+		d.Get(userMappingServerNameAttr), user,
+	).Scan(pgArray(&options), &visible)
+	switch {
+	case err == sql.ErrNoRows:
+		log.Printf("[WARN] PostgreSQL user mapping (%s) not found", d.Id())
+		d.SetId("")
+		return nil
+	case err != nil:
+		return errwrap.Wrapf("Error reading user mapping: {{err}}", err)
+	}
+
+	if visible {
+		d.Set(userMappingOptionsAttr, flattenFDWOptions(options))
+	} else {
+		log.Printf("[WARN] the options of PostgreSQL user mapping (%s) are not visible to the provider role", d.Id())
+	}
+	d.Set(userMappingDatabaseAttr, database)
+	d.SetId(generateUserMappingID(d))
+
+	return nil
+}
+
+func resourcePostgreSQLUserMappingUpdate(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getUserMappingDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	serverName := d.Get(userMappingServerNameAttr).(string)
+
+	oldOptions, newOptions := d.GetChange(userMappingOptionsAttr)
+	if alterOptions := alterFDWOptions(oldOptions.(map[string]interface{}), newOptions.(map[string]interface{})); alterOptions != "" {
+		query := fmt.Sprintf("ALTER USER MAPPING FOR %s SERVER %s OPTIONS (%s)",
+			pqQuoteRoleName(d.Get(userMappingUserAttr).(string)), pqQuoteIdentifier(serverName), alterOptions,
+		)
+		if _, err := txn.ExecContext(c.ctx, query); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("Error updating user mapping for foreign server %s: {{err}}", serverName), err)
+		}
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing user mapping: {{err}}", err)
+	}
+
+	return resourcePostgreSQLUserMappingReadImpl(d, c)
+}
+
+func resourcePostgreSQLUserMappingDelete(d *schema.ResourceData, meta interface{}) error {
+	c := meta.(*Client)
+
+	database := getUserMappingDatabase(d, c)
+
+	defer c.lockDatabase(database)()
+
+	txn, err := startTransaction(c, database)
+	if err != nil {
+		return err
+	}
+	defer deferredRollback(txn)
+
+	serverName := d.Get(userMappingServerNameAttr).(string)
+	query := fmt.Sprintf("DROP USER MAPPING IF EXISTS FOR %s SERVER %s",
+		pqQuoteRoleName(d.Get(userMappingUserAttr).(string)), pqQuoteIdentifier(serverName),
+	)
+	if _, err := txn.ExecContext(c.ctx, query); err != nil {
+		return errwrap.Wrapf(fmt.Sprintf("Error deleting user mapping for foreign server %s: {{err}}", serverName), err)
+	}
+
+	if err := commitTransaction(c, txn); err != nil {
+		return errwrap.Wrapf("Error committing user mapping deletion: {{err}}", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// resourcePostgreSQLUserMappingImport imports a user mapping
+// from an ID with the database/server_name/user format.
+func resourcePostgreSQLUserMappingImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts, err := splitImportID(d.Id(), "database/server_name/user")
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set(userMappingDatabaseAttr, parts[0])
+	d.Set(userMappingServerNameAttr, parts[1])
+	d.Set(userMappingUserAttr, parts[2])
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func getUserMappingDatabase(d *schema.ResourceData, c *Client) string {
+	if v, ok := d.GetOk(userMappingDatabaseAttr); ok {
+		return v.(string)
+	}
+	return c.databaseName
+}
+
+func generateUserMappingID(d *schema.ResourceData) string {
+	return strings.Join([]string{
+		d.Get(userMappingDatabaseAttr).(string), d.Get(userMappingServerNameAttr).(string), d.Get(userMappingUserAttr).(string),
+	}, ".")
+}
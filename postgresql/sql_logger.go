@@ -0,0 +1,45 @@
+package postgresql
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// sqlLogger is a query tracer logging the executed statements (see the log_sql
+// provider attribute) with their duration and the number of rows, at the TRACE level.
+// The statements are redacted (see redactStatement) and their arguments are not logged.
+// It forwards the traces to the tracer it wraps.
+type sqlLogger struct {
+	tracer pgx.QueryTracer
+}
+
+type traceStartKey struct{}
+
+func (l *sqlLogger) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx = l.tracer.TraceQueryStart(ctx, conn, data)
+	return context.WithValue(ctx, traceStartKey{}, time.Now())
+}
+
+func (l *sqlLogger) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	l.tracer.TraceQueryEnd(ctx, conn, data)
+
+	statement, _ := ctx.Value(traceStatementKey{}).(string)
+	var duration time.Duration
+	if start, ok := ctx.Value(traceStartKey{}).(time.Time); ok {
+		duration = time.Since(start)
+	}
+
+	var database string
+	if conn != nil {
+		database = conn.Config().Database
+	}
+
+	if data.Err != nil {
+		log.Printf("[TRACE] SQL on database %s failed after %s: %s: %v", database, duration, redactStatement(statement), data.Err)
+		return
+	}
+	log.Printf("[TRACE] SQL on database %s (%s, %d rows): %s", database, duration, data.CommandTag.RowsAffected(), redactStatement(statement))
+}
@@ -59,7 +59,7 @@ func skipIfNotAcc(t *testing.T) {
 
 // dbExecute is a test helper to create a pool, execute one query then close the pool
 func dbExecute(t *testing.T, dsn, query string, args ...interface{}) {
-	db, err := sql.Open("postgres", dsn)
+	db, err := sql.Open("pgx", dsn)
 	if err != nil {
 		t.Fatalf("could to create connection pool: %v", err)
 	}
@@ -102,6 +102,8 @@ func setupTestDatabase(t *testing.T, createDB, createRole bool) (string, func())
 	}
 
 	return suffix, func() {
+		// Close the connections the provider may have kept to the test database.
+		closeDBPools(dbName)
 		dbExecute(t, config.connStr("postgres"), fmt.Sprintf("DROP DATABASE IF EXISTS %s", dbName))
 		dbExecute(t, config.connStr("postgres"), fmt.Sprintf("DROP ROLE IF EXISTS %s", roleName))
 	}
@@ -111,7 +113,7 @@ func createTestTables(t *testing.T, dbSuffix string, tables []string) func() {
 	config := getTestConfig(t)
 	dbName, _ := getTestDBNames(dbSuffix)
 
-	db, err := sql.Open("postgres", config.connStr(dbName))
+	db, err := sql.Open("pgx", config.connStr(dbName))
 	if err != nil {
 		t.Fatalf("could not open connection pool for db %s: %v", dbName, err)
 	}
@@ -138,7 +140,7 @@ func testCheckTablesPrivileges(t *testing.T, dbSuffix string, tables []string, a
 	config.Username = roleName
 	config.Password = testRolePassword
 
-	db, err := sql.Open("postgres", config.connStr(dbName))
+	db, err := sql.Open("pgx", config.connStr(dbName))
 	if err != nil {
 		t.Fatalf("could not open connection pool for db %s: %v", dbName, err)
 	}
@@ -171,3 +173,20 @@ func testCheckTablesPrivileges(t *testing.T, dbSuffix string, tables []string, a
 	}
 	return nil
 }
+
+// createTestExtension creates the extension in the test database.
+// The test is skipped if the extension is not available on the server.
+func createTestExtension(t *testing.T, dbSuffix, extName string) {
+	config := getTestConfig(t)
+	dbName, _ := getTestDBNames(dbSuffix)
+
+	db, err := sql.Open("pgx", config.connStr(dbName))
+	if err != nil {
+		t.Fatalf("could not open connection pool for db %s: %v", dbName, err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(fmt.Sprintf("CREATE EXTENSION IF NOT EXISTS %s CASCADE", extName)); err != nil {
+		t.Skip(fmt.Sprintf("Skip %s tests, the extension is not available: %v", extName, err))
+	}
+}
@@ -0,0 +1,104 @@
+package postgresql
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+const (
+	waitAttr             = "wait"
+	waitTimeoutAttr      = "timeout"
+	waitPollIntervalAttr = "poll_interval"
+	waitCheckQueryAttr   = "check_query"
+
+	defaultWaitTimeout      = 60
+	defaultWaitPollInterval = 2
+)
+
+// waitSchema returns the schema of the wait block, used to wait after the creation
+// of an object until it's visible (e.g.: on the replicas behind a load balancer).
+func waitSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Description: "Wait after the creation until the object is visible from the connections of the provider",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				waitTimeoutAttr: {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      defaultWaitTimeout,
+					ValidateFunc: validation.IntAtLeast(1),
+					Description:  "The maximum wait, in seconds",
+				},
+				waitPollIntervalAttr: {
+					Type:         schema.TypeInt,
+					Optional:     true,
+					Default:      defaultWaitPollInterval,
+					ValidateFunc: validation.IntAtLeast(1),
+					Description:  "The time, in seconds, between two checks",
+				},
+				waitCheckQueryAttr: {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "The query checking that the object is visible, it must return a single boolean (the existence of the object by default)",
+				},
+			},
+		},
+	}
+}
+
+// waitForVisibility polls, if the resource has a wait block, until the query returns true
+// on the database. The query of the block, if any, is used instead of the one of the resource.
+// Each check gets a connection from the pool, so it may reach another server behind the endpoint.
+func waitForVisibility(c *Client, d *schema.ResourceData, database, object, query string, args ...interface{}) error {
+	if d.Get(waitAttr+".#").(int) == 0 {
+		return nil
+	}
+
+	timeout := time.Duration(d.Get(waitAttr+".0."+waitTimeoutAttr).(int)) * time.Second
+	pollInterval := time.Duration(d.Get(waitAttr+".0."+waitPollIntervalAttr).(int)) * time.Second
+	if timeout <= 0 {
+		timeout = defaultWaitTimeout * time.Second
+	}
+	if pollInterval <= 0 {
+		pollInterval = defaultWaitPollInterval * time.Second
+	}
+	if checkQuery := d.Get(waitAttr + ".0." + waitCheckQueryAttr).(string); checkQuery != "" {
+		query = checkQuery
+		args = nil
+	}
+
+	client, err := databaseClient(c, database)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		var visible bool
+		if err := client.DB().QueryRowContext(c.ctx, query, args...).Scan(&visible); err != nil {
+			return errwrap.Wrapf(fmt.Sprintf("could not check if %s is visible: {{err}}", object), err)
+		}
+		if visible {
+			return nil
+		}
+
+		if time.Now().Add(pollInterval).After(deadline) {
+			return fmt.Errorf("%s is still not visible after %s", object, timeout)
+		}
+
+		log.Printf("[DEBUG] %s is not visible yet, checking again in %s", object, pollInterval)
+		select {
+		case <-c.ctx.Done():
+			return c.ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}